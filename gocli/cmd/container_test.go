@@ -0,0 +1,38 @@
+//go:build integration
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScratchContainer builds the FROM scratch image of the Dockerfile and
+// checks that it boots into a login shell whose applets work.
+// Run with: go test -tags integration ./cmd
+func TestScratchContainer(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+	const image = "gocli-scratch-test"
+
+	build := exec.Command("docker", "build", "-t", image, ".")
+	build.Dir = ".."
+	output, err := build.CombinedOutput()
+	require.NoError(t, err, string(output))
+	t.Cleanup(func() {
+		_ = exec.Command("docker", "image", "rm", "-f", image).Run()
+	})
+
+	run := exec.Command("docker", "run", "--rm", "-i", image)
+	run.Stdin = strings.NewReader("echo $PATH\nls /bin | grep -w head\necho hello world | cut -d' ' -f2\n")
+	output, err = run.CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.Contains(t, string(output), "/bin\n")
+	assert.Contains(t, string(output), "head\n")
+	assert.Contains(t, string(output), "world\n")
+}
@@ -1,13 +1,148 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/art22m/MHS-Software-Design-F25/gocli/internal/shell"
+	"github.com/art22m/MHS-Software-Design-F25/gocli/internal/testrunner"
 )
 
 func main() {
-	shell := shell.NewShell()
-	exitCode := shell.Run()
-	syscall.Exit(exitCode)
+	if len(os.Args) > 1 && os.Args[1] == "test" {
+		syscall.Exit(testrunner.Main(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// Like busybox, the binary runs a builtin when it is invoked under its
+	// name, e.g. through a symlink, or is given the name as its first argument.
+	if shell.AppletName(os.Args[0]) != "" {
+		syscall.Exit(shell.RunApplet(os.Args, os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && shell.AppletName(os.Args[1]) != "" && os.Args[1] == filepath.Base(os.Args[1]) {
+		syscall.Exit(shell.RunApplet(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	sandboxed := flag.Bool("sandbox", false, "run in a fresh temporary directory with a scrubbed environment")
+	keep := flag.Bool("keep", false, "do not delete the sandbox directory on exit")
+	resume := flag.Bool("resume", false, "restore the working directory, directory stack and variables of the previous session")
+	pty := flag.Bool("pty", false, "run external commands on a pseudo-terminal when their output is not a terminal")
+	metrics := flag.String("metrics", "", "serve Prometheus metrics of the session at `ADDR`/metrics, e.g. localhost:9100")
+	login := flag.Bool("l", false, "run as a login shell, sourcing /etc/profile and ~/.gocli_profile or ~/.profile")
+	logFile := flag.String("log-file", "", "append log records to `PATH`; the level is taken from "+shell.LogLevelVar)
+	appletInstall := flag.String("applet-install", "", "create a symlink in `DIR` for every builtin that runs as an applet, and /etc/profile if missing, then exit")
+	importAliases := flag.Bool(strings.TrimPrefix(shell.ImportAliasesFlag, "-"), false, "define the aliases a parent gocli passed in "+shell.AliasVarPrefix+"* variables; set by gocli itself")
+	flag.Parse()
+
+	if *appletInstall != "" {
+		executable, err := os.Executable()
+		if err == nil {
+			err = shell.InstallApplets(*appletInstall, executable)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot install applets: %v\n", err)
+			syscall.Exit(1)
+		}
+		syscall.Exit(0)
+	}
+
+	var opts []shell.Option
+	if level := os.Getenv(shell.LogLevelVar); level != "" || *logFile != "" {
+		out := os.Stderr
+		if *logFile != "" {
+			f, err := os.OpenFile(*logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot open log file: %v\n", err)
+				syscall.Exit(1)
+			}
+			out = f
+		}
+		logger, err := shell.NewLogger(out, level)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: %v\n", err)
+			syscall.Exit(1)
+		}
+		opts = append(opts, shell.WithLogger(logger))
+	}
+	if *importAliases {
+		opts = append(opts, shell.WithImportedAliases())
+	}
+	var sandbox *shell.Sandbox
+	if *sandboxed {
+		var err error
+		sandbox, err = shell.NewSandbox()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot create sandbox: %v\n", err)
+			syscall.Exit(1)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "gocli: sandbox at %s\n", sandbox.Dir)
+		opts = append(opts, shell.WithEnv(sandbox.Env()))
+	}
+
+	sh := shell.NewShell(opts...)
+	if *pty {
+		_ = sh.SetOption(shell.OptionPTY, true)
+	}
+	// Plugins and extensions are part of the user's setup, which a sandbox leaves out.
+	if sandbox == nil {
+		if err := sh.LoadPlugins(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot load plugins: %v\n", err)
+		}
+		if err := sh.LoadExtensions(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot load extensions: %v\n", err)
+		}
+	}
+	if *metrics != "" {
+		if err := sh.ServeMetrics(*metrics); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot serve metrics: %v\n", err)
+		}
+	}
+	if *resume {
+		if err := sh.RestoreSession(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot resume session: %v\n", err)
+		}
+	}
+
+	// finish ends the process once the session is over.
+	finish := func(exitCode int) {
+		// Sandboxed sessions are throwaway and must not replace the saved session.
+		if sandbox == nil {
+			if err := sh.SaveSession(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot save session: %v\n", err)
+			}
+		}
+
+		if sandbox != nil {
+			if err := sandbox.Close(*keep); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "gocli: cannot clean up sandbox: %v\n", err)
+			} else if *keep {
+				_, _ = fmt.Fprintf(os.Stderr, "gocli: sandbox kept at %s\n", sandbox.Dir)
+			}
+		}
+		syscall.Exit(exitCode)
+	}
+
+	if len(shell.ShutdownSignals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, shell.ShutdownSignals...)
+		// Run ends the session once it sees the request; a repeated
+		// signal hangs up the commands started since.
+		go func() {
+			for sig := range signals {
+				sh.Shutdown(sig)
+			}
+		}()
+	}
+
+	// login(1) starts login shells with a "-" in front of the program name.
+	if *login || strings.HasPrefix(filepath.Base(os.Args[0]), "-") {
+		if exitCode, exited := sh.StartLogin(); exited {
+			finish(exitCode)
+		}
+	}
+
+	finish(sh.Run())
 }
@@ -0,0 +1,259 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// aliasTable holds the aliases of a shell. It is shared by the command
+// factory, where alias and unalias change it, and the input processor,
+// which expands it.
+type aliasTable struct {
+	mu      sync.RWMutex
+	aliases map[string]string
+}
+
+func newAliasTable() *aliasTable {
+	return &aliasTable{aliases: make(map[string]string)}
+}
+
+func (t *aliasTable) get(name string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	value, ok := t.aliases[name]
+	return value, ok
+}
+
+func (t *aliasTable) set(name, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.aliases[name] = value
+}
+
+// delete removes the alias and reports whether it existed.
+func (t *aliasTable) delete(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.aliases[name]
+	delete(t.aliases, name)
+	return ok
+}
+
+func (t *aliasTable) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.aliases = make(map[string]string)
+}
+
+// names returns the names of all aliases, sorted.
+func (t *aliasTable) names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.aliases))
+	for name := range t.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aliasScope lists the aliases whose expansion produced a token, innermost first.
+// An alias is not expanded again inside its own text, so "alias ls='ls -F'"
+// runs the ls program and mutually recursive aliases stop after one round.
+type aliasScope struct {
+	name   string
+	parent *aliasScope
+}
+
+func (s *aliasScope) contains(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAlias replaces the current token, the first word of a command, with
+// the tokens of its alias and reports whether it did. Quoted words are not
+// expanded, which is the usual way to bypass an alias: 'ls'.
+func (p *parser) expandAlias() (bool, error) {
+	word := p.tok
+	if word.quoted || word.group || word.alias.contains(word.text) {
+		return false, nil
+	}
+	value, ok := p.aliases.get(word.text)
+	if !ok {
+		return false, nil
+	}
+
+	scope := &aliasScope{name: word.text, parent: word.alias}
+	lex := newLexer(value, word.pos)
+	var tokens []token
+	for {
+		tok, err := lex.next()
+		if err != nil {
+			return false, err
+		}
+		if tok.kind == tokenEOF {
+			break
+		}
+		tok.alias = scope
+		tokens = append(tokens, tok)
+	}
+	p.queued = append(tokens, p.queued...)
+	return true, p.advance()
+}
+
+// aliasCommand defines aliases, or prints them when given only names.
+type aliasCommand struct {
+	aliases *aliasTable
+	// args holds the NAME=VALUE and NAME arguments.
+	args []string
+}
+
+func parseAliasCommand(d CommandDescription, aliases *aliasTable) (Command, error) {
+	for _, arg := range d.arguments[1:] {
+		name, _, _ := strings.Cut(arg, "=")
+		if !isAliasName(name) {
+			return nil, fmt.Errorf("alias: %s: invalid alias name", name)
+		}
+	}
+	return &aliasCommand{aliases: aliases, args: d.arguments[1:]}, nil
+}
+
+// Execute returns 1 if a name to print has no alias.
+func (a *aliasCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	args := a.args
+	if len(args) == 0 {
+		args = a.aliases.names()
+	}
+	for _, arg := range args {
+		name, value, assign := strings.Cut(arg, "=")
+		if assign {
+			a.aliases.set(name, value)
+			continue
+		}
+		value, ok := a.aliases.get(name)
+		if !ok {
+			_, _ = fmt.Fprintf(errOut, "alias: %s: not found\n", name)
+			retCode = 1
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "alias %s=%s\n", name, shellQuote(value))
+	}
+	return retCode, false
+}
+
+// unaliasCommand removes aliases.
+type unaliasCommand struct {
+	aliases *aliasTable
+	names   []string
+	all     bool
+}
+
+func parseUnaliasCommand(d CommandDescription, aliases *aliasTable) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) == 1 && args[0] == "-a" {
+		return &unaliasCommand{aliases: aliases, all: true}, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("unalias: usage: unalias [-a] NAME...")
+	}
+	return &unaliasCommand{aliases: aliases, names: args}, nil
+}
+
+// Execute returns 1 if any of the names has no alias.
+func (u *unaliasCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if u.all {
+		u.aliases.clear()
+		return 0, false
+	}
+	for _, name := range u.names {
+		if !u.aliases.delete(name) {
+			_, _ = fmt.Fprintf(errOut, "unalias: %s: not found\n", name)
+			retCode = 1
+		}
+	}
+	return retCode, false
+}
+
+// AliasVarPrefix starts the names of the variables that pass aliases to child
+// gocli instances when the export-aliases option is on: "alias ll='ls -l'"
+// becomes GOCLI_ALIAS_ll=ls -l.
+const AliasVarPrefix = "GOCLI_ALIAS_"
+
+// exportAliases replaces the alias variables in vars, the environment of an
+// external command, with the current aliases.
+func exportAliases(vars map[string]string, aliases *aliasTable) {
+	for key := range vars {
+		if strings.HasPrefix(key, AliasVarPrefix) {
+			delete(vars, key)
+		}
+	}
+	for _, name := range aliases.names() {
+		value, _ := aliases.get(name)
+		vars[AliasVarPrefix+name] = value
+	}
+}
+
+// ImportAliasesFlag is the command-line flag on which a gocli defines the
+// aliases in its AliasVarPrefix variables. A parent with export-aliases on
+// adds it when it runs its own executable; without it the variables are
+// ignored, so that whoever sets the environment cannot redefine commands.
+const ImportAliasesFlag = "-import-aliases"
+
+// childShellArgs returns args, the command line of the program at path, with
+// ImportAliasesFlag added if the program is the running gocli as a shell,
+// not as an applet or the test runner.
+func childShellArgs(path string, args []string) []string {
+	if len(args) == 0 || AppletName(args[0]) != "" {
+		return args
+	}
+	if len(args) > 1 && (args[1] == "test" || AppletName(args[1]) != "") {
+		return args
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return args
+	}
+	selfInfo, err := os.Stat(self)
+	if err != nil {
+		return args
+	}
+	info, err := os.Stat(path)
+	if err != nil || !os.SameFile(info, selfInfo) {
+		return args
+	}
+	return append([]string{args[0], ImportAliasesFlag}, args[1:]...)
+}
+
+// importAliases defines the aliases passed by a parent gocli and removes
+// their variables from env. It reports whether there were any.
+func importAliases(env Env, aliases *aliasTable) bool {
+	imported := false
+	for key, value := range env.GetAll() {
+		name, ok := strings.CutPrefix(key, AliasVarPrefix)
+		if !ok {
+			continue
+		}
+		env.Unset(key)
+		if isAliasName(name) {
+			aliases.set(name, value)
+			imported = true
+		}
+	}
+	return imported
+}
+
+// isAliasName reports whether s can name an alias: a non-empty word
+// without quotes, slashes, substitutions or characters special to the parser.
+func isAliasName(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t\n'\"\\/$`=;|&<>(){}")
+}
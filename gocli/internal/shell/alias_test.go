@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("alias hi='echo hello' say=echo; alias; alias say")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "alias hi='echo hello'\nalias say=echo\nalias say=echo\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("alias nothing 2> " + filepath.Join(t.TempDir(), "err"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestShell_AliasExpansion(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("alias hi='echo hello' up=\"tr a-z A-Z\"")
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("hi world | up; X=1 hi there && echo hi")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "HELLO WORLD\nhello there\nhi\n", readShellOutput(t, stdout))
+}
+
+func TestShell_AliasExpansion_Operators(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("alias both='echo one; echo two |'")
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("both cat")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "one\ntwo\n", readShellOutput(t, stdout))
+}
+
+func TestShell_AliasExpansion_Recursion(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("alias echo='echo [' a=b b=a")
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("echo x; 'echo' y")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "[ x\ny\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("a 2> " + filepath.Join(t.TempDir(), "err"))
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, retCode)
+}
+
+func TestShell_AliasInSubshell(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("alias hi='echo hello'; (hi)")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "hello\n", readShellOutput(t, stdout))
+}
+
+func TestShell_AliasFromProfile(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	profile := filepath.Join(t.TempDir(), "profile")
+	require.NoError(t, os.WriteFile(profile, []byte("alias greet='echo hi from profile'\n"), 0644))
+
+	_, _, err := sh.Source(profile)
+	require.NoError(t, err)
+	retCode, _, err := sh.Execute("greet")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "hi from profile\n", readShellOutput(t, stdout))
+}
+
+func TestUnaliasCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("alias a=x b=y c=z; unalias a; alias")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "alias b=y\nalias c=z\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("unalias -a; alias")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "alias b=y\nalias c=z\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("unalias b 2> " + filepath.Join(t.TempDir(), "err"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestParseAliasCommand_Invalid(t *testing.T) {
+	for _, args := range [][]string{{"alias", "a/b=x"}, {"alias", "=x"}, {"unalias"}} {
+		var err error
+		if args[0] == "alias" {
+			_, err = parseAliasCommand(CommandDescription{arguments: args}, newAliasTable())
+		} else {
+			_, err = parseUnaliasCommand(CommandDescription{arguments: args}, newAliasTable())
+		}
+		assert.Error(t, err, args)
+	}
+}
+
+func TestShell_ExportAliases(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("alias hi='echo hello'; printenv | grep ^" + AliasVarPrefix + "; set -o export-aliases; printenv | grep ^" + AliasVarPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, AliasVarPrefix+"hi=echo hello\n", readShellOutput(t, stdout), "aliases are only passed with the option")
+
+	vars := map[string]string{AliasVarPrefix + "hi": "echo hello", AliasVarPrefix + "a/b": "x"}
+	stranger := NewShell(WithEnv(NewEnvFromMap(vars)))
+	assert.Empty(t, stranger.factory.aliases.names(), "the variables alone are ignored")
+	assert.False(t, stranger.factory.options.isSet(OptionExportAliases))
+
+	child := NewShell(WithEnv(NewEnvFromMap(vars)), WithImportedAliases())
+	value, ok := child.factory.aliases.get("hi")
+	assert.True(t, ok)
+	assert.Equal(t, "echo hello", value)
+	assert.Equal(t, []string{"hi"}, child.factory.aliases.names(), "invalid names are skipped")
+	assert.Empty(t, child.env.GetAll(), "alias variables are not kept as variables")
+	assert.True(t, child.factory.options.isSet(OptionExportAliases), "a child passes aliases on")
+}
+
+func TestChildShellArgs(t *testing.T) {
+	self, err := os.Executable()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{self, ImportAliasesFlag, "script.sh"}, childShellArgs(self, []string{self, "script.sh"}))
+	assert.Equal(t, []string{"sh", "-c", "true"}, childShellArgs("/bin/sh", []string{"sh", "-c", "true"}), "other programs are run as given")
+	assert.Equal(t, []string{self, "test", "a.gocli"}, childShellArgs(self, []string{self, "test", "a.gocli"}))
+	assert.Equal(t, []string{self, "cat", "f"}, childShellArgs(self, []string{self, "cat", "f"}), "applets take no shell flags")
+}
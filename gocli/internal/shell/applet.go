@@ -0,0 +1,116 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// applets are the builtins that also work as standalone programs when the
+// gocli binary is invoked under their name, like busybox applets. Builtins
+// that only make sense inside a session, such as cd or set, are left out.
+var applets = map[CommandName]bool{
+	BasenameCommand: true,
+	CatCommand:      true,
+	CmpCommand:      true,
+	CpCommand:       true,
+	CutCommand:      true,
+	DateCommand:     true,
+	DedupeCommand:   true,
+	DirnameCommand:  true,
+	EchoCommand:     true,
+	GrepCommand:     true,
+	HeadCommand:     true,
+	JSONCommand:     true,
+	LsCommand:       true,
+	MvCommand:       true,
+	NcCommand:       true,
+	PWDCommand:      true,
+	PortCommand:     true,
+	PrintfCommand:   true,
+	RmCommand:       true,
+	SedCommand:      true,
+	SeqCommand:      true,
+	SleepCommand:    true,
+	SortCommand:     true,
+	SpongeCommand:   true,
+	SyncCommand:     true,
+	TOMLCommand:     true,
+	TreeCommand:     true,
+	WCCommand:       true,
+	YAMLCommand:     true,
+	YesCommand:      true,
+}
+
+// Applets returns the names the gocli binary can be invoked under to run a builtin directly.
+func Applets() []string {
+	names := make([]string, 0, len(applets))
+	for name := range applets {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InstallApplets creates a symlink to executable in dir for every applet,
+// skipping those that are already there, and writes a minimal system profile
+// for login shells unless one exists. Together with the binary this is a
+// complete userland for a container built FROM scratch.
+func InstallApplets(dir, executable string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range Applets() {
+		link := filepath.Join(dir, name)
+		if target, err := os.Readlink(link); err == nil && target == executable {
+			continue
+		}
+		if err := os.Symlink(executable, link); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(systemProfile); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(systemProfile), 0755); err != nil {
+		return err
+	}
+	profile := "# Written by gocli --applet-install.\nexport PATH=" + dir + "\n"
+	return os.WriteFile(systemProfile, []byte(profile), 0644)
+}
+
+// AppletName returns the applet that a program invoked as argv0 stands for,
+// e.g. "cat" for "/usr/local/bin/cat", or "" if it is none.
+func AppletName(argv0 string) string {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	if applets[CommandName(name)] {
+		return name
+	}
+	return ""
+}
+
+// RunApplet runs the builtin name as a standalone program with the command line
+// args, args[0] being the name itself, and returns its exit status.
+// Invalid arguments are reported on stderr with status 2.
+func RunApplet(args []string, stdin, stdout, stderr *os.File) int {
+	name := AppletName(args[0])
+	if name == "" {
+		_, _ = fmt.Fprintf(stderr, "gocli: %s: applet not found\n", args[0])
+		return 127
+	}
+
+	env := NewEnv()
+	desc := CommandDescription{name: CommandName(name), arguments: append([]string{name}, args[1:]...)}
+	cmd, err := newCommandFactory(env).GetCommand(desc)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	retCode, _ := cmd.Execute(stdin, stdout, stderr, env)
+	return retCode
+}
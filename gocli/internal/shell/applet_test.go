@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppletName(t *testing.T) {
+	assert.Equal(t, "cat", AppletName("cat"))
+	assert.Equal(t, "head", AppletName("/usr/local/bin/head"))
+	assert.Equal(t, "wc", AppletName("wc.exe"))
+	assert.Equal(t, "", AppletName("gocli"))
+	assert.Equal(t, "", AppletName("-gocli"))
+	assert.Equal(t, "", AppletName("cd"))
+}
+
+func TestRunApplet(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	require.NoError(t, os.WriteFile(input, []byte("one\ntwo\nthree\n"), 0644))
+	stdin, err := os.Open(input)
+	require.NoError(t, err)
+	defer func() { _ = stdin.Close() }()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	defer func() { _ = stdout.Close() }()
+
+	assert.Equal(t, 0, RunApplet([]string{"/bin/head", "-n", "2"}, stdin, stdout, os.Stderr))
+	assert.Equal(t, 0, RunApplet([]string{"wc", input}, nil, stdout, os.Stderr))
+
+	output, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n3 3 14 "+input+"\n", string(output))
+}
+
+func TestRunApplet_Errors(t *testing.T) {
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+	defer func() { _ = stderr.Close() }()
+
+	assert.Equal(t, 127, RunApplet([]string{"cd"}, nil, nil, stderr))
+	assert.Equal(t, 2, RunApplet([]string{"head", "-n", "-1"}, nil, nil, stderr))
+	assert.Equal(t, 1, RunApplet([]string{"cat", "/nonexistent/file"}, nil, nil, stderr))
+
+	output, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "gocli: cd: applet not found\nhead: invalid number of lines: -1\ncat: open /nonexistent/file: no such file or directory\n", string(output))
+}
+
+func TestInstallApplets(t *testing.T) {
+	root := t.TempDir()
+	oldSystem := systemProfile
+	systemProfile = filepath.Join(root, "etc", "profile")
+	t.Cleanup(func() { systemProfile = oldSystem })
+	bin := filepath.Join(root, "bin")
+	executable := filepath.Join(bin, "gocli")
+
+	require.NoError(t, InstallApplets(bin, executable))
+	// Installing again keeps the links that are already there.
+	require.NoError(t, InstallApplets(bin, executable))
+
+	for _, name := range Applets() {
+		target, err := os.Readlink(filepath.Join(bin, name))
+		require.NoError(t, err, name)
+		assert.Equal(t, executable, target)
+	}
+	profile, err := os.ReadFile(systemProfile)
+	require.NoError(t, err)
+	assert.Contains(t, string(profile), "\nexport PATH="+bin+"\n")
+
+	require.NoError(t, os.WriteFile(systemProfile, []byte("custom\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(bin, "cat")))
+	require.NoError(t, InstallApplets(bin, executable))
+	profile, err = os.ReadFile(systemProfile)
+	require.NoError(t, err)
+	assert.Equal(t, "custom\n", string(profile))
+
+	require.NoError(t, os.Remove(filepath.Join(bin, "cat")))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "cat"), nil, 0755))
+	assert.Error(t, InstallApplets(bin, executable))
+}
@@ -0,0 +1,120 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type assertCommand struct {
+	factory       CommandFactory
+	args          []string
+	status        int
+	stdoutPattern *regexp.Regexp
+}
+
+func parseAssertCommand(d CommandDescription, factory CommandFactory) (Command, error) {
+	fs := newFlagSet("assert")
+	status := fs.Int("status", 0, "expected exit status")
+	stdoutPattern := fs.String("stdout", "", "regular expression the standard output must match")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("assert: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("assert: command required")
+	}
+
+	cmd := &assertCommand{
+		factory: factory,
+		args:    fs.Args(),
+		status:  *status,
+	}
+	if *stdoutPattern != "" {
+		re, err := regexp.Compile(*stdoutPattern)
+		if err != nil {
+			return nil, fmt.Errorf("assert: invalid pattern: %w", err)
+		}
+		cmd.stdoutPattern = re
+	}
+	return cmd, nil
+}
+
+// Execute runs the wrapped command with its standard output captured and
+// reports every unmet expectation as a unified-diff-like block on stderr.
+func (a *assertCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	cmd, err := a.factory.GetCommand(CommandDescription{
+		name:      CommandName(a.args[0]),
+		arguments: a.args,
+	})
+	if err != nil || cmd == nil {
+		_, _ = fmt.Fprintf(errOut, "assert: %s: command not found\n", a.args[0])
+		return 127, false
+	}
+
+	stdout, status, err := captureOutput(cmd, in, errOut, env)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "assert: %v\n", err)
+		return 1, false
+	}
+
+	var failures []string
+	if status != a.status {
+		failures = append(failures, diffBlock("status", []string{fmt.Sprint(a.status)}, []string{fmt.Sprint(status)}))
+	}
+	if a.stdoutPattern != nil && !a.stdoutPattern.MatchString(stdout) {
+		failures = append(failures, diffBlock("stdout", []string{a.stdoutPattern.String()}, splitOutputLines(stdout)))
+	}
+
+	if len(failures) == 0 {
+		return 0, false
+	}
+
+	_, _ = fmt.Fprintf(errOut, "assert: expectation failed: %s\n", strings.Join(a.args, " "))
+	for _, failure := range failures {
+		_, _ = fmt.Fprint(errOut, failure)
+	}
+	return 1, false
+}
+
+// captureOutput executes cmd with its standard output redirected into a pipe
+// that is drained concurrently, so commands producing large output never block.
+func captureOutput(cmd Command, in, errOut *os.File, env Env) (string, int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", 0, err
+	}
+
+	captured := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		_ = r.Close()
+		captured <- string(data)
+	}()
+
+	status, _ := cmd.Execute(in, w, errOut, env)
+	_ = w.Close()
+	return <-captured, status, nil
+}
+
+func diffBlock(what string, expected, actual []string) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "--- expected %s\n+++ actual %s\n", what, what)
+	for _, line := range expected {
+		_, _ = fmt.Fprintf(&sb, "-%s\n", line)
+	}
+	for _, line := range actual {
+		_, _ = fmt.Fprintf(&sb, "+%s\n", line)
+	}
+	return sb.String()
+}
+
+func splitOutputLines(output string) []string {
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
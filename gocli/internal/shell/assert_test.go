@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertCommand_Execute(t *testing.T) {
+	env := NewEnv()
+	factory := NewCommandFactory(env)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{
+			name:     "default status passes",
+			args:     []string{"assert", "echo", "hello"},
+			wantCode: 0,
+		},
+		{
+			name:     "stdout pattern matches",
+			args:     []string{"assert", "--stdout", "^hel+o", "echo", "hello"},
+			wantCode: 0,
+		},
+		{
+			name:     "stdout pattern mismatch",
+			args:     []string{"assert", "--stdout", "bye", "echo", "hello"},
+			wantCode: 1,
+		},
+		{
+			name:     "expected failure status",
+			args:     []string{"assert", "--status", "1", "cat", "/nonexistent/file"},
+			wantCode: 0,
+		},
+		{
+			name:     "unexpected status",
+			args:     []string{"assert", "cat", "/nonexistent/file"},
+			wantCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := factory.GetCommand(CommandDescription{
+				name:      AssertCommand,
+				arguments: tt.args,
+			})
+			require.NoError(t, err)
+
+			retCode, exited := cmd.Execute(nil, nil, os.Stderr, env)
+			assert.Equal(t, tt.wantCode, retCode)
+			assert.False(t, exited)
+		})
+	}
+}
+
+func TestAssertCommand_Parse_Errors(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	_, err := parseAssertCommand(CommandDescription{name: AssertCommand, arguments: []string{"assert"}}, factory)
+	assert.Error(t, err)
+
+	_, err = parseAssertCommand(CommandDescription{
+		name:      AssertCommand,
+		arguments: []string{"assert", "--stdout", "(", "echo"},
+	}, factory)
+	assert.Error(t, err)
+}
+
+func TestDiffBlock(t *testing.T) {
+	expected := "--- expected stdout\n+++ actual stdout\n-foo\n+bar\n+baz\n"
+	assert.Equal(t, expected, diffBlock("stdout", []string{"foo"}, splitOutputLines("bar\nbaz\n")))
+}
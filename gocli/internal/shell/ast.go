@@ -0,0 +1,50 @@
+package shell
+
+import "io/fs"
+
+// listNode is a command line: pipelines separated by newlines and the
+// ";", "&&" and "||" operators.
+type listNode struct {
+	items []listItem
+}
+
+// listItem is a pipeline of a list and the operator that follows it.
+type listItem struct {
+	pipeline *pipelineNode
+	next     chainOperator
+}
+
+// pipelineNode is a sequence of commands joined by "|". Its output may be
+// captured into a variable with "|>" or "|>>" at the end.
+type pipelineNode struct {
+	commands []*commandNode
+	capture  *captureNode
+}
+
+// captureNode is the "|> NAME" or "|>> NAME" that ends a pipeline.
+type captureNode struct {
+	pos    Pos
+	name   string
+	append bool
+}
+
+// commandNode is a simple command: variable assignments followed by words
+// and redirections in any order. It has either words or assignments, and
+// the first word may be a "(...)" group, which must then be the only word.
+type commandNode struct {
+	pos         Pos
+	assignments []token
+	words       []token
+	redirects   []redirectNode
+}
+
+// redirectNode is a redirection of a command. Here-documents and here-strings
+// carry their text in doc; "2>&1" has no target.
+type redirectNode struct {
+	pos    Pos
+	op     string
+	target token
+	doc    *hereDoc
+	// mode, if set, is the "mode=NNN" of an output redirection.
+	mode *fs.FileMode
+}
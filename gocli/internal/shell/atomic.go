@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// openAtomic opens a new temporary file in the directory of path for a
+// redirection that replaces path. The returned commit renames the file over
+// path if ok is true and removes it otherwise, so that readers never see
+// a half-written file and a failed pipeline leaves the old one intact.
+// The file is synced before the rename, so that a crash cannot leave
+// an empty file in place of the old one.
+//
+// The new file gets mode if it is set, else the permissions of the file it
+// replaces or, for a new file, perm less the umask. A symbolic link at path
+// is followed, so that the file it points to is replaced, not the link.
+func openAtomic(path string, perm fs.FileMode, mode *fs.FileMode) (*os.File, func(ok bool) error, error) {
+	target := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		target = resolved
+	}
+	info, err := os.Stat(target)
+	switch {
+	case err == nil && !info.Mode().IsRegular():
+		return nil, nil, &fs.PathError{Op: "open", Path: path, Err: errors.New("not a regular file")}
+	case err == nil && mode == nil:
+		mode = new(fs.FileMode)
+		*mode = info.Mode().Perm()
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return nil, nil, err
+	}
+
+	var suffix [6]byte
+	_, _ = rand.Read(suffix[:])
+	tmp := filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".tmp-"+hex.EncodeToString(suffix[:]))
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mode != nil {
+		if err := f.Chmod(*mode); err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+			return nil, nil, err
+		}
+	}
+
+	commit := func(ok bool) error {
+		err := f.Sync()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if ok && err == nil {
+			err = os.Rename(tmp, target)
+		}
+		if !ok || err != nil {
+			_ = os.Remove(tmp)
+		}
+		return err
+	}
+	return f, commit, nil
+}
@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionAtomicWrite(t *testing.T) {
+	dir := tempWorkDir(t)
+	sh, _ := newTestShell(t)
+	require.NoError(t, os.WriteFile("out", []byte("old\n"), 0640))
+	require.NoError(t, sh.SetOption(OptionAtomicWrite, true))
+	content := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	retCode, _, err := sh.Execute("cat missing > out")
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, retCode)
+	assert.Equal(t, "old\n", content("out"))
+
+	_, _, err = sh.Execute("echo new > out; echo created > fresh mode=600")
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", content("out"))
+	info, err := os.Stat("out")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0640), info.Mode().Perm())
+	info, err = os.Stat("fresh")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, os.Symlink("out", "link"))
+	_, _, err = sh.Execute("echo linked > link")
+	require.NoError(t, err)
+	assert.Equal(t, "linked\n", content("out"))
+	target, err := os.Readlink("link")
+	require.NoError(t, err)
+	assert.Equal(t, "out", target)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "no temporary files are left behind")
+}
@@ -0,0 +1,122 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// basenameCommand prints the last element of paths, like basename(1).
+// Paths are split on "/" the POSIX way whatever the platform, so that
+// scripts behave the same everywhere.
+type basenameCommand struct {
+	names  []string
+	suffix string
+	zero   bool
+}
+
+func parseBasenameCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("basename")
+	multiple := fs.Bool("a", false, "support multiple arguments and treat each as a NAME")
+	suffix := fs.String("s", "", "remove a trailing SUFFIX; implies -a")
+	zero := fs.Bool("z", false, "end each output line with NUL, not newline")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("basename: %w", err)
+	}
+
+	b := &basenameCommand{names: fs.Args(), suffix: *suffix, zero: *zero}
+	switch {
+	case fs.NArg() == 0:
+		return nil, fmt.Errorf("basename: missing operand")
+	case *multiple || *suffix != "":
+	case fs.NArg() == 2:
+		b.names, b.suffix = fs.Args()[:1], fs.Arg(1)
+	case fs.NArg() > 2:
+		return nil, fmt.Errorf("basename: extra operand '%s'", fs.Arg(2))
+	}
+	return b, nil
+}
+
+func (b *basenameCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	w := bufio.NewWriter(out)
+	for _, name := range b.names {
+		base := posixBasename(name)
+		// A name that is only the suffix keeps it, like "basename .txt .txt".
+		if b.suffix != "" && base != b.suffix {
+			base = strings.TrimSuffix(base, b.suffix)
+		}
+		_, _ = w.WriteString(base)
+		_ = w.WriteByte(lineEnd(b.zero))
+	}
+	_ = w.Flush()
+	return 0, false
+}
+
+// dirnameCommand prints paths without their last element, like dirname(1).
+type dirnameCommand struct {
+	names []string
+	zero  bool
+}
+
+func parseDirnameCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("dirname")
+	zero := fs.Bool("z", false, "end each output line with NUL, not newline")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("dirname: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("dirname: missing operand")
+	}
+	return &dirnameCommand{names: fs.Args(), zero: *zero}, nil
+}
+
+func (c *dirnameCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	w := bufio.NewWriter(out)
+	for _, name := range c.names {
+		_, _ = w.WriteString(posixDirname(name))
+		_ = w.WriteByte(lineEnd(c.zero))
+	}
+	_ = w.Flush()
+	return 0, false
+}
+
+func lineEnd(zero bool) byte {
+	if zero {
+		return 0
+	}
+	return '\n'
+}
+
+// posixBasename returns the last element of a path, ignoring trailing
+// slashes; unlike filepath.Base, an empty path stays empty.
+func posixBasename(name string) string {
+	if name == "" {
+		return ""
+	}
+	trimmed := strings.TrimRight(name, "/")
+	if trimmed == "" {
+		return "/"
+	}
+	return trimmed[strings.LastIndexByte(trimmed, '/')+1:]
+}
+
+// posixDirname returns a path without its last element and the slashes
+// before it: "." if there is no directory part, "/" for the root.
+func posixDirname(name string) string {
+	trimmed := strings.TrimRight(name, "/")
+	if trimmed == "" {
+		if name == "" {
+			return "."
+		}
+		return "/"
+	}
+	i := strings.LastIndexByte(trimmed, '/')
+	if i < 0 {
+		return "."
+	}
+	if dir := strings.TrimRight(trimmed[:i], "/"); dir != "" {
+		return dir
+	}
+	return "/"
+}
@@ -0,0 +1,37 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosixBasenameAndDirname(t *testing.T) {
+	for _, tc := range []struct{ name, base, dir string }{
+		{"/usr/lib/", "lib", "/usr"},
+		{"usr//lib", "lib", "usr"},
+		{"file.txt", "file.txt", "."},
+		{"/file", "file", "/"},
+		{"//", "/", "/"},
+		{"", "", "."},
+	} {
+		assert.Equal(t, tc.base, posixBasename(tc.name), tc.name)
+		assert.Equal(t, tc.dir, posixDirname(tc.name), tc.name)
+	}
+}
+
+func TestBasenameAndDirnameCommands(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("basename /tmp/report.txt .txt; basename .txt .txt; basename -s .go a.go dir/b.go; " +
+		"basename -a x/ y; dirname /tmp/report.txt a/b/ c; echo $(basename $(dirname /srv/app/bin/run))")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "report\n.txt\na\nb\nx\ny\n/tmp\na\n.\nbin\n", readShellOutput(t, stdout))
+
+	for _, args := range [][]string{{"basename"}, {"basename", "a", "b", "c"}, {"dirname"}} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: CommandName(args[0]), arguments: args})
+		assert.Error(t, err, args)
+	}
+}
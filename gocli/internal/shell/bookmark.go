@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const bookmarksFile = "bookmarks"
+
+// loadBookmarks reads the bookmark file, one "NAME<TAB>PATH" per line.
+// A missing file means there are no bookmarks yet.
+func loadBookmarks() (map[string]string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(dir, bookmarksFile))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	bookmarks := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, path, ok := strings.Cut(scanner.Text(), "\t")
+		if ok {
+			bookmarks[name] = path
+		}
+	}
+	return bookmarks, scanner.Err()
+}
+
+func saveBookmarks(bookmarks map[string]string) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, name := range sortedKeys(bookmarks) {
+		_, _ = fmt.Fprintf(&sb, "%s\t%s\n", name, bookmarks[name])
+	}
+	return os.WriteFile(filepath.Join(dir, bookmarksFile), []byte(sb.String()), 0644)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolveBookmark expands a leading "@NAME" in path into the bookmarked
+// directory, so "@proj/build" points into the "proj" bookmark.
+// Other paths are returned unchanged.
+func resolveBookmark(path string) (string, error) {
+	if !strings.HasPrefix(path, "@") {
+		return path, nil
+	}
+
+	name, rest, _ := strings.Cut(path[1:], "/")
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return "", err
+	}
+	dir, ok := bookmarks[name]
+	if !ok {
+		return "", fmt.Errorf("no such bookmark: %s", name)
+	}
+	return filepath.Join(dir, rest), nil
+}
+
+func validBookmarkName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/@ \t\n")
+}
+
+type bookmarkCommand struct {
+	add  bool
+	name string
+	dir  string
+}
+
+func parseBookmarkCommand(d CommandDescription) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) == 0 {
+		return nil, fmt.Errorf("bookmark: expected add or list")
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) > 1 {
+			return nil, fmt.Errorf("bookmark: list takes no arguments")
+		}
+		return &bookmarkCommand{}, nil
+	case "add":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("bookmark: usage: bookmark add NAME [DIR]")
+		}
+		if !validBookmarkName(args[1]) {
+			return nil, fmt.Errorf("bookmark: invalid name %q", args[1])
+		}
+		cmd := &bookmarkCommand{add: true, name: args[1], dir: "."}
+		if len(args) == 3 {
+			cmd.dir = args[2]
+		}
+		return cmd, nil
+	default:
+		return nil, fmt.Errorf("bookmark: unknown subcommand %q", args[0])
+	}
+}
+
+func (b *bookmarkCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "bookmark: %v\n", err)
+		return 1, false
+	}
+
+	if !b.add {
+		for _, name := range sortedKeys(bookmarks) {
+			_, _ = fmt.Fprintf(out, "@%s\t%s\n", name, bookmarks[name])
+		}
+		return 0, false
+	}
+
+	dir, err := resolveBookmark(b.dir)
+	if err == nil {
+		dir, err = filepath.Abs(dir)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "bookmark: %v\n", err)
+		return 1, false
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		_, _ = fmt.Fprintf(errOut, "bookmark: not a directory: %s\n", dir)
+		return 1, false
+	}
+
+	bookmarks[b.name] = dir
+	if err := saveBookmarks(bookmarks); err != nil {
+		_, _ = fmt.Fprintf(errOut, "bookmark: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isolateConfig points the user configuration directory into a temporary one.
+func isolateConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+func TestShell_Execute_BookmarkAddList(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+	require.NoError(t, os.Mkdir("proj", 0755))
+
+	sh, stdout := newTestShell(t)
+	for _, line := range []string{"bookmark add here", "bookmark add proj proj", "bookmark list"} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retCode, line)
+	}
+	assert.Equal(t, "@here\t"+root+"\n@proj\t"+filepath.Join(root, "proj")+"\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_MkcdIntoBookmark(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+
+	sh, _ := newTestShell(t)
+	_, _, err := sh.Execute("bookmark add root")
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(os.TempDir()))
+
+	retCode, _, err := sh.Execute("mkcd @root/build")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, filepath.Join(root, "build"), currentDir(t))
+}
+
+func TestResolveBookmark(t *testing.T) {
+	isolateConfig(t)
+	require.NoError(t, saveBookmarks(map[string]string{"proj": "/src/proj"}))
+
+	resolved, err := resolveBookmark("@proj")
+	require.NoError(t, err)
+	assert.Equal(t, "/src/proj", resolved)
+
+	resolved, err = resolveBookmark("@proj/cmd/main")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/src/proj", "cmd", "main"), resolved)
+
+	resolved, err = resolveBookmark("plain/path")
+	require.NoError(t, err)
+	assert.Equal(t, "plain/path", resolved)
+
+	_, err = resolveBookmark("@missing")
+	assert.Error(t, err)
+}
+
+func TestBookmarkCommand_Execute_NotADirectory(t *testing.T) {
+	isolateConfig(t)
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("file", nil, 0644))
+
+	cmd, err := parseBookmarkCommand(CommandDescription{name: BookmarkCommand, arguments: []string{"bookmark", "add", "f", "file"}})
+	require.NoError(t, err)
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestParseBookmarkCommand_Errors(t *testing.T) {
+	for _, args := range [][]string{{"bookmark"}, {"bookmark", "add"}, {"bookmark", "add", "a/b"}, {"bookmark", "drop"}, {"bookmark", "list", "x"}} {
+		_, err := parseBookmarkCommand(CommandDescription{name: BookmarkCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxBraceWords limits how many words a single brace expression may produce,
+// so a typo like {1..1000000000} does not exhaust memory.
+const maxBraceWords = 100000
+
+// expandBraces expands "{a,b,c}" alternatives and "{1..10}" or "{a..e}"
+// sequences (with an optional step, "{1..10..2}") in word, including nested and
+// repeated ones. Braces that form neither, those of "${VAR}" and those inside
+// "$(...)" or quotes are left as they are.
+func expandBraces(word string) []string {
+	for i := 0; i < len(word); i++ {
+		if end := skipNested(word, i); end != i {
+			i = end
+			continue
+		}
+		if word[i] != '{' || (i > 0 && word[i-1] == '$') {
+			continue
+		}
+		end := braceEnd(word, i)
+		if end < 0 {
+			return []string{word}
+		}
+
+		alternatives := braceAlternatives(word[i+1 : end])
+		if alternatives == nil {
+			continue
+		}
+
+		prefix, suffix := word[:i], word[end+1:]
+		var words []string
+		for _, alt := range alternatives {
+			for _, expanded := range expandBraces(prefix + alt + suffix) {
+				words = append(words, expanded)
+				if len(words) > maxBraceWords {
+					return []string{word}
+				}
+			}
+		}
+		return words
+	}
+	return []string{word}
+}
+
+// braceEnd returns the index of the brace closing the one at s[open], or -1.
+func braceEnd(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		if end := skipNested(s, i); end != i {
+			i = end
+			continue
+		}
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// braceAlternatives returns the words a brace body stands for, or nil if it
+// is neither a comma-separated list nor a sequence.
+func braceAlternatives(body string) []string {
+	var parts []string
+	last, depth := 0, 0
+	for i := 0; i < len(body); i++ {
+		if end := skipNested(body, i); end != i {
+			i = end
+			continue
+		}
+		switch body[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, body[last:i])
+				last = i + 1
+			}
+		}
+	}
+	if parts != nil {
+		return append(parts, body[last:])
+	}
+	return braceSequence(body)
+}
+
+// braceSequence expands "FIRST..LAST[..STEP]" where the bounds are both integers
+// or both single letters. Integers keep the width of a zero-padded bound.
+func braceSequence(body string) []string {
+	bounds := strings.Split(body, "..")
+	if len(bounds) != 2 && len(bounds) != 3 {
+		return nil
+	}
+
+	step := 1
+	if len(bounds) == 3 {
+		s, err := strconv.Atoi(bounds[2])
+		if err != nil || s == 0 {
+			return nil
+		}
+		step = max(s, -s)
+	}
+
+	first, errFirst := strconv.Atoi(bounds[0])
+	last, errLast := strconv.Atoi(bounds[1])
+	isLetters := len(bounds[0]) == 1 && len(bounds[1]) == 1 && isLetter(bounds[0][0]) && isLetter(bounds[1][0])
+	switch {
+	case errFirst == nil && errLast == nil:
+	case isLetters:
+		first, last = int(bounds[0][0]), int(bounds[1][0])
+	default:
+		return nil
+	}
+	if (max(first, last)-min(first, last))/step >= maxBraceWords {
+		return nil
+	}
+
+	width := 0
+	if !isLetters && (isZeroPadded(bounds[0]) || isZeroPadded(bounds[1])) {
+		width = max(len(bounds[0]), len(bounds[1]))
+	}
+
+	if first > last {
+		step = -step
+	}
+	var words []string
+	for n := first; (step > 0 && n <= last) || (step < 0 && n >= last); n += step {
+		switch {
+		case isLetters:
+			words = append(words, string(rune(n)))
+		case width > 0:
+			words = append(words, padNumber(n, width))
+		default:
+			words = append(words, strconv.Itoa(n))
+		}
+	}
+	return words
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isZeroPadded(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	return len(s) > 1 && s[0] == '0'
+}
+
+func padNumber(n, width int) string {
+	digits := strconv.Itoa(max(n, -n))
+	if n < 0 {
+		width--
+	}
+	if len(digits) < width {
+		digits = strings.Repeat("0", width-len(digits)) + digits
+	}
+	if n < 0 {
+		return "-" + digits
+	}
+	return digits
+}
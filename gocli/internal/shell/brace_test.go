@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "file{1..5}.txt", want: []string{"file1.txt", "file2.txt", "file3.txt", "file4.txt", "file5.txt"}},
+		{word: "{a,b,c}", want: []string{"a", "b", "c"}},
+		{word: "x{a,}y", want: []string{"xay", "xy"}},
+		{word: "{3..1}", want: []string{"3", "2", "1"}},
+		{word: "{0..10..5}", want: []string{"0", "5", "10"}},
+		{word: "{08..10}", want: []string{"08", "09", "10"}},
+		{word: "{a..c}", want: []string{"a", "b", "c"}},
+		{word: "{a,b}{1,2}", want: []string{"a1", "a2", "b1", "b2"}},
+		{word: "{a,{b,c}d}", want: []string{"a", "bd", "cd"}},
+		{word: "{}", want: []string{"{}"}},
+		{word: "{single}", want: []string{"{single}"}},
+		{word: "${HOME}", want: []string{"${HOME}"}},
+		{word: "$(echo {a,b})", want: []string{"$(echo {a,b})"}},
+		{word: "{a,b", want: []string{"{a,b"}},
+		{word: "{1..a}", want: []string{"{1..a}"}},
+		{word: "{x,{1..2}}", want: []string{"x", "1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandBraces(tt.word))
+		})
+	}
+}
+
+func TestInputProcessor_Parse_BraceExpansion(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse(`X={a,b} touch f{1..2}.txt '{c,d}' > out{1,2}`)
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+
+	require.Len(t, descriptions[0].assignments, 1)
+	assert.Equal(t, []string{"X", "{a,b}"}, descriptions[0].assignments[0].arguments)
+	assert.Equal(t, []string{"touch", "f1.txt", "f2.txt", "{c,d}"}, descriptions[0].arguments)
+	assert.Equal(t, "out{1,2}", descriptions[0].fileOutPath)
+	assert.True(t, descriptions[0].singleQuotedArgs[3])
+}
+
+func TestShell_Execute_BraceExpansion(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("N=1; echo {${N},2}{x,y} \"{a,b}\"")
+	require.NoError(t, err)
+	assert.Equal(t, "1x 1y 2x 2y {a,b}\n", readShellOutput(t, stdout))
+}
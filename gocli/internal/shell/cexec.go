@@ -0,0 +1,279 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultDockerSocket = "/var/run/docker.sock"
+	// podmanSocket is where rootless Podman serves the Docker-compatible API,
+	// relative to XDG_RUNTIME_DIR.
+	podmanSocket = "podman/podman.sock"
+)
+
+// cexecCommand runs a command in a running container through the Docker
+// Engine API, which Podman serves as well. Its standard streams and exit
+// status are those of the command, as for a local external program.
+type cexecCommand struct {
+	container string
+	args      []string
+	user      string
+	workDir   string
+}
+
+func parseCexecCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("cexec")
+	user := fs.String("u", "", "run as USER[:GROUP] in the container")
+	workDir := fs.String("w", "", "working directory in the container")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("cexec: %w", err)
+	}
+	if fs.NArg() < 2 {
+		return nil, fmt.Errorf("cexec: usage: cexec [-u USER] [-w DIR] CONTAINER COMMAND...")
+	}
+
+	return &cexecCommand{
+		container: fs.Arg(0),
+		args:      fs.Args()[1:],
+		user:      *user,
+		workDir:   *workDir,
+	}, nil
+}
+
+// Execute feeds the input to the command unless it is a terminal, since
+// without a pseudo-terminal in the container it could not be used interactively.
+func (c *cexecCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	client, err := newDockerClient(env)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cexec: %v\n", err)
+		return 1, false
+	}
+	defer client.http.CloseIdleConnections()
+	_, inIsTerminal := terminalWidth(in)
+	attachStdin := in != nil && !inIsTerminal
+
+	id, err := client.createExec(c.container, dockerExecConfig{
+		Cmd:          c.args,
+		User:         c.user,
+		WorkingDir:   c.workDir,
+		AttachStdin:  attachStdin,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cexec: %v\n", err)
+		return 1, false
+	}
+
+	conn, stream, err := client.startExec(id)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cexec: %v\n", err)
+		return 1, false
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if attachStdin {
+		go func() {
+			_, _ = io.Copy(conn, in)
+			if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+				_ = cw.CloseWrite()
+			}
+		}()
+	}
+
+	if err := demuxDockerStream(stream, out, errOut); err != nil {
+		_, _ = fmt.Fprintf(errOut, "cexec: %v\n", err)
+		return 1, false
+	}
+	code, err := client.execExitCode(id)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cexec: %v\n", err)
+		return 1, false
+	}
+	return code, false
+}
+
+// dockerClient speaks the parts of the Docker Engine API that cexec needs.
+type dockerClient struct {
+	network, address string
+	http             *http.Client
+}
+
+// newDockerClient connects to DOCKER_HOST from env, or else to the Docker
+// socket or, if that is missing, to the socket of rootless Podman.
+func newDockerClient(env Env) (*dockerClient, error) {
+	network, address := "unix", defaultDockerSocket
+	if host, ok := env.Get("DOCKER_HOST"); ok && host != "" {
+		u, err := url.Parse(host)
+		if err != nil || (u.Scheme != "unix" && u.Scheme != "tcp") {
+			return nil, fmt.Errorf("unsupported DOCKER_HOST %q", host)
+		}
+		network, address = u.Scheme, u.Host
+		if u.Scheme == "unix" {
+			address = u.Path
+		}
+	} else if runtimeDir, ok := env.Get("XDG_RUNTIME_DIR"); ok && runtimeDir != "" {
+		if _, err := os.Stat(defaultDockerSocket); err != nil {
+			if podman := filepath.Join(runtimeDir, podmanSocket); fileExists(podman) {
+				address = podman
+			}
+		}
+	}
+
+	c := &dockerClient{network: network, address: address}
+	c.http = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, c.network, c.address)
+		},
+	}}
+	return c, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+type dockerExecConfig struct {
+	Cmd          []string
+	User         string `json:",omitempty"`
+	WorkingDir   string `json:",omitempty"`
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+}
+
+// createExec creates an exec instance in the container and returns its ID.
+func (c *dockerClient) createExec(container string, config dockerExecConfig) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := c.call(http.MethodPost, "/containers/"+url.PathEscape(container)+"/exec", config, &created)
+	return created.ID, err
+}
+
+// execExitCode returns the exit status of a finished exec instance.
+func (c *dockerClient) execExitCode(id string) (int, error) {
+	var inspect struct {
+		Running  bool
+		ExitCode int
+	}
+	if err := c.call(http.MethodGet, "/exec/"+url.PathEscape(id)+"/json", nil, &inspect); err != nil {
+		return 0, err
+	}
+	if inspect.Running {
+		return 0, errors.New("exec instance is still running")
+	}
+	return inspect.ExitCode, nil
+}
+
+// call makes an API request with a JSON body and decodes the JSON reply into result.
+func (c *dockerClient) call(method, path string, body, result any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://docker"+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if err := dockerError(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// startExec starts an exec instance and takes over the connection, which then
+// carries the input of the command one way and its multiplexed output the other.
+func (c *dockerClient) startExec(id string) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.Dial(c.network, c.address)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://docker/exec/"+url.PathEscape(id)+"/start",
+		strings.NewReader(`{"Detach":false,"Tty":false}`))
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err == nil {
+		err = dockerError(resp)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return conn, reader, nil
+}
+
+// dockerError turns an unsuccessful API response into an error with the daemon's message.
+func dockerError(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var reply struct{ Message string }
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Message == "" {
+		return fmt.Errorf("daemon responded %s", resp.Status)
+	}
+	return errors.New(reply.Message)
+}
+
+// demuxDockerStream copies the output of a command without a terminal, where
+// every frame starts with a header: the stream (1 stdout, 2 stderr), three
+// zero bytes and the big-endian size of the frame.
+func demuxDockerStream(stream io.Reader, out, errOut io.Writer) error {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(stream, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		dst := out
+		if header[0] == 2 {
+			dst = errOut
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(dst, stream, size); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker serves the exec endpoints of the Docker Engine API for a single
+// container "web". Its exec instances upper-case their input to stdout,
+// print the command to stderr and exit with status 3.
+type fakeDocker struct {
+	mu      sync.Mutex
+	configs map[string]dockerExecConfig
+}
+
+func startFakeDocker(t *testing.T) string {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	d := &fakeDocker{configs: make(map[string]dockerExecConfig)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /containers/{name}/exec", d.create)
+	mux.HandleFunc("POST /exec/{id}/start", d.start)
+	mux.HandleFunc("GET /exec/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"Running":false,"ExitCode":3}`)
+	})
+	server := &http.Server{Handler: mux}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return socket
+}
+
+func (d *fakeDocker) create(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("name") != "web" {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"No such container: `+r.PathValue("name")+`"}`)
+		return
+	}
+	var config dockerExecConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	d.mu.Lock()
+	d.configs["e1"] = config
+	d.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, `{"Id":"e1"}`)
+}
+
+func (d *fakeDocker) start(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	config := d.configs[r.PathValue("id")]
+	d.mu.Unlock()
+	_, _ = io.Copy(io.Discard, r.Body)
+
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, _ = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	_ = buf.Flush()
+
+	var input []byte
+	if config.AttachStdin {
+		input, _ = io.ReadAll(buf)
+	}
+	writeFrame(conn, 1, bytes.ToUpper(input))
+	writeFrame(conn, 2, []byte(strings.Join(config.Cmd, " ")+" as "+config.User+"\n"))
+}
+
+func writeFrame(w io.Writer, stream byte, data []byte) {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	_, _ = w.Write(append(header, data...))
+}
+
+func TestCexecCommand_Execute(t *testing.T) {
+	socket := startFakeDocker(t)
+	sh, stdout := newTestShell(t)
+	sh.env.Set("DOCKER_HOST", "unix://"+socket)
+	errPath := filepath.Join(t.TempDir(), "err")
+
+	retCode, _, err := sh.Execute("echo hello | cexec -u app web cat -n 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 3, retCode)
+	assert.Equal(t, "HELLO\n", readShellOutput(t, stdout))
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Equal(t, "cat -n as app\n", string(stderr))
+}
+
+func TestCexecCommand_Execute_NoSuchContainer(t *testing.T) {
+	socket := startFakeDocker(t)
+	sh, _ := newTestShell(t)
+	sh.env.Set("DOCKER_HOST", "unix://"+socket)
+	errPath := filepath.Join(t.TempDir(), "err")
+
+	retCode, _, err := sh.Execute("cexec db true 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Equal(t, "cexec: No such container: db\n", string(stderr))
+}
+
+func TestDemuxDockerStream(t *testing.T) {
+	var stream, out, errOut bytes.Buffer
+	writeFrame(&stream, 1, []byte("out "))
+	writeFrame(&stream, 2, []byte("err"))
+	writeFrame(&stream, 1, []byte("again"))
+
+	require.NoError(t, demuxDockerStream(&stream, &out, &errOut))
+	assert.Equal(t, "out again", out.String())
+	assert.Equal(t, "err", errOut.String())
+
+	stream.Write([]byte{1, 0, 0, 0, 0, 0, 0, 9, 'x'})
+	assert.Error(t, demuxDockerStream(&stream, &out, &errOut))
+}
+
+func TestParseCexecCommand_Usage(t *testing.T) {
+	_, err := parseCexecCommand(CommandDescription{name: CexecCommand, arguments: []string{"cexec", "web"}})
+	assert.Error(t, err)
+}
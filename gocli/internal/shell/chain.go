@@ -0,0 +1,27 @@
+package shell
+
+// chainOperator tells whether the pipeline following a command runs
+// depending on that command's exit status.
+type chainOperator int
+
+const (
+	// chainAlways separates pipelines with ";" or a newline.
+	chainAlways chainOperator = iota
+	// chainOnSuccess is "&&": the next pipeline runs only after a zero status.
+	chainOnSuccess
+	// chainOnFailure is "||": the next pipeline runs only after a non-zero status.
+	chainOnFailure
+)
+
+// shouldRun reports whether a pipeline joined by op to the previous one runs
+// when the last executed pipeline finished with status.
+func (op chainOperator) shouldRun(status int) bool {
+	switch op {
+	case chainOnSuccess:
+		return status == 0
+	case chainOnFailure:
+		return status != 0
+	default:
+		return true
+	}
+}
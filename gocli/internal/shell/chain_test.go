@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputProcessor_Parse_OperatorsInQuotes(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse(`a && b | c || d; e "x && y" '||' $(f && g)`)
+	require.NoError(t, err)
+	require.Len(t, descriptions, 5)
+	assert.Equal(t, chainOnSuccess, descriptions[0].next)
+	assert.True(t, descriptions[1].isPiped)
+	assert.Equal(t, chainOnFailure, descriptions[2].next)
+	assert.Equal(t, chainAlways, descriptions[3].next)
+	assert.Equal(t, []string{"e", "x && y", "||", "$(f && g)"}, descriptions[4].arguments)
+}
+
+func TestInputProcessor_Parse_Conditionals(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("cat f | grep x && echo found || echo missing")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 4)
+
+	assert.True(t, descriptions[0].isPiped)
+	assert.False(t, descriptions[1].isPiped)
+	assert.Equal(t, chainOnSuccess, descriptions[1].next)
+	assert.Equal(t, chainOnFailure, descriptions[2].next)
+	assert.Equal(t, chainAlways, descriptions[3].next)
+}
+
+func TestShell_Execute_Conditionals(t *testing.T) {
+	tests := []struct {
+		line     string
+		output   string
+		wantCode int
+	}{
+		{line: "true && echo yes", output: "yes\n"},
+		{line: "false && echo yes", wantCode: 1},
+		{line: "false || echo fallback", output: "fallback\n"},
+		{line: "true || echo fallback"},
+		{line: "false && echo a || echo b", output: "b\n"},
+		{line: "true || echo a && echo b", output: "b\n"},
+		{line: "false && echo a; echo always", output: "always\n"},
+		{line: "echo first; echo second", output: "first\nsecond\n"},
+		{line: "echo hi | grep nothing || echo none", output: "none\n"},
+		{line: "sh -c 'exit 3' || sh -c 'exit 4' && echo unreachable", wantCode: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			sh, stdout := newTestShell(t)
+
+			retCode, exited, err := sh.Execute(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, retCode)
+			assert.False(t, exited)
+			assert.Equal(t, tt.output, readShellOutput(t, stdout))
+		})
+	}
+}
+
+func TestShell_Execute_ExitInChain(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, exited, err := sh.Execute("false || exit; echo unreachable")
+	require.NoError(t, err)
+	assert.True(t, exited)
+	assert.Empty(t, readShellOutput(t, stdout))
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"os"
+	"sync"
+)
+
+// processTable tracks the external commands that are running, so that they
+// can be told when the shell goes away. Starting and finishing processes
+// are published on events as job-state-changed.
+type processTable struct {
+	mu     sync.Mutex
+	procs  map[*os.Process]struct{}
+	events *eventBus
+}
+
+func newProcessTable(events *eventBus) *processTable {
+	return &processTable{procs: make(map[*os.Process]struct{}), events: events}
+}
+
+func (t *processTable) add(p *os.Process, args []string) {
+	t.mu.Lock()
+	t.procs[p] = struct{}{}
+	t.mu.Unlock()
+	t.events.publish(Event{Kind: EventJobStateChanged, Args: args, PID: p.Pid, State: JobRunning})
+}
+
+func (t *processTable) remove(p *os.Process, args []string) {
+	t.mu.Lock()
+	delete(t.procs, p)
+	t.mu.Unlock()
+	t.events.publish(Event{Kind: EventJobStateChanged, Args: args, PID: p.Pid, State: JobDone})
+}
+
+// signal sends sig to every running process and returns how many there were.
+func (t *processTable) signal(sig os.Signal) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for p := range t.procs {
+		_ = p.Signal(sig)
+	}
+	return len(t.procs)
+}
@@ -0,0 +1,204 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type cmpCommand struct {
+	firstPath  string
+	secondPath string
+	silent     bool
+}
+
+func parseCmpCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("cmp")
+	silent := fs.Bool("s", false, "suppress all normal output")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("cmp: %w", err)
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 || len(paths) > 2 {
+		return nil, fmt.Errorf("cmp: expected FILE1 [FILE2]")
+	}
+
+	secondPath := "-"
+	if len(paths) == 2 {
+		secondPath = paths[1]
+	}
+	if paths[0] == "-" && secondPath == "-" {
+		return nil, fmt.Errorf("cmp: only one of the files may be standard input")
+	}
+
+	return &cmpCommand{
+		firstPath:  paths[0],
+		secondPath: secondPath,
+		silent:     *silent,
+	}, nil
+}
+
+func (c *cmpCommand) open(path string, in *os.File) (*os.File, error) {
+	if path == "-" {
+		return in, nil
+	}
+	return os.Open(path)
+}
+
+func (c *cmpCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	first, err := c.open(c.firstPath, in)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cmp: %v\n", err)
+		return 2, false
+	}
+	if first != in {
+		defer func() {
+			_ = first.Close()
+		}()
+	}
+
+	second, err := c.open(c.secondPath, in)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cmp: %v\n", err)
+		return 2, false
+	}
+	if second != in {
+		defer func() {
+			_ = second.Close()
+		}()
+	}
+
+	firstReader := bufio.NewReader(first)
+	secondReader := bufio.NewReader(second)
+
+	offset, line := int64(1), int64(1)
+	for {
+		a, errA := firstReader.ReadByte()
+		b, errB := secondReader.ReadByte()
+
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			_, _ = fmt.Fprintf(errOut, "cmp: %s: %v\n", c.firstPath, errA)
+			return 2, false
+		}
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			_, _ = fmt.Fprintf(errOut, "cmp: %s: %v\n", c.secondPath, errB)
+			return 2, false
+		}
+
+		switch {
+		case errA != nil && errB != nil:
+			return 0, false
+		case errA != nil || errB != nil:
+			if !c.silent {
+				shorter := c.firstPath
+				if errB != nil {
+					shorter = c.secondPath
+				}
+				_, _ = fmt.Fprintf(errOut, "cmp: EOF on %s after byte %d, line %d\n", shorter, offset-1, line)
+			}
+			return 1, false
+		case a != b:
+			if !c.silent {
+				_, _ = fmt.Fprintf(out, "%s %s differ: byte %d, line %d\n", c.firstPath, c.secondPath, offset, line)
+			}
+			return 1, false
+		}
+
+		if a == '\n' {
+			line++
+		}
+		offset++
+	}
+}
+
+type dedupeCommand struct {
+	root string
+	// empty reports empty files as duplicates of each other too.
+	empty bool
+}
+
+func parseDedupeCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("dedupe")
+	empty := fs.Bool("z", false, "report empty files as duplicates too")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("dedupe: %w", err)
+	}
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("dedupe: expected [-z] DIR")
+	}
+	return &dedupeCommand{root: fs.Arg(0), empty: *empty}, nil
+}
+
+// Execute prints groups of files with identical contents, one path per line,
+// groups separated by a blank line. Files are first bucketed by size,
+// so only same-sized candidates are ever hashed. Empty files are all alike
+// and rarely worth reporting, so they are skipped unless asked for.
+func (d *dedupeCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	bySize := make(map[int64][]string)
+	err := filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() == 0 && !d.empty {
+			return nil
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], path)
+		return nil
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "dedupe: %v\n", err)
+		return 1, false
+	}
+
+	var groups [][]string
+	for _, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, path := range candidates {
+			sum, err := fileMD5(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "dedupe: %v\n", err)
+				retCode = 1
+				continue
+			}
+			byHash[sum] = append(byHash[sum], path)
+		}
+		for _, paths := range byHash {
+			if len(paths) > 1 {
+				sort.Strings(paths)
+				groups = append(groups, paths)
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	for i, group := range groups {
+		if i > 0 {
+			_, _ = fmt.Fprintln(out)
+		}
+		for _, path := range group {
+			_, _ = fmt.Fprintln(out, path)
+		}
+	}
+
+	return retCode, false
+}
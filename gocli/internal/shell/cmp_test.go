@@ -0,0 +1,130 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmpCommand_Execute_Identical(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "a")
+	second := filepath.Join(tmpDir, "b")
+	require.NoError(t, os.WriteFile(first, []byte("same\n"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("same\n"), 0644))
+
+	cmd := &cmpCommand{firstPath: first, secondPath: second}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+}
+
+func TestCmpCommand_Execute_Differ(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "a")
+	second := filepath.Join(tmpDir, "b")
+	require.NoError(t, os.WriteFile(first, []byte("line one\nline two\n"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("line one\nline 2\n"), 0644))
+
+	cmd, err := parseCmpCommand(CommandDescription{
+		name:      CmpCommand,
+		arguments: []string{"cmp", first, second},
+	})
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 1, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, first+" "+second+" differ: byte 15, line 2\n", string(output))
+}
+
+func TestCmpCommand_Execute_Prefix(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "a")
+	second := filepath.Join(tmpDir, "b")
+	require.NoError(t, os.WriteFile(first, []byte("abc"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("abcdef"), 0644))
+
+	cmd := &cmpCommand{firstPath: first, secondPath: second}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestCmpCommand_Execute_NonexistentFile(t *testing.T) {
+	cmd := &cmpCommand{firstPath: "/nonexistent/a", secondPath: "/nonexistent/b"}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 2, retCode)
+}
+
+func TestDedupeCommand_Execute(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755))
+	files := map[string]string{
+		"a.txt":       "duplicate",
+		"sub/b.txt":   "duplicate",
+		"c.txt":       "different",
+		"d.txt":       "unique content",
+		"sub/e.txt":   "other",
+		"sub/f.txt":   "other",
+		"sub/g.empty": "",
+		"h.empty":     "",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	cmd := &dedupeCommand{root: tmpDir}
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	expected := []string{
+		filepath.Join(tmpDir, "a.txt"),
+		filepath.Join(tmpDir, "sub/b.txt"),
+		"",
+		filepath.Join(tmpDir, "sub/e.txt"),
+		filepath.Join(tmpDir, "sub/f.txt"),
+	}
+	assert.Equal(t, strings.Join(expected, "\n")+"\n", string(output))
+
+	r, w, err = os.Pipe()
+	require.NoError(t, err)
+
+	cmd = &dedupeCommand{root: tmpDir, empty: true}
+	retCode, _ = cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+
+	output, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), filepath.Join(tmpDir, "h.empty")+"\n"+filepath.Join(tmpDir, "sub/g.empty")+"\n")
+}
+
+func TestParseCmpCommand_BothStdin(t *testing.T) {
+	for _, args := range [][]string{{"cmp", "-"}, {"cmp", "-", "-"}, {"cmp", "-s", "-"}} {
+		_, err := parseCmpCommand(CommandDescription{name: CmpCommand, arguments: args})
+		assert.EqualError(t, err, "cmp: only one of the files may be standard input", args)
+	}
+
+	_, err := parseCmpCommand(CommandDescription{name: CmpCommand, arguments: []string{"cmp", "a.txt", "-"}})
+	assert.NoError(t, err)
+}
@@ -2,27 +2,97 @@ package shell
 
 import (
 	"bufio"
-	"flag"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
 // NewCommandFactory creates a new CommandFactory that uses the given
 // environment to create command instances.
 func NewCommandFactory(env Env) CommandFactory {
-	return &commandFactory{env}
+	return newCommandFactory(env)
+}
+
+func newCommandFactory(env Env) *commandFactory {
+	events := newEventBus()
+	return &commandFactory{
+		env:      env,
+		mocks:    newMockRegistry(),
+		funcs:    newFuncRegistry(),
+		aliases:  newAliasTable(),
+		plugins:  newPluginTable(),
+		history:  newHistoryList(),
+		stats:    newCommandStats(),
+		segments: newSegmentRegistry(),
+		events:   events,
+		options:  newShellOptions(),
+		trash:    newTrashBin(),
+		deferred: newDeferStack(),
+		dirs:     newDirStack(),
+		snaps:    newEnvSnapshots(),
+		children: newProcessTable(events),
+		unsets:   newUnsetHistory(),
+		schedule: newScheduler(),
+		status:   &exitStatus{},
+		fsys:     OSFileSystem,
+	}
 }
 
 type commandFactory struct {
-	env Env
+	env      Env
+	mocks    *mockRegistry
+	funcs    *funcRegistry
+	aliases  *aliasTable
+	plugins  *pluginTable
+	history  *historyList
+	stats    *commandStats
+	segments *segmentRegistry
+	events   *eventBus
+	options  *shellOptions
+	trash    *trashBin
+	deferred *deferStack
+	dirs     *dirStack
+	snaps    *envSnapshots
+	children *processTable
+	unsets   *unsetHistory
+	schedule *scheduler
+	// status is $?, the status of the last pipeline.
+	status *exitStatus
+	// terminal is set while an interactive session runs.
+	terminal *terminalControl
+	// fsys is the filesystem that file-reading builtins work on.
+	fsys FileSystem
 }
 
 // GetCommand implements CommandFactory.
+// When the safety option is on, commands that look destructive are wrapped
+// so that they ask for confirmation before running.
 func (c *commandFactory) GetCommand(d CommandDescription) (Command, error) {
+	if isURL(d.fileInPath) && c.options.isSet(OptionURLInput) {
+		// Builtins that open the input redirection themselves could not
+		// download it; they read the body from stdin instead.
+		d.fileInPath = ""
+	}
+	cmd, err := c.newCommand(d)
+	if fallback, ok := c.systemFallback(d, err); ok {
+		cmd, err = fallback, nil
+	}
+	if err != nil || cmd == nil || !c.options.isSet(OptionSafety) {
+		return cmd, err
+	}
+	if reason := dangerReason(d, c.env); reason != "" {
+		return &guardedCommand{inner: cmd, reason: reason}, nil
+	}
+	return cmd, nil
+}
+
+func (c *commandFactory) newCommand(d CommandDescription) (Command, error) {
 	switch d.name {
 	case EnvAssignmentCmd:
 		return &envAssignmentCmd{
@@ -41,30 +111,229 @@ func (c *commandFactory) GetCommand(d CommandDescription) (Command, error) {
 		}
 		return &catCommand{
 			filePath: filePath,
+			fsys:     inputFS(c.fsys, c.options),
 		}, nil
 	case EchoCommand:
 		return &echoCommand{
 			args: d.arguments[1:],
 		}, nil
 	case WCCommand:
+		args := d.arguments[1:]
+		chars := len(args) > 0 && args[0] == "-m"
+		if chars {
+			args = args[1:]
+		}
 		var filePath string
-		if len(d.arguments) >= 2 {
-			filePath = d.arguments[1]
+		if len(args) >= 1 {
+			filePath = args[0]
 		} else if d.fileInPath != "" {
 			filePath = d.fileInPath
 		}
 		return &wcCommand{
 			filePath: filePath,
+			chars:    chars,
+			fsys:     inputFS(c.fsys, c.options),
 		}, nil
 	case GrepCommand:
-		return parseGrepCommand(d)
+		return parseGrepCommand(d, inputFS(c.fsys, c.options))
+	case SortCommand:
+		return parseSortCommand(d)
+	case HeadCommand:
+		return parseHeadCommand(d)
+	case CutCommand:
+		return parseCutCommand(d)
+	case SedCommand:
+		return parseSedCommand(d)
+	case LsCommand:
+		return parseLsCommand(d, c.fsys)
+	case TreeCommand:
+		return parseTreeCommand(d, c.fsys)
+	case CmpCommand:
+		return parseCmpCommand(d)
+	case DedupeCommand:
+		return parseDedupeCommand(d)
+	case SyncCommand:
+		return parseSyncCommand(d)
+	case AssertCommand:
+		return parseAssertCommand(d, c)
+	case MockCommand:
+		return parseMockCommand(d, c.mocks)
+	case SetCommand:
+		return parseSetCommand(d, c.env, c.options)
+	case NiceCommand:
+		return parseNiceCommand(d, c)
+	case LimitCommand:
+		return parseLimitCommand(d, c)
+	case UnbufferCommand:
+		return parseUnbufferCommand(d, c)
+	case RmCommand:
+		var trash *trashBin
+		if c.options.isSet(OptionSafety) {
+			trash = c.trash
+		}
+		return parseRmCommand(d, trash)
+	case TrashCommand, UndoCommand:
+		return parseTrashCommand(d, c.trash)
+	case DeferCommand:
+		return parseDeferCommand(d, c.deferred)
+	case EachCommand, FilterCommand:
+		return parseEachCommand(d, c, &inputProcessor{aliases: c.aliases}, c.fsys)
+	case SpongeCommand:
+		return parseSpongeCommand(d)
+	case CDCommand:
+		return parseCdCommand(d, c.dirs, c.events)
+	case MkcdCommand:
+		return parseMkcdCommand(d, c.dirs, c.events)
+	case UpCommand:
+		return parseUpCommand(d, c.dirs, c.events)
+	case BackCommand:
+		return &backCommand{stack: c.dirs, events: c.events}, nil
+	case BookmarkCommand:
+		return parseBookmarkCommand(d)
+	case EnvSnapshotCommand:
+		return parseEnvSnapshotCommand(d, c.snaps)
+	case ThemeCommand:
+		return parseThemeCommand(d, c.segments.render)
+	case ExportCommand:
+		return parseExportCommand(d)
+	case UnsetCommand:
+		return parseUnsetCommand(d, c.unsets)
+	case CexecCommand:
+		return parseCexecCommand(d)
+	case KexecCommand:
+		return parseKexecCommand(d)
+	case AliasCommand:
+		return parseAliasCommand(d, c.aliases)
+	case UnaliasCommand:
+		return parseUnaliasCommand(d, c.aliases)
+	case PluginCommand:
+		return parsePluginCommand(d, c.plugins, c.funcs)
+	case HistoryCommand:
+		return parseHistoryCommand(d, c.history)
+	case SourceCommand, DotCommand:
+		return parseSourceCommand(d, c)
+	case PortCommand:
+		return parsePortCommand(d)
+	case NcCommand:
+		return parseNcCommand(d)
+	case OnchangeCommand:
+		return parseOnchangeCommand(d, c, &inputProcessor{aliases: c.aliases}, c.fsys)
+	case SeqCommand:
+		return parseSeqCommand(d)
+	case EveryCommand:
+		return parseEveryCommand(d, c.schedule)
+	case AtCommand:
+		return parseAtCommand(d, c.schedule)
+	case ScheduleCommand:
+		return parseScheduleCommand(d, c.schedule)
+	case JSONCommand, YAMLCommand, TOMLCommand:
+		return parseDocumentCommand(d, inputFS(c.fsys, c.options))
+	case YesCommand:
+		return parseYesCommand(d)
+	case DateCommand:
+		return parseDateCommand(d)
+	case TplCommand:
+		return parseTplCommand(d, inputFS(c.fsys, c.options))
+	case BasenameCommand:
+		return parseBasenameCommand(d)
+	case DirnameCommand:
+		return parseDirnameCommand(d)
+	case SQLCommand:
+		return parseSQLCommand(d)
+	case MvCommand:
+		var trash *trashBin
+		if c.options.isSet(OptionSafety) {
+			trash = c.trash
+		}
+		return parseMvCommand(d, trash)
+	case GroupByCommand:
+		return parseGroupByCommand(d)
+	case CpCommand:
+		return parseCpCommand(d)
+	case WhereCommand:
+		return parseWhereCommand(d)
+	case SelectCommand:
+		return parseSelectCommand(d)
+	case PrintfCommand:
+		return parsePrintfCommand(d)
+	case SleepCommand:
+		return parseSleepCommand(d)
+	case SuggestCommand:
+		return parseSuggestCommand(d, c.stats)
+	case PreviewCommand:
+		return parsePreviewCommand(d, c)
+	case EnvCommand:
+		return parseEnvCommand(d, c)
+	case WhichCommand:
+		return parseWhichCommand(d, c.mocks, c.funcs)
+	case SubshellCommand:
+		return parseSubshellCommand(d, c)
 	default:
-		return &externalCommand{
-			args:        d.arguments,
-			redirectOut: d.fileInPath != "",
-			redirectIn:  d.fileOutPath != "",
-		}, nil
+		if fn, ok := c.funcs.get(string(d.name)); ok {
+			return &funcCommand{name: string(d.name), fn: fn, args: d.arguments[1:]}, nil
+		}
+		if mock, ok := c.mocks.get(string(d.name)); ok {
+			return &mockCommand{mock: mock, args: d.arguments}, nil
+		}
+		return c.externalCommand(d), nil
+	}
+}
+
+func (c *commandFactory) externalCommand(d CommandDescription) *externalCommand {
+	backend := c.execBackend()
+	_, local := backend.(localBackend)
+	return &externalCommand{
+		args:        d.arguments,
+		redirectOut: d.fileOutPath != "",
+		redirectIn:  d.fileInPath != "",
+		namespaces:  c.options.isSet(OptionNamespaces),
+		offerSudo:   c.options.isSet(OptionSudoPrompt) && local,
+		pty:         c.options.isSet(OptionPTY) || isInteractiveProgram(d.arguments[0]),
+		sanitize:    c.options.isSet(OptionSanitizeEnv),
+		aliases:     c.exportedAliases(),
+		children:    c.children,
+		terminal:    c.terminal,
+		backend:     backend,
+	}
+}
+
+// exportedAliases returns the aliases to pass to external commands, or nil
+// if export-aliases is off.
+func (c *commandFactory) exportedAliases() *aliasTable {
+	if !c.options.isSet(OptionExportAliases) {
+		return nil
+	}
+	return c.aliases
+}
+
+// systemFallback runs the system program a builtin is named after when the
+// builtin does not support one of the given options, so that "rm -v" still
+// works. With the safety option on, rm and mv never fall back, since the
+// program would bypass the trash.
+func (c *commandFactory) systemFallback(d CommandDescription, err error) (Command, bool) {
+	if !errors.Is(err, errUnsupportedFlag) {
+		return nil, false
+	}
+	if (d.name == RmCommand || d.name == MvCommand) && c.options.isSet(OptionSafety) {
+		return nil, false
 	}
+	path, lookErr := exec.LookPath(string(d.name))
+	if lookErr != nil || isOwnExecutable(path) {
+		return nil, false
+	}
+	return c.externalCommand(d), true
+}
+
+// isOwnExecutable tells whether path is the gocli binary itself, like the
+// applet links it installs, which would hand the options straight back.
+func isOwnExecutable(path string) bool {
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	self, selfErr := filepath.EvalSymlinks(self)
+	path, pathErr := filepath.EvalSymlinks(path)
+	return selfErr == nil && pathErr == nil && self == path
 }
 
 var (
@@ -75,6 +344,67 @@ var (
 	_ Command = (*echoCommand)(nil)
 	_ Command = (*wcCommand)(nil)
 	_ Command = (*grepCommand)(nil)
+	_ Command = (*sortCommand)(nil)
+	_ Command = (*headCommand)(nil)
+	_ Command = (*cutCommand)(nil)
+	_ Command = (*sedCommand)(nil)
+	_ Command = (*lsCommand)(nil)
+	_ Command = (*treeCommand)(nil)
+	_ Command = (*cmpCommand)(nil)
+	_ Command = (*dedupeCommand)(nil)
+	_ Command = (*syncCommand)(nil)
+	_ Command = (*assertCommand)(nil)
+	_ Command = (*mockBuiltin)(nil)
+	_ Command = (*mockCommand)(nil)
+	_ Command = (*setCommand)(nil)
+	_ Command = (*guardedCommand)(nil)
+	_ Command = (*rmCommand)(nil)
+	_ Command = (*trashCommand)(nil)
+	_ Command = (*deferCommand)(nil)
+	_ Command = (*eachCommand)(nil)
+	_ Command = (*spongeCommand)(nil)
+	_ Command = (*mkcdCommand)(nil)
+	_ Command = (*cdCommand)(nil)
+	_ Command = (*upCommand)(nil)
+	_ Command = (*backCommand)(nil)
+	_ Command = (*bookmarkCommand)(nil)
+	_ Command = (*envSnapshotCommand)(nil)
+	_ Command = (*themeCommand)(nil)
+	_ Command = (*exportCommand)(nil)
+	_ Command = (*unsetCommand)(nil)
+	_ Command = (*cexecCommand)(nil)
+	_ Command = (*kexecCommand)(nil)
+	_ Command = (*aliasCommand)(nil)
+	_ Command = (*unaliasCommand)(nil)
+	_ Command = (*pluginCommand)(nil)
+	_ Command = (*historyCommand)(nil)
+	_ Command = (*sourceCommand)(nil)
+	_ Command = (*portCommand)(nil)
+	_ Command = (*ncCommand)(nil)
+	_ Command = (*onchangeCommand)(nil)
+	_ Command = (*seqCommand)(nil)
+	_ Command = (*scheduleAddCommand)(nil)
+	_ Command = (*scheduleCommand)(nil)
+	_ Command = (*documentCommand)(nil)
+	_ Command = (*yesCommand)(nil)
+	_ Command = (*dateCommand)(nil)
+	_ Command = (*tplCommand)(nil)
+	_ Command = (*basenameCommand)(nil)
+	_ Command = (*dirnameCommand)(nil)
+	_ Command = (*sqlCommand)(nil)
+	_ Command = (*mvCommand)(nil)
+	_ Command = (*groupByCommand)(nil)
+	_ Command = (*cpCommand)(nil)
+	_ Command = (*whereCommand)(nil)
+	_ Command = (*selectCommand)(nil)
+	_ Command = (*printfCommand)(nil)
+	_ Command = (*sleepCommand)(nil)
+	_ Command = (*suggestCommand)(nil)
+	_ Command = (*previewCommand)(nil)
+	_ Command = (*envCommand)(nil)
+	_ Command = (*funcCommand)(nil)
+	_ Command = (*whichCommand)(nil)
+	_ Command = (*subshellCommand)(nil)
 	_ Command = (*externalCommand)(nil)
 )
 
@@ -83,7 +413,7 @@ type envAssignmentCmd struct {
 	key, value string
 }
 
-func (e *envAssignmentCmd) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (e *envAssignmentCmd) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	e.env.Set(e.key, e.value)
 	return 0, false
 }
@@ -91,7 +421,7 @@ func (e *envAssignmentCmd) Execute(in, out *os.File, env Env) (retCode int, exit
 type pwdCommand struct {
 }
 
-func (c *pwdCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (c *pwdCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return -1, true
@@ -105,22 +435,23 @@ func (c *pwdCommand) Execute(in, out *os.File, env Env) (retCode int, exited boo
 type exitCommand struct {
 }
 
-func (e *exitCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (e *exitCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	return 0, true
 }
 
 type catCommand struct {
 	filePath string
+	fsys     FileSystem
 }
 
-func (c *catCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
-	var source *os.File
+func (c *catCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	var source io.ReadCloser
 	var shouldClose bool
 
 	if c.filePath != "" {
-		file, err := os.Open(c.filePath)
+		file, err := c.fsys.Open(c.filePath)
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "cat: %v\n", err)
+			_, _ = fmt.Fprintf(errOut, "cat: %v\n", err)
 			return 1, false
 		}
 		source = file
@@ -131,14 +462,18 @@ func (c *catCommand) Execute(in, out *os.File, env Env) (retCode int, exited boo
 	}
 
 	if shouldClose {
-		defer func(file *os.File) {
+		defer func(file io.Closer) {
 			_ = file.Close()
 		}(source)
 	}
 
 	_, err := io.Copy(out, source)
+	if errors.Is(err, syscall.EPIPE) {
+		// The reader went away, like head after enough lines; that is not worth a message.
+		return 1, false
+	}
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "cat: %v\n", err)
+		_, _ = fmt.Fprintf(errOut, "cat: %v\n", err)
 		return 1, false
 	}
 
@@ -149,26 +484,31 @@ type echoCommand struct {
 	args []string
 }
 
-func (e *echoCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (e *echoCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	output := strings.Join(e.args, " ")
 	_, _ = fmt.Fprintln(out, output)
 	return 0, false
 }
 
+// wcCommand counts lines, words and bytes, or with -m only characters,
+// counted as grapheme clusters so that "é" written with a combining accent
+// or a flag emoji is one character.
 type wcCommand struct {
 	filePath string
+	chars    bool
+	fsys     FileSystem
 }
 
-func (w *wcCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
-	var source *os.File
+func (w *wcCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	var source io.ReadCloser
 	var shouldClose bool
 	var bytes int64
 	var displayName string
 
 	if w.filePath != "" {
-		file, err := os.Open(w.filePath)
+		file, err := w.fsys.Open(w.filePath)
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "wc: %v\n", err)
+			_, _ = fmt.Fprintf(errOut, "wc: %v\n", err)
 			return 1, false
 		}
 		source = file
@@ -178,7 +518,7 @@ func (w *wcCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool
 		fileInfo, err := file.Stat()
 		if err != nil {
 			_ = file.Close()
-			_, _ = fmt.Fprintf(os.Stderr, "wc: %v\n", err)
+			_, _ = fmt.Fprintf(errOut, "wc: %v\n", err)
 			return 1, false
 		}
 		bytes = fileInfo.Size()
@@ -189,31 +529,41 @@ func (w *wcCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool
 	}
 
 	if shouldClose {
-		defer func(file *os.File) {
+		defer func(file io.Closer) {
 			_ = file.Close()
 		}(source)
 	}
 
 	scanner := bufio.NewScanner(source)
+	scanner.Split(scanLinesWithEnd)
 	lines := 0
 	words := 0
+	chars := 0
 
 	for scanner.Scan() {
 		lines++
 		line := scanner.Text()
-		if line != "" {
-			words += len(strings.Fields(line))
-		}
+		words += len(strings.Fields(line))
+		chars += graphemeCount(line)
 		if w.filePath == "" {
-			bytes += int64(len(scanner.Bytes()) + 1)
+			bytes += int64(len(scanner.Bytes()))
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "wc: %v\n", err)
+		_, _ = fmt.Fprintf(errOut, "wc: %v\n", err)
 		return 1, false
 	}
 
+	if w.chars {
+		if displayName != "" {
+			_, _ = fmt.Fprintf(out, "%d %s\n", chars, displayName)
+		} else {
+			_, _ = fmt.Fprintf(out, "%d\n", chars)
+		}
+		return 0, false
+	}
+
 	if displayName != "" {
 		_, _ = fmt.Fprintf(out, "%d %d %d %s\n", lines, words, bytes, displayName)
 	} else {
@@ -223,16 +573,29 @@ func (w *wcCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool
 	return 0, false
 }
 
+// scanLinesWithEnd is bufio.ScanLines that keeps the line terminator, so the
+// newline is only counted where the input has one.
+func scanLinesWithEnd(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := strings.IndexByte(string(data), '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 type grepCommand struct {
 	pattern         string
 	filePath        string
 	wholeWord       bool
 	caseInsensitive bool
 	afterLines      int
+	fsys            FileSystem
 }
 
-func parseGrepCommand(d CommandDescription) (Command, error) {
-	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+func parseGrepCommand(d CommandDescription, fsys FileSystem) (Command, error) {
+	fs := newFlagSet("grep")
 	wholeWord := fs.Bool("w", false, "match whole word")
 	caseInsensitive := fs.Bool("i", false, "case-insensitive search")
 	afterLines := fs.Int("A", 0, "print N lines after match")
@@ -261,10 +624,11 @@ func parseGrepCommand(d CommandDescription) (Command, error) {
 		wholeWord:       *wholeWord,
 		caseInsensitive: *caseInsensitive,
 		afterLines:      *afterLines,
+		fsys:            fsys,
 	}, nil
 }
 
-func (g *grepCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (g *grepCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	pattern := g.pattern
 
 	var regexFlags string
@@ -279,17 +643,17 @@ func (g *grepCommand) Execute(in, out *os.File, env Env) (retCode int, exited bo
 
 	re, err := regexp.Compile(regexFlags + pattern)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "grep: invalid pattern: %v\n", err)
+		_, _ = fmt.Fprintf(errOut, "grep: invalid pattern: %v\n", err)
 		return 1, false
 	}
 
-	var source *os.File
+	var source io.ReadCloser
 	var shouldClose bool
 
 	if g.filePath != "" {
-		file, err := os.Open(g.filePath)
+		file, err := g.fsys.Open(g.filePath)
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "grep: %v\n", err)
+			_, _ = fmt.Fprintf(errOut, "grep: %v\n", err)
 			return 1, false
 		}
 		source = file
@@ -300,7 +664,7 @@ func (g *grepCommand) Execute(in, out *os.File, env Env) (retCode int, exited bo
 	}
 
 	if shouldClose {
-		defer func(file *os.File) {
+		defer func(file io.Closer) {
 			_ = file.Close()
 		}(source)
 	}
@@ -312,7 +676,7 @@ func (g *grepCommand) Execute(in, out *os.File, env Env) (retCode int, exited bo
 	}
 
 	if err := scanner.Err(); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "grep: %v\n", err)
+		_, _ = fmt.Fprintf(errOut, "grep: %v\n", err)
 		return 1, false
 	}
 
@@ -348,18 +712,77 @@ type externalCommand struct {
 	args        []string
 	redirectOut bool
 	redirectIn  bool
+	namespaces  bool
+	offerSudo   bool
+	pty         bool
+	// sanitize passes the process only the variables that sanitizeEnv keeps.
+	sanitize bool
+	// aliases, if set, are passed to the process for a child gocli to define.
+	aliases *aliasTable
+	limits  resourceLimits
+	// children, if set, tracks the process while it runs.
+	children *processTable
+	// terminal, if set, runs the process in the foreground of the terminal.
+	terminal *terminalControl
+	// backend, if set, starts the process instead of running it locally.
+	backend ExecBackend
 }
 
-func (e *externalCommand) Execute(in, out *os.File, env Env) (retCode int, exited bool) {
+func (e *externalCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
 	cmdName := e.args[0]
-	cmdArgs := e.args[1:]
 
-	cmd := exec.Command(cmdName, cmdArgs...)
+	var backend ExecBackend = localBackend{}
+	if e.backend != nil {
+		backend = e.backend
+	}
+	cmd := backend.Command(e.args)
 	cmd.Stdin = in
 	cmd.Stdout = out
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = errOut
+
+	var stderrTail *tailBuffer
+	outSize := int64(-1)
+	if e.offerSudo {
+		stderrTail = &tailBuffer{limit: stderrTailSize}
+		cmd.Stderr = io.MultiWriter(errOut, stderrTail)
+		if e.redirectOut {
+			outSize = outputSize(out)
+		}
+	}
 
-	envMap := env.GetAll()
+	if _, isTerminal := terminalWidth(out); e.pty && !isTerminal {
+		sizeFrom := errOut
+		if _, ok := terminalWidth(errOut); !ok {
+			sizeFrom = in
+		}
+		finish, err := attachPTY(cmd, out, sizeFrom)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "%s: %v\n", cmdName, err)
+			return 1, false
+		}
+		defer finish()
+	}
+
+	if e.namespaces {
+		attrs, err := namespaceAttrs()
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "%s: %v\n", cmdName, err)
+			return 1, false
+		}
+		cmd.SysProcAttr = attrs
+	}
+
+	envMap := env.Exported()
+	if e.sanitize {
+		allow, _ := env.Get(EnvAllowVar)
+		envMap = sanitizeEnv(envMap, allow)
+	}
+	if e.aliases != nil {
+		exportAliases(envMap, e.aliases)
+		if e.backend == nil {
+			cmd.Args = childShellArgs(cmd.Path, cmd.Args)
+		}
+	}
 
 	envList := make([]string, 0, len(envMap))
 	for k, v := range envMap {
@@ -367,13 +790,47 @@ func (e *externalCommand) Execute(in, out *os.File, env Env) (retCode int, exite
 	}
 	cmd.Env = envList
 
-	err := cmd.Run()
+	err := e.run(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
+			if stderrTail != nil && e.shouldRetryWithSudo(in, stderrTail.String()) {
+				if err := restoreOutput(out, outSize); err != nil {
+					_, _ = fmt.Fprintf(errOut, "%s: %v\n", cmdName, err)
+					return exitErr.ExitCode(), false
+				}
+				return e.withSudo().Execute(in, out, errOut, env)
+			}
 			return exitErr.ExitCode(), false
 		}
-		_, _ = fmt.Fprintln(os.Stderr, err)
+		_, _ = fmt.Fprintln(errOut, err)
 		return 1, false
 	}
 	return 0, false
 }
+
+// run starts cmd with the configured resource limits and waits for completion.
+func (e *externalCommand) run(cmd *exec.Cmd) error {
+	start := func(cmd *exec.Cmd) error {
+		if e.limits.isZero() {
+			return cmd.Start()
+		}
+		return startWithLimits(cmd, e.limits)
+	}
+	cmd, err := e.terminal.start(cmd, start)
+	if err != nil {
+		return err
+	}
+
+	if e.children != nil {
+		e.children.add(cmd.Process, e.args)
+		defer e.children.remove(cmd.Process, e.args)
+	}
+	return cmd.Wait()
+}
+
+func (e *externalCommand) withSudo() *externalCommand {
+	retry := *e
+	retry.args = append([]string{"sudo"}, e.args...)
+	retry.offerSudo = false
+	return &retry
+}
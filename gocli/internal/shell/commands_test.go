@@ -18,7 +18,7 @@ func TestEnvAssignmentCmd_Execute(t *testing.T) {
 		value: "test_value",
 	}
 
-	retCode, exited := cmd.Execute(nil, nil, env)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, env)
 	assert.Equal(t, 0, retCode)
 	assert.False(t, exited)
 
@@ -29,14 +29,14 @@ func TestEnvAssignmentCmd_Execute(t *testing.T) {
 
 func TestPwdCommand_Execute(t *testing.T) {
 	cmd := &pwdCommand{}
-	retCode, exited := cmd.Execute(nil, nil, nil)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
 	assert.Equal(t, 0, retCode)
 	assert.False(t, exited)
 }
 
 func TestExitCommand_Execute(t *testing.T) {
 	cmd := &exitCommand{}
-	retCode, exited := cmd.Execute(nil, nil, nil)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
 	assert.Equal(t, 0, retCode)
 	assert.True(t, exited)
 }
@@ -49,11 +49,11 @@ func TestCatCommand_Execute(t *testing.T) {
 	err := os.WriteFile(testFile, []byte(content), 0644)
 	require.NoError(t, err)
 
-	cmd := &catCommand{filePath: testFile}
+	cmd := &catCommand{filePath: testFile, fsys: OSFileSystem}
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, nil)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -65,8 +65,8 @@ func TestCatCommand_Execute(t *testing.T) {
 }
 
 func TestCatCommand_Execute_NonexistentFile(t *testing.T) {
-	cmd := &catCommand{filePath: "/nonexistent/file.txt"}
-	retCode, exited := cmd.Execute(nil, nil, nil)
+	cmd := &catCommand{filePath: "/nonexistent/file.txt", fsys: OSFileSystem}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
 	assert.Equal(t, 1, retCode)
 	assert.False(t, exited)
 }
@@ -76,7 +76,7 @@ func TestEchoCommand_Execute(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, nil)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -94,11 +94,11 @@ func TestWcCommand_Execute(t *testing.T) {
 	err := os.WriteFile(testFile, []byte("line one\nline two\n"), 0644)
 	require.NoError(t, err)
 
-	cmd := &wcCommand{filePath: testFile}
+	cmd := &wcCommand{filePath: testFile, fsys: OSFileSystem}
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, nil)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -114,14 +114,14 @@ func TestWcCommand_Execute(t *testing.T) {
 }
 
 func TestWcCommand_Execute_NonexistentFile(t *testing.T) {
-	cmd := &wcCommand{filePath: "/nonexistent/file.txt"}
-	retCode, exited := cmd.Execute(nil, nil, nil)
+	cmd := &wcCommand{filePath: "/nonexistent/file.txt", fsys: OSFileSystem}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
 	assert.Equal(t, 1, retCode)
 	assert.False(t, exited)
 }
 
 func TestWcCommand_Execute_FromStdin(t *testing.T) {
-	cmd := &wcCommand{filePath: ""}
+	cmd := &wcCommand{filePath: "", fsys: OSFileSystem}
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
@@ -133,7 +133,7 @@ func TestWcCommand_Execute_FromStdin(t *testing.T) {
 	outputR, outputW, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(r, outputW, nil)
+	retCode, exited := cmd.Execute(r, outputW, os.Stderr, nil)
 	assert.NoError(t, outputW.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -257,7 +257,7 @@ func TestGrepCommand_Execute_BasicMatch(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -289,7 +289,7 @@ func TestGrepCommand_Execute_RegexMatch(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -321,7 +321,7 @@ func TestGrepCommand_Execute_CaseInsensitive(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -357,7 +357,7 @@ func TestGrepCommand_Execute_WholeWord(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -393,7 +393,7 @@ func TestGrepCommand_Execute_AfterLines(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -429,7 +429,7 @@ func TestGrepCommand_Execute_AfterLinesOverlap(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -468,7 +468,7 @@ func TestGrepCommand_Execute_FromStdin(t *testing.T) {
 	outputR, outputW, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(inputR, outputW, env)
+	retCode, exited := cmd.Execute(inputR, outputW, os.Stderr, env)
 	assert.NoError(t, outputW.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -497,7 +497,7 @@ func TestGrepCommand_Execute_NoMatch(t *testing.T) {
 	cmd, err := factory.GetCommand(desc)
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, nil, env)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, env)
 	assert.Equal(t, 1, retCode)
 	assert.False(t, exited)
 }
@@ -513,7 +513,7 @@ func TestGrepCommand_Execute_NonexistentFile(t *testing.T) {
 	cmd, err := factory.GetCommand(desc)
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, nil, env)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, env)
 	assert.Equal(t, 1, retCode)
 	assert.False(t, exited)
 }
@@ -535,7 +535,7 @@ func TestGrepCommand_Execute_InvalidPattern(t *testing.T) {
 	cmd, err := factory.GetCommand(desc)
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, nil, env)
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, env)
 	assert.Equal(t, 1, retCode)
 	assert.False(t, exited)
 }
@@ -560,7 +560,7 @@ func TestGrepCommand_Execute_CombinedFlags(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -596,7 +596,7 @@ func TestGrepCommand_Execute_AfterLinesZero(t *testing.T) {
 	r, w, err := os.Pipe()
 	require.NoError(t, err)
 
-	retCode, exited := cmd.Execute(nil, w, env)
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, env)
 	assert.NoError(t, w.Close())
 
 	assert.Equal(t, 0, retCode)
@@ -614,6 +614,6 @@ func TestGrepCommand_Parse_NoPattern(t *testing.T) {
 		arguments: []string{"grep"},
 	}
 
-	_, err := parseGrepCommand(desc)
+	_, err := parseGrepCommand(desc, OSFileSystem)
 	assert.Error(t, err)
 }
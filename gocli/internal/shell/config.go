@@ -0,0 +1,20 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configDir returns the directory holding gocli's persistent files,
+// creating it if necessary.
+func configDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "gocli")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,29 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// confirm asks a yes/no question on the controlling terminal, so that the answer
+// is never taken from redirected or piped standard input. It returns false when
+// there is no terminal, which keeps non-interactive sessions from blocking.
+var confirm = func(question string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = tty.Close()
+	}()
+
+	_, _ = fmt.Fprintf(tty, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,134 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cpCommand copies files, and with -r directories, keeping their
+// permissions and modification times, and with -p their owners too.
+type cpCommand struct {
+	sources   []string
+	dest      string
+	recursive bool
+	verbose   bool
+	preserve  bool
+}
+
+func parseCpCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("cp")
+	recursive := fs.Bool("r", false, "copy directories recursively")
+	fs.BoolVar(recursive, "R", false, "same as -r")
+	verbose := fs.Bool("v", false, "explain what is being done")
+	preserve := fs.Bool("p", false, "also preserve the owner and group where permitted")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("cp: %w", err)
+	}
+	switch fs.NArg() {
+	case 0:
+		return nil, fmt.Errorf("cp: missing file operand")
+	case 1:
+		return nil, fmt.Errorf("cp: missing destination file operand after '%s'", fs.Arg(0))
+	}
+
+	args := fs.Args()
+	return &cpCommand{
+		sources:   args[:len(args)-1],
+		dest:      args[len(args)-1],
+		recursive: *recursive,
+		verbose:   *verbose,
+		preserve:  *preserve,
+	}, nil
+}
+
+// Execute copies every source into the destination if it is a directory,
+// or onto it otherwise, which is only allowed for a single source.
+func (c *cpCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	info, err := os.Stat(c.dest)
+	intoDir := err == nil && info.IsDir()
+	if len(c.sources) > 1 && !intoDir {
+		_, _ = fmt.Fprintf(errOut, "cp: target '%s' is not a directory\n", c.dest)
+		return 1, false
+	}
+
+	for _, src := range c.sources {
+		target := c.dest
+		if intoDir {
+			target = filepath.Join(c.dest, filepath.Base(filepath.Clean(src)))
+		}
+		if err := c.copy(src, target, out); err != nil {
+			_, _ = fmt.Fprintf(errOut, "cp: %v\n", err)
+			retCode = 1
+		}
+	}
+	return retCode, false
+}
+
+func (c *cpCommand) copy(src, dst string, out io.Writer) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("cannot stat '%s': %w", src, err)
+	}
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return fmt.Errorf("'%s' and '%s' are the same file", src, dst)
+	}
+	report := func(src, dst string) {
+		if c.preserve {
+			preserveOwner(src, dst)
+		}
+		if c.verbose {
+			_, _ = fmt.Fprintf(out, "'%s' -> '%s'\n", src, dst)
+		}
+	}
+
+	if !info.IsDir() {
+		if err := copyFile(src, dst, info.Mode()); err != nil {
+			return err
+		}
+		report(src, dst)
+		return nil
+	}
+	if !c.recursive {
+		return fmt.Errorf("-r not specified; omitting directory '%s'", src)
+	}
+	if isWithin(dst, src) {
+		return fmt.Errorf("cannot copy a directory, '%s', into itself, '%s'", src, dst)
+	}
+	return copyTree(src, dst, report)
+}
+
+// preserveOwner gives dst the owner and group of src. Like cp -p, it quietly
+// keeps the current ones when it is not permitted to change them.
+func preserveOwner(src, dst string) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return
+	}
+	if _, uid, gid, ok := fileOwnership(info); ok {
+		_ = os.Lchown(dst, int(uid), int(gid))
+	}
+}
+
+// isWithin reports whether path is dir or lies inside it.
+func isWithin(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCpCommand_Files(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("a", []byte("alpha"), 0640))
+	require.NoError(t, os.WriteFile("b", []byte("beta"), 0600))
+	stamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes("a", stamp, stamp))
+	require.NoError(t, os.Mkdir("dir", 0755))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("cp a copy; cp -v a b dir")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "'a' -> 'dir/a'\n'b' -> 'dir/b'\n", readShellOutput(t, stdout))
+
+	for _, name := range []string{"copy", "dir/a"} {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "alpha", string(content))
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0640), info.Mode().Perm())
+		assert.True(t, info.ModTime().Equal(stamp), name)
+	}
+
+	for _, line := range []string{"cp a", "cp missing x", "cp a b copy", "cp a a", "cp dir other"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+	_, err = os.Stat("other")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "directories need -r")
+}
+
+func TestCpCommand_Recursive(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.MkdirAll("src/sub", 0755))
+	require.NoError(t, os.WriteFile("src/sub/f", []byte("data"), 0644))
+	require.NoError(t, os.Symlink("sub/f", "src/link"))
+	require.NoError(t, os.Chmod("src/sub", 0555))
+	t.Cleanup(func() { _ = os.Chmod("src/sub", 0755) })
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("cp -r src dst; mkdir into; cp -r src into")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	for _, root := range []string{"dst", "into/src"} {
+		t.Cleanup(func() { _ = os.Chmod(filepath.Join(root, "sub"), 0755) })
+		content, err := os.ReadFile(filepath.Join(root, "sub/f"))
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(content))
+		target, err := os.Readlink(filepath.Join(root, "link"))
+		require.NoError(t, err)
+		assert.Equal(t, "sub/f", target)
+		info, err := os.Stat(filepath.Join(root, "sub"))
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0555), info.Mode().Perm())
+	}
+
+	retCode, _, err = sh.Execute("cp -r src src/sub/inner 2> /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestCpCommand_BundledFlags(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.MkdirAll("src/sub", 0755))
+	require.NoError(t, os.WriteFile("src/sub/f", []byte("data"), 0644))
+	stamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes("src/sub", stamp, stamp))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("cp -rv src dst; cp -pR src kept")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "'src' -> 'dst'\n'src/sub' -> 'dst/sub'\n'src/sub/f' -> 'dst/sub/f'\n", readShellOutput(t, stdout))
+	for _, root := range []string{"dst", "kept"} {
+		info, err := os.Stat(filepath.Join(root, "sub"))
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(stamp), root)
+	}
+}
@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// CrashStatus is the status of a command that panicked (EX_SOFTWARE in sysexits.h).
+const CrashStatus = 70
+
+const (
+	crashLogFile = "crash.log"
+	bugReportURL = "https://github.com/art22m/MHS-Software-Design-F25/issues/new"
+	// maxReportStack keeps the pre-filled bug report URL short enough for browsers.
+	maxReportStack = 4000
+)
+
+// crash is a recovered panic together with where it happened.
+type crash struct {
+	args  []string
+	value any
+	stack []byte
+}
+
+// report writes the crash to the crash log and tells the user about it on errOut.
+// With offerReport it also prints a link that opens a pre-filled bug report.
+func (c crash) report(errOut io.Writer, offerReport bool) {
+	where := "gocli"
+	if len(c.args) > 0 {
+		where = c.args[0]
+	}
+	msg := fmt.Sprintf("gocli: %s: panic: %v", where, c.value)
+	if path, err := c.writeLog(); err == nil {
+		msg += " (stack trace saved to " + path + ")"
+	}
+	_, _ = fmt.Fprintln(errOut, msg)
+	if offerReport {
+		_, _ = fmt.Fprintf(errOut, "gocli: please report the bug at %s\n", c.reportURL())
+	}
+}
+
+// writeLog appends the crash with its stack trace to the crash log in the config directory.
+func (c crash) writeLog() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, crashLogFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = fmt.Fprintf(f, "=== %s\ncommand: %q\npanic: %v\n\n%s\n",
+		time.Now().Format(time.RFC3339), c.args, c.value, c.stack)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
+
+// reportURL returns a link to a new issue describing the crash.
+func (c crash) reportURL() string {
+	stack := string(c.stack)
+	if len(stack) > maxReportStack {
+		stack = stack[:maxReportStack] + "\n..."
+	}
+	body := fmt.Sprintf("Command: `%s`\nPlatform: %s/%s, %s\n\n```\npanic: %v\n\n%s```\n",
+		strings.Join(c.args, " "), runtime.GOOS, runtime.GOARCH, runtime.Version(), c.value, stack)
+	query := url.Values{
+		"title": {fmt.Sprintf("Crash: %v", c.value)},
+		"body":  {body},
+	}
+	return bugReportURL + "?" + query.Encode()
+}
+
+// runCommand executes cmd, turning a panic into CrashStatus so that a broken
+// command does not take the whole session down.
+func (p *pipelineRunner) runCommand(cmd Command, args []string, in, out, errOut *os.File, env Env) (code int, exited bool) {
+	defer func() {
+		if value := recover(); value != nil {
+			p.log().Error("command panicked", "args", args, "panic", value)
+			c := crash{args: args, value: value, stack: debug.Stack()}
+			c.report(errOut, p.options != nil && p.options.isSet(OptionBugReport))
+			code, exited = CrashStatus, false
+		}
+	}()
+	return cmd.Execute(in, out, errOut, env)
+}
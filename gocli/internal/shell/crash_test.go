@@ -0,0 +1,110 @@
+package shell
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicCommand struct{}
+
+func (c *panicCommand) Execute(_, _, _ *os.File, _ Env) (int, bool) {
+	panic("boom")
+}
+
+// panicFactory creates panicking commands for "crash" and defers to the real factory otherwise.
+type panicFactory struct {
+	CommandFactory
+}
+
+func (f panicFactory) GetCommand(desc CommandDescription) (Command, error) {
+	if desc.name == "crash" {
+		return &panicCommand{}, nil
+	}
+	return f.CommandFactory.GetCommand(desc)
+}
+
+func TestPipelineRunner_Execute_RecoversPanic(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	dir := t.TempDir()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+	defer func() { _ = stderr.Close() }()
+
+	env := NewEnv()
+	runner := &pipelineRunner{env: env, factory: panicFactory{NewCommandFactory(env)}, stderr: stderr}
+	cmds, err := NewInputProcessor().Parse("echo hi | crash && echo unreachable || echo recovered > " + filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	retCode, exited := runner.Execute(cmds, env)
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	out, err := os.ReadFile(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	assert.Equal(t, "recovered\n", string(out))
+
+	logPath := filepath.Join(configHome, "gocli", crashLogFile)
+	message, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "gocli: crash: panic: boom (stack trace saved to "+logPath+")\n", string(message))
+
+	crashLog, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(crashLog), "command: [\"crash\"]\npanic: boom\n")
+	assert.Contains(t, string(crashLog), "panicCommand")
+}
+
+func TestPipelineRunner_Execute_CrashStatus(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	env := NewEnv()
+	runner := &pipelineRunner{env: env, factory: panicFactory{NewCommandFactory(env)}, stderr: os.Stderr}
+	cmds, err := NewInputProcessor().Parse("crash")
+	require.NoError(t, err)
+
+	retCode, exited := runner.Execute(cmds, env)
+	assert.Equal(t, CrashStatus, retCode)
+	assert.False(t, exited)
+}
+
+func TestShell_Execute_CrashSetsStatus(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	sh, stdout := newTestShell(t)
+	sh.RegisterFunc("crash", func(context.Context, []string, io.Reader, io.Writer) (int, error) {
+		panic("boom")
+	})
+
+	retCode, _, err := sh.Execute("crash 2> /dev/null; echo $?")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "70\n", readShellOutput(t, stdout))
+}
+
+func TestCrash_ReportURL(t *testing.T) {
+	c := crash{args: []string{"crash", "now"}, value: "boom", stack: []byte(strings.Repeat("x", maxReportStack+10))}
+
+	link, err := url.Parse(c.reportURL())
+	require.NoError(t, err)
+	assert.Equal(t, bugReportURL, link.Scheme+"://"+link.Host+link.Path)
+	assert.Equal(t, "Crash: boom", link.Query().Get("title"))
+	body := link.Query().Get("body")
+	assert.Contains(t, body, "Command: `crash now`\n")
+	assert.Contains(t, body, "panic: boom\n")
+	assert.Contains(t, body, "x\n...```")
+	assert.NotContains(t, body, strings.Repeat("x", maxReportStack+1))
+}
+
+func TestCrash_Report_OfferBugReport(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var sb strings.Builder
+	crash{args: []string{"crash"}, value: "boom"}.report(&sb, true)
+	assert.Contains(t, sb.String(), "gocli: please report the bug at "+bugReportURL+"?")
+}
@@ -0,0 +1,193 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cutRange is an inclusive range of 1-based field or character positions.
+type cutRange struct {
+	from, to int
+}
+
+// cutCommand prints selected fields (-f, split at -d) or characters (-c)
+// of every line of its files or input.
+type cutCommand struct {
+	filePaths []string
+	ranges    []cutRange
+	chars     bool
+	delimiter string
+	// onlyDelimited drops lines without the delimiter instead of printing them whole.
+	onlyDelimited bool
+}
+
+func parseCutCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("cut")
+	delimiter := fs.String("d", "\t", "use DELIM instead of TAB as the field delimiter")
+	fields := fs.String("f", "", "select only these fields")
+	chars := fs.String("c", "", "select only these characters")
+	onlyDelimited := fs.Bool("s", false, "do not print lines not containing delimiters")
+
+	if err := fs.Parse(splitAttachedValues(d.arguments[1:], "-d", "-f", "-c")); err != nil {
+		return nil, fmt.Errorf("cut: %w", err)
+	}
+	if (*fields == "") == (*chars == "") {
+		return nil, fmt.Errorf("cut: specify a list of fields (-f) or characters (-c)")
+	}
+	if len([]rune(*delimiter)) != 1 {
+		return nil, fmt.Errorf("cut: the delimiter must be a single character")
+	}
+
+	list := *fields
+	if *chars != "" {
+		list = *chars
+	}
+	ranges, err := parseCutList(list)
+	if err != nil {
+		return nil, fmt.Errorf("cut: %w", err)
+	}
+
+	filePaths := fs.Args()
+	if len(filePaths) == 0 && d.fileInPath != "" {
+		filePaths = []string{d.fileInPath}
+	}
+
+	return &cutCommand{
+		filePaths:     filePaths,
+		ranges:        ranges,
+		chars:         *chars != "",
+		delimiter:     *delimiter,
+		onlyDelimited: *onlyDelimited,
+	}, nil
+}
+
+// splitAttachedValues rewrites options written together with their value,
+// like "-d:" or "-f2-", into two arguments, as the flag package expects.
+func splitAttachedValues(args []string, options ...string) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return append(result, args[i:]...)
+		}
+		split := false
+		for _, option := range options {
+			if len(arg) > len(option) && strings.HasPrefix(arg, option) {
+				result = append(result, option, arg[len(option):])
+				split = true
+				break
+			}
+		}
+		if !split {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// parseCutList parses a comma-separated list of positions and ranges:
+// "N", "N-M", "N-" (to the end of the line) and "-M" (from the start).
+func parseCutList(list string) ([]cutRange, error) {
+	var ranges []cutRange
+	for _, item := range strings.Split(list, ",") {
+		fromText, toText, isRange := strings.Cut(item, "-")
+		r := cutRange{from: 1, to: math.MaxInt}
+		var err error
+		if fromText != "" {
+			if r.from, err = strconv.Atoi(fromText); err != nil || r.from < 1 {
+				return nil, fmt.Errorf("invalid position %q in list %q", fromText, list)
+			}
+		}
+		if toText != "" {
+			if r.to, err = strconv.Atoi(toText); err != nil || r.to < 1 {
+				return nil, fmt.Errorf("invalid position %q in list %q", toText, list)
+			}
+		}
+		switch {
+		case !isRange:
+			r.to = r.from
+		case fromText == "" && toText == "":
+			return nil, fmt.Errorf("invalid range with no endpoint in list %q", list)
+		case r.from > r.to:
+			return nil, fmt.Errorf("invalid decreasing range %q", item)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// selected reports whether the 1-based position pos is in one of the ranges.
+func (c *cutCommand) selected(pos int) bool {
+	for _, r := range c.ranges {
+		if pos >= r.from && pos <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// cut returns the selected part of line and whether the line is printed at all.
+func (c *cutCommand) cut(line string) (string, bool) {
+	if c.chars {
+		var sb strings.Builder
+		for i, r := range []rune(line) {
+			if c.selected(i + 1) {
+				sb.WriteRune(r)
+			}
+		}
+		return sb.String(), true
+	}
+
+	if !strings.Contains(line, c.delimiter) {
+		return line, !c.onlyDelimited
+	}
+	var picked []string
+	for i, field := range strings.Split(line, c.delimiter) {
+		if c.selected(i + 1) {
+			picked = append(picked, field)
+		}
+	}
+	return strings.Join(picked, c.delimiter), true
+}
+
+func (c *cutCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	sources := c.filePaths
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+
+	writer := bufio.NewWriter(out)
+	defer func() { _ = writer.Flush() }()
+	for _, path := range sources {
+		source := in
+		if path != "" {
+			file, err := os.Open(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "cut: %v\n", err)
+				retCode = 1
+				continue
+			}
+			source = file
+		}
+
+		scanner := bufio.NewScanner(source)
+		for scanner.Scan() {
+			if text, ok := c.cut(scanner.Text()); ok {
+				_, _ = writer.WriteString(text + "\n")
+			}
+		}
+		err := scanner.Err()
+
+		if path != "" {
+			_ = source.Close()
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "cut: %v\n", err)
+			return 1, false
+		}
+	}
+	return retCode, false
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCut(t *testing.T, content string, args ...string) string {
+	testFile := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte(content), 0644))
+
+	cmd, err := parseCutCommand(CommandDescription{
+		name:      CutCommand,
+		arguments: append(append([]string{"cut"}, args...), testFile),
+	})
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestCutCommand_Execute_Fields(t *testing.T) {
+	passwd := "root:x:0:0:root:/root:/bin/bash\nnobody:x:65534:65534:nobody:/:/sbin/nologin\n"
+	assert.Equal(t, "root\nnobody\n", runCut(t, passwd, "-d:", "-f1"))
+	assert.Equal(t, "root:0\nnobody:65534\n", runCut(t, passwd, "-d", ":", "-f", "3,1"))
+	assert.Equal(t, "/root:/bin/bash\n/:/sbin/nologin\n", runCut(t, passwd, "-d:", "-f6-"))
+	assert.Equal(t, "root:x\nnobody:x\n", runCut(t, passwd, "-d:", "-f-2"))
+	assert.Equal(t, "b\tc\n", runCut(t, "a\tb\tc\n", "-f2-"))
+}
+
+func TestCutCommand_Execute_LinesWithoutDelimiter(t *testing.T) {
+	input := "a,b\nplain\n"
+	assert.Equal(t, "b\nplain\n", runCut(t, input, "-d,", "-f2"))
+	assert.Equal(t, "b\n", runCut(t, input, "-d,", "-f2", "-s"))
+}
+
+func TestCutCommand_Execute_Chars(t *testing.T) {
+	assert.Equal(t, "hel\nwor\n", runCut(t, "hello\nworld\n", "-c1-3"))
+	assert.Equal(t, "llo\nрок\n", runCut(t, "hello\nморок\n", "-c", "3-"))
+	assert.Equal(t, "ho\n", runCut(t, "hello\n", "-c5,1"))
+}
+
+func TestParseCutCommand_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"cut"},
+		{"cut", "-f1", "-c1"},
+		{"cut", "-f", "0"},
+		{"cut", "-f", "3-1"},
+		{"cut", "-f", "-"},
+		{"cut", "-f", "a"},
+		{"cut", "-d", "::", "-f1"},
+	} {
+		_, err := parseCutCommand(CommandDescription{name: CutCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
+
+func TestSplitAttachedValues(t *testing.T) {
+	assert.Equal(t,
+		[]string{"-d", ":", "-f", "2-", "-s", "file", "-dx"},
+		splitAttachedValues([]string{"-d:", "-f2-", "-s", "file", "-dx"}, "-d", "-f"))
+	assert.Equal(t, []string{"-d", "=", "--", "-f1"}, splitAttachedValues([]string{"-d=", "--", "-f1"}, "-d", "-f"))
+}
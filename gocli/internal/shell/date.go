@@ -0,0 +1,192 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateCommand prints the current time, or the one given with -d, in the
+// format of date(1) or the one after "+". A format with "%" is read as
+// strftime, any other as a Go layout like "+2006-01-02".
+type dateCommand struct {
+	format string
+	utc    bool
+	date   string
+}
+
+func parseDateCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("date")
+	utc := fs.Bool("u", false, "print Coordinated Universal Time")
+	date := fs.String("d", "", "print the time given as @SECONDS, RFC 3339 or YYYY-MM-DD[ HH:MM[:SS]] instead of now")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("date: %w", err)
+	}
+	c := &dateCommand{format: "%a %b %e %H:%M:%S %Z %Y", utc: *utc, date: *date}
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		format, ok := strings.CutPrefix(fs.Arg(0), "+")
+		if !ok {
+			return nil, fmt.Errorf("date: invalid format '%s', it must start with '+'", fs.Arg(0))
+		}
+		c.format = format
+	default:
+		return nil, fmt.Errorf("date: extra operand '%s'", fs.Arg(1))
+	}
+	return c, nil
+}
+
+// Execute uses the zone in the TZ variable of the shell if it is set.
+func (c *dateCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	location := time.Local
+	if name, ok := env.Get("TZ"); ok && name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "date: unknown time zone '%s'\n", name)
+			return 1, false
+		}
+		location = loc
+	}
+	if c.utc {
+		location = time.UTC
+	}
+
+	now := time.Now()
+	if c.date != "" {
+		t, err := parseDate(c.date, location)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "date: %v\n", err)
+			return 1, false
+		}
+		now = t
+	}
+	now = now.In(location)
+
+	text := now.Format(c.format)
+	if strings.Contains(c.format, "%") {
+		text = strftime(now, c.format)
+	}
+	_, _ = fmt.Fprintln(out, text)
+	return 0, false
+}
+
+// dateLayouts are the layouts -d accepts besides @SECONDS; the ones without
+// a zone are in the zone date prints in.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+func parseDate(value string, location *time.Location) (time.Time, error) {
+	if seconds, ok := strings.CutPrefix(value, "@"); ok {
+		if s, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+			return time.Unix(s, 0), nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+}
+
+// strftime formats t like strftime(3) with the conversions date(1) has.
+// Unknown conversions are written as they are.
+func strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch c := format[i]; c {
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'b', 'h':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'c':
+			b.WriteString(strftime(t, "%a %b %e %H:%M:%S %Y"))
+		case 'C':
+			fmt.Fprintf(&b, "%02d", t.Year()/100)
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'D', 'x':
+			b.WriteString(t.Format("01/02/06"))
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'F':
+			b.WriteString(t.Format(time.DateOnly))
+		case 'G':
+			year, _ := t.ISOWeek()
+			fmt.Fprintf(&b, "%d", year)
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'k':
+			fmt.Fprintf(&b, "%2d", t.Hour())
+		case 'l':
+			fmt.Fprintf(&b, "%2d", (t.Hour()+11)%12+1)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'n':
+			b.WriteByte('\n')
+		case 'N':
+			fmt.Fprintf(&b, "%09d", t.Nanosecond())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'P':
+			b.WriteString(t.Format("pm"))
+		case 'r':
+			b.WriteString(t.Format("03:04:05 PM"))
+		case 'R':
+			b.WriteString(t.Format("15:04"))
+		case 's':
+			fmt.Fprintf(&b, "%d", t.Unix())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 't':
+			b.WriteByte('\t')
+		case 'T', 'X':
+			b.WriteString(t.Format(time.TimeOnly))
+		case 'u':
+			fmt.Fprintf(&b, "%d", (int(t.Weekday())+6)%7+1)
+		case 'V':
+			_, week := t.ISOWeek()
+			fmt.Fprintf(&b, "%02d", week)
+		case 'w':
+			fmt.Fprintf(&b, "%d", int(t.Weekday()))
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'Y':
+			fmt.Fprintf(&b, "%d", t.Year())
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrftime(t *testing.T) {
+	stamp := time.Date(2024, 3, 5, 14, 7, 9, 1500, time.UTC)
+	for format, expected := range map[string]string{
+		"%Y-%m-%d %H:%M:%S": "2024-03-05 14:07:09",
+		"%a %b %e %I%p":     "Tue Mar  5 02PM",
+		"%F %T %z %Z":       "2024-03-05 14:07:09 +0000 UTC",
+		"%j %V %u %s":       "065 10 2 1709647629",
+		"%N 100%% %q":       "000001500 100% %q",
+		"%c":                "Tue Mar  5 14:07:09 2024",
+	} {
+		assert.Equal(t, expected, strftime(stamp, format), format)
+	}
+}
+
+func TestDateCommand(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("date -u -d @0 +%F; date -d '2024-03-05 14:07' +2006-01-02T15:04; " +
+		"TZ=Asia/Tokyo; date -d 2024-03-05T00:00:00Z '+%H %Z'; date -u +%s")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	lines := strings.Split(strings.TrimSuffix(readShellOutput(t, stdout), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"1970-01-01", "2024-03-05T14:07", "09 JST"}, lines[:3])
+	seconds, err := strconv.ParseInt(lines[3], 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), seconds, 5)
+
+	for _, line := range []string{"date %Y", "date +%Y extra", "date -d yesterday", "TZ=Nowhere/City; date"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+}
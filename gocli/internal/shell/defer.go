@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// deferStack holds the cleanup command lines queued with the defer builtin.
+type deferStack struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func newDeferStack() *deferStack {
+	return &deferStack{}
+}
+
+func (d *deferStack) push(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lines = append(d.lines, line)
+}
+
+// drain empties the stack and returns its lines in LIFO order.
+func (d *deferStack) drain() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines := make([]string, len(d.lines))
+	for i, line := range d.lines {
+		lines[len(d.lines)-1-i] = line
+	}
+	d.lines = nil
+	return lines
+}
+
+type deferCommand struct {
+	line  string
+	stack *deferStack
+}
+
+// parseDeferCommand queues the rest of the command line for execution when
+// the session ends. Like in Go, arguments are evaluated at the point of defer
+// unless they are single-quoted.
+func parseDeferCommand(d CommandDescription, stack *deferStack) (Command, error) {
+	if len(d.arguments) < 2 {
+		return nil, fmt.Errorf("defer: usage: defer COMMAND")
+	}
+	return &deferCommand{
+		line:  commandLine(d, 1),
+		stack: stack,
+	}, nil
+}
+
+// commandLine turns the arguments of d from index from on back into a
+// command line for builtins that run it later. A single argument is taken
+// as a command line, like with sh -c. Otherwise single-quoted arguments are
+// kept as they are, so that they are parsed and expanded when the line
+// runs, and the others, already expanded, are quoted to stay one word each.
+func commandLine(d CommandDescription, from int) string {
+	if len(d.arguments) == from+1 {
+		return d.arguments[from]
+	}
+	words := make([]string, 0, len(d.arguments)-from)
+	for i, arg := range d.arguments[from:] {
+		if d.singleQuotedArgs[from+i] {
+			words = append(words, arg)
+		} else {
+			words = append(words, shellQuote(arg))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (d *deferCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	d.stack.push(d.line)
+	return 0, false
+}
+
+// RunDeferred executes the commands queued with defer, most recent first.
+// Their exit statuses are ignored; commands deferred while running
+// the queue are executed as well.
+func (s *Shell) RunDeferred() {
+	for {
+		lines := s.factory.deferred.drain()
+		if len(lines) == 0 {
+			return
+		}
+		for _, line := range lines {
+			if _, _, err := s.Execute(line); err != nil {
+				_, _ = fmt.Fprintf(s.stderr, "defer: %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_RunDeferred_LIFO(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	for _, line := range []string{"defer echo first", "defer echo second", "echo body"} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retCode)
+	}
+	assert.Equal(t, "body\n", readShellOutput(t, stdout))
+
+	sh.RunDeferred()
+	assert.Equal(t, "body\nsecond\nfirst\n", readShellOutput(t, stdout))
+
+	sh.RunDeferred()
+	assert.Equal(t, "body\nsecond\nfirst\n", readShellOutput(t, stdout), "queue must be emptied")
+}
+
+func TestShell_RunDeferred_QuotingControlsEvaluation(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute(`X=early; defer echo "$X" '$X'; X=late`)
+	require.NoError(t, err)
+
+	sh.RunDeferred()
+	assert.Equal(t, "early late\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Run_RunsDeferredOnExit(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "scratch")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("defer rm " + file + "\nexit 3\necho unreachable\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+
+	stdout, err := os.CreateTemp(dir, "stdout")
+	require.NoError(t, err)
+
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout))
+	sh.Run()
+
+	assert.NoFileExists(t, file)
+	assert.NotContains(t, readShellOutput(t, stdout), "unreachable")
+}
+
+func TestDeferCommand_Usage(t *testing.T) {
+	_, err := parseDeferCommand(CommandDescription{name: DeferCommand, arguments: []string{"defer"}}, newDeferStack())
+	assert.Error(t, err)
+}
+
+func TestShell_RunDeferred_KeepsQuotedArguments(t *testing.T) {
+	dir := tempWorkDir(t)
+	for _, name := range []string{"my file", "my", "file"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+	sh, _ := newTestShell(t)
+
+	_, _, err := sh.Execute(`defer rm "my file"`)
+	require.NoError(t, err)
+	sh.RunDeferred()
+
+	assert.NoFileExists(t, filepath.Join(dir, "my file"))
+	assert.FileExists(t, filepath.Join(dir, "my"))
+	assert.FileExists(t, filepath.Join(dir, "file"))
+}
@@ -0,0 +1,220 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// dirStack remembers the directories the session has left,
+// so that "back" can return to them.
+type dirStack struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+func newDirStack() *dirStack {
+	return &dirStack{}
+}
+
+func (s *dirStack) push(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirs = append(s.dirs, dir)
+}
+
+// list returns the stacked directories, oldest first.
+func (s *dirStack) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.dirs...)
+}
+
+func (s *dirStack) clone() *dirStack {
+	return &dirStack{dirs: s.list()}
+}
+
+func (s *dirStack) pop() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.dirs) == 0 {
+		return "", false
+	}
+	dir := s.dirs[len(s.dirs)-1]
+	s.dirs = s.dirs[:len(s.dirs)-1]
+	return dir, true
+}
+
+// changeDir makes dir the working directory of the process and updates PWD
+// and OLDPWD. Unless stack is nil, the previous directory is pushed onto it.
+// The change is published on events as cwd-changed.
+func changeDir(dir string, env Env, stack *dirStack, events *eventBus) error {
+	prev, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if stack != nil {
+		stack.push(prev)
+	}
+	env.Set("OLDPWD", prev)
+	env.Set("PWD", cwd)
+	env.Export("OLDPWD")
+	env.Export("PWD")
+	events.publish(Event{Kind: EventCwdChanged, Dir: cwd, PrevDir: prev})
+	return nil
+}
+
+type cdCommand struct {
+	dir    string
+	stack  *dirStack
+	events *eventBus
+}
+
+func parseCdCommand(d CommandDescription, stack *dirStack, events *eventBus) (Command, error) {
+	if len(d.arguments) > 2 {
+		return nil, fmt.Errorf("cd: too many arguments")
+	}
+	cmd := &cdCommand{stack: stack, events: events}
+	if len(d.arguments) == 2 {
+		cmd.dir = d.arguments[1]
+	}
+	return cmd, nil
+}
+
+// Execute enters the directory, which defaults to $HOME. "cd -" returns to
+// $OLDPWD and prints it; the path may also start with a bookmark.
+func (c *cdCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir := c.dir
+	switch dir {
+	case "":
+		home, ok := env.Get("HOME")
+		if !ok || home == "" {
+			_, _ = fmt.Fprintln(errOut, "cd: HOME not set")
+			return 1, false
+		}
+		dir = home
+	case "-":
+		prev, ok := env.Get("OLDPWD")
+		if !ok || prev == "" {
+			_, _ = fmt.Fprintln(errOut, "cd: OLDPWD not set")
+			return 1, false
+		}
+		dir = prev
+	}
+
+	dir, err := resolveBookmark(dir)
+	if err == nil {
+		err = changeDir(dir, env, c.stack, c.events)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "cd: %v\n", err)
+		return 1, false
+	}
+	if c.dir == "-" {
+		pwd, _ := env.Get("PWD")
+		_, _ = fmt.Fprintln(out, pwd)
+	}
+	return 0, false
+}
+
+type mkcdCommand struct {
+	dir    string
+	stack  *dirStack
+	events *eventBus
+}
+
+func parseMkcdCommand(d CommandDescription, stack *dirStack, events *eventBus) (Command, error) {
+	if len(d.arguments) != 2 {
+		return nil, fmt.Errorf("mkcd: usage: mkcd DIR")
+	}
+	return &mkcdCommand{dir: d.arguments[1], stack: stack, events: events}, nil
+}
+
+// Execute creates the directory with all missing parents and enters it.
+// The path may start with a bookmark, as in "mkcd @proj/build".
+func (m *mkcdCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir, err := resolveBookmark(m.dir)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "mkcd: %v\n", err)
+		return 1, false
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		_, _ = fmt.Fprintf(errOut, "mkcd: %v\n", err)
+		return 1, false
+	}
+	if err := changeDir(dir, env, m.stack, m.events); err != nil {
+		_, _ = fmt.Fprintf(errOut, "mkcd: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
+
+type upCommand struct {
+	levels int
+	stack  *dirStack
+	events *eventBus
+}
+
+func parseUpCommand(d CommandDescription, stack *dirStack, events *eventBus) (Command, error) {
+	if len(d.arguments) > 2 {
+		return nil, fmt.Errorf("up: usage: up [N]")
+	}
+
+	levels := 1
+	if len(d.arguments) == 2 {
+		n, err := strconv.Atoi(d.arguments[1])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("up: invalid number of levels: %s", d.arguments[1])
+		}
+		levels = n
+	}
+	return &upCommand{levels: levels, stack: stack, events: events}, nil
+}
+
+// Execute goes the given number of levels up, stopping at the root.
+func (u *upCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "up: %v\n", err)
+		return 1, false
+	}
+	for i := 0; i < u.levels; i++ {
+		dir = filepath.Dir(dir)
+	}
+	if err := changeDir(dir, env, u.stack, u.events); err != nil {
+		_, _ = fmt.Fprintf(errOut, "up: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
+
+type backCommand struct {
+	stack  *dirStack
+	events *eventBus
+}
+
+// Execute returns to the directory the session was in before the last
+// directory change. Going back does not push onto the stack, so repeated
+// "back" walks further into the history.
+func (b *backCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir, ok := b.stack.pop()
+	if !ok {
+		_, _ = fmt.Fprintln(errOut, "back: directory stack is empty")
+		return 1, false
+	}
+	if err := changeDir(dir, env, nil, b.events); err != nil {
+		_, _ = fmt.Fprintf(errOut, "back: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempWorkDir(t *testing.T) string {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	t.Chdir(dir)
+	return dir
+}
+
+func currentDir(t *testing.T) string {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	return cwd
+}
+
+func TestShell_Execute_MkcdUpBack(t *testing.T) {
+	root := tempWorkDir(t)
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("mkcd a/b/c")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, filepath.Join(root, "a", "b", "c"), currentDir(t))
+	pwd, _ := sh.env.Get("PWD")
+	assert.Equal(t, filepath.Join(root, "a", "b", "c"), pwd)
+	oldpwd, _ := sh.env.Get("OLDPWD")
+	assert.Equal(t, root, oldpwd)
+
+	_, _, err = sh.Execute("up 2")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a"), currentDir(t))
+
+	_, _, err = sh.Execute("back")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a", "b", "c"), currentDir(t))
+
+	_, _, err = sh.Execute("back")
+	require.NoError(t, err)
+	assert.Equal(t, root, currentDir(t))
+
+	retCode, _, err = sh.Execute("back")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, root, currentDir(t))
+}
+
+func TestUpCommand_Execute_StopsAtRoot(t *testing.T) {
+	tempWorkDir(t)
+
+	cmd := &upCommand{levels: 1000, stack: newDirStack()}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, NewEnvFromMap(nil))
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, string(filepath.Separator), currentDir(t))
+}
+
+func TestParseUpCommand_InvalidLevels(t *testing.T) {
+	for _, arg := range []string{"0", "-1", "x"} {
+		_, err := parseUpCommand(CommandDescription{name: UpCommand, arguments: []string{"up", arg}}, newDirStack(), nil)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestMkcdCommand_Execute_NotADirectory(t *testing.T) {
+	root := tempWorkDir(t)
+	require.NoError(t, os.WriteFile("file", nil, 0644))
+
+	cmd := &mkcdCommand{dir: "file/sub", stack: newDirStack()}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, NewEnvFromMap(nil))
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, root, currentDir(t))
+}
+
+func TestShell_Execute_Cd(t *testing.T) {
+	root := tempWorkDir(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b"), 0755))
+	sh, stdout := newTestShell(t)
+	sh.env.Set("HOME", filepath.Join(root, "a"))
+
+	retCode, _, err := sh.Execute("cd a/b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, filepath.Join(root, "a", "b"), currentDir(t))
+	pwd, _ := sh.env.Get("PWD")
+	assert.Equal(t, filepath.Join(root, "a", "b"), pwd)
+
+	_, _, err = sh.Execute("cd ..; cd -")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a", "b"), currentDir(t))
+	assert.Equal(t, filepath.Join(root, "a", "b")+"\n", readShellOutput(t, stdout))
+
+	_, _, err = sh.Execute("cd")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a"), currentDir(t))
+
+	_, _, err = sh.Execute("back")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a", "b"), currentDir(t))
+}
+
+func TestCdCommand_Execute_Errors(t *testing.T) {
+	root := tempWorkDir(t)
+
+	cmd := &cdCommand{dir: "missing", stack: newDirStack()}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, NewEnvFromMap(nil))
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, root, currentDir(t))
+
+	cmd = &cdCommand{dir: "-", stack: newDirStack()}
+	retCode, _ = cmd.Execute(nil, nil, os.Stderr, NewEnvFromMap(nil))
+	assert.Equal(t, 1, retCode)
+
+	_, err := parseCdCommand(CommandDescription{name: CDCommand, arguments: []string{"cd", "a", "b"}}, newDirStack(), nil)
+	assert.Error(t, err)
+}
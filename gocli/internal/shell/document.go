@@ -0,0 +1,573 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// documentFormats are the formats of the json, yaml and toml builtins,
+// which read their own format and write any of them.
+var documentFormats = map[string]bool{"json": true, "yaml": true, "toml": true}
+
+// documentCommand queries JSON, YAML and TOML documents with jq-style paths
+// and converts them between the formats. Documents are held as YAML nodes,
+// so that the order of keys is kept from input to output.
+type documentCommand struct {
+	name    string
+	output  string
+	query   []queryStage
+	raw     bool
+	compact bool
+	file    string
+	fsys    FileSystem
+}
+
+func parseDocumentCommand(d CommandDescription, fsys FileSystem) (Command, error) {
+	name := string(d.name)
+	fs := newFlagSet(name)
+	output := fs.String("o", name, "write `FORMAT`: json, yaml or toml")
+	raw := fs.Bool("r", false, "write strings without quotes")
+	compact := fs.Bool("c", false, "write JSON on one line")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if !documentFormats[*output] {
+		return nil, fmt.Errorf("%s: unknown output format %q", name, *output)
+	}
+	if fs.NArg() > 2 {
+		return nil, fmt.Errorf("%s: usage: %s [-o json|yaml|toml] [-r] [-c] [QUERY] [FILE]", name, name)
+	}
+
+	c := &documentCommand{name: name, output: *output, raw: *raw, compact: *compact, file: fs.Arg(1), fsys: fsys}
+	query := "."
+	if fs.NArg() > 0 {
+		query = fs.Arg(0)
+	}
+	var err error
+	if c.query, err = parseQuery(query); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
+
+func (c *documentCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	var source io.Reader = in
+	if c.file != "" && c.file != "-" {
+		file, err := c.fsys.Open(c.file)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "%s: %v\n", c.name, err)
+			return 1, false
+		}
+		defer func() { _ = file.Close() }()
+		source = file
+	}
+
+	w := bufio.NewWriter(out)
+	defer func() { _ = w.Flush() }()
+	if err := c.run(source, w); err != nil {
+		_ = w.Flush()
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", c.name, err)
+		return 1, false
+	}
+	return 0, false
+}
+
+func (c *documentCommand) run(r io.Reader, w io.Writer) error {
+	documents, err := decodeDocuments(c.name, r)
+	if err != nil {
+		return err
+	}
+	results, err := evalQuery(c.query, documents)
+	if err != nil {
+		return err
+	}
+
+	for i, node := range results {
+		if node.Kind == yaml.AliasNode {
+			node = resolveNode(node)
+		}
+		if value := resolveNode(node); c.raw && value.Kind == yaml.ScalarNode && value.ShortTag() == "!!str" {
+			if _, err := fmt.Fprintln(w, node.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		switch c.output {
+		case "json":
+			var buf bytes.Buffer
+			if err := writeNodeJSON(&buf, node); err != nil {
+				return err
+			}
+			if !c.compact {
+				var indented bytes.Buffer
+				_ = json.Indent(&indented, buf.Bytes(), "", "  ")
+				buf = indented
+			}
+			buf.WriteByte('\n')
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		case "yaml":
+			// Like yq, results follow each other without "---" between them.
+			encoder := yaml.NewEncoder(w)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(node); err != nil {
+				return err
+			}
+			if err := encoder.Close(); err != nil {
+				return err
+			}
+		case "toml":
+			var value any
+			if err := node.Decode(&value); err != nil {
+				return err
+			}
+			if _, ok := value.(map[string]any); !ok {
+				return fmt.Errorf("only tables can be written as TOML, not %s", nodeKind(resolveNode(node)))
+			}
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			encoder := toml.NewEncoder(w)
+			encoder.Indent = ""
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeDocuments reads every document of the input: JSON values one after
+// another, YAML documents separated by "---", or a single TOML document.
+func decodeDocuments(format string, r io.Reader) ([]*yaml.Node, error) {
+	var documents []*yaml.Node
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		for {
+			node, err := decodeJSONNode(decoder)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, node)
+		}
+	case "yaml":
+		decoder := yaml.NewDecoder(r)
+		for {
+			var node yaml.Node
+			if err := decoder.Decode(&node); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			documents = append(documents, &node)
+		}
+	case "toml":
+		var value map[string]any
+		if _, err := toml.NewDecoder(r).Decode(&value); err != nil {
+			return nil, err
+		}
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return nil, err
+		}
+		documents = append(documents, &node)
+	}
+	return documents, nil
+}
+
+// decodeJSONNode reads the next JSON value token by token, keeping the order of object keys.
+func decodeJSONNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			item, err := decodeJSONNode(decoder)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			node.Content = append(node.Content, item)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	default:
+		return nullNode(), nil
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func nullNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// resolveNode skips the document node around a value and follows aliases.
+func resolveNode(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	}
+	if node.ShortTag() == "!!null" {
+		return "null"
+	}
+	return "a scalar"
+}
+
+// writeNodeJSON writes a node as compact JSON. Timestamps become strings,
+// as do the floats JSON has no numbers for.
+func writeNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	node = resolveNode(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(resolveNode(node.Content[i]).Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.DocumentNode:
+		buf.WriteString("null")
+		return nil
+	}
+
+	var value any
+	switch node.ShortTag() {
+	case "!!null":
+		value = nil
+	case "!!bool", "!!int":
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return err
+		}
+		value = f
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			value = node.Value
+		}
+	case "!!timestamp":
+		var t time.Time
+		if err := node.Decode(&t); err != nil {
+			return err
+		}
+		value = t.Format(time.RFC3339Nano)
+	default:
+		value = node.Value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// queryStage is a stage of a query, which are separated by "|": a path
+// like ".items[0].name" or the function keys or length.
+type queryStage struct {
+	path     []queryStep
+	function string
+}
+
+// queryStep is a key, an index, or "[]" which yields every item.
+type queryStep struct {
+	key     string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+func parseQuery(query string) ([]queryStage, error) {
+	var stages []queryStage
+	for _, text := range splitQuery(query) {
+		text = strings.TrimSpace(text)
+		if text == "keys" || text == "length" {
+			stages = append(stages, queryStage{function: text})
+			continue
+		}
+		path, err := parseQueryPath(text)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, queryStage{path: path})
+	}
+	return stages, nil
+}
+
+// splitQuery splits a query at the "|" that are not in quotes.
+func splitQuery(query string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == '|' && !quoted:
+			parts = append(parts, query[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, query[start:])
+}
+
+func parseQueryPath(text string) ([]queryStep, error) {
+	if !strings.HasPrefix(text, ".") {
+		return nil, fmt.Errorf("invalid query %q: a path starts with '.'", text)
+	}
+	var steps []queryStep
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '.':
+			i++
+			if i < len(text) && text[i] == '"' {
+				quoted, err := strconv.QuotedPrefix(text[i:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid query %q: %w", text, err)
+				}
+				key, _ := strconv.Unquote(quoted)
+				steps = append(steps, queryStep{key: key})
+				i += len(quoted)
+				continue
+			}
+			end := i
+			for end < len(text) && isQueryKeyChar(text[end]) {
+				end++
+			}
+			if end == i && (i != 1 || i < len(text) && text[i] != '[') {
+				return nil, fmt.Errorf("invalid query %q: key expected after '.'", text)
+			}
+			if end > i {
+				steps = append(steps, queryStep{key: text[i:end]})
+			}
+			i = end
+		case text[i] == '[':
+			end := strings.IndexByte(text[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid query %q: ']' expected", text)
+			}
+			inner := strings.TrimSpace(text[i+1 : i+end])
+			switch {
+			case inner == "":
+				steps = append(steps, queryStep{iterate: true})
+			case strings.HasPrefix(inner, `"`):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid query %q: %w", text, err)
+				}
+				steps = append(steps, queryStep{key: key})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid query %q: invalid index %q", text, inner)
+				}
+				steps = append(steps, queryStep{index: index, isIndex: true})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("invalid query %q: unexpected %q", text, text[i:])
+		}
+	}
+	return steps, nil
+}
+
+func isQueryKeyChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// evalQuery runs the stages on every document. Like jq, a missing key or
+// index gives null rather than an error.
+func evalQuery(stages []queryStage, documents []*yaml.Node) ([]*yaml.Node, error) {
+	nodes := documents
+	for _, stage := range stages {
+		if stage.function != "" {
+			next := make([]*yaml.Node, 0, len(nodes))
+			for _, node := range nodes {
+				result, err := applyQueryFunction(stage.function, resolveNode(node))
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, result)
+			}
+			nodes = next
+			continue
+		}
+		for _, step := range stage.path {
+			var next []*yaml.Node
+			for _, node := range nodes {
+				results, err := applyQueryStep(step, resolveNode(node))
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, results...)
+			}
+			nodes = next
+		}
+	}
+	return nodes, nil
+}
+
+func applyQueryStep(step queryStep, node *yaml.Node) ([]*yaml.Node, error) {
+	isNull := node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+	switch {
+	case step.iterate:
+		switch node.Kind {
+		case yaml.SequenceNode:
+			return node.Content, nil
+		case yaml.MappingNode:
+			values := make([]*yaml.Node, 0, len(node.Content)/2)
+			for i := 1; i < len(node.Content); i += 2 {
+				values = append(values, node.Content[i])
+			}
+			return values, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", nodeKind(node))
+	case step.isIndex:
+		if isNull {
+			return []*yaml.Node{nullNode()}, nil
+		}
+		if node.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("cannot index %s with a number", nodeKind(node))
+		}
+		index := step.index
+		if index < 0 {
+			index += len(node.Content)
+		}
+		if index < 0 || index >= len(node.Content) {
+			return []*yaml.Node{nullNode()}, nil
+		}
+		return []*yaml.Node{node.Content[index]}, nil
+	default:
+		if isNull {
+			return []*yaml.Node{nullNode()}, nil
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("cannot index %s with %q", nodeKind(node), step.key)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if resolveNode(node.Content[i]).Value == step.key {
+				return []*yaml.Node{node.Content[i+1]}, nil
+			}
+		}
+		return []*yaml.Node{nullNode()}, nil
+	}
+}
+
+// applyQueryFunction runs keys, which lists keys in document order rather
+// than sorted like jq, or length.
+func applyQueryFunction(function string, node *yaml.Node) (*yaml.Node, error) {
+	count := func(n int) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(n)}
+	}
+	switch {
+	case function == "keys" && node.Kind == yaml.MappingNode:
+		keys := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := 0; i < len(node.Content); i += 2 {
+			keys.Content = append(keys.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: resolveNode(node.Content[i]).Value})
+		}
+		return keys, nil
+	case function == "keys" && node.Kind == yaml.SequenceNode:
+		keys := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := range node.Content {
+			keys.Content = append(keys.Content, count(i))
+		}
+		return keys, nil
+	case function == "length" && node.Kind == yaml.MappingNode:
+		return count(len(node.Content) / 2), nil
+	case function == "length" && node.Kind == yaml.SequenceNode:
+		return count(len(node.Content)), nil
+	case function == "length" && node.ShortTag() == "!!null":
+		return count(0), nil
+	case function == "length" && node.ShortTag() == "!!str":
+		return count(utf8.RuneCountInString(node.Value)), nil
+	}
+	return nil, fmt.Errorf("%s has no %s", nodeKind(node), function)
+}
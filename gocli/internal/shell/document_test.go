@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	stages, err := parseQuery(`.a["b c"].d[-1][] | keys | .[0]`)
+	require.NoError(t, err)
+	assert.Equal(t, []queryStage{
+		{path: []queryStep{{key: "a"}, {key: "b c"}, {key: "d"}, {index: -1, isIndex: true}, {iterate: true}}},
+		{function: "keys"},
+		{path: []queryStep{{index: 0, isIndex: true}}},
+	}, stages)
+
+	stages, err = parseQuery(`."x|y"`)
+	require.NoError(t, err)
+	assert.Equal(t, []queryStage{{path: []queryStep{{key: "x|y"}}}}, stages)
+
+	for _, query := range []string{"a", "..", ".a.", ".a[", ".a[x]", "values"} {
+		_, err := parseQuery(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestDocumentCommands(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("app.yaml", []byte(`name: web
+version: "1.10"
+servers:
+  - host: a.example
+    weight: 2
+  - host: b.example
+    weight: 1
+`), 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`yaml -r .servers[].host app.yaml; yaml '.servers | length' app.yaml; yaml .version app.yaml; ` +
+		`yaml -o json -c . app.yaml; yaml -o toml .servers[1] app.yaml; ` +
+		`yaml -o json .servers[0] app.yaml | json -o yaml; echo '{"b": 1, "a": [true, null]}{"b": 2}' | json -c .b; ` +
+		`printf 'title = "x"\n[owner]\nname = "Tom"\n' | toml -o yaml 'keys'; yaml .missing.key app.yaml`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	for _, line := range []string{"yaml .name.first app.yaml", "yaml -o toml .servers app.yaml", "echo '{' | json", "yaml -o xml . app.yaml", "yaml . missing.yaml"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+	assert.Equal(t, `a.example
+b.example
+2
+"1.10"
+{"name":"web","version":"1.10","servers":[{"host":"a.example","weight":2},{"host":"b.example","weight":1}]}
+host = "b.example"
+weight = 1
+host: a.example
+weight: 2
+1
+2
+- owner
+- title
+null
+`, readShellOutput(t, stdout))
+}
@@ -0,0 +1,189 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// eachLineVar holds the current input line while a template runs. Every "{}"
+// in the template is rewritten into a reference to it when the template is
+// parsed, so line contents are never re-parsed or expanded themselves.
+const eachLineVar = "__gocli_line"
+
+// lineEnv exposes the current input line to a template without adding it
+// to the session environment.
+type lineEnv struct {
+	Env
+	line string
+}
+
+func (l *lineEnv) Get(key string) (string, bool) {
+	if key == eachLineVar {
+		return l.line, true
+	}
+	return l.Env.Get(key)
+}
+
+// eachCommand runs a command template for every line of its input.
+// In filter mode the template is a predicate: its output is discarded
+// and the lines it succeeds on are printed.
+type eachCommand struct {
+	name     string
+	template []CommandDescription
+	parser   InputProcessor
+	factory  CommandFactory
+	fsys     FileSystem
+	filter   bool
+	// lineOnStdin is set for a template without "{}", which gets the line
+	// on its stdin instead, as in "filter 'grep -q x'".
+	lineOnStdin bool
+}
+
+func parseEachCommand(d CommandDescription, factory CommandFactory, parser InputProcessor, fsys FileSystem) (Command, error) {
+	name := string(d.name)
+	if len(d.arguments) < 2 {
+		return nil, fmt.Errorf("%s: usage: %s TEMPLATE", name, name)
+	}
+
+	template, err := parser.Parse(commandLine(d, 1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(template) == 0 {
+		return nil, fmt.Errorf("%s: usage: %s TEMPLATE", name, name)
+	}
+
+	// Single-quoted words are not expanded, so "{}" is replaced there with
+	// the line itself when the template runs.
+	lineRef := "${" + eachLineVar + "}"
+	lineOnStdin := true
+	for i := range template {
+		if strings.Contains(template[i].fileInPath, "{}") || strings.Contains(template[i].fileOutPath, "{}") {
+			lineOnStdin = false
+		}
+		args := make([]string, len(template[i].arguments))
+		for j, arg := range template[i].arguments {
+			if strings.Contains(arg, "{}") {
+				lineOnStdin = false
+			}
+			if !template[i].singleQuotedArgs[j] {
+				arg = strings.ReplaceAll(arg, "{}", lineRef)
+			}
+			args[j] = arg
+		}
+		template[i].arguments = args
+	}
+
+	return &eachCommand{
+		name:     name,
+		template: template,
+		parser:   parser,
+		factory:  factory,
+		fsys:     fsys,
+		filter:   d.name == FilterCommand,
+
+		lineOnStdin: lineOnStdin,
+	}, nil
+}
+
+func (e *eachCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", e.name, err)
+		return 2, false
+	}
+	defer func() {
+		_ = devNull.Close()
+	}()
+
+	templateOut := out
+	if e.filter {
+		templateOut = devNull
+		retCode = 1
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		scope := &lineEnv{Env: env, line: line}
+		runner := &pipelineRunner{
+			env:     scope,
+			factory: e.factory,
+			parser:  e.parser,
+			stdin:   devNull,
+			stdout:  templateOut,
+			stderr:  errOut,
+			fsys:    e.fsys,
+		}
+
+		var status int
+		if e.lineOnStdin {
+			status, err = e.runWithLine(runner, line, scope)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "%s: %v\n", e.name, err)
+				return 2, false
+			}
+		} else {
+			status, _ = runner.Execute(e.instantiate(line), scope)
+		}
+		switch {
+		case e.filter && status == 0:
+			_, _ = fmt.Fprintln(out, line)
+			retCode = 0
+		case !e.filter && status != 0:
+			retCode = status
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", e.name, err)
+		return 2, false
+	}
+
+	return retCode, false
+}
+
+// runWithLine runs the template with line on its stdin. The line is written
+// from another goroutine, so a template that does not read it cannot block
+// the writer: closing the read end after the template ends releases it.
+func (e *eachCommand) runWithLine(runner *pipelineRunner, line string, env Env) (int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		_, _ = w.WriteString(line + "\n")
+		_ = w.Close()
+	}()
+
+	runner.stdin = r
+	status, _ := runner.Execute(e.instantiate(line), env)
+	_ = r.Close()
+	<-written
+	return status, nil
+}
+
+// instantiate returns a copy of the template for line. Unquoted and
+// double-quoted arguments already refer to the line through a variable;
+// single-quoted arguments and redirections are not expanded by the runner,
+// so "{}" is replaced there directly.
+func (e *eachCommand) instantiate(line string) []CommandDescription {
+	pipeline := make([]CommandDescription, len(e.template))
+	for i, desc := range e.template {
+		args := make([]string, len(desc.arguments))
+		for j, arg := range desc.arguments {
+			if desc.singleQuotedArgs[j] {
+				arg = strings.ReplaceAll(arg, "{}", line)
+			}
+			args[j] = arg
+		}
+		desc.arguments = args
+		desc.fileInPath = strings.ReplaceAll(desc.fileInPath, "{}", line)
+		desc.fileOutPath = strings.ReplaceAll(desc.fileOutPath, "{}", line)
+		pipeline[i] = desc
+	}
+	return pipeline
+}
@@ -0,0 +1,110 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_Execute_Each(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte("a b\n$HOME\n$(exit)\n"), 0644))
+
+	sh, stdout := newTestShell(t)
+	retCode, _, err := sh.Execute("cat " + input + " | each 'printf [%s] {} | cat'")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "[a b][$HOME][$(exit)]", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_EachQuoting(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte("a b\n"), 0644))
+
+	sh, stdout := newTestShell(t)
+	for _, line := range []string{
+		`cat ` + input + ` | each echo "<{}>"`,
+		`cat ` + input + ` | each "echo '[{}]'"`,
+		`alias say='echo said'; cat ` + input + ` | each say {}`,
+	} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retCode, line)
+	}
+	assert.Equal(t, "<a b>\n[a b]\nsaid a b\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_EachRedirection(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "names.txt")
+	require.NoError(t, os.WriteFile(input, []byte("one\ntwo\n"), 0644))
+
+	sh, _ := newTestShell(t)
+	_, _, err := sh.Execute("cat " + input + " | each 'echo {} > " + dir + "/{}.txt'")
+	require.NoError(t, err)
+
+	for _, name := range []string{"one", "two"} {
+		content, err := os.ReadFile(filepath.Join(dir, name+".txt"))
+		require.NoError(t, err)
+		assert.Equal(t, name+"\n", string(content))
+	}
+}
+
+func TestShell_Execute_EachReportsFailure(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte("/nonexistent\n"+input+"\n"), 0644))
+
+	sh, _ := newTestShell(t)
+	retCode, _, err := sh.Execute("cat " + input + " | each cat {}")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestShell_Execute_Filter(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "exists")
+	require.NoError(t, os.WriteFile(existing, []byte("x"), 0644))
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte(existing+"\n"+dir+"/missing\n"), 0644))
+
+	sh, stdout := newTestShell(t)
+	retCode, _, err := sh.Execute("cat " + input + " | filter 'test -f {}'")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, existing+"\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("cat " + input + " | filter 'test -d {}'")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestShell_Execute_EachLineOnStdin(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.NoError(t, os.WriteFile(input, []byte("apple\nbanana\ncherry\n"), 0644))
+
+	sh, stdout := newTestShell(t)
+	retCode, _, err := sh.Execute("cat " + input + " | filter 'grep an'")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "banana\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("cat " + input + " | each 'cat | cat'")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "banana\napple\nbanana\ncherry\n", readShellOutput(t, stdout))
+}
+
+func TestParseEachCommand_Usage(t *testing.T) {
+	_, err := parseEachCommand(CommandDescription{name: EachCommand, arguments: []string{"each"}}, nil, NewInputProcessor(), nil)
+	assert.Error(t, err)
+
+	_, err = parseEachCommand(CommandDescription{name: FilterCommand, arguments: []string{"filter", " "}}, nil, NewInputProcessor(), nil)
+	assert.Error(t, err)
+}
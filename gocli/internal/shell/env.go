@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// envCommand runs a command with variables set and exported only for it,
+// as both "env K=V cmd" and the prefix form "K=V cmd" do.
+// Without a command it prints the environment that a command would receive.
+type envCommand struct {
+	// assignments holds the NAME=VALUE arguments.
+	assignments []string
+	// clear starts from an empty environment instead of a copy of the shell one.
+	clear bool
+	// sanitize starts from the exported variables that sanitizeEnv keeps.
+	sanitize bool
+	// inner is the command to run, nil to print the environment.
+	inner Command
+}
+
+func parseEnvCommand(d CommandDescription, factory CommandFactory) (Command, error) {
+	fs := newFlagSet("env")
+	clear := fs.Bool("i", false, "start with an empty environment")
+	sanitize := fs.Bool("sanitized", false, "start with only PATH, HOME, LANG and the variables in "+EnvAllowVar)
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("env: %w", err)
+	}
+
+	args := fs.Args()
+	var assignments []string
+	for len(args) > 0 {
+		name, _, ok := strings.Cut(args[0], "=")
+		if !ok {
+			break
+		}
+		if name == "" {
+			return nil, fmt.Errorf("env: %s: invalid variable name", args[0])
+		}
+		assignments = append(assignments, args[0])
+		args = args[1:]
+	}
+
+	env := &envCommand{assignments: assignments, clear: *clear, sanitize: *sanitize}
+	if len(args) == 0 {
+		return env, nil
+	}
+
+	inner := d
+	inner.name = CommandName(args[0])
+	inner.arguments = args
+	inner.singleQuotedArgs, inner.doubleQuotedArgs, inner.unquotedArgs = nil, nil, nil
+	cmd, err := factory.GetCommand(inner)
+	if err != nil {
+		return nil, err
+	}
+	env.inner = cmd
+	return env, nil
+}
+
+// Execute leaves the shell environment untouched: the command gets a copy,
+// so builtins like cd or export run this way do not change the shell either.
+func (e *envCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	scope := NewEnvFromMap(nil)
+	switch {
+	case e.clear:
+	case e.sanitize:
+		allow, _ := env.Get(EnvAllowVar)
+		scope = NewEnvFromMap(sanitizeEnv(env.Exported(), allow))
+	default:
+		scope = cloneEnv(env)
+	}
+	for _, arg := range e.assignments {
+		name, value, _ := strings.Cut(arg, "=")
+		scope.Set(name, value)
+		scope.Export(name)
+	}
+
+	if e.inner != nil {
+		return e.inner.Execute(in, out, errOut, scope)
+	}
+
+	vars := scope.Exported()
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(out, "%s=%s\n", key, vars[key])
+	}
+	return 0, false
+}
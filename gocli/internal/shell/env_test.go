@@ -0,0 +1,73 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvCommand_Execute_Print(t *testing.T) {
+	_, stdout := newTestShell(t)
+	env := NewEnvFromMap(map[string]string{"B": "2", "A": "1"})
+	sh := NewShell(WithEnv(env), WithStdout(stdout))
+
+	retCode, _, err := sh.Execute("LOCAL=x; env; env -i C=3")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "A=1\nB=2\nC=3\n", readShellOutput(t, stdout))
+}
+
+func TestEnvCommand_Execute_Command(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("GOCLI_ENV=old; env GOCLI_ENV=new sh -c 'echo $GOCLI_ENV'; echo $GOCLI_ENV")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "new\nold\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("env -i GOCLI_ENV=1 env")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "old\nGOCLI_ENV=1\n")
+}
+
+func TestShell_PrefixAssignments(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`X=outer; Y=kept; X=inner Y="$X two" sh -c 'echo "$X|$Y"'; echo $X $Y`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "inner|outer two\nouter kept\n", readShellOutput(t, stdout))
+
+	_, ok := sh.env.Exported()["X"]
+	assert.False(t, ok)
+}
+
+func TestShell_PrefixAssignments_ArgumentsSeeOldValue(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("FOO=0; FOO=1 echo $FOO; echo after $FOO")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "0\nafter 0\n", readShellOutput(t, stdout))
+}
+
+func TestInputProcessor_Parse_PrefixAssignments(t *testing.T) {
+	descriptions, err := NewInputProcessor().Parse("A=1 B=$X cmd arg")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+
+	desc := descriptions[0]
+	assert.Equal(t, CommandName("cmd"), desc.name)
+	require.Len(t, desc.assignments, 2)
+	assert.Equal(t, []string{"A", "1"}, desc.assignments[0].arguments)
+	assert.Equal(t, []string{"B", "$X"}, desc.assignments[1].arguments)
+}
+
+func TestParseEnvCommand_Invalid(t *testing.T) {
+	for _, args := range [][]string{{"env", "=1"}, {"env", "-x"}} {
+		_, err := parseEnvCommand(CommandDescription{name: EnvCommand, arguments: args}, NewCommandFactory(NewEnvFromMap(nil)))
+		assert.Error(t, err, args)
+	}
+}
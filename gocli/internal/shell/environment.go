@@ -1,23 +1,53 @@
 package shell
 
-import "os"
+import (
+	"os"
+	"sync"
+)
 
 // NewEnv creates a new Env instance backed by an in-memory map
 // for storing and retrieving environment variables.
-// It initializes the environment with system environment variables.
+// It initializes the environment with system environment variables, all exported.
 func NewEnv() Env {
 	env := &envMap{
-		store: make(map[string]string),
+		store:    make(map[string]string),
+		exported: make(map[string]bool),
 	}
 	for _, pair := range os.Environ() {
 		parts := splitEnvPair(pair)
 		if len(parts) == 2 {
 			env.store[parts[0]] = parts[1]
+			env.exported[parts[0]] = true
 		}
 	}
 	return env
 }
 
+// NewEnvFromMap creates a new Env instance that contains only the given variables,
+// all exported, without inheriting anything from the process environment.
+func NewEnvFromMap(vars map[string]string) Env {
+	env := &envMap{
+		store:    make(map[string]string, len(vars)),
+		exported: make(map[string]bool, len(vars)),
+	}
+	for k, v := range vars {
+		env.store[k] = v
+		env.exported[k] = true
+	}
+	return env
+}
+
+// cloneEnv copies env with the same variables exported.
+func cloneEnv(env Env) Env {
+	clone := NewEnvFromMap(env.Exported())
+	for k, v := range env.GetAll() {
+		if _, ok := clone.Get(k); !ok {
+			clone.Set(k, v)
+		}
+	}
+	return clone
+}
+
 func splitEnvPair(pair string) []string {
 	for i := 0; i < len(pair); i++ {
 		if pair[i] == '=' {
@@ -27,13 +57,21 @@ func splitEnvPair(pair string) []string {
 	return []string{pair}
 }
 
+// envMap is safe for concurrent use, since the stages of a pipeline run at the same time.
 type envMap struct {
+	mu    sync.RWMutex
 	store map[string]string
+	// exported holds the names of exported variables, including unset ones.
+	exported map[string]bool
+	// events, if set, gets every change of a variable.
+	events *eventBus
 }
 
 // Get implements Env interface.
 // Retrieves the value associated with the given key from the environment store.
 func (e *envMap) Get(key string) (value string, ok bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	value, ok = e.store[key]
 	return
 }
@@ -41,15 +79,52 @@ func (e *envMap) Get(key string) (value string, ok bool) {
 // Set implements Env interface.
 // Stores a key-value pair in the environment.
 func (e *envMap) Set(key string, value string) {
+	e.mu.Lock()
 	e.store[key] = value
+	e.mu.Unlock()
+	e.events.publish(Event{Kind: EventVarChanged, Name: key, Value: value})
 }
 
 // GetAll implements Env interface.
 // Returns all environment variables as a map.
 func (e *envMap) GetAll() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	result := make(map[string]string, len(e.store))
 	for k, v := range e.store {
 		result[k] = v
 	}
 	return result
 }
+
+// Export implements Env interface.
+// Marks the variable key as exported.
+func (e *envMap) Export(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exported[key] = true
+}
+
+// Exported implements Env interface.
+// Returns the exported variables that are set.
+func (e *envMap) Exported() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	result := make(map[string]string, len(e.exported))
+	for k := range e.exported {
+		if v, ok := e.store[k]; ok {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// Unset implements Env interface.
+// Removes the variable key and its export mark.
+func (e *envMap) Unset(key string) {
+	e.mu.Lock()
+	delete(e.store, key)
+	delete(e.exported, key)
+	e.mu.Unlock()
+	e.events.publish(Event{Kind: EventVarChanged, Name: key, Unset: true})
+}
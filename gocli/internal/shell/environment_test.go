@@ -48,3 +48,18 @@ func TestEnvMap_Overwrite(t *testing.T) {
 	require.True(t, ok, "expected key to be found")
 	assert.Equal(t, "new_value", value)
 }
+
+func TestEnvMap_Export(t *testing.T) {
+	env := NewEnvFromMap(map[string]string{"INHERITED": "1"})
+	env.Set("LOCAL", "2")
+	env.Export("LATER")
+	assert.Equal(t, map[string]string{"INHERITED": "1"}, env.Exported())
+
+	env.Set("LATER", "3")
+	env.Set("INHERITED", "4")
+	assert.Equal(t, map[string]string{"INHERITED": "4", "LATER": "3"}, env.Exported())
+
+	clone := cloneEnv(env)
+	assert.Equal(t, env.GetAll(), clone.GetAll())
+	assert.Equal(t, env.Exported(), clone.Exported())
+}
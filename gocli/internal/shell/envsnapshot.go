@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// envSnapshots keeps named copies of the variable set for the session.
+type envSnapshots struct {
+	mu    sync.Mutex
+	saved map[string]map[string]string
+}
+
+func newEnvSnapshots() *envSnapshots {
+	return &envSnapshots{saved: make(map[string]map[string]string)}
+}
+
+func (s *envSnapshots) save(name string, vars map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[name] = vars
+}
+
+func (s *envSnapshots) get(name string) (map[string]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vars, ok := s.saved[name]
+	return vars, ok
+}
+
+type envSnapshotCommand struct {
+	snapshots *envSnapshots
+	name      string
+	diff      bool
+}
+
+func parseEnvSnapshotCommand(d CommandDescription, snapshots *envSnapshots) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) != 2 || (args[0] != "save" && args[0] != "diff") {
+		return nil, fmt.Errorf("env-snapshot: usage: env-snapshot save|diff NAME")
+	}
+	return &envSnapshotCommand{
+		snapshots: snapshots,
+		name:      args[1],
+		diff:      args[0] == "diff",
+	}, nil
+}
+
+// Execute either stores the current variables under the snapshot name or
+// prints how they differ from it: "+KEY=VALUE" for added variables,
+// "-KEY=VALUE" for removed ones and "~KEY=OLD -> NEW" for modified ones.
+// Like diff, it returns 1 when there are differences.
+func (c *envSnapshotCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if !c.diff {
+		c.snapshots.save(c.name, env.GetAll())
+		return 0, false
+	}
+
+	before, ok := c.snapshots.get(c.name)
+	if !ok {
+		_, _ = fmt.Fprintf(errOut, "env-snapshot: no snapshot named %q\n", c.name)
+		return 2, false
+	}
+	after := env.GetAll()
+
+	union := make(map[string]string, len(after))
+	for key := range before {
+		union[key] = ""
+	}
+	for key := range after {
+		union[key] = ""
+	}
+
+	for _, key := range sortedKeys(union) {
+		oldValue, wasSet := before[key]
+		newValue, isSet := after[key]
+		switch {
+		case wasSet && !isSet:
+			_, _ = fmt.Fprintf(out, "-%s=%s\n", key, oldValue)
+		case !wasSet && isSet:
+			_, _ = fmt.Fprintf(out, "+%s=%s\n", key, newValue)
+		case oldValue != newValue:
+			_, _ = fmt.Fprintf(out, "~%s=%s -> %s\n", key, oldValue, newValue)
+		default:
+			continue
+		}
+		retCode = 1
+	}
+	return retCode, false
+}
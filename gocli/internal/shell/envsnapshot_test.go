@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_Execute_EnvSnapshotDiff(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	sh.env.Set("KEPT", "same")
+	sh.env.Set("CHANGED", "old")
+	sh.env.Set("REMOVED", "gone")
+
+	retCode, _, err := sh.Execute("env-snapshot save before")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	retCode, _, err = sh.Execute("env-snapshot diff before")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Empty(t, readShellOutput(t, stdout))
+
+	_, _, err = sh.Execute("CHANGED=new; ADDED=1")
+	require.NoError(t, err)
+	delete(sh.env.(*envMap).store, "REMOVED")
+
+	retCode, _, err = sh.Execute("env-snapshot diff before")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "+ADDED=1\n~CHANGED=old -> new\n-REMOVED=gone\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_EnvSnapshotUnknown(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("env-snapshot diff nothing")
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+}
+
+func TestParseEnvSnapshotCommand_Usage(t *testing.T) {
+	for _, args := range [][]string{{"env-snapshot"}, {"env-snapshot", "save"}, {"env-snapshot", "load", "x"}} {
+		_, err := parseEnvSnapshotCommand(CommandDescription{name: EnvSnapshotCommand, arguments: args}, newEnvSnapshots())
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind names a kind of shell lifecycle event.
+type EventKind string
+
+const (
+	// EventCommandStarted is published when a command of a pipeline starts.
+	EventCommandStarted EventKind = "command-started"
+	// EventCommandFinished is published when a command of a pipeline finishes.
+	EventCommandFinished EventKind = "command-finished"
+	// EventPipelineFinished is published when all commands of a pipeline are done.
+	EventPipelineFinished EventKind = "pipeline-finished"
+	// EventCwdChanged is published when a builtin changes the working directory.
+	EventCwdChanged EventKind = "cwd-changed"
+	// EventJobStateChanged is published when an external process starts or exits.
+	EventJobStateChanged EventKind = "job-state-changed"
+	// EventVarChanged is published when a shell variable is set or unset.
+	EventVarChanged EventKind = "var-changed"
+)
+
+// eventKinds lists the kinds of events in the order they are documented.
+var eventKinds = []EventKind{
+	EventCommandStarted, EventCommandFinished, EventPipelineFinished,
+	EventCwdChanged, EventJobStateChanged, EventVarChanged,
+}
+
+// Job states reported by EventJobStateChanged.
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+)
+
+// Event describes something that happened in the shell. Only the fields
+// that belong to its kind are set.
+type Event struct {
+	Kind EventKind
+	// Args is the command line of command-started, command-finished and job-state-changed.
+	Args []string
+	// Status is the exit status of command-finished and pipeline-finished.
+	Status int
+	// Duration is how long the command or pipeline took.
+	Duration time.Duration
+	// Dir and PrevDir are the new and the previous working directory of cwd-changed.
+	Dir, PrevDir string
+	// Name and Value are the variable of var-changed; Unset reports that it was removed.
+	Name, Value string
+	Unset       bool
+	// PID and State are the process of job-state-changed and JobRunning or JobDone.
+	PID   int
+	State string
+}
+
+// Subscribe calls fn for every event of the given kind until the returned
+// function is called. Events are delivered synchronously, in the goroutine
+// that publishes them, which may be one of the commands of a pipeline, so fn
+// must be quick and safe for concurrent use.
+func (s *Shell) Subscribe(kind EventKind, fn func(Event)) (unsubscribe func()) {
+	return s.factory.events.subscribe(kind, fn)
+}
+
+// eventBus delivers events to the subscribers of their kind. It decouples
+// the places that publish events, like the pipeline runner, cd and the
+// environment, from those that react to them, like metrics and extensions.
+// All methods accept a nil receiver, which publishes nothing.
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventKind][]*eventSubscriber
+}
+
+type eventSubscriber struct {
+	fn func(Event)
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[EventKind][]*eventSubscriber)}
+}
+
+func (b *eventBus) subscribe(kind EventKind, fn func(Event)) func() {
+	sub := &eventSubscriber{fn: fn}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[kind] = append(b.subscribers[kind], sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			subs := b.subscribers[kind]
+			for i, s := range subs {
+				if s == sub {
+					b.subscribers[kind] = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// publish calls the subscribers of the event's kind in the order they
+// subscribed. They run without the lock held, so they may subscribe or
+// unsubscribe themselves.
+func (b *eventBus) publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subs := b.subscribers[e.Kind]
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		sub.fn(e)
+	}
+}
+
+// isEventKind reports whether name is one of the event kinds.
+func isEventKind(name string) bool {
+	for _, kind := range eventKinds {
+		if string(kind) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus_Subscribe(t *testing.T) {
+	bus := newEventBus()
+	var got []string
+	unsubscribe := bus.subscribe(EventVarChanged, func(e Event) {
+		got = append(got, "first "+e.Name)
+	})
+	bus.subscribe(EventVarChanged, func(e Event) {
+		got = append(got, "second "+e.Name)
+	})
+	bus.subscribe(EventCwdChanged, func(e Event) {
+		got = append(got, "cwd "+e.Dir)
+	})
+
+	bus.publish(Event{Kind: EventVarChanged, Name: "A"})
+	unsubscribe()
+	unsubscribe()
+	bus.publish(Event{Kind: EventVarChanged, Name: "B"})
+	bus.publish(Event{Kind: EventJobStateChanged})
+	assert.Equal(t, []string{"first A", "second A", "second B"}, got)
+
+	var nilBus *eventBus
+	nilBus.publish(Event{Kind: EventVarChanged})
+}
+
+// eventRecorder collects the events of a shell, which may be published
+// by the commands of a pipeline at the same time.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func recordEvents(sh *Shell, kinds ...EventKind) *eventRecorder {
+	r := &eventRecorder{}
+	for _, kind := range kinds {
+		sh.Subscribe(kind, func(e Event) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, e)
+		})
+	}
+	return r
+}
+
+func (r *eventRecorder) list() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func TestShell_Subscribe_Commands(t *testing.T) {
+	sh, _ := newTestShell(t)
+	r := recordEvents(sh, EventCommandStarted, EventCommandFinished, EventPipelineFinished)
+
+	retCode, _, err := sh.Execute("echo hi | grep nothing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+
+	var started, finished [][]string
+	for _, e := range r.list()[:4] {
+		switch e.Kind {
+		case EventCommandStarted:
+			started = append(started, e.Args)
+		case EventCommandFinished:
+			finished = append(finished, e.Args)
+			if e.Args[0] == "grep" {
+				assert.Equal(t, 1, e.Status)
+			}
+		}
+	}
+	assert.ElementsMatch(t, [][]string{{"echo", "hi"}, {"grep", "nothing"}}, started)
+	assert.ElementsMatch(t, [][]string{{"echo", "hi"}, {"grep", "nothing"}}, finished)
+	last := r.list()[4]
+	assert.Equal(t, EventPipelineFinished, last.Kind)
+	assert.Equal(t, 1, last.Status)
+}
+
+func TestShell_Subscribe_Variables(t *testing.T) {
+	sh, _ := newTestShell(t)
+	r := recordEvents(sh, EventVarChanged)
+
+	_, _, err := sh.Execute("X=1; unset X")
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		{Kind: EventVarChanged, Name: "X", Value: "1"},
+		{Kind: EventVarChanged, Name: "X", Unset: true},
+	}, r.list())
+}
+
+func TestShell_Subscribe_Cwd(t *testing.T) {
+	dir := tempWorkDir(t)
+	sh, _ := newTestShell(t)
+	r := recordEvents(sh, EventCwdChanged)
+
+	_, _, err := sh.Execute("mkcd sub; back")
+	require.NoError(t, err)
+	sub := filepath.Join(dir, "sub")
+	assert.Equal(t, []Event{
+		{Kind: EventCwdChanged, Dir: sub, PrevDir: dir},
+		{Kind: EventCwdChanged, Dir: dir, PrevDir: sub},
+	}, r.list())
+}
+
+func TestShell_Subscribe_Jobs(t *testing.T) {
+	sh, _ := newTestShell(t)
+	r := recordEvents(sh, EventJobStateChanged)
+
+	_, _, err := sh.Execute("sh -c true")
+	require.NoError(t, err)
+	events := r.list()
+	require.Len(t, events, 2)
+	assert.Equal(t, JobRunning, events[0].State)
+	assert.Equal(t, JobDone, events[1].State)
+	assert.Equal(t, []string{"sh", "-c", "true"}, events[1].Args)
+	assert.NotZero(t, events[0].PID)
+	assert.Equal(t, events[0].PID, events[1].PID)
+}
+
+func TestShell_LoadExtension_On(t *testing.T) {
+	dir := tempWorkDir(t)
+	sh, stdout := newTestShell(t)
+	path := writeExtension(t, t.TempDir(), "hooks.star", `
+def entered(e):
+    print("entered", e.dir.removeprefix(e.prev_dir))
+
+on("cwd-changed", entered)
+on("command-finished", lambda e: stdout.write("%s: %d\n" % (e.args[0], e.status)))
+`)
+	require.NoError(t, sh.LoadExtension(path))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	_, _, err := sh.Execute("cd sub")
+	require.NoError(t, err)
+	assert.Equal(t, "entered /sub\ncd: 0\n", readShellOutput(t, stdout))
+
+	assert.Error(t, sh.LoadExtension(writeExtension(t, t.TempDir(), "bad.star", `on("never", print)`)))
+}
@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// ExecBackend decides where external commands run. Builtins, redirections
+// and expansions are always handled by the shell itself.
+type ExecBackend interface {
+	// Command returns the process that runs the program args[0] with the arguments args[1:].
+	Command(args []string) *exec.Cmd
+}
+
+// localBackend runs external commands on this machine.
+type localBackend struct{}
+
+func (localBackend) Command(args []string) *exec.Cmd {
+	return exec.Command(args[0], args[1:]...)
+}
+
+// sshBackend runs external commands on a remote host through the ssh client,
+// which is given the standard streams of the command. The remote command
+// starts in the login directory with the login environment of the remote user.
+type sshBackend struct {
+	// destination is "host" or "user@host".
+	destination string
+	port        string
+}
+
+func (b sshBackend) Command(args []string) *exec.Cmd {
+	sshArgs := []string{"-q"}
+	if b.port != "" {
+		sshArgs = append(sshArgs, "-p", b.port)
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	sshArgs = append(sshArgs, b.destination, "--", strings.Join(quoted, " "))
+	return exec.Command("ssh", sshArgs...)
+}
+
+// parseExecBackend parses the value of the exec-backend option:
+// empty or "local" for this machine, or "ssh://[user@]host[:port]".
+func parseExecBackend(value string) (ExecBackend, error) {
+	if value == "" || value == "local" {
+		return localBackend{}, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend %q: %w", value, err)
+	}
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("invalid backend %q: want local or ssh://[user@]host[:port]", value)
+	}
+	if u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("invalid backend %q: want ssh://[user@]host[:port]", value)
+	}
+	destination := u.Hostname()
+	if u.User != nil {
+		destination = u.User.Username() + "@" + destination
+	}
+	return sshBackend{destination: destination, port: u.Port()}, nil
+}
+
+// shellQuote quotes s for a POSIX shell, leaving simple words as they are.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:,+@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// execBackend returns the backend selected with the exec-backend option.
+func (c *commandFactory) execBackend() ExecBackend {
+	backend, err := parseExecBackend(c.options.value(OptionExecBackend))
+	if err != nil {
+		// The value was checked when the option was set.
+		return localBackend{}
+	}
+	return backend
+}
@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecBackend(t *testing.T) {
+	for _, value := range []string{"", "local"} {
+		backend, err := parseExecBackend(value)
+		require.NoError(t, err)
+		assert.Equal(t, localBackend{}, backend)
+	}
+
+	backend, err := parseExecBackend("ssh://deploy@build-1:2222")
+	require.NoError(t, err)
+	assert.Equal(t, sshBackend{destination: "deploy@build-1", port: "2222"}, backend)
+
+	for _, value := range []string{"ssh://", "ssh://host/path", "docker://web", "host"} {
+		_, err := parseExecBackend(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSSHBackend_Command(t *testing.T) {
+	cmd := sshBackend{destination: "box", port: "22"}.Command([]string{"grep", "-r", "it's here", "/var/log"})
+	assert.Equal(t, []string{"ssh", "-q", "-p", "22", "box", "--", `grep -r 'it'\''s here' /var/log`}, cmd.Args)
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "a.txt", shellQuote("a.txt"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "'$HOME'", shellQuote("$HOME"))
+	assert.Equal(t, "'a b'", shellQuote("a b"))
+}
+
+func TestShell_ExecBackendSSH(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"[$arg]\"; done\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0755))
+	t.Setenv("PATH", bin)
+	sh, stdout := newTestShell(t)
+	out := filepath.Join(t.TempDir(), "out")
+
+	retCode, _, err := sh.Execute("set -o exec-backend=ssh://me@box")
+	require.NoError(t, err)
+	require.Equal(t, 0, retCode)
+
+	retCode, _, err = sh.Execute("X=local; echo $X && uptime 'a b' $X > " + out)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "local\n", readShellOutput(t, stdout))
+	remote, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "[-q]\n[me@box]\n[--]\n[uptime 'a b' local]\n", string(remote))
+
+	retCode, _, err = sh.Execute("set +o exec-backend")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, localBackend{}, sh.factory.execBackend())
+}
+
+func TestSetCommand_Execute_ValueOptions(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	for _, line := range []string{"set -o exec-backend", "set -o exec-backend=ftp://host", "set -o namespaces=yes"} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		assert.Equal(t, 1, retCode, line)
+	}
+
+	retCode, _, err := sh.Execute("set -o exec-backend=ssh://host; set -o")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "exec-backend   \tssh://host\n")
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// exportCommand marks variables, optionally assigning them first, to be passed
+// to external commands. Without names, or with -p, it lists the exported variables.
+type exportCommand struct {
+	// assignments holds the NAME or NAME=VALUE arguments.
+	assignments []string
+}
+
+func parseExportCommand(d CommandDescription) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) > 0 && args[0] == "-p" {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("export: -p takes no names")
+		}
+		args = nil
+	}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("export: %s: not a valid identifier", arg)
+		}
+	}
+	return &exportCommand{assignments: args}, nil
+}
+
+func (e *exportCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if len(e.assignments) == 0 {
+		vars := env.Exported()
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(out, "export %s=%s\n", key, shellQuote(vars[key]))
+		}
+		return 0, false
+	}
+
+	for _, arg := range e.assignments {
+		name, value, assign := strings.Cut(arg, "=")
+		if assign {
+			env.Set(name, value)
+		}
+		env.Export(name)
+	}
+	return 0, false
+}
@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCommand_Execute(t *testing.T) {
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithEnv(NewEnvFromMap(map[string]string{"PATH": "/usr/bin:/bin"})), WithStdout(stdout))
+
+	for _, line := range []string{
+		"LOCAL=1",
+		"export SHARED='a b'",
+		`sh -c 'echo "[$LOCAL][$SHARED]"'`,
+		"export LOCAL",
+		`sh -c 'echo "[$LOCAL][$SHARED]"'`,
+		"export -p",
+	} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		require.Equal(t, 0, retCode, line)
+	}
+
+	assert.Equal(t, "[][a b]\n[1][a b]\n"+
+		"export LOCAL=1\nexport PATH=/usr/bin:/bin\nexport SHARED='a b'\n", readShellOutput(t, stdout))
+}
+
+func TestExportCommand_Subshell(t *testing.T) {
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithEnv(NewEnvFromMap(map[string]string{"PATH": "/usr/bin:/bin"})), WithStdout(stdout))
+
+	retCode, _, err := sh.Execute(`LOCAL=1; (export INNER=2; sh -c 'echo "[$LOCAL][$INNER]"'); export -p`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "[][2]\nexport PATH=/usr/bin:/bin\n", readShellOutput(t, stdout))
+}
+
+func TestParseExportCommand_InvalidName(t *testing.T) {
+	for _, args := range [][]string{{"export", "1X=2"}, {"export", "=x"}, {"export", "-p", "X"}} {
+		_, err := parseExportCommand(CommandDescription{name: ExportCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
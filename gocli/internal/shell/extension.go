@@ -0,0 +1,384 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// extensionsDir is the directory in the config directory whose Starlark
+	// files LoadExtensions runs.
+	extensionsDir = "ext"
+	extensionExt  = ".star"
+	// extensionCallKey is the thread-local value holding the extensionCall of a thread.
+	extensionCallKey = "gocli.call"
+)
+
+// extensionFileOptions enables the Starlark features that scripts expect
+// but the core dialect leaves out.
+var extensionFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+	Recursion:       true,
+}
+
+// LoadExtensions runs every Starlark file (*.star) in the ext directory of
+// the gocli config directory, see LoadExtension. A file that fails does not
+// stop the others.
+func (s *Shell) LoadExtensions() error {
+	base, err := configDir()
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(base, extensionsDir, "*"+extensionExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	var errs []error
+	for _, path := range paths {
+		errs = append(errs, s.LoadExtension(path))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadExtension runs the Starlark file at path. Scripts have no access to
+// files, the network or processes except through this API:
+//
+//	command(name, fn)         fn(args) runs as the command name; it returns the exit status or None for 0
+//	prompt_segment(name, fn)  fn() renders the {name} segment of the prompt
+//	on(event, fn)             fn(event) is called for every event of the kind, see Subscribe
+//	env.get(name, default=None), env.set(name, value)
+//	run(line)                 runs a command line and returns a struct with its status and stdout
+//	stdin.read(), stdin.readline()
+//	stdout.write(text), stderr.write(text)
+//
+// Inside a command the streams and env are those of the command; print
+// writes to its standard output.
+func (s *Shell) LoadExtension(path string) error {
+	call := &extensionCall{
+		env:    s.env,
+		stdin:  bufio.NewReader(strings.NewReader("")),
+		stdout: s.stdout,
+		stderr: s.stderr,
+	}
+	thread := newExtensionThread(filepath.Base(path), call)
+
+	var registered []func()
+	predeclared := starlark.StringDict{
+		"command": starlark.NewBuiltin("command", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var fn starlark.Callable
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "fn", &fn); err != nil {
+				return nil, err
+			}
+			if builtins[CommandName(name)] {
+				return nil, fmt.Errorf("%s: %s is a shell builtin", b.Name(), name)
+			}
+			if !isAliasName(name) {
+				return nil, fmt.Errorf("%s: invalid command name %q", b.Name(), name)
+			}
+			registered = append(registered, func() {
+				s.factory.funcs.set(name, s.extensionCommand(name, fn))
+			})
+			return starlark.None, nil
+		}),
+		"prompt_segment": starlark.NewBuiltin("prompt_segment", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			var fn starlark.Callable
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "fn", &fn); err != nil {
+				return nil, err
+			}
+			registered = append(registered, func() {
+				s.factory.segments.set(name, s.extensionSegment(name, fn))
+			})
+			return starlark.None, nil
+		}),
+		"on": starlark.NewBuiltin("on", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var kind string
+			var fn starlark.Callable
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "event", &kind, "fn", &fn); err != nil {
+				return nil, err
+			}
+			if !isEventKind(kind) {
+				return nil, fmt.Errorf("%s: unknown event %q", b.Name(), kind)
+			}
+			registered = append(registered, func() {
+				s.Subscribe(EventKind(kind), s.extensionHook(kind, fn))
+			})
+			return starlark.None, nil
+		}),
+		"env": &starlarkstruct.Module{Name: "env", Members: starlark.StringDict{
+			"get": starlark.NewBuiltin("env.get", extensionEnvGet),
+			"set": starlark.NewBuiltin("env.set", extensionEnvSet),
+		}},
+		"run": starlark.NewBuiltin("run", s.extensionRun),
+		"stdin": &starlarkstruct.Module{Name: "stdin", Members: starlark.StringDict{
+			"read":     starlark.NewBuiltin("stdin.read", extensionRead),
+			"readline": starlark.NewBuiltin("stdin.readline", extensionReadLine),
+		}},
+		"stdout": &starlarkstruct.Module{Name: "stdout", Members: starlark.StringDict{
+			"write": starlark.NewBuiltin("stdout.write", extensionWrite(func(c *extensionCall) io.Writer { return c.stdout })),
+		}},
+		"stderr": &starlarkstruct.Module{Name: "stderr", Members: starlark.StringDict{
+			"write": starlark.NewBuiltin("stderr.write", extensionWrite(func(c *extensionCall) io.Writer { return c.stderr })),
+		}},
+	}
+
+	globals, err := starlark.ExecFileOptions(extensionFileOptions, thread, path, nil, predeclared)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return errors.New(evalErr.Backtrace())
+		}
+		return err
+	}
+	// Frozen values can be shared by the threads of commands that run at the same time.
+	globals.Freeze()
+	for _, register := range registered {
+		register()
+	}
+	return nil
+}
+
+// extensionCall holds what the API functions work on in one thread:
+// the streams and environment of a command, or of the shell while a file loads.
+type extensionCall struct {
+	env    Env
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newExtensionThread(name string, call *extensionCall) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			_, _ = fmt.Fprintln(call.stdout, msg)
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("load(%q): modules are not supported", module)
+		},
+	}
+	thread.SetLocal(extensionCallKey, call)
+	return thread
+}
+
+func currentCall(thread *starlark.Thread) *extensionCall {
+	return thread.Local(extensionCallKey).(*extensionCall)
+}
+
+// extensionCommand returns the body of a command implemented by the Starlark function fn.
+func (s *Shell) extensionCommand(name string, fn starlark.Callable) funcBody {
+	return func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, env Env) (int, error) {
+		call := &extensionCall{env: env, stdin: bufio.NewReader(stdin), stdout: stdout, stderr: stderr}
+		thread := newExtensionThread(name, call)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				thread.Cancel("interrupted")
+			case <-done:
+			}
+		}()
+
+		list := make([]starlark.Value, len(args))
+		for i, arg := range args {
+			list[i] = starlark.String(arg)
+		}
+		result, err := starlark.Call(thread, fn, starlark.Tuple{starlark.NewList(list)}, nil)
+		if err != nil {
+			return 1, err
+		}
+		switch result := result.(type) {
+		case starlark.NoneType:
+			return 0, nil
+		case starlark.Int:
+			status, ok := result.Int64()
+			if !ok {
+				return 1, fmt.Errorf("exit status %s out of range", result)
+			}
+			return int(status), nil
+		default:
+			return 1, fmt.Errorf("command returned %s, want int or None", result.Type())
+		}
+	}
+}
+
+// extensionSegment returns the renderer of a prompt segment implemented by the
+// Starlark function fn. A failing function renders nothing.
+func (s *Shell) extensionSegment(name string, fn starlark.Callable) func() string {
+	return func() string {
+		call := &extensionCall{
+			env:    s.env,
+			stdin:  bufio.NewReader(strings.NewReader("")),
+			stdout: io.Discard,
+			stderr: io.Discard,
+		}
+		result, err := starlark.Call(newExtensionThread(name, call), fn, nil, nil)
+		if err != nil {
+			s.logger.Warn("prompt segment failed", "segment", name, "error", err)
+			return ""
+		}
+		if text, ok := starlark.AsString(result); ok {
+			return text
+		}
+		return result.String()
+	}
+}
+
+// extensionHook returns a subscriber that calls the Starlark function fn with
+// the event as a struct. The hook writes to the streams of the shell; an error
+// is only logged, it does not affect what published the event.
+func (s *Shell) extensionHook(name string, fn starlark.Callable) func(Event) {
+	return func(e Event) {
+		call := &extensionCall{
+			env:    s.env,
+			stdin:  bufio.NewReader(strings.NewReader("")),
+			stdout: s.stdout,
+			stderr: s.stderr,
+		}
+		args := make([]starlark.Value, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = starlark.String(arg)
+		}
+		event := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"kind":     starlark.String(e.Kind),
+			"args":     starlark.Tuple(args),
+			"status":   starlark.MakeInt(e.Status),
+			"duration": starlark.Float(e.Duration.Seconds()),
+			"dir":      starlark.String(e.Dir),
+			"prev_dir": starlark.String(e.PrevDir),
+			"name":     starlark.String(e.Name),
+			"value":    starlark.String(e.Value),
+			"unset":    starlark.Bool(e.Unset),
+			"pid":      starlark.MakeInt(e.PID),
+			"state":    starlark.String(e.State),
+		})
+		if _, err := starlark.Call(newExtensionThread(name, call), fn, starlark.Tuple{event}, nil); err != nil {
+			s.logger.Warn("event hook failed", "event", name, "error", err)
+		}
+	}
+}
+
+func extensionEnvGet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var fallback starlark.Value = starlark.None
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "default?", &fallback); err != nil {
+		return nil, err
+	}
+	if value, ok := currentCall(thread).env.Get(name); ok {
+		return starlark.String(value), nil
+	}
+	return fallback, nil
+}
+
+func extensionEnvSet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name, value string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+		return nil, err
+	}
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("%s: %s: not a valid identifier", b.Name(), name)
+	}
+	currentCall(thread).env.Set(name, value)
+	return starlark.None, nil
+}
+
+// extensionRun runs a command line in the environment of the caller.
+func (s *Shell) extensionRun(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var line string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "line", &line); err != nil {
+		return nil, err
+	}
+	runner, ok := s.runner.(*pipelineRunner)
+	if !ok {
+		return nil, fmt.Errorf("%s: not supported by this shell", b.Name())
+	}
+	output, status, err := runner.capture(line, currentCall(thread).env)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"status": starlark.MakeInt(status),
+		"stdout": starlark.String(output),
+	}), nil
+}
+
+func extensionRead(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(currentCall(thread).stdin)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlark.String(data), nil
+}
+
+// extensionReadLine returns the next line with its newline, or "" at the end of the input.
+func extensionReadLine(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	line, err := currentCall(thread).stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return starlark.String(line), nil
+}
+
+func extensionWrite(stream func(*extensionCall) io.Writer) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var text string
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "text", &text); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(stream(currentCall(thread)), text); err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name(), err)
+		}
+		return starlark.None, nil
+	}
+}
+
+// segmentRegistry holds the prompt segments defined by extensions.
+type segmentRegistry struct {
+	mu       sync.Mutex
+	segments map[string]func() string
+}
+
+func newSegmentRegistry() *segmentRegistry {
+	return &segmentRegistry{segments: make(map[string]func() string)}
+}
+
+func (r *segmentRegistry) set(name string, render func() string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.segments[name] = render
+}
+
+// render is a segmentSource.
+func (r *segmentRegistry) render(name string) (string, bool) {
+	r.mu.Lock()
+	render, ok := r.segments[name]
+	r.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	return render(), true
+}
@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExtension(t *testing.T, dir, name, source string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(source), 0644))
+	return path
+}
+
+func TestShell_LoadExtension_Command(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	path := writeExtension(t, t.TempDir(), "tools.star", `
+def count(args):
+    lines = 0
+    while stdin.readline():
+        lines += 1
+    stdout.write("%d lines, args %s\n" % (lines, " ".join(args)))
+    env.set("COUNTED", str(lines))
+    if lines == 0:
+        stderr.write("no input\n")
+        return 1
+
+def greet(args):
+    print("hello", env.get("WHO", "nobody"))
+
+command("count", count)
+command("greet", greet)
+`)
+	require.NoError(t, sh.LoadExtension(path))
+
+	retCode, _, err := sh.Execute("echo a b | tr ' ' '\\n' | count x y")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	retCode, _, err = sh.Execute("WHO=you greet; greet")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "2 lines, args x y\nhello you\nhello nobody\n", readShellOutput(t, stdout))
+}
+
+func TestShell_LoadExtension_Run(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	path := writeExtension(t, t.TempDir(), "run.star", `
+def twice(args):
+    result = run("echo " + args[0] + " | tr a-z A-Z")
+    stdout.write(result.stdout * 2)
+    return result.status
+
+command("twice", twice)
+`)
+	require.NoError(t, sh.LoadExtension(path))
+
+	retCode, _, err := sh.Execute("twice hey")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "HEY\nHEY\n", readShellOutput(t, stdout))
+}
+
+func TestShell_LoadExtension_PromptSegment(t *testing.T) {
+	sh, _ := newTestShell(t)
+	path := writeExtension(t, t.TempDir(), "prompt.star", `
+prompt_segment("user", lambda: env.get("USERNAME", "?"))
+`)
+	require.NoError(t, sh.LoadExtension(path))
+	sh.env.Set("USERNAME", "ada")
+
+	assert.Equal(t, "ada@0 ", renderPrompt(NewEnvFromMap(map[string]string{"PS1": "{user}@{status} "}), 0, sh.factory.segments.render))
+}
+
+func TestShell_LoadExtension_Errors(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	dir := t.TempDir()
+
+	for name, source := range map[string]string{
+		"syntax.star":  "def broken(:\n",
+		"builtin.star": "command('cat', lambda args: 0)\n",
+		"load.star":    "load('os.star', 'system')\n",
+		"fail.star":    "fail('boom')\n",
+	} {
+		assert.Error(t, sh.LoadExtension(writeExtension(t, dir, name, source)), name)
+	}
+
+	require.NoError(t, sh.LoadExtension(writeExtension(t, dir, "bad.star", `
+command("bad", lambda args: "text")
+command("oops", lambda args: 1 // 0)
+`)))
+	errPath := filepath.Join(dir, "err")
+	retCode, _, err := sh.Execute("bad 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Equal(t, "bad: command returned string, want int or None\n", string(stderr))
+
+	retCode, _, err = sh.Execute("oops 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Empty(t, readShellOutput(t, stdout))
+}
+
+func TestShell_LoadExtensions(t *testing.T) {
+	isolateConfig(t)
+	sh, stdout := newTestShell(t)
+	require.NoError(t, sh.LoadExtensions())
+
+	base, err := configDir()
+	require.NoError(t, err)
+	dir := filepath.Join(base, extensionsDir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	writeExtension(t, dir, "hi.star", `command("hi", lambda args: print("hi"))`)
+	writeExtension(t, dir, "notes.txt", `not starlark`)
+	require.NoError(t, sh.LoadExtensions())
+
+	retCode, _, err := sh.Execute("hi")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "hi\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,115 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// copyFile copies the contents of src into dst, creating or truncating dst
+// with the given permissions, and carries over the source modification time.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = source.Close()
+	}()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	target, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(target, source); err != nil {
+		_ = target.Close()
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// copyTree recursively copies src to dst, preserving permissions and modification times.
+// Symbolic links are recreated rather than followed. If copied is set, it is
+// called with the source and target of every entry once it has been copied.
+func copyTree(src, dst string, copied func(src, dst string)) error {
+	// Directories are made writable while their contents are copied and
+	// get their own permissions afterwards, deepest first.
+	type dirMode struct {
+		path    string
+		mode    fs.FileMode
+		modTime time.Time
+	}
+	var dirs []dirMode
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			err = os.MkdirAll(target, info.Mode().Perm()|0700)
+			dirs = append(dirs, dirMode{path: target, mode: info.Mode().Perm(), modTime: info.ModTime()})
+		case entry.Type()&fs.ModeSymlink != 0:
+			var link string
+			if link, err = os.Readlink(path); err == nil {
+				err = os.Symlink(link, target)
+			}
+		default:
+			err = copyFile(path, target, info.Mode())
+		}
+		if err == nil && copied != nil {
+			copied(path, target)
+		}
+		return err
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if chmodErr := os.Chmod(dirs[i].path, dirs[i].mode); err == nil {
+			err = chmodErr
+		}
+		if chtimesErr := os.Chtimes(dirs[i].path, dirs[i].modTime, dirs[i].modTime); err == nil {
+			err = chtimesErr
+		}
+	}
+	return err
+}
+
+// moveFile renames src to dst, falling back to copy and delete
+// when they are on different filesystems.
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst, nil); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
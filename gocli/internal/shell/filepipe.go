@@ -0,0 +1,20 @@
+package shell
+
+import "os"
+
+// newFilePipe connects two stages of a pipeline through a temporary file,
+// which the next stage reads once the previous one has finished writing it.
+func newFilePipe() (r, w *os.File, err error) {
+	w, err = os.CreateTemp("", "gocli-pipe-")
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err = os.Open(w.Name())
+	if err != nil {
+		_ = w.Close()
+		_ = os.Remove(w.Name())
+		return nil, nil, err
+	}
+	_ = os.Remove(w.Name())
+	return r, w, nil
+}
@@ -0,0 +1,168 @@
+package shell
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem is the filesystem that file-reading builtins and redirections work on.
+// Names are paths as the user typed them: absolute, or relative to the working directory.
+// Every FileSystem is an fs.FS; FromFS turns any fs.FS into a read-only FileSystem.
+type FileSystem interface {
+	fs.FS
+	Stat(name string) (fs.FileInfo, error)
+	// Lstat is like Stat, but does not follow a symbolic link at name.
+	Lstat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+	// OpenFile opens name for writing; flag takes the os.O_* flags.
+	OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error)
+}
+
+// OSFileSystem is the FileSystem of the operating system, the default of a shell.
+var OSFileSystem FileSystem = osFileSystem{}
+
+type osFileSystem struct{}
+
+func (osFileSystem) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFileSystem) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (osFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+// FromFS returns a read-only FileSystem backed by fsys, such as an embed.FS
+// or an fstest.MapFS. Both absolute and relative names are resolved against
+// the root of fsys, which has no symbolic links.
+func FromFS(fsys fs.FS) FileSystem {
+	return readOnlyFS{fsys: fsys}
+}
+
+type readOnlyFS struct {
+	fsys fs.FS
+}
+
+// fsName turns a path typed by the user into a name valid for an fs.FS.
+func fsName(name string) string {
+	name = strings.TrimLeft(path.Clean(filepath.ToSlash(name)), "/")
+	for name == ".." || strings.HasPrefix(name, "../") {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, ".."), "/")
+	}
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (r readOnlyFS) Open(name string) (fs.File, error) {
+	return r.fsys.Open(fsName(name))
+}
+
+func (r readOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.fsys, fsName(name))
+}
+
+func (r readOnlyFS) Lstat(name string) (fs.FileInfo, error) {
+	return r.Stat(name)
+}
+
+func (r readOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(r.fsys, fsName(name))
+}
+
+func (r readOnlyFS) Readlink(name string) (string, error) {
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+func (r readOnlyFS) OpenFile(name string, _ int, _ fs.FileMode) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+// inputFile gives a command an *os.File to read src from. Files that are not
+// backed by the OS, like here-documents, are fed through a pipe; src is closed
+// once it has been read.
+func inputFile(src io.ReadCloser) (*os.File, error) {
+	if f, ok := src.(*os.File); ok {
+		return f, nil
+	}
+	r, w, err := newPipe()
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+	feed := func() {
+		_, _ = io.Copy(w, src)
+		_ = src.Close()
+		_ = w.Close()
+	}
+	if pipesSupported {
+		go feed()
+	} else {
+		feed()
+	}
+	return r, nil
+}
+
+// outputFile gives a command an *os.File to write to dst. For a dst not backed
+// by the OS, the output goes through a pipe, and wait must be called after
+// the command has finished to close the pipe and let dst receive the rest.
+// Either way, wait closes dst.
+func outputFile(dst io.WriteCloser) (f *os.File, wait func(), err error) {
+	if f, ok := dst.(*os.File); ok {
+		return f, func() { _ = f.Close() }, nil
+	}
+	r, w, err := newPipe()
+	if err != nil {
+		_ = dst.Close()
+		return nil, nil, err
+	}
+	drain := func() {
+		_, _ = io.Copy(dst, r)
+		_ = r.Close()
+		_ = dst.Close()
+	}
+	if !pipesSupported {
+		return w, func() {
+			_ = w.Close()
+			drain()
+		}, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		drain()
+		close(done)
+	}()
+	return w, func() {
+		_ = w.Close()
+		<-done
+	}, nil
+}
+
+// fileSystem returns the filesystem redirections of the runner go to.
+func (p *pipelineRunner) fileSystem() FileSystem {
+	if p.fsys == nil {
+		return OSFileSystem
+	}
+	return p.fsys
+}
@@ -0,0 +1,110 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFS is an in-memory FileSystem whose written files appear in files once closed.
+type memFS struct {
+	FileSystem
+	files fstest.MapFS
+}
+
+func newMemFS(files fstest.MapFS) *memFS {
+	return &memFS{FileSystem: FromFS(files), files: files}
+}
+
+func (m *memFS) OpenFile(name string, flag int, _ fs.FileMode) (io.WriteCloser, error) {
+	w := &memFile{fs: m, name: fsName(name)}
+	if flag&os.O_APPEND != 0 {
+		if f, ok := m.files[w.name]; ok {
+			w.buf.Write(f.Data)
+		}
+	}
+	return w, nil
+}
+
+type memFile struct {
+	fs   *memFS
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error {
+	f.fs.files[f.name] = &fstest.MapFile{Data: f.buf.Bytes(), Mode: 0644}
+	return nil
+}
+
+func newMemShell(t *testing.T, files fstest.MapFS) (*Shell, *os.File) {
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = stdout.Close()
+	})
+	return NewShell(WithStdout(stdout), WithFileSystem(newMemFS(files))), stdout
+}
+
+func TestShell_WithFileSystem_Builtins(t *testing.T) {
+	sh, stdout := newMemShell(t, fstest.MapFS{
+		"notes.txt":      {Data: []byte("apple\nbanana\ncherry\n")},
+		"docs/guide.md":  {Data: []byte("# guide\n")},
+		"docs/readme.md": {Data: []byte("# readme\n")},
+	})
+
+	for _, line := range []string{"cat /notes.txt", "grep an notes.txt", "wc notes.txt", "ls docs", "tree docs"} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retCode, line)
+	}
+
+	assert.Equal(t, "apple\nbanana\ncherry\n"+
+		"banana\n"+
+		"3 3 20 notes.txt\n"+
+		"guide.md\nreadme.md\n"+
+		"docs\n├── guide.md\n└── readme.md\n\n0 directories, 2 files\n", readShellOutput(t, stdout))
+}
+
+func TestShell_WithFileSystem_Redirections(t *testing.T) {
+	files := fstest.MapFS{"in.txt": {Data: []byte("b\na\nb\n")}}
+	sh, stdout := newMemShell(t, files)
+
+	retCode, _, err := sh.Execute("grep b < in.txt > out.txt && wc < out.txt 2> err.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "b\nb\n", string(files["out.txt"].Data))
+	assert.Equal(t, "", string(files["err.txt"].Data))
+	assert.Equal(t, "2 2 4 out.txt\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("cat missing.txt 2>> out.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Contains(t, string(files["out.txt"].Data), "b\nb\ncat: open missing.txt: file does not exist\n")
+}
+
+func TestFromFS_ReadOnly(t *testing.T) {
+	fsys := FromFS(fstest.MapFS{"a/b.txt": {Data: []byte("x")}})
+
+	info, err := fsys.Stat("/a/../a/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Size())
+
+	entries, err := fsys.ReadDir("../a")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "b.txt", entries[0].Name())
+
+	_, err = fsys.OpenFile("a/c.txt", os.O_WRONLY|os.O_CREATE, 0644)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// errUnsupportedFlag is returned by parseFlags for an option the builtin does
+// not have. The factory then runs the system program of the same name, if
+// there is one, as the shell did before the builtin replaced it.
+var errUnsupportedFlag = errors.New("invalid option")
+
+// newFlagSet returns a flag set for the builtin name. Parse errors are only
+// returned, not printed: the runner writes them to the stderr of the stage.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses args with fs the POSIX way: short options may be bundled,
+// as in "rm -rf", and the value of the last one may be attached, as in
+// "head -n5". Options the flag set knows by their full name, like "-records",
+// are parsed as they are.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	expanded := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			expanded = append(expanded, args[i:]...)
+			break
+		}
+
+		// A short option takes everything after it as its value, "=" too.
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := fs.Lookup(name); len(name) > 1 && (f != nil || name == "help") {
+			expanded = append(expanded, arg)
+			if f != nil && !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				expanded = append(expanded, args[i])
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			return fmt.Errorf("%w '%s'", errUnsupportedFlag, arg)
+		}
+
+		for j, r := range arg[1:] {
+			f := fs.Lookup(string(r))
+			if f == nil {
+				return fmt.Errorf("%w -- '%c'", errUnsupportedFlag, r)
+			}
+			expanded = append(expanded, "-"+f.Name)
+			if isBoolFlag(f) {
+				continue
+			}
+			if value := arg[1+j+utf8.RuneLen(r):]; value != "" {
+				expanded = append(expanded, value)
+			} else if i+1 < len(args) {
+				i++
+				expanded = append(expanded, args[i])
+			}
+			break
+		}
+	}
+	return fs.Parse(expanded)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
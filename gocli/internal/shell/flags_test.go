@@ -0,0 +1,50 @@
+package shell
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args      []string
+		recursive bool
+		force     bool
+		count     int
+		rest      []string
+	}{
+		{args: []string{"-rf", "dir"}, recursive: true, force: true, rest: []string{"dir"}},
+		{args: []string{"-r", "-n", "-1", "-f"}, recursive: true, count: -1, force: true, rest: []string{}},
+		{args: []string{"-fn5", "a"}, force: true, count: 5, rest: []string{"a"}},
+		{args: []string{"-rn", "3", "--", "-f"}, recursive: true, count: 3, rest: []string{"-f"}},
+		{args: []string{"-records", "-"}, rest: []string{"-"}},
+		{args: []string{"-n", "7", "-records=false"}, count: 7, rest: []string{}},
+		{args: []string{"a", "-r"}, rest: []string{"a", "-r"}},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		recursive := fs.Bool("r", false, "")
+		force := fs.Bool("f", false, "")
+		count := fs.Int("n", 0, "")
+		fs.Bool("records", false, "")
+		require.NoError(t, parseFlags(fs, tc.args), tc.args)
+		assert.Equal(t, tc.recursive, *recursive, tc.args)
+		assert.Equal(t, tc.force, *force, tc.args)
+		assert.Equal(t, tc.count, *count, tc.args)
+		assert.Equal(t, tc.rest, fs.Args(), tc.args)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("r", false, "")
+	err := parseFlags(fs, []string{"-rv"})
+	assert.ErrorIs(t, err, errUnsupportedFlag)
+	assert.EqualError(t, err, "invalid option -- 'v'")
+	assert.ErrorIs(t, parseFlags(fs, []string{"--verbose"}), errUnsupportedFlag)
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	delimiter := fs.String("d", "", "")
+	require.NoError(t, parseFlags(fs, []string{"-d="}))
+	assert.Equal(t, "=", *delimiter)
+}
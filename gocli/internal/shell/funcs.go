@@ -0,0 +1,163 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Func is a Go function run as a shell command. It gets the arguments
+// without the command name, and the input and output of its pipeline stage.
+// The context is cancelled when the user presses Ctrl-C. A non-nil error is
+// printed to the error output of the command, which then fails with status 1
+// unless the function returned another non-zero one.
+type Func func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error)
+
+// RegisterFunc makes name run fn. Registered functions take precedence over
+// mocks and programs in PATH, but not over builtins.
+func (s *Shell) RegisterFunc(name string, fn Func) {
+	s.factory.funcs.set(name, func(ctx context.Context, args []string, stdin io.Reader, stdout, _ io.Writer, _ Env) (int, error) {
+		return fn(ctx, args, stdin, stdout)
+	})
+}
+
+// RegisterFlagFunc makes name run fn with the flags bound to the fields of T,
+// which must be a struct. A field becomes a flag when it has a `flag:"NAME"`
+// tag; `usage:"..."` describes it and `default:"..."` sets its initial value.
+// Supported field types are string, bool, int, int64, uint, uint64, float64
+// and time.Duration. fn receives a fresh T and the arguments left after the flags.
+func RegisterFlagFunc[T any](s *Shell, name string, fn func(ctx context.Context, flags *T, args []string, stdin io.Reader, stdout io.Writer) (int, error)) error {
+	// Bind once up front, so that a bad struct is reported now rather than on every call.
+	if _, err := bindFlags(name, new(T)); err != nil {
+		return err
+	}
+
+	s.factory.funcs.set(name, func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, _ Env) (int, error) {
+		flags := new(T)
+		fs, err := bindFlags(name, flags)
+		if err != nil {
+			return 1, err
+		}
+		fs.SetOutput(stderr)
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0, nil
+			}
+			// The flag set has already printed the error with the usage.
+			return 2, nil
+		}
+		return fn(ctx, flags, fs.Args(), stdin, stdout)
+	})
+	return nil
+}
+
+// bindFlags defines a flag for every tagged field of the struct that target points to.
+func bindFlags(name string, target any) (*flag.FlagSet, error) {
+	v := reflect.ValueOf(target).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: flags must be a struct, not %s", name, v.Type())
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		flagName, ok := field.Tag.Lookup("flag")
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return nil, fmt.Errorf("%s: field %s is not exported", name, field.Name)
+		}
+		usage := field.Tag.Get("usage")
+
+		switch ptr := v.Field(i).Addr().Interface().(type) {
+		case *string:
+			fs.StringVar(ptr, flagName, "", usage)
+		case *bool:
+			fs.BoolVar(ptr, flagName, false, usage)
+		case *int:
+			fs.IntVar(ptr, flagName, 0, usage)
+		case *int64:
+			fs.Int64Var(ptr, flagName, 0, usage)
+		case *uint:
+			fs.UintVar(ptr, flagName, 0, usage)
+		case *uint64:
+			fs.Uint64Var(ptr, flagName, 0, usage)
+		case *float64:
+			fs.Float64Var(ptr, flagName, 0, usage)
+		case *time.Duration:
+			fs.DurationVar(ptr, flagName, 0, usage)
+		default:
+			return nil, fmt.Errorf("%s: field %s: unsupported flag type %s", name, field.Name, field.Type)
+		}
+
+		if value, ok := field.Tag.Lookup("default"); ok {
+			f := fs.Lookup(flagName)
+			if err := f.Value.Set(value); err != nil {
+				return nil, fmt.Errorf("%s: field %s: invalid default %q: %w", name, field.Name, value, err)
+			}
+			f.DefValue = value
+		}
+	}
+	return fs, nil
+}
+
+// funcBody is how registered functions are stored, with the error output
+// that RegisterFlagFunc needs for the usage message and the environment
+// that process plugins pass on.
+type funcBody func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, env Env) (int, error)
+
+type funcRegistry struct {
+	mu    sync.Mutex
+	funcs map[string]funcBody
+}
+
+func newFuncRegistry() *funcRegistry {
+	return &funcRegistry{funcs: make(map[string]funcBody)}
+}
+
+func (r *funcRegistry) get(name string) (funcBody, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn, ok := r.funcs[name]
+	return fn, ok
+}
+
+func (r *funcRegistry) set(name string, fn funcBody) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+}
+
+// funcCommand runs a registered Go function.
+type funcCommand struct {
+	name string
+	fn   funcBody
+	args []string
+}
+
+func (f *funcCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var stdin io.Reader = strings.NewReader("")
+	if in != nil {
+		stdin = in
+	}
+	code, err := f.fn(ctx, f.args, stdin, out, errOut, env)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", f.name, err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code, false
+}
@@ -0,0 +1,108 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_RegisterFunc(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	sh.RegisterFunc("upper", func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return 1, err
+		}
+		_, _ = fmt.Fprint(stdout, strings.ToUpper(string(data)), strings.Join(args, ","), "\n")
+		return 0, nil
+	})
+
+	retCode, _, err := sh.Execute("echo hello | upper a b | cat")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "HELLO\na,b\n", readShellOutput(t, stdout))
+}
+
+func TestShell_RegisterFunc_Error(t *testing.T) {
+	sh, _ := newTestShell(t)
+	sh.RegisterFunc("fail", func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		return 0, errors.New("no luck")
+	})
+	errPath := filepath.Join(t.TempDir(), "err")
+
+	retCode, _, err := sh.Execute("fail 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Equal(t, "fail: no luck\n", string(stderr))
+}
+
+type deployFlags struct {
+	Env     string        `flag:"env" usage:"target environment" default:"staging"`
+	DryRun  bool          `flag:"n" usage:"only print what would be done"`
+	Timeout time.Duration `flag:"timeout" default:"1m"`
+	Retries int           `flag:"retries"`
+	Note    string
+}
+
+func TestRegisterFlagFunc(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	err := RegisterFlagFunc(sh, "deploy", func(ctx context.Context, flags *deployFlags, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		_, _ = fmt.Fprintf(stdout, "%s %t %s %d %v\n", flags.Env, flags.DryRun, flags.Timeout, flags.Retries, args)
+		return 0, nil
+	})
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("deploy -n -retries 3 api web; deploy -env prod api")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "staging true 1m0s 3 [api web]\nprod false 1m0s 0 [api]\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("deploy -retries many 2> " + filepath.Join(t.TempDir(), "err"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+}
+
+func TestRegisterFlagFunc_Invalid(t *testing.T) {
+	sh, _ := newTestShell(t)
+	noop := func(ctx context.Context, flags *struct {
+		Level float32 `flag:"level"`
+	}, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		return 0, nil
+	}
+	assert.Error(t, RegisterFlagFunc(sh, "bad", noop))
+
+	badDefault := func(ctx context.Context, flags *struct {
+		Count int `flag:"count" default:"ten"`
+	}, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		return 0, nil
+	}
+	assert.Error(t, RegisterFlagFunc(sh, "bad", badDefault))
+
+	notStruct := func(ctx context.Context, flags *string, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		return 0, nil
+	}
+	assert.Error(t, RegisterFlagFunc(sh, "bad", notStruct))
+}
+
+func TestWhichCommand_Func(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	sh.RegisterFunc("mytool", func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+		return 0, nil
+	})
+
+	retCode, _, err := sh.Execute("which mytool")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "mytool: function\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"bufio"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file.
+type ignoreRule struct {
+	// base is the directory of the .gitignore file, relative to the root of
+	// the walk, "." for the root itself.
+	base    string
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns contain a slash and match the whole path below base;
+	// the others match the name of a file at any depth.
+	anchored bool
+}
+
+// gitignore holds the rules of the .gitignore files from the root of a walk
+// down to the directory being listed. Rules of deeper files come later and
+// win, as in git. A nil *gitignore ignores nothing.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load returns g extended by the rules of the .gitignore file in dir, whose
+// path relative to the root of the walk is rel. g itself is not changed, so
+// that sibling directories do not see each other's rules.
+func (g *gitignore) load(fsys FileSystem, dir, rel string) *gitignore {
+	f, err := fsys.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return g
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []ignoreRule
+	if g != nil {
+		rules = append(rules, g.rules...)
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), rel); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return &gitignore{rules: rules}
+}
+
+// parseIgnoreRule parses a line of a .gitignore file in the directory base.
+// It reports false for blank lines and comments.
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// "\#" and "\!" start patterns with a literal "#" or "!".
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	rule.pattern = strings.TrimPrefix(line, "/")
+	return rule, rule.pattern != ""
+}
+
+// ignored reports whether the file at rel, relative to the root of the walk,
+// is ignored.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.matches(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(rel string) bool {
+	if r.base != "." {
+		var ok bool
+		if rel, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+			return false
+		}
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(rel))
+		return ok
+	}
+	return matchPathSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchPathSegments matches a path against a pattern segment by segment,
+// where a "**" segment stands for any number of directories.
+func matchPathSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchPathSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,41 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitignore_Ignored(t *testing.T) {
+	var g gitignore
+	for _, line := range []string{"*.log", "!keep.log", "/tmp/", "docs/**/draft.md", `\#notes`, "", "# comment"} {
+		if rule, ok := parseIgnoreRule(line, "."); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	if rule, ok := parseIgnoreRule("gen", "src"); ok {
+		g.rules = append(g.rules, rule)
+	}
+
+	for _, tc := range []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"src/app.log", false, true},
+		{"keep.log", false, false},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"src/tmp", true, false},
+		{"docs/draft.md", false, true},
+		{"docs/a/b/draft.md", false, true},
+		{"draft.md", false, false},
+		{"#notes", false, true},
+		{"src/gen", true, true},
+		{"gen", true, false},
+	} {
+		assert.Equal(t, tc.ignored, g.ignored(tc.rel, tc.isDir), tc.rel)
+	}
+	assert.False(t, (*gitignore)(nil).ignored("app.log", false))
+}
@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// hasGlobMeta reports whether s contains any of the "*", "?" or "[" pattern characters.
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// expandGlobs replaces every word that matches existing paths with the
+// sorted matches. Words matching nothing, and malformed patterns, are kept
+// as they are. Like in POSIX shells, names starting with a dot only match
+// a pattern component that starts with a dot too.
+func expandGlobs(words []string) []string {
+	expanded := make([]string, 0, len(words))
+	for _, word := range words {
+		if !hasGlobMeta(word) {
+			expanded = append(expanded, word)
+			continue
+		}
+
+		matches, err := filepath.Glob(word)
+		if err != nil {
+			expanded = append(expanded, word)
+			continue
+		}
+		matches = withoutHidden(word, matches)
+		if len(matches) == 0 {
+			expanded = append(expanded, word)
+			continue
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded
+}
+
+// withoutHidden drops the matches that have a dot file in a position where
+// pattern has a component not starting with a dot. Components are compared
+// from the end, since Glob may clean a leading "./" from the matches.
+func withoutHidden(pattern string, matches []string) []string {
+	patternParts := strings.Split(filepath.ToSlash(pattern), "/")
+	visible := matches[:0]
+	for _, match := range matches {
+		matchParts := strings.Split(filepath.ToSlash(match), "/")
+		hidden := false
+		for k := 1; k <= min(len(patternParts), len(matchParts)); k++ {
+			part, patternPart := matchParts[len(matchParts)-k], patternParts[len(patternParts)-k]
+			if strings.HasPrefix(part, ".") && !strings.HasPrefix(patternPart, ".") {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			visible = append(visible, match)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandGlobs(t *testing.T) {
+	tempWorkDir(t)
+	for _, name := range []string{"a.txt", "b.txt", "test-1.log", "test-22.log", ".hidden.txt"} {
+		require.NoError(t, os.WriteFile(name, nil, 0644))
+	}
+	require.NoError(t, os.Mkdir("dir", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("dir", "c.txt"), nil, 0644))
+
+	assert.Equal(t, []string{"a.txt", "b.txt"}, expandGlobs([]string{"*.txt"}))
+	assert.Equal(t, []string{"test-1.log"}, expandGlobs([]string{"test-?.log"}))
+	assert.Equal(t, []string{"a.txt"}, expandGlobs([]string{"[a].txt"}))
+	assert.Equal(t, []string{"a.txt", "b.txt"}, expandGlobs([]string{"./*.txt"}))
+	assert.Equal(t, []string{".hidden.txt"}, expandGlobs([]string{".*.txt"}))
+	assert.Equal(t, []string{filepath.Join("dir", "c.txt")}, expandGlobs([]string{"*/*.txt"}))
+	assert.Equal(t, []string{"*.md", "[", "plain"}, expandGlobs([]string{"*.md", "[", "plain"}))
+}
+
+func TestInputProcessor_Parse_UnquotedArgs(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse(`ls *.go "*.md" '?' x"[a]" plain`)
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+	assert.Equal(t, map[int]bool{0: true, 1: true, 5: true}, descriptions[0].unquotedArgs)
+}
+
+func TestShell_Execute_Globbing(t *testing.T) {
+	tempWorkDir(t)
+	for _, name := range []string{"one.txt", "two.txt", "skip.log"} {
+		require.NoError(t, os.WriteFile(name, []byte(name+"\n"), 0644))
+	}
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`echo *.txt "*.txt" *.none; X=*.log; echo "$X"`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "one.txt two.txt *.txt *.none\n*.log\n", readShellOutput(t, stdout))
+}
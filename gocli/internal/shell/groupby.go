@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// groupByCommand writes a row per distinct combination of key fields, in
+// the order they first appear, with the keys and aggregates of the rows.
+type groupByCommand struct {
+	format     recordFormat
+	keys       []string
+	aggregates []aggregate
+}
+
+// aggregate is "count" or FUNCTION:FIELD, where FUNCTION is sum, avg, min
+// or max. These work on the values of FIELD that are numbers.
+type aggregate struct {
+	function string
+	field    string
+}
+
+// name is the field of the aggregate in the output, like "count" or "sum_size".
+func (a aggregate) name() string {
+	if a.field == "" {
+		return a.function
+	}
+	return a.function + "_" + a.field
+}
+
+func parseGroupByCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("group-by")
+	var format recordFormat
+	format.addFlags(fs)
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("group-by: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("group-by: usage: group-by [-d DELIM [-no-header]] FIELD[,FIELD...] [count | sum:FIELD | avg:FIELD | min:FIELD | max:FIELD]...")
+	}
+	keys := splitFieldList(fs.Args()[:1])
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("group-by: no fields to group by")
+	}
+
+	var aggregates []aggregate
+	for _, arg := range fs.Args()[1:] {
+		function, field, _ := strings.Cut(arg, ":")
+		switch {
+		case function == "count" && field == "":
+		case (function == "sum" || function == "avg" || function == "min" || function == "max") && field != "":
+		default:
+			return nil, fmt.Errorf("group-by: %s: unknown aggregate", arg)
+		}
+		aggregates = append(aggregates, aggregate{function: function, field: field})
+	}
+	if len(aggregates) == 0 {
+		aggregates = []aggregate{{function: "count"}}
+	}
+	return &groupByCommand{format: format, keys: keys, aggregates: aggregates}, nil
+}
+
+// rowGroup accumulates the rows with the same keys.
+type rowGroup struct {
+	keys  []any
+	count int
+	// Per aggregate: the number of numeric values seen, their sum, minimum and maximum.
+	numbers []int
+	sums    []float64
+	mins    []float64
+	maxs    []float64
+}
+
+func (g *rowGroup) add(aggregates []aggregate, get recordGetter) {
+	g.count++
+	for i, a := range aggregates {
+		if a.field == "" {
+			continue
+		}
+		value, _ := get(a.field)
+		n, ok := recordNumber(value)
+		if !ok {
+			continue
+		}
+		if g.numbers[i] == 0 || n < g.mins[i] {
+			g.mins[i] = n
+		}
+		if g.numbers[i] == 0 || n > g.maxs[i] {
+			g.maxs[i] = n
+		}
+		g.numbers[i]++
+		g.sums[i] += n
+	}
+}
+
+// value returns the result of an aggregate; without numbers, avg, min and max are null.
+func (g *rowGroup) value(i int, a aggregate) any {
+	switch {
+	case a.function == "count":
+		return g.count
+	case a.function == "sum":
+		return g.sums[i]
+	case g.numbers[i] == 0:
+		return nil
+	case a.function == "avg":
+		return g.sums[i] / float64(g.numbers[i])
+	case a.function == "min":
+		return g.mins[i]
+	default:
+		return g.maxs[i]
+	}
+}
+
+// Execute puts rows without a key field into the group where it is null, or empty with -d.
+func (c *groupByCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	var order []*rowGroup
+	groups := make(map[string]*rowGroup)
+	retCode = c.format.each("group-by", in, errOut, func([]string) error { return nil }, func(_ string, get recordGetter) error {
+		keys := make([]any, len(c.keys))
+		parts := make([]string, len(c.keys))
+		for i, field := range c.keys {
+			keys[i], _ = get(field)
+			parts[i] = recordString(keys[i])
+		}
+		id := strings.Join(parts, "\x00")
+		group, ok := groups[id]
+		if !ok {
+			n := len(c.aggregates)
+			group = &rowGroup{keys: keys, numbers: make([]int, n), sums: make([]float64, n), mins: make([]float64, n), maxs: make([]float64, n)}
+			groups[id] = group
+			order = append(order, group)
+		}
+		group.add(c.aggregates, get)
+		return nil
+	})
+
+	names := append([]string(nil), c.keys...)
+	for _, a := range c.aggregates {
+		names = append(names, a.name())
+	}
+	writer := bufio.NewWriter(out)
+	defer func() { _ = writer.Flush() }()
+	if len(order) > 0 {
+		_ = c.format.writeHeader(writer, names)
+	}
+	for _, group := range order {
+		values := append([]any(nil), group.keys...)
+		for i, a := range c.aggregates {
+			values = append(values, group.value(i, a))
+		}
+		if err := c.format.writeRow(writer, names, values); err != nil {
+			return 1, false
+		}
+	}
+	return retCode, false
+}
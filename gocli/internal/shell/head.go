@@ -0,0 +1,121 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// headCommand prints the first lines, or with -c the first bytes,
+// of every file or of its input.
+type headCommand struct {
+	filePaths []string
+	lines     int
+	// bytes is the number of bytes to print, or -1 to count lines.
+	bytes int
+}
+
+func parseHeadCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("head")
+	lines := fs.Int("n", 10, "print the first N lines")
+	bytes := fs.Int("c", -1, "print the first N bytes")
+
+	args := make([]string, 0, len(d.arguments))
+	for i, arg := range d.arguments[1:] {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			args = append(args, d.arguments[1+i:]...)
+			break
+		}
+		// The traditional "head -3" means "head -n 3".
+		isValue := i > 0 && (d.arguments[i] == "-n" || d.arguments[i] == "-c")
+		if count := arg[1:]; !isValue && count != "" && strings.Trim(count, "0123456789") == "" {
+			arg = "-n" + count
+		}
+		args = append(args, arg)
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return nil, fmt.Errorf("head: %w", err)
+	}
+	if *lines < 0 {
+		return nil, fmt.Errorf("head: invalid number of lines: %d", *lines)
+	}
+	if *bytes < -1 {
+		return nil, fmt.Errorf("head: invalid number of bytes: %d", *bytes)
+	}
+
+	filePaths := fs.Args()
+	if len(filePaths) == 0 && d.fileInPath != "" {
+		filePaths = []string{d.fileInPath}
+	}
+
+	return &headCommand{
+		filePaths: filePaths,
+		lines:     *lines,
+		bytes:     *bytes,
+	}, nil
+}
+
+func (h *headCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	sources := h.filePaths
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+
+	for i, path := range sources {
+		source := in
+		if path != "" {
+			file, err := os.Open(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "head: %v\n", err)
+				retCode = 1
+				continue
+			}
+			source = file
+		}
+		if len(sources) > 1 {
+			if i > 0 {
+				_, _ = fmt.Fprintln(out)
+			}
+			_, _ = fmt.Fprintf(out, "==> %s <==\n", path)
+		}
+
+		err := h.copyHead(out, source)
+		if path != "" {
+			_ = source.Close()
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "head: %v\n", err)
+			return 1, false
+		}
+	}
+	return retCode, false
+}
+
+// copyHead copies the beginning of source to out.
+func (h *headCommand) copyHead(out io.Writer, source io.Reader) error {
+	if h.bytes >= 0 {
+		_, err := io.CopyN(out, source, int64(h.bytes))
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	reader := bufio.NewReader(source)
+	for n := 0; n < h.lines; n++ {
+		line, err := reader.ReadString('\n')
+		if _, writeErr := io.WriteString(out, line); writeErr != nil {
+			return writeErr
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
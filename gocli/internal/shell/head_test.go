@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runHead(t *testing.T, input string, args ...string) string {
+	cmd, err := parseHeadCommand(CommandDescription{
+		name:      HeadCommand,
+		arguments: append([]string{"head"}, args...),
+	})
+	require.NoError(t, err)
+
+	in, err := os.Create(filepath.Join(t.TempDir(), "input.txt"))
+	require.NoError(t, err)
+	defer func() { _ = in.Close() }()
+	_, err = in.WriteString(input)
+	require.NoError(t, err)
+	_, err = in.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(in, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestHeadCommand_Execute_Lines(t *testing.T) {
+	input := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	assert.Equal(t, "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", runHead(t, input))
+	assert.Equal(t, "1\n2\n", runHead(t, input, "-n", "2"))
+	assert.Equal(t, "1\n2\n3\n", runHead(t, input, "-n3"))
+	assert.Equal(t, "", runHead(t, input, "-n", "0"))
+	assert.Equal(t, "a\nb", runHead(t, "a\nb", "-n", "5"))
+	assert.Equal(t, "1\n2\n3\n", runHead(t, input, "-3"))
+	assert.Equal(t, "1\n", runHead(t, input, "-4", "-n1"))
+}
+
+func TestHeadCommand_Execute_Bytes(t *testing.T) {
+	assert.Equal(t, "hel", runHead(t, "hello\nworld\n", "-c", "3"))
+	assert.Equal(t, "hi\n", runHead(t, "hi\n", "-c", "100"))
+}
+
+func TestHeadCommand_Execute_Files(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	require.NoError(t, os.WriteFile(first, []byte("a\nb\n"), 0644))
+	require.NoError(t, os.WriteFile(second, []byte("c\nd\n"), 0644))
+
+	assert.Equal(t, "a\n", runHead(t, "", "-n", "1", first))
+	expected := "==> " + first + " <==\na\n\n==> " + second + " <==\nc\n"
+	assert.Equal(t, expected, runHead(t, "", "-n", "1", first, second))
+}
+
+func TestHeadCommand_Execute_MissingFile(t *testing.T) {
+	cmd := &headCommand{filePaths: []string{"/nonexistent/file"}, lines: 10, bytes: -1}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 1, retCode)
+	assert.False(t, exited)
+}
+
+func TestParseHeadCommand_InvalidCount(t *testing.T) {
+	_, err := parseHeadCommand(CommandDescription{name: HeadCommand, arguments: []string{"head", "-n", "-1"}})
+	assert.EqualError(t, err, "head: invalid number of lines: -1")
+	_, err = parseHeadCommand(CommandDescription{name: HeadCommand, arguments: []string{"head", "-c", "x"}})
+	assert.Error(t, err)
+}
+
+func TestShell_Execute_HeadStopsPipeline(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	path := filepath.Join(t.TempDir(), "big.txt")
+	retCode, _, err := sh.Execute("seq 1 200000 > " + path)
+	require.NoError(t, err)
+	require.Equal(t, 0, retCode)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, err = sh.Execute("cat " + path + " | head -n 2")
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline did not finish after head stopped reading")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,66 @@
+package shell
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrIncompleteInput is returned by the input processor when a command needs
+// more lines than it was given, e.g. a here-document without its delimiter.
+var ErrIncompleteInput = errors.New("incomplete input")
+
+// hereDoc is text fed to a command's standard input from the command line itself,
+// either a here-document or a here-string.
+type hereDoc struct {
+	delimiter string
+	stripTabs bool
+	// expand enables $VAR and $(...) expansion of the text,
+	// which is off when the delimiter is quoted.
+	expand bool
+	text   string
+}
+
+// newHereDoc creates a here-document from a "<<WORD" or "<<-WORD" operator.
+func newHereDoc(operator string, quoted bool) *hereDoc {
+	doc := &hereDoc{expand: !quoted}
+	operator = strings.TrimPrefix(operator, "<<")
+	if strings.HasPrefix(operator, "-") {
+		doc.stripTabs = true
+		operator = operator[1:]
+	}
+	doc.delimiter = operator
+	return doc
+}
+
+// newHereString creates the input of a "<<< WORD" operator: the word followed
+// by a newline, expanded unless it was single-quoted.
+func newHereString(word string, singleQuoted bool) *hereDoc {
+	return &hereDoc{expand: !singleQuoted, text: word + "\n"}
+}
+
+// read collects the body from lines up to the delimiter line and returns
+// how many lines it consumed, including the delimiter. It reports false
+// when the delimiter is missing.
+func (d *hereDoc) read(lines []string) (int, bool) {
+	var sb strings.Builder
+	for n, line := range lines {
+		if d.stripTabs {
+			line = strings.TrimLeft(line, "\t")
+		}
+		if line == d.delimiter {
+			d.text = sb.String()
+			return n + 1, true
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return 0, false
+}
+
+// hereDocInput returns a pipe from which the command reads text. The text is
+// written from a separate goroutine, so it may exceed the pipe buffer.
+func hereDocInput(text string) (*os.File, error) {
+	return inputFile(io.NopCloser(strings.NewReader(text)))
+}
@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputProcessor_Parse_HereDocument(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("cat <<-'END' | wc; echo after\n\tline $X\n\t\tnested\n\tEND\necho next")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 4)
+
+	doc := descriptions[0].hereDoc
+	require.NotNil(t, doc)
+	assert.Equal(t, []string{"cat"}, descriptions[0].arguments)
+	assert.Equal(t, "END", doc.delimiter)
+	assert.False(t, doc.expand)
+	assert.Equal(t, "line $X\nnested\n", doc.text)
+
+	assert.Equal(t, WCCommand, descriptions[1].name)
+	assert.Equal(t, []string{"echo", "after"}, descriptions[2].arguments)
+	assert.Equal(t, []string{"echo", "next"}, descriptions[3].arguments)
+}
+
+func TestInputProcessor_Parse_HereDocumentIncomplete(t *testing.T) {
+	processor := NewInputProcessor()
+
+	_, err := processor.Parse("cat << EOF\nno delimiter yet")
+	assert.ErrorIs(t, err, ErrIncompleteInput)
+}
+
+func TestShell_Execute_HereDocumentExpansion(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("X=value\ncat <<EOF\n$X and $(echo sub)\nEOF")
+	require.NoError(t, err)
+	assert.Equal(t, "value and sub\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Run_HereDocument(t *testing.T) {
+	dir := t.TempDir()
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("cat << EOF\nfirst\nsecond\nEOF\necho done\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout))
+	assert.Equal(t, 0, sh.Run())
+	assert.Equal(t, "$ > > > first\nsecond\n$ done\n$ ", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_LargeHereDocument(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	body := strings.Repeat("0123456789\n", 20000)
+
+	_, _, err := sh.Execute("wc << EOF\n" + body + "EOF")
+	require.NoError(t, err)
+	assert.Equal(t, "20000 20000 220000\n", readShellOutput(t, stdout))
+}
+
+func TestInputProcessor_Parse_HereString(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse(`grep foo <<< "$VAR and more"; cat <<<'$VAR'; cat <<<word`)
+	require.NoError(t, err)
+	require.Len(t, descriptions, 3)
+
+	assert.Equal(t, []string{"grep", "foo"}, descriptions[0].arguments)
+	assert.Equal(t, &hereDoc{expand: true, text: "$VAR and more\n"}, descriptions[0].hereDoc)
+	assert.Equal(t, &hereDoc{expand: false, text: "$VAR\n"}, descriptions[1].hereDoc)
+	assert.Equal(t, &hereDoc{expand: true, text: "word\n"}, descriptions[2].hereDoc)
+}
+
+func TestShell_Execute_HereString(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	sh.env.Set("VAR", "foo bar")
+
+	retCode, _, err := sh.Execute(`grep foo <<< "$VAR"`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "foo bar\n", readShellOutput(t, stdout))
+}
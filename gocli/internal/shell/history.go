@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxHistory is how many lines the history keeps; older ones are dropped.
+const maxHistory = 1000
+
+// historyList holds the lines run in the session, oldest first.
+type historyList struct {
+	mu      sync.Mutex
+	entries []string
+	// first is the number of entries[0]; numbers do not change when old lines are dropped.
+	first int
+}
+
+func newHistoryList() *historyList {
+	return &historyList{first: 1}
+}
+
+// add records line, unless it is blank.
+func (h *historyList) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, line)
+	if extra := len(h.entries) - maxHistory; extra > 0 {
+		h.entries = append([]string(nil), h.entries[extra:]...)
+		h.first += extra
+	}
+}
+
+func (h *historyList) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = nil
+	h.first = 1
+}
+
+// last returns the number of the first of the last n entries, and the entries
+// themselves. A negative n means all of them.
+func (h *historyList) last(n int) (int, []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	start := 0
+	if n >= 0 && n < len(h.entries) {
+		start = len(h.entries) - n
+	}
+	return h.first + start, append([]string(nil), h.entries[start:]...)
+}
+
+// historyCommand prints the lines run in the session, numbered, or clears them.
+type historyCommand struct {
+	history *historyList
+	clear   bool
+	// count is the number of last entries to print, -1 for all.
+	count int
+}
+
+func parseHistoryCommand(d CommandDescription, history *historyList) (Command, error) {
+	args := d.arguments[1:]
+	switch {
+	case len(args) == 0:
+		return &historyCommand{history: history, count: -1}, nil
+	case len(args) == 1 && args[0] == "-c":
+		return &historyCommand{history: history, clear: true}, nil
+	case len(args) == 1:
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("history: %s: numeric argument required", args[0])
+		}
+		return &historyCommand{history: history, count: count}, nil
+	default:
+		return nil, fmt.Errorf("history: usage: history [-c] [N]")
+	}
+}
+
+func (h *historyCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if h.clear {
+		h.history.clear()
+		return 0, false
+	}
+	first, entries := h.history.last(h.count)
+	for i, line := range entries {
+		_, _ = fmt.Fprintf(out, "%5d  %s\n", first+i, line)
+	}
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	for _, line := range []string{"echo one", "  ", "echo 'two", "echo 'two\nlines'", "history"} {
+		_, _, _ = sh.Execute(line)
+	}
+	assert.Equal(t, "one\ntwo\nlines\n"+
+		"    1  echo one\n    2  echo 'two\nlines'\n    3  history\n", readShellOutput(t, stdout))
+}
+
+func TestHistoryCommand_Execute_Last(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("echo a")
+	require.NoError(t, err)
+	retCode, _, err := sh.Execute("history 2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "a\n    1  echo a\n    2  history 2\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("history 1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "\n    3  history 1\n")
+}
+
+func TestHistoryCommand_Execute_Clear(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("echo a; history -c")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("history")
+	require.NoError(t, err)
+	assert.Equal(t, "a\n    1  history\n", readShellOutput(t, stdout))
+}
+
+func TestHistoryList_Limit(t *testing.T) {
+	history := newHistoryList()
+	for i := 1; i <= maxHistory+5; i++ {
+		history.add(fmt.Sprint("echo ", i))
+	}
+
+	first, entries := history.last(-1)
+	assert.Equal(t, 6, first)
+	assert.Len(t, entries, maxHistory)
+	assert.Equal(t, "echo 6", entries[0])
+}
+
+func TestShell_Source_NotInHistory(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	profile := filepath.Join(t.TempDir(), "profile")
+	require.NoError(t, os.WriteFile(profile, []byte("X=1\n"), 0644))
+
+	_, _, err := sh.Source(profile)
+	require.NoError(t, err)
+	_, _, err = sh.Execute("history")
+	require.NoError(t, err)
+	assert.Equal(t, "    1  history\n", readShellOutput(t, stdout))
+}
+
+func TestParseHistoryCommand_Invalid(t *testing.T) {
+	for _, args := range [][]string{{"history", "x"}, {"history", "-1"}, {"history", "-c", "3"}} {
+		_, err := parseHistoryCommand(CommandDescription{arguments: args}, newHistoryList())
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"bufio"
+	"strconv"
+	"time"
+)
+
+// idleTimeoutVar holds the number of seconds the shell waits for a command
+// line before it ends the session. There is no timeout while it is unset,
+// zero or not a number.
+const idleTimeoutVar = "TMOUT"
+
+// idleTimeout returns the timeout set in TMOUT, or zero if there is none.
+func idleTimeout(env Env) time.Duration {
+	value, ok := env.Get(idleTimeoutVar)
+	if !ok {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// scanWithTimeout advances scanner like Scan, giving up after timeout unless
+// it is zero, or when done is closed. After giving up the scanner is still
+// in use and must not be touched again.
+func scanWithTimeout(scanner *bufio.Scanner, timeout time.Duration, done <-chan struct{}) (ok, timedOut bool) {
+	scanned := make(chan bool, 1)
+	go func() {
+		scanned <- scanner.Scan()
+	}()
+	var expired <-chan time.Time
+	if timeout != 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case ok := <-scanned:
+		return ok, false
+	case <-expired:
+		return false, true
+	case <-done:
+		return false, false
+	}
+}
@@ -0,0 +1,58 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	env := NewEnv()
+	for value, expected := range map[string]time.Duration{
+		"":    0,
+		"30":  30 * time.Second,
+		"0":   0,
+		"-5":  0,
+		"1.5": 0,
+		"abc": 0,
+	} {
+		env.Set(idleTimeoutVar, value)
+		assert.Equal(t, expected, idleTimeout(env), value)
+	}
+}
+
+func TestShell_Run_IdleTimeout(t *testing.T) {
+	dir := t.TempDir()
+	stdin, input, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = input.Close() }()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout), WithStderr(stderr))
+	_, err = input.WriteString("defer echo bye\nTMOUT=1\n")
+	require.NoError(t, err)
+
+	finished := make(chan int)
+	go func() {
+		finished <- sh.Run()
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shell did not time out")
+	}
+
+	errOutput, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(errOutput), "gocli: timed out waiting for input: auto-logout\n")
+	output, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "bye\n")
+}
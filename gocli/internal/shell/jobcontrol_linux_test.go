@@ -0,0 +1,79 @@
+//go:build linux
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestNewTerminalControl_NotControllingTerminal(t *testing.T) {
+	requirePTY(t)
+	master, slave, err := openPTY()
+	require.NoError(t, err)
+	defer func() { _ = master.Close(); _ = slave.Close() }()
+
+	assert.Nil(t, newTerminalControl(slave))
+	assert.Nil(t, newTerminalControl(nil))
+}
+
+func TestTerminalControl_RestoreModes(t *testing.T) {
+	requirePTY(t)
+	master, slave, err := openPTY()
+	require.NoError(t, err)
+	defer func() { _ = master.Close(); _ = slave.Close() }()
+	fd := int(slave.Fd())
+
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	require.NotZero(t, saved.Lflag&unix.ECHO)
+	control := &terminalControl{fd: fd, saved: saved}
+
+	// Raw mode left behind by a command is undone.
+	raw := *saved
+	raw.Lflag &^= unix.ECHO | unix.ICANON
+	require.NoError(t, unix.IoctlSetTermios(fd, ioctlSetTermios, &raw))
+	control.beginJob()
+	control.endJob()
+	current, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	assert.Equal(t, saved.Lflag, current.Lflag)
+
+	// Other settings, as changed with stty, are kept.
+	tweaked := *saved
+	tweaked.Lflag &^= unix.ECHOCTL
+	require.NoError(t, unix.IoctlSetTermios(fd, ioctlSetTermios, &tweaked))
+	control.beginJob()
+	control.endJob()
+	current, err = unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	assert.Zero(t, current.Lflag&unix.ECHOCTL)
+}
+
+func TestTerminalControl_StartOutsideJob(t *testing.T) {
+	control := &terminalControl{}
+	cmd, err := control.start(exec.Command("true"), func(cmd *exec.Cmd) error { return cmd.Start() })
+	require.NoError(t, err)
+	require.NoError(t, cmd.Wait())
+	assert.Nil(t, cmd.SysProcAttr)
+}
+
+func TestCloneCmd(t *testing.T) {
+	cmd := exec.Command("echo", "a", "b")
+	cmd.Env = []string{"X=1"}
+	cmd.Dir = "/"
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 42}
+
+	clone := cloneCmd(cmd)
+	assert.Equal(t, cmd.Path, clone.Path)
+	assert.Equal(t, []string{"echo", "a", "b"}, clone.Args)
+	assert.Equal(t, cmd.Env, clone.Env)
+	assert.Equal(t, "/", clone.Dir)
+	assert.Equal(t, 42, clone.SysProcAttr.Pgid)
+	assert.NotSame(t, cmd.SysProcAttr, clone.SysProcAttr)
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package shell
+
+import (
+	"os"
+	"os/exec"
+)
+
+// terminalControl does nothing on platforms without process groups and
+// terminal job control.
+type terminalControl struct{}
+
+func newTerminalControl(tty *os.File) *terminalControl {
+	return nil
+}
+
+func (t *terminalControl) beginJob() {}
+
+func (t *terminalControl) endJob() {}
+
+func (t *terminalControl) start(cmd *exec.Cmd, startFunc func(*exec.Cmd) error) (*exec.Cmd, error) {
+	return cmd, startFunc(cmd)
+}
@@ -0,0 +1,164 @@
+//go:build unix
+
+package shell
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalControl hands the terminal of an interactive session to the
+// pipeline that runs in the foreground and takes it back afterwards.
+//
+// The external commands of a pipeline share a process group, which becomes
+// the foreground group of the terminal, so that keyboard signals such as
+// Ctrl-C reach the commands rather than the shell. When the pipeline is done,
+// the shell reclaims the terminal and restores its modes if a command left
+// it without echo or line editing, as crashed full-screen programs do.
+type terminalControl struct {
+	fd        int
+	shellPgid int
+	// saved are the terminal modes the session started with.
+	saved *unix.Termios
+
+	mu sync.Mutex
+	// jobPgid is the process group of the foreground pipeline, or 0 before
+	// its first command starts.
+	jobPgid int
+	depth   int
+}
+
+// newTerminalControl returns the terminal control for tty, or nil when tty
+// is not a terminal or the shell is not its foreground process group.
+func newTerminalControl(tty *os.File) *terminalControl {
+	if tty == nil {
+		return nil
+	}
+	fd := int(tty.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil
+	}
+	foreground, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil {
+		return nil
+	}
+	if pgid, err := unix.Getpgid(0); err != nil || pgid != foreground {
+		return nil
+	}
+	return &terminalControl{fd: fd, shellPgid: foreground, saved: saved}
+}
+
+// beginJob marks the start of a foreground pipeline. Pipelines nested in it,
+// such as command substitutions, join its process group.
+func (t *terminalControl) beginJob() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.depth++
+}
+
+// endJob marks the end of a foreground pipeline; after the outermost one the
+// shell takes the terminal back.
+func (t *terminalControl) endJob() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.depth--
+	if t.depth > 0 {
+		return
+	}
+	if t.jobPgid != 0 {
+		t.jobPgid = 0
+		t.reclaim()
+	}
+	t.restoreModes()
+}
+
+// reclaim makes the shell the foreground process group again.
+func (t *terminalControl) reclaim() {
+	_ = unix.IoctlSetPointerInt(t.fd, unix.TIOCSPGRP, t.shellPgid)
+	// The shell is back in the foreground, and commands started from now on
+	// must not inherit the ignored dispositions.
+	signal.Reset(syscall.SIGTTOU, syscall.SIGTTIN)
+}
+
+// restoreModes restores the saved terminal modes when echo or canonical
+// input was left turned off.
+func (t *terminalControl) restoreModes() {
+	current, err := unix.IoctlGetTermios(t.fd, ioctlGetTermios)
+	if err != nil {
+		return
+	}
+	const cooked = unix.ECHO | unix.ICANON
+	if current.Lflag&cooked != t.saved.Lflag&cooked {
+		_ = unix.IoctlSetTermios(t.fd, ioctlSetTermios, t.saved)
+	}
+}
+
+// start starts cmd with startFunc in the process group of the foreground
+// pipeline, creating the group and giving it the terminal if cmd is its
+// first command. It returns the command that was started, which is a copy
+// of cmd if the group had to be created again.
+func (t *terminalControl) start(cmd *exec.Cmd, startFunc func(*exec.Cmd) error) (*exec.Cmd, error) {
+	if t == nil {
+		return cmd, startFunc(cmd)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.depth == 0 {
+		return cmd, startFunc(cmd)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if t.jobPgid != 0 {
+		cmd.SysProcAttr.Setpgid, cmd.SysProcAttr.Pgid = true, t.jobPgid
+		err := startFunc(cmd)
+		if !errors.Is(err, syscall.EPERM) && !errors.Is(err, syscall.ESRCH) {
+			return cmd, err
+		}
+		// All commands of the group have finished and been reaped,
+		// so the group is gone; start a new one.
+		cmd = cloneCmd(cmd)
+	}
+
+	// While the pipeline owns the terminal, builtins of the pipeline that read
+	// or configure it would stop the whole shell; with the signals ignored they
+	// get an error instead. Setting the foreground group back needs the same.
+	signal.Ignore(syscall.SIGTTOU, syscall.SIGTTIN)
+	cmd.SysProcAttr.Setpgid, cmd.SysProcAttr.Pgid = true, 0
+	cmd.SysProcAttr.Foreground, cmd.SysProcAttr.Ctty = true, t.fd
+	if err := startFunc(cmd); err != nil {
+		if t.jobPgid == 0 {
+			signal.Reset(syscall.SIGTTOU, syscall.SIGTTIN)
+		}
+		return cmd, err
+	}
+	t.jobPgid = cmd.Process.Pid
+	return cmd, nil
+}
+
+// cloneCmd returns an unstarted copy of cmd, which failed to start.
+func cloneCmd(cmd *exec.Cmd) *exec.Cmd {
+	clone := exec.Command(cmd.Path, cmd.Args[1:]...)
+	clone.Args = cmd.Args
+	clone.Env = cmd.Env
+	clone.Dir = cmd.Dir
+	clone.Stdin, clone.Stdout, clone.Stderr = cmd.Stdin, cmd.Stdout, cmd.Stderr
+	clone.ExtraFiles = cmd.ExtraFiles
+	attrs := *cmd.SysProcAttr
+	clone.SysProcAttr = &attrs
+	return clone
+}
@@ -0,0 +1,50 @@
+package shell
+
+import (
+	"fmt"
+)
+
+// kexecCommand runs a command in a container of a Kubernetes pod as a regular
+// pipeline stage. It is only available in builds with the kubernetes tag.
+type kexecCommand struct {
+	pod        string
+	container  string
+	namespace  string
+	context    string
+	kubeconfig string
+	args       []string
+}
+
+// parseKexecCommand accepts the options both before and right after the pod,
+// as in "kexec POD -c CONTAINER COMMAND...".
+func parseKexecCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("kexec")
+	container := fs.String("c", "", "container of the pod, by default its only or default one")
+	namespace := fs.String("n", "", "namespace of the pod, by default that of the context")
+	context := fs.String("context", "", "kubeconfig context to use")
+	kubeconfig := fs.String("kubeconfig", "", "path of the kubeconfig file")
+
+	usage := fmt.Errorf("kexec: usage: kexec [-n NAMESPACE] [--context NAME] POD [-c CONTAINER] COMMAND...")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("kexec: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, usage
+	}
+	pod := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return nil, fmt.Errorf("kexec: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, usage
+	}
+
+	return &kexecCommand{
+		pod:        pod,
+		container:  *container,
+		namespace:  *namespace,
+		context:    *context,
+		kubeconfig: *kubeconfig,
+		args:       fs.Args(),
+	}, nil
+}
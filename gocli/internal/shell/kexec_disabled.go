@@ -0,0 +1,13 @@
+//go:build !kubernetes
+
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+func (k *kexecCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	_, _ = fmt.Fprintln(errOut, "kexec: gocli was built without Kubernetes support, rebuild it with -tags kubernetes")
+	return 1, false
+}
@@ -0,0 +1,112 @@
+//go:build kubernetes
+
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// Execute streams the input (unless it is a terminal) and the output of the
+// command like kubectl exec does. Ctrl-C stops the command instead of the shell.
+func (k *kexecCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	config, namespace, err := k.clientConfig(env)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "kexec: %v\n", err)
+		return 1, false
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "kexec: %v\n", err)
+		return 1, false
+	}
+
+	var stdin io.Reader
+	if _, isTerminal := terminalWidth(in); in != nil && !isTerminal {
+		stdin = in
+	}
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(k.pod).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: k.container,
+			Command:   k.args,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := newPodExecutor(config, req)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "kexec: %v\n", err)
+		return 1, false
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: stdin, Stdout: out, Stderr: errOut})
+	var exitErr utilexec.ExitError
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		return exitErr.ExitStatus(), false
+	case ctx.Err() != nil:
+		return 130, false
+	}
+	_, _ = fmt.Fprintf(errOut, "kexec: %v\n", err)
+	return 1, false
+}
+
+// clientConfig loads the kubeconfig like kubectl, but takes KUBECONFIG from
+// the shell environment, and returns the namespace to use.
+func (k *kexecCommand) clientConfig(env Env) (*rest.Config, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if paths, ok := env.Get("KUBECONFIG"); ok && paths != "" {
+		rules.Precedence = filepath.SplitList(paths)
+	}
+	rules.ExplicitPath = k.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: k.context}
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	namespace := k.namespace
+	if namespace == "" {
+		if namespace, _, err = loader.Namespace(); err != nil {
+			return nil, "", err
+		}
+	}
+	return config, namespace, nil
+}
+
+// newPodExecutor prefers the WebSocket protocol and falls back to SPDY for
+// API servers that do not support it, as kubectl does.
+func newPodExecutor(config *rest.Config, req *rest.Request) (remotecommand.Executor, error) {
+	spdy, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return nil, err
+	}
+	websocket, err := remotecommand.NewWebSocketExecutor(config, "GET", req.URL().String())
+	if err != nil {
+		return nil, err
+	}
+	return remotecommand.NewFallbackExecutor(websocket, spdy, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+}
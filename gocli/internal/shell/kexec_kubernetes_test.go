@@ -0,0 +1,46 @@
+//go:build kubernetes
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+contexts:
+- name: dev
+  context: {cluster: dev, namespace: team-a, user: dev}
+- name: prod
+  context: {cluster: prod, user: dev}
+clusters:
+- name: dev
+  cluster: {server: "https://dev.example:6443"}
+- name: prod
+  cluster: {server: "https://prod.example:6443"}
+users:
+- name: dev
+  user: {token: secret}
+`
+
+func TestKexecCommand_ClientConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte(testKubeconfig), 0600))
+	env := NewEnvFromMap(map[string]string{"KUBECONFIG": path})
+
+	config, namespace, err := (&kexecCommand{}).clientConfig(env)
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example:6443", config.Host)
+	assert.Equal(t, "team-a", namespace)
+
+	config, namespace, err = (&kexecCommand{context: "prod", namespace: "web"}).clientConfig(env)
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example:6443", config.Host)
+	assert.Equal(t, "web", namespace)
+}
@@ -0,0 +1,31 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKexecCommand(t *testing.T) {
+	cmd, err := parseKexecCommand(CommandDescription{
+		name:      KexecCommand,
+		arguments: []string{"kexec", "-n", "prod", "api-0", "-c", "app", "ls", "-l", "/srv"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &kexecCommand{pod: "api-0", container: "app", namespace: "prod", args: []string{"ls", "-l", "/srv"}}, cmd)
+
+	cmd, err = parseKexecCommand(CommandDescription{
+		name:      KexecCommand,
+		arguments: []string{"kexec", "--context", "staging", "api-0", "--", "-dash"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &kexecCommand{pod: "api-0", context: "staging", args: []string{"-dash"}}, cmd)
+}
+
+func TestParseKexecCommand_Usage(t *testing.T) {
+	for _, args := range [][]string{{"kexec"}, {"kexec", "api-0"}, {"kexec", "api-0", "-c", "app"}, {"kexec", "-x", "api-0", "ls"}} {
+		_, err := parseKexecCommand(CommandDescription{name: KexecCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,363 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the kind of a lexical token of a command line.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenNewline
+	// tokenSemicolon is ";".
+	tokenSemicolon
+	// tokenAnd is "&&".
+	tokenAnd
+	// tokenOr is "||".
+	tokenOr
+	// tokenPipe is "|".
+	tokenPipe
+	// tokenRedirect is one of redirectOperators.
+	tokenRedirect
+	// tokenCapture is "|>" or "|>>".
+	tokenCapture
+)
+
+// redirectOperators are the supported redirection operators, longest first,
+// so that the lexer picks the longest one that matches.
+var redirectOperators = []string{"2>&1", "2>>", "<<<", "<<-", "2>", "<<", "<", ">>", ">"}
+
+// Pos is a position in the input. Lines and columns start at 1,
+// columns are counted in characters.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// token is a word or an operator of a command line.
+type token struct {
+	kind tokenKind
+	pos  Pos
+	// text is the operator itself or the word with its quotes and
+	// backslash escapes removed. Command substitutions are kept verbatim,
+	// backticks are rewritten into the $(...) form. An escaped "$" is kept
+	// as escapedDollar, so that expansion leaves it alone.
+	text string
+	// singleQuoted and doubleQuoted tell that the word starts with a quoted part.
+	singleQuoted bool
+	doubleQuoted bool
+	// quoted tells that the word contains quotes; plainPrefix is the length
+	// of the text before the first of them.
+	quoted      bool
+	plainPrefix int
+	// group marks a "(...)" subshell group, which is kept verbatim,
+	// parentheses included.
+	group bool
+	// alias is set on the tokens that come from expanding aliases.
+	alias *aliasScope
+}
+
+// describe returns the token as it is quoted in syntax error messages.
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF, tokenNewline:
+		return "newline"
+	case tokenWord:
+		if t.group {
+			return "("
+		}
+		return t.text
+	default:
+		return t.text
+	}
+}
+
+// lexer splits a command line into tokens. It drops blanks, comments and
+// backslash-newline continuations, and reads the bodies of here-documents
+// after the newline that ends the line they were requested on.
+type lexer struct {
+	src string
+	off int
+	// base is the position of src[0], for command lines nested in others.
+	base Pos
+	// pending are the here-documents whose bodies start after the next newline.
+	pending []*hereDoc
+}
+
+func newLexer(src string, base Pos) *lexer {
+	return &lexer{src: src, base: base}
+}
+
+// posAt returns the position of src[off].
+func (l *lexer) posAt(off int) Pos {
+	lineStart := strings.LastIndexByte(l.src[:off], '\n') + 1
+	pos := Pos{
+		Line:   l.base.Line + strings.Count(l.src[:off], "\n"),
+		Column: utf8.RuneCountInString(l.src[lineStart:off]) + 1,
+	}
+	if lineStart == 0 {
+		pos.Column += l.base.Column - 1
+	}
+	return pos
+}
+
+// incomplete returns the error for input that ends at off before what
+// started there is finished.
+func (l *lexer) incomplete(off int, what string) error {
+	return &SyntaxError{Pos: l.posAt(off), Msg: "unexpected end of input: " + what, incomplete: true}
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	if err := l.skipBlanks(); err != nil {
+		return token{}, err
+	}
+	start := l.off
+	pos := l.posAt(start)
+	if start == len(l.src) {
+		if len(l.pending) > 0 {
+			return token{}, l.incomplete(start, "here-document delimited by "+l.pending[0].delimiter)
+		}
+		return token{kind: tokenEOF, pos: pos}, nil
+	}
+
+	rest := l.src[start:]
+	operator := func(kind tokenKind, text string) (token, error) {
+		l.off += len(text)
+		return token{kind: kind, pos: pos, text: text}, nil
+	}
+	switch {
+	case rest[0] == '\n':
+		l.off++
+		if err := l.readHereDocs(); err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenNewline, pos: pos, text: "\n"}, nil
+	case rest[0] == ';':
+		return operator(tokenSemicolon, ";")
+	case strings.HasPrefix(rest, "&&"):
+		return operator(tokenAnd, "&&")
+	case strings.HasPrefix(rest, "||"):
+		return operator(tokenOr, "||")
+	case strings.HasPrefix(rest, "|>>"):
+		return operator(tokenCapture, "|>>")
+	case strings.HasPrefix(rest, "|>"):
+		return operator(tokenCapture, "|>")
+	case rest[0] == '|':
+		return operator(tokenPipe, "|")
+	case rest[0] == ')':
+		return token{}, &SyntaxError{Pos: pos, Msg: "syntax error near unexpected token `)'"}
+	}
+	for _, op := range redirectOperators {
+		if strings.HasPrefix(rest, op) {
+			return operator(tokenRedirect, op)
+		}
+	}
+	return l.word()
+}
+
+// skipBlanks skips blanks, continuations and a comment up to the end of the line.
+func (l *lexer) skipBlanks() error {
+	for l.off < len(l.src) {
+		switch c := l.src[l.off]; {
+		case c == ' ' || c == '\t':
+			l.off++
+		case c == '\\' && l.off+1 == len(l.src):
+			return l.incomplete(l.off, "line continuation")
+		case strings.HasPrefix(l.src[l.off:], "\\\n"):
+			l.off += 2
+		case c == '#':
+			end := strings.IndexByte(l.src[l.off:], '\n')
+			if end < 0 {
+				l.off = len(l.src)
+			} else {
+				l.off += end
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// isWordEnd reports whether an unquoted c ends a word.
+func isWordEnd(s string, i int) bool {
+	switch s[i] {
+	case ' ', '\t', '\n', ';', '|', '<', '>', ')':
+		return true
+	case '&':
+		return strings.HasPrefix(s[i:], "&&")
+	}
+	return false
+}
+
+// word reads a word starting at the current offset.
+func (l *lexer) word() (token, error) {
+	start := l.off
+	tok := token{kind: tokenWord, pos: l.posAt(start), plainPrefix: -1}
+	var sb strings.Builder
+	markQuote := func() {
+		if !tok.quoted {
+			tok.quoted = true
+			tok.plainPrefix = sb.Len()
+		}
+	}
+
+	if l.src[l.off] == '(' {
+		end := parenEnd(l.src, l.off)
+		if end < 0 {
+			return token{}, l.incomplete(start, "unclosed (")
+		}
+		l.off = end + 1
+		tok.text, tok.group = l.src[start:l.off], true
+		return tok, nil
+	}
+
+	for l.off < len(l.src) && !isWordEnd(l.src, l.off) {
+		c := l.src[l.off]
+		switch {
+		case c == '\\' && l.off+1 == len(l.src):
+			return token{}, l.incomplete(l.off, "line continuation")
+		case strings.HasPrefix(l.src[l.off:], "\\\n"):
+			l.off += 2
+		case c == '\\':
+			// An escaped character is quoted: it is taken as it is.
+			markQuote()
+			l.escaped(&sb, l.off+1)
+		case c == '$' || c == '`':
+			found, err := l.substitution(&sb)
+			if err != nil {
+				return token{}, err
+			}
+			if !found {
+				sb.WriteByte(c)
+				l.off++
+			}
+		case c == '\'':
+			if sb.Len() == 0 {
+				tok.singleQuoted = true
+			}
+			markQuote()
+			end := strings.IndexByte(l.src[l.off+1:], '\'')
+			if end < 0 {
+				return token{}, l.incomplete(l.off, "unclosed quote")
+			}
+			sb.WriteString(l.src[l.off+1 : l.off+1+end])
+			l.off += end + 2
+		case c == '"':
+			if sb.Len() == 0 {
+				tok.doubleQuoted = true
+			}
+			markQuote()
+			if err := l.doubleQuoted(&sb); err != nil {
+				return token{}, err
+			}
+		default:
+			sb.WriteByte(c)
+			l.off++
+		}
+	}
+
+	tok.text = sb.String()
+	if !tok.quoted {
+		tok.plainPrefix = len(tok.text)
+	}
+	return tok, nil
+}
+
+// doubleQuoted reads a double-quoted part of a word that starts at the
+// current offset into sb, without the quotes.
+func (l *lexer) doubleQuoted(sb *strings.Builder) error {
+	open := l.off
+	l.off++
+	for l.off < len(l.src) {
+		c := l.src[l.off]
+		switch {
+		case c == '"':
+			l.off++
+			return nil
+		case c == '\\' && l.off+1 == len(l.src):
+			return l.incomplete(l.off, "line continuation")
+		case strings.HasPrefix(l.src[l.off:], "\\\n"):
+			l.off += 2
+		case c == '\\' && strings.ContainsRune("\"\\$`", rune(l.src[l.off+1])):
+			// Elsewhere in double quotes a backslash is an ordinary character.
+			l.escaped(sb, l.off+1)
+		case c == '$' || c == '`':
+			found, err := l.substitution(sb)
+			if err != nil {
+				return err
+			}
+			if !found {
+				sb.WriteByte(c)
+				l.off++
+			}
+		default:
+			sb.WriteByte(c)
+			l.off++
+		}
+	}
+	return l.incomplete(open, "unclosed quote")
+}
+
+// escaped copies the character at off, which follows a backslash, into sb
+// as a literal one and moves past it.
+func (l *lexer) escaped(sb *strings.Builder, off int) {
+	r, size := utf8.DecodeRuneInString(l.src[off:])
+	if r == '$' {
+		sb.WriteString(escapedDollar)
+	} else {
+		sb.WriteString(l.src[off : off+size])
+	}
+	l.off = off + size
+}
+
+// substitution copies a "$(...)" or backtick substitution starting at the
+// current offset into sb and reports whether there is one.
+func (l *lexer) substitution(sb *strings.Builder) (bool, error) {
+	var end int
+	switch {
+	case isSubstitutionStart(l.src, l.off):
+		end = substitutionEnd(l.src, l.off)
+	case l.src[l.off] == '`':
+		end = backtickEnd(l.src, l.off)
+	default:
+		return false, nil
+	}
+	if end < 0 {
+		return true, l.incomplete(l.off, "unclosed command substitution")
+	}
+
+	if l.src[l.off] == '`' {
+		sb.WriteString("$(" + l.src[l.off+1:end] + ")")
+	} else {
+		sb.WriteString(l.src[l.off : end+1])
+	}
+	l.off = end + 1
+	return true, nil
+}
+
+// readHereDocs reads the bodies of the pending here-documents from the lines
+// following the current offset.
+func (l *lexer) readHereDocs() error {
+	for _, doc := range l.pending {
+		lines := strings.Split(l.src[l.off:], "\n")
+		consumed, ok := doc.read(lines)
+		if !ok {
+			return l.incomplete(len(l.src), "here-document delimited by "+doc.delimiter)
+		}
+		for _, line := range lines[:consumed] {
+			l.off = min(l.off+len(line)+1, len(l.src))
+		}
+	}
+	l.pending = nil
+	return nil
+}
@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lexAll(src string) ([]token, error) {
+	l := newLexer(src, Pos{Line: 1, Column: 1})
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil || tok.kind == tokenEOF {
+			return tokens, err
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func TestLexer_Operators(t *testing.T) {
+	tokens, err := lexAll("a>out 2>&1|b&&c||d;e<in 2>>log x&y|>V|>>W>>f")
+	require.NoError(t, err)
+
+	var kinds []tokenKind
+	var texts []string
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.kind)
+		texts = append(texts, tok.text)
+	}
+	assert.Equal(t, []string{"a", ">", "out", "2>&1", "|", "b", "&&", "c", "||", "d", ";", "e", "<", "in", "2>>", "log", "x&y", "|>", "V", "|>>", "W", ">>", "f"}, texts)
+	assert.Equal(t, []tokenKind{
+		tokenWord, tokenRedirect, tokenWord, tokenRedirect, tokenPipe, tokenWord, tokenAnd, tokenWord,
+		tokenOr, tokenWord, tokenSemicolon, tokenWord, tokenRedirect, tokenWord, tokenRedirect, tokenWord, tokenWord,
+		tokenCapture, tokenWord, tokenCapture, tokenWord, tokenRedirect, tokenWord,
+	}, kinds)
+}
+
+func TestLexer_Quotes(t *testing.T) {
+	tokens, err := lexAll(`'a | b' "c;
+d" e"f"g X='y z' $(h "|" i) ` + "`j`")
+	require.NoError(t, err)
+	require.Len(t, tokens, 6)
+
+	assert.Equal(t, "a | b", tokens[0].text)
+	assert.True(t, tokens[0].singleQuoted)
+	assert.Equal(t, "c;\nd", tokens[1].text)
+	assert.True(t, tokens[1].doubleQuoted)
+	assert.Equal(t, "efg", tokens[2].text)
+	assert.False(t, tokens[2].doubleQuoted)
+	assert.True(t, tokens[2].quoted)
+	assert.Equal(t, 1, tokens[2].plainPrefix)
+	assert.Equal(t, "X=y z", tokens[3].text)
+	assert.Equal(t, 2, tokens[3].plainPrefix)
+	assert.Equal(t, `$(h "|" i)`, tokens[4].text)
+	assert.False(t, tokens[4].quoted)
+	assert.Equal(t, "$(j)", tokens[5].text)
+}
+
+func TestLexer_Escapes(t *testing.T) {
+	for _, tc := range []struct {
+		src, text string
+	}{
+		{`a\ b`, "a b"},
+		{`\;\|\>\&\(`, ";|>&("},
+		{`\'x\'`, "'x'"},
+		{`\"x`, `"x`},
+		{`\\n`, `\n`},
+		{`\$HOME`, escapedDollar + "HOME"},
+		{"\\`date\\`", "`date`"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"a\\b"`, `a\b`},
+		{`"\$HOME"`, escapedDollar + "HOME"},
+		{"\"\\`date\\`\"", "`date`"},
+		{`"a\nb"`, `a\nb`},
+		{`\é`, "é"},
+	} {
+		tokens, err := lexAll(tc.src)
+		require.NoError(t, err, tc.src)
+		require.Len(t, tokens, 1, tc.src)
+		assert.Equal(t, tc.text, tokens[0].text, tc.src)
+		assert.True(t, tokens[0].quoted, tc.src)
+	}
+}
+
+func TestLexer_Positions(t *testing.T) {
+	tokens, err := lexAll("echo \"пр\" x\n  ls \\\n -l")
+	require.NoError(t, err)
+	require.Len(t, tokens, 6)
+	assert.Equal(t, Pos{Line: 1, Column: 6}, tokens[1].pos)
+	assert.Equal(t, Pos{Line: 1, Column: 11}, tokens[2].pos)
+	assert.Equal(t, Pos{Line: 1, Column: 12}, tokens[3].pos)
+	assert.Equal(t, Pos{Line: 2, Column: 3}, tokens[4].pos)
+	assert.Equal(t, Pos{Line: 3, Column: 2}, tokens[5].pos)
+}
+
+func TestLexer_Incomplete(t *testing.T) {
+	for _, src := range []string{`echo "open`, "echo 'open", "echo $(open", "echo `open", "(open", "echo \\"} {
+		_, err := lexAll(src)
+		assert.ErrorIs(t, err, ErrIncompleteInput, src)
+	}
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// LogLevelVar names the environment variable with the lowest level of log
+// records to write: debug, info, warn or error.
+const LogLevelVar = "GOCLI_LOG_LEVEL"
+
+// discardLogger is used by shells and runners that were not given a logger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// NewLogger returns a logger that writes records of the given level and above to w
+// as key=value pairs. An empty level means info.
+func NewLogger(w io.Writer, level string) (*slog.Logger, error) {
+	var min slog.Level
+	if level != "" {
+		if err := min.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: min})), nil
+}
+
+// WithLogger makes the shell log session, parser and pipeline events to l.
+// Without it nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Shell) {
+		s.logger = l
+	}
+}
+
+// log returns the logger of the runner. Runners made for substitutions,
+// loops and subshells may have none.
+func (p *pipelineRunner) log() *slog.Logger {
+	if p.logger == nil {
+		return discardLogger
+	}
+	return p.logger
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_Levels(t *testing.T) {
+	var sb strings.Builder
+	logger, err := NewLogger(&sb, "warn")
+	require.NoError(t, err)
+	logger.Info("hidden")
+	logger.Warn("shown", "key", "value")
+	assert.NotContains(t, sb.String(), "hidden")
+	assert.Contains(t, sb.String(), "level=WARN msg=shown key=value")
+
+	_, err = NewLogger(&sb, "loud")
+	assert.Error(t, err)
+}
+
+func TestShell_Run_Logging(t *testing.T) {
+	dir := t.TempDir()
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("echo hi | cat\ncat < missing\necho |\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	logger, err := NewLogger(&sb, "debug")
+	require.NoError(t, err)
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout), WithStderr(stderr), WithLogger(logger))
+	sh.Run()
+
+	log := sb.String()
+	assert.Contains(t, log, "msg=\"session started\"")
+	assert.Contains(t, log, "msg=\"pipeline started\" commands=2")
+	assert.Contains(t, log, "msg=\"command finished\" args=\"[echo hi]\" status=0")
+	assert.Contains(t, log, "msg=\"cannot open input\" path=missing")
+	assert.Contains(t, log, "msg=\"parse error\"")
+	assert.Contains(t, log, "msg=\"session finished\" status=2")
+}
@@ -0,0 +1,344 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// columnSpacing is the number of blanks between columns in multi-column output.
+const columnSpacing = 2
+
+type lsCommand struct {
+	paths      []string
+	sortByTime bool
+	sortBySize bool
+	reverse    bool
+	recursive  bool
+	all        bool
+	long       bool
+	onePerLine bool
+	// directory lists directories themselves rather than their contents.
+	directory bool
+	// records makes ls write a record per file instead of names.
+	records bool
+	fsys    FileSystem
+}
+
+func parseLsCommand(d CommandDescription, fsys FileSystem) (Command, error) {
+	fs := newFlagSet("ls")
+	sortByTime := fs.Bool("t", false, "sort by modification time, newest first")
+	sortBySize := fs.Bool("S", false, "sort by file size, largest first")
+	reverse := fs.Bool("r", false, "reverse order while sorting")
+	recursive := fs.Bool("R", false, "list subdirectories recursively")
+	all := fs.Bool("a", false, "do not ignore entries starting with .")
+	long := fs.Bool("l", false, "use a long listing format")
+	onePerLine := fs.Bool("1", false, "list one file per line")
+	directory := fs.Bool("d", false, "list directories themselves, not their contents")
+	records := fs.Bool("records", false, "write a JSON record per file")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("ls: %w", err)
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	return &lsCommand{
+		paths:      paths,
+		sortByTime: *sortByTime,
+		sortBySize: *sortBySize,
+		reverse:    *reverse,
+		recursive:  *recursive,
+		all:        *all,
+		long:       *long,
+		onePerLine: *onePerLine,
+		directory:  *directory,
+		records:    *records,
+		fsys:       fsys,
+	}, nil
+}
+
+type lsEntry struct {
+	name string
+	path string
+	info fs.FileInfo
+}
+
+func (l *lsCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	width, isTerminal := terminalWidth(out)
+
+	var files []lsEntry
+	var dirs []string
+	for _, path := range l.paths {
+		info, err := l.fsys.Stat(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "ls: cannot access '%s': %v\n", path, err)
+			retCode = 2
+			continue
+		}
+		if info.IsDir() && !l.directory {
+			dirs = append(dirs, path)
+		} else {
+			files = append(files, lsEntry{name: path, path: path, info: info})
+		}
+	}
+
+	l.sortEntries(files)
+	l.printEntries(out, files, width, isTerminal)
+
+	// Records carry their paths, so they need no headers to tell directories apart.
+	withHeaders := (l.recursive || len(l.paths) > 1) && !l.records
+	for i, dir := range dirs {
+		if (i > 0 || len(files) > 0) && !l.records {
+			_, _ = fmt.Fprintln(out)
+		}
+		if code := l.listDir(out, errOut, dir, withHeaders, width, isTerminal); code != 0 {
+			retCode = code
+		}
+	}
+
+	return retCode, false
+}
+
+func (l *lsCommand) listDir(out, errOut *os.File, dir string, withHeader bool, width int, isTerminal bool) int {
+	dirEntries, err := l.fsys.ReadDir(dir)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "ls: cannot open directory '%s': %v\n", dir, err)
+		return 2
+	}
+
+	entries := make([]lsEntry, 0, len(dirEntries)+2)
+	if l.all {
+		for _, name := range []string{".", ".."} {
+			if info, err := l.fsys.Stat(filepath.Join(dir, name)); err == nil {
+				entries = append(entries, lsEntry{name: name, path: filepath.Join(dir, name), info: info})
+			}
+		}
+	}
+	for _, dirEntry := range dirEntries {
+		if !l.all && strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, lsEntry{name: dirEntry.Name(), path: filepath.Join(dir, dirEntry.Name()), info: info})
+	}
+	l.sortEntries(entries)
+
+	if withHeader && !l.records {
+		_, _ = fmt.Fprintf(out, "%s:\n", dir)
+	}
+	l.printEntries(out, entries, width, isTerminal)
+
+	if !l.recursive {
+		return 0
+	}
+
+	retCode := 0
+	for _, entry := range entries {
+		if !entry.info.IsDir() || entry.name == "." || entry.name == ".." {
+			continue
+		}
+		if !l.records {
+			_, _ = fmt.Fprintln(out)
+		}
+		if code := l.listDir(out, errOut, filepath.Join(dir, entry.name), true, width, isTerminal); code != 0 {
+			retCode = code
+		}
+	}
+	return retCode
+}
+
+func (l *lsCommand) sortEntries(entries []lsEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if l.reverse {
+			a, b = b, a
+		}
+		switch {
+		case l.sortBySize && a.info.Size() != b.info.Size():
+			return a.info.Size() > b.info.Size()
+		case l.sortByTime && !a.info.ModTime().Equal(b.info.ModTime()):
+			return a.info.ModTime().After(b.info.ModTime())
+		}
+		return a.name < b.name
+	})
+}
+
+func (l *lsCommand) printEntries(out *os.File, entries []lsEntry, width int, isTerminal bool) {
+	if len(entries) == 0 {
+		return
+	}
+	if l.records {
+		w := bufio.NewWriter(out)
+		for _, entry := range entries {
+			names, values := fileRecord(entry.name, entry.path, entry.info)
+			_ = writeRecord(w, names, values)
+		}
+		_ = w.Flush()
+		return
+	}
+	if l.long {
+		_, _ = fmt.Fprint(out, formatLong(l.fsys, entries, time.Now()))
+		return
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.name
+	}
+
+	if !isTerminal || l.onePerLine {
+		for _, name := range names {
+			_, _ = fmt.Fprintln(out, name)
+		}
+		return
+	}
+	_, _ = fmt.Fprint(out, formatColumns(names, width))
+}
+
+// formatLong prints one entry per line with its mode, number of links, owner,
+// group, size and modification time. Times older than half a year, or in the
+// future, show the year instead of the time of day. Symbolic links are
+// resolved on fsys to show their targets.
+func formatLong(fsys FileSystem, entries []lsEntry, now time.Time) string {
+	type column struct {
+		links, owner, group, size string
+	}
+	columns := make([]column, len(entries))
+	var linksWidth, ownerWidth, groupWidth, sizeWidth int
+	names := newOwnerNames()
+	for i, entry := range entries {
+		col := column{links: "1", owner: "-", group: "-", size: strconv.FormatInt(entry.info.Size(), 10)}
+		if links, uid, gid, ok := fileOwnership(entry.info); ok {
+			col.links = strconv.FormatUint(links, 10)
+			col.owner, col.group = names.user(uid), names.group(gid)
+		}
+		columns[i] = col
+		linksWidth = max(linksWidth, len(col.links))
+		ownerWidth = max(ownerWidth, len(col.owner))
+		groupWidth = max(groupWidth, len(col.group))
+		sizeWidth = max(sizeWidth, len(col.size))
+	}
+
+	var sb strings.Builder
+	for i, entry := range entries {
+		modTime := entry.info.ModTime()
+		stamp := modTime.Format("Jan _2 15:04")
+		if modTime.Before(now.AddDate(0, -6, 0)) || modTime.After(now) {
+			stamp = modTime.Format("Jan _2  2006")
+		}
+		name := entry.name
+		if entry.info.Mode()&fs.ModeSymlink != 0 {
+			if target, err := fsys.Readlink(entry.path); err == nil {
+				name += " -> " + target
+			}
+		}
+		col := columns[i]
+		_, _ = fmt.Fprintf(&sb, "%s %*s %-*s %-*s %*s %s %s\n", entry.info.Mode(),
+			linksWidth, col.links, ownerWidth, col.owner, groupWidth, col.group, sizeWidth, col.size, stamp, name)
+	}
+	return sb.String()
+}
+
+// ownerNames looks up the names of users and groups once per listing, and
+// falls back to the numeric IDs, like ls does for unknown ones.
+type ownerNames struct {
+	users, groups map[uint32]string
+}
+
+func newOwnerNames() *ownerNames {
+	return &ownerNames{users: make(map[uint32]string), groups: make(map[uint32]string)}
+}
+
+func (o *ownerNames) user(uid uint32) string {
+	name, ok := o.users[uid]
+	if !ok {
+		name = strconv.FormatUint(uint64(uid), 10)
+		if u, err := user.LookupId(name); err == nil {
+			name = u.Username
+		}
+		o.users[uid] = name
+	}
+	return name
+}
+
+func (o *ownerNames) group(gid uint32) string {
+	name, ok := o.groups[gid]
+	if !ok {
+		name = strconv.FormatUint(uint64(gid), 10)
+		if g, err := user.LookupGroupId(name); err == nil {
+			name = g.Name
+		}
+		o.groups[gid] = name
+	}
+	return name
+}
+
+// formatColumns lays names out in as many columns as fit into width,
+// filling columns top to bottom like ls does on a terminal.
+func formatColumns(names []string, width int) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	rows, colWidths := len(names), []int{maxNameWidth(names)}
+	for cols := len(names); cols > 1; cols-- {
+		candidateRows := (len(names) + cols - 1) / cols
+		widths := columnWidths(names, candidateRows)
+		total := 0
+		for _, w := range widths {
+			total += w + columnSpacing
+		}
+		if total-columnSpacing <= width {
+			rows, colWidths = candidateRows, widths
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for row := 0; row < rows; row++ {
+		for col := range colWidths {
+			idx := col*rows + row
+			if idx >= len(names) {
+				break
+			}
+			sb.WriteString(names[idx])
+			isLast := col == len(colWidths)-1 || idx+rows >= len(names)
+			if !isLast {
+				sb.WriteString(strings.Repeat(" ", colWidths[col]-displayWidth(names[idx])+columnSpacing))
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func columnWidths(names []string, rows int) []int {
+	var widths []int
+	for start := 0; start < len(names); start += rows {
+		end := min(start+rows, len(names))
+		widths = append(widths, maxNameWidth(names[start:end]))
+	}
+	return widths
+}
+
+func maxNameWidth(names []string) int {
+	widest := 0
+	for _, name := range names {
+		widest = max(widest, displayWidth(name))
+	}
+	return widest
+}
@@ -0,0 +1,137 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runLs(t *testing.T, args ...string) string {
+	cmd, err := parseLsCommand(CommandDescription{
+		name:      LsCommand,
+		arguments: append([]string{"ls"}, args...),
+	}, OSFileSystem)
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func makeLsFixture(t *testing.T) string {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"b.txt", 30, 2 * time.Hour},
+		{"a.txt", 10, 1 * time.Hour},
+		{"c.txt", 20, 3 * time.Hour},
+		{".hidden", 1, 0},
+	}
+	for _, f := range files {
+		path := filepath.Join(tmpDir, f.name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", f.size)), 0644))
+		require.NoError(t, os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)))
+	}
+	return tmpDir
+}
+
+func TestLsCommand_Execute_DefaultSort(t *testing.T) {
+	dir := makeLsFixture(t)
+	assert.Equal(t, "a.txt\nb.txt\nc.txt\n", runLs(t, dir))
+}
+
+func TestLsCommand_Execute_SortFlags(t *testing.T) {
+	dir := makeLsFixture(t)
+	assert.Equal(t, "b.txt\nc.txt\na.txt\n", runLs(t, "-S", dir))
+	assert.Equal(t, "a.txt\nb.txt\nc.txt\n", runLs(t, "-t", dir))
+	assert.Equal(t, "c.txt\nb.txt\na.txt\n", runLs(t, "-t", "-r", dir))
+}
+
+func TestLsCommand_Execute_Recursive(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "inner"), nil, 0644))
+
+	expected := dir + ":\nfile\nsub\n\n" + filepath.Join(dir, "sub") + ":\ninner\n"
+	assert.Equal(t, expected, runLs(t, "-R", dir))
+}
+
+func TestLsCommand_Execute_All(t *testing.T) {
+	dir := makeLsFixture(t)
+	assert.Equal(t, ".\n..\n.hidden\na.txt\nb.txt\nc.txt\n", runLs(t, "-a", dir))
+	assert.Equal(t, "a.txt\nb.txt\nc.txt\n", runLs(t, "-1", dir))
+}
+
+func TestLsCommand_Execute_Long(t *testing.T) {
+	dir := makeLsFixture(t)
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(dir, "link")))
+
+	lines := strings.Split(strings.TrimSuffix(runLs(t, "-l", dir), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^-rw-r--r-- 1 \S+ +\S+ +10 \w{3} [ \d]\d \d\d:\d\d a\.txt$`, lines[0])
+	assert.Regexp(t, `^-rw-r--r-- 1 \S+ +\S+ +30 .* b\.txt$`, lines[1])
+	assert.Regexp(t, `^L\S+ 1 \S+ +\S+ +5 .* link -> a\.txt$`, lines[3])
+}
+
+func TestLsCommand_Execute_BundledFlags(t *testing.T) {
+	dir := makeLsFixture(t)
+
+	lines := strings.Split(strings.TrimSuffix(runLs(t, "-la", dir), "\n"), "\n")
+	require.Len(t, lines, 6)
+	assert.Regexp(t, `^d\S+ +\d+ .* \.$`, lines[0])
+	assert.Regexp(t, ` \.hidden$`, lines[2])
+	assert.Equal(t, dir+"\n", runLs(t, "-d", dir))
+	assert.Equal(t, "c.txt\nb.txt\na.txt\n", runLs(t, "-1r", dir))
+}
+
+func TestFormatLong(t *testing.T) {
+	dir := makeLsFixture(t)
+	info, err := os.Stat(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	entries := []lsEntry{{name: "a.txt", info: info}}
+
+	owner := "- -"
+	if _, uid, gid, ok := fileOwnership(info); ok {
+		names := newOwnerNames()
+		owner = names.user(uid) + " " + names.group(gid)
+	}
+
+	recent := info.ModTime().Add(time.Hour)
+	assert.Equal(t, "-rw-r--r-- 1 "+owner+" 10 "+info.ModTime().Format("Jan _2 15:04")+" a.txt\n", formatLong(OSFileSystem, entries, recent))
+	old := info.ModTime().AddDate(1, 0, 0)
+	assert.Equal(t, "-rw-r--r-- 1 "+owner+" 10 "+info.ModTime().Format("Jan _2  2006")+" a.txt\n", formatLong(OSFileSystem, entries, old))
+}
+
+func TestLsCommand_Execute_NonexistentPath(t *testing.T) {
+	cmd := &lsCommand{paths: []string{"/nonexistent/dir"}, fsys: OSFileSystem}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 2, retCode)
+	assert.False(t, exited)
+}
+
+func TestFormatColumns(t *testing.T) {
+	names := []string{"one", "two", "three", "four", "five"}
+
+	assert.Equal(t, "one  two  three  four  five\n", formatColumns(names, 80))
+	assert.Equal(t, "one    four\ntwo    five\nthree\n", formatColumns(names, 12))
+	assert.Equal(t, "one\ntwo\nthree\nfour\nfive\n", formatColumns(names, 3))
+}
@@ -0,0 +1,134 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// pipelineDurationBuckets are the upper bounds, in seconds, of the pipeline latency histogram.
+var pipelineDurationBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60}
+
+// shellMetrics counts what the session runs, for the Prometheus endpoint.
+// All methods accept a nil receiver, which records nothing.
+type shellMetrics struct {
+	mu       sync.Mutex
+	commands int64
+	failures map[int]int64
+	active   int64
+	// buckets[i] counts the pipelines that took at most pipelineDurationBuckets[i].
+	buckets     []int64
+	durationSum float64
+	pipelines   int64
+}
+
+func newShellMetrics() *shellMetrics {
+	return &shellMetrics{
+		failures: make(map[int]int64),
+		buckets:  make([]int64, len(pipelineDurationBuckets)),
+	}
+}
+
+// subscribe makes the metrics count the commands and pipelines published on bus.
+func (m *shellMetrics) subscribe(bus *eventBus) {
+	bus.subscribe(EventCommandStarted, func(Event) { m.commandStarted() })
+	bus.subscribe(EventCommandFinished, func(e Event) { m.commandFinished(e.Status) })
+	bus.subscribe(EventPipelineFinished, func(e Event) { m.pipelineFinished(e.Duration) })
+}
+
+// commandStarted records a command that starts running.
+func (m *shellMetrics) commandStarted() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands++
+	m.active++
+}
+
+// commandFinished records a command that finished with code.
+func (m *shellMetrics) commandFinished(code int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active--
+	if code != 0 {
+		m.failures[code]++
+	}
+}
+
+// pipelineFinished records a pipeline that took d.
+func (m *shellMetrics) pipelineFinished(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seconds := d.Seconds()
+	for i, bound := range pipelineDurationBuckets {
+		if seconds <= bound {
+			m.buckets[i]++
+		}
+	}
+	m.durationSum += seconds
+	m.pipelines++
+}
+
+// write prints the metrics in the Prometheus text exposition format.
+func (m *shellMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _ = fmt.Fprintln(w, "# HELP gocli_commands_total Commands started by the shell.")
+	_, _ = fmt.Fprintln(w, "# TYPE gocli_commands_total counter")
+	_, _ = fmt.Fprintf(w, "gocli_commands_total %d\n", m.commands)
+
+	_, _ = fmt.Fprintln(w, "# HELP gocli_command_failures_total Commands that finished with a non-zero status, by status.")
+	_, _ = fmt.Fprintln(w, "# TYPE gocli_command_failures_total counter")
+	codes := make([]int, 0, len(m.failures))
+	for code := range m.failures {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		_, _ = fmt.Fprintf(w, "gocli_command_failures_total{code=\"%d\"} %d\n", code, m.failures[code])
+	}
+
+	_, _ = fmt.Fprintln(w, "# HELP gocli_active_commands Commands running right now.")
+	_, _ = fmt.Fprintln(w, "# TYPE gocli_active_commands gauge")
+	_, _ = fmt.Fprintf(w, "gocli_active_commands %d\n", m.active)
+
+	_, _ = fmt.Fprintln(w, "# HELP gocli_pipeline_duration_seconds Time it took to run a pipeline.")
+	_, _ = fmt.Fprintln(w, "# TYPE gocli_pipeline_duration_seconds histogram")
+	for i, bound := range pipelineDurationBuckets {
+		_, _ = fmt.Fprintf(w, "gocli_pipeline_duration_seconds_bucket{le=\"%g\"} %d\n", bound, m.buckets[i])
+	}
+	_, _ = fmt.Fprintf(w, "gocli_pipeline_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.pipelines)
+	_, _ = fmt.Fprintf(w, "gocli_pipeline_duration_seconds_sum %g\n", m.durationSum)
+	_, _ = fmt.Fprintf(w, "gocli_pipeline_duration_seconds_count %d\n", m.pipelines)
+}
+
+// ServeMetrics exposes the metrics of the session for Prometheus at
+// http://addr/metrics. The server runs until the process exits.
+func (s *Shell) ServeMetrics(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.metrics.write(w)
+	})
+	go func() {
+		_ = http.Serve(listener, mux)
+	}()
+	return nil
+}
@@ -0,0 +1,45 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellMetrics_Execute(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	_, _, err := sh.Execute("echo hi | grep nothing; false; true")
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	sh.metrics.write(&sb)
+	out := sb.String()
+	assert.Contains(t, out, "gocli_commands_total 4\n")
+	assert.Contains(t, out, "gocli_command_failures_total{code=\"1\"} 2\n")
+	assert.Contains(t, out, "gocli_active_commands 0\n")
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_bucket{le=\"+Inf\"} 3\n")
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_count 3\n")
+}
+
+func TestShellMetrics_Histogram(t *testing.T) {
+	m := newShellMetrics()
+	m.pipelineFinished(20 * time.Millisecond)
+	m.pipelineFinished(2 * time.Second)
+
+	var sb strings.Builder
+	m.write(&sb)
+	out := sb.String()
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_bucket{le=\"0.01\"} 0\n")
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_bucket{le=\"0.05\"} 1\n")
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_bucket{le=\"5\"} 2\n")
+	assert.Contains(t, out, "gocli_pipeline_duration_seconds_sum 2.02\n")
+}
+
+func TestShell_ServeMetrics_InvalidAddress(t *testing.T) {
+	sh, _ := newTestShell(t)
+	assert.Error(t, sh.ServeMetrics("not an address"))
+}
@@ -0,0 +1,155 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mock is a scripted fake that shadows an external command of the same name.
+// Every invocation writes Stdout to the command's output, exits with Status
+// and is recorded, so scripts can be tested without touching real programs.
+type Mock struct {
+	Stdout string
+	Status int
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+// Calls returns the argument vectors (including the command name) of all invocations so far.
+func (m *Mock) Calls() [][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([][]string, len(m.calls))
+	for i, call := range m.calls {
+		calls[i] = append([]string(nil), call...)
+	}
+	return calls
+}
+
+func (m *Mock) record(args []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, append([]string(nil), args...))
+}
+
+// Mock makes every following invocation of the external command name run m instead.
+// Builtins cannot be shadowed.
+func (s *Shell) Mock(name string, m *Mock) {
+	s.factory.mocks.set(name, m)
+}
+
+// Unmock removes the fake registered for name, restoring the real command.
+func (s *Shell) Unmock(name string) {
+	s.factory.mocks.delete(name)
+}
+
+type mockRegistry struct {
+	mu    sync.Mutex
+	mocks map[string]*Mock
+}
+
+func newMockRegistry() *mockRegistry {
+	return &mockRegistry{mocks: make(map[string]*Mock)}
+}
+
+func (r *mockRegistry) get(name string) (*Mock, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.mocks[name]
+	return m, ok
+}
+
+func (r *mockRegistry) set(name string, m *Mock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mocks[name] = m
+}
+
+func (r *mockRegistry) delete(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mocks, name)
+}
+
+type mockCommand struct {
+	mock *Mock
+	args []string
+}
+
+func (c *mockCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	c.mock.record(c.args)
+	_, _ = out.WriteString(c.mock.Stdout)
+	return c.mock.Status, false
+}
+
+type mockAction int
+
+const (
+	mockDefine mockAction = iota
+	mockPrintCalls
+	mockClear
+)
+
+type mockBuiltin struct {
+	registry *mockRegistry
+	action   mockAction
+	name     string
+	stdout   string
+	status   int
+}
+
+func parseMockCommand(d CommandDescription, registry *mockRegistry) (Command, error) {
+	fs := newFlagSet("mock")
+	status := fs.Int("status", 0, "exit status of the fake")
+	stdout := fs.String("stdout", "", "line printed by the fake")
+	calls := fs.Bool("calls", false, "print recorded invocations of the fake")
+	clearMock := fs.Bool("clear", false, "remove the fake and restore the real command")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("mock: %w", err)
+	}
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("mock: expected NAME")
+	}
+
+	cmd := &mockBuiltin{
+		registry: registry,
+		name:     fs.Arg(0),
+		status:   *status,
+	}
+	if *stdout != "" {
+		cmd.stdout = *stdout + "\n"
+	}
+	switch {
+	case *calls && *clearMock:
+		return nil, fmt.Errorf("mock: -calls and -clear are mutually exclusive")
+	case *calls:
+		cmd.action = mockPrintCalls
+	case *clearMock:
+		cmd.action = mockClear
+	}
+	return cmd, nil
+}
+
+func (c *mockBuiltin) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	switch c.action {
+	case mockPrintCalls:
+		m, ok := c.registry.get(c.name)
+		if !ok {
+			_, _ = fmt.Fprintf(errOut, "mock: %s: not mocked\n", c.name)
+			return 1, false
+		}
+		for _, call := range m.Calls() {
+			_, _ = fmt.Fprintln(out, strings.Join(call, " "))
+		}
+	case mockClear:
+		c.registry.delete(c.name)
+	default:
+		c.registry.set(c.name, &Mock{Stdout: c.stdout, Status: c.status})
+	}
+	return 0, false
+}
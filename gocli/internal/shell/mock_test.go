@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestShell(t *testing.T) (*Shell, *os.File) {
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = stdout.Close()
+	})
+	return NewShell(WithStdout(stdout)), stdout
+}
+
+func readShellOutput(t *testing.T, stdout *os.File) string {
+	content, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestShell_Mock(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	fake := &Mock{Stdout: "faked\n", Status: 3}
+	sh.Mock("git", fake)
+
+	retCode, exited, err := sh.Execute("git push origin main")
+	require.NoError(t, err)
+	assert.Equal(t, 3, retCode)
+	assert.False(t, exited)
+	assert.Equal(t, "faked\n", readShellOutput(t, stdout))
+	assert.Equal(t, [][]string{{"git", "push", "origin", "main"}}, fake.Calls())
+
+	sh.Unmock("git")
+	_, ok := sh.factory.mocks.get("git")
+	assert.False(t, ok)
+}
+
+func TestMockBuiltin_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	for _, line := range []string{
+		"mock -stdout deployed -status 0 deploy",
+		"deploy staging",
+		"deploy prod --force",
+		"mock -calls deploy",
+	} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		require.Equal(t, 0, retCode, line)
+	}
+
+	expected := "deployed\ndeployed\ndeploy staging\ndeploy prod --force\n"
+	assert.Equal(t, expected, readShellOutput(t, stdout))
+
+	retCode, _, err := sh.Execute("mock -clear deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	retCode, _, err = sh.Execute("mock -calls deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestMockBuiltin_Parse_Errors(t *testing.T) {
+	registry := newMockRegistry()
+
+	_, err := parseMockCommand(CommandDescription{name: MockCommand, arguments: []string{"mock"}}, registry)
+	assert.Error(t, err)
+
+	_, err = parseMockCommand(CommandDescription{
+		name:      MockCommand,
+		arguments: []string{"mock", "-calls", "-clear", "x"},
+	}, registry)
+	assert.Error(t, err)
+}
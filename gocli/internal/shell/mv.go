@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// mvCommand renames files and directories, copying and deleting them when
+// the destination is on another filesystem.
+type mvCommand struct {
+	sources   []string
+	dest      string
+	noClobber bool
+	verbose   bool
+	// trash, when set, receives the files that would be overwritten and
+	// records the moves, so that "undo" can reverse them.
+	trash *trashBin
+}
+
+func parseMvCommand(d CommandDescription, trash *trashBin) (Command, error) {
+	fs := newFlagSet("mv")
+	// As with mv(1), the last of -n and -f wins.
+	noClobber := false
+	fs.BoolFunc("n", "do not overwrite an existing file", func(string) error {
+		noClobber = true
+		return nil
+	})
+	fs.BoolFunc("f", "overwrite existing files, the default", func(string) error {
+		noClobber = false
+		return nil
+	})
+	verbose := fs.Bool("v", false, "explain what is being done")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("mv: %w", err)
+	}
+	switch fs.NArg() {
+	case 0:
+		return nil, fmt.Errorf("mv: missing file operand")
+	case 1:
+		return nil, fmt.Errorf("mv: missing destination file operand after '%s'", fs.Arg(0))
+	}
+
+	args := fs.Args()
+	return &mvCommand{
+		sources:   args[:len(args)-1],
+		dest:      args[len(args)-1],
+		noClobber: noClobber,
+		verbose:   *verbose,
+		trash:     trash,
+	}, nil
+}
+
+// Execute moves every source into the destination if it is a directory,
+// or onto it otherwise, which is only allowed for a single source.
+func (m *mvCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	info, err := os.Stat(m.dest)
+	intoDir := err == nil && info.IsDir()
+	if len(m.sources) > 1 && !intoDir {
+		_, _ = fmt.Fprintf(errOut, "mv: target '%s' is not a directory\n", m.dest)
+		return 1, false
+	}
+
+	for _, src := range m.sources {
+		target := m.dest
+		if intoDir {
+			target = filepath.Join(m.dest, filepath.Base(filepath.Clean(src)))
+		}
+		if err := m.move(src, target, out); err != nil {
+			_, _ = fmt.Fprintf(errOut, "mv: %v\n", err)
+			retCode = 1
+		}
+	}
+	return retCode, false
+}
+
+func (m *mvCommand) move(src, dst string, out io.Writer) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("cannot stat '%s': %w", src, err)
+	}
+	dstInfo, err := os.Lstat(dst)
+	exists := err == nil
+	if exists {
+		if os.SameFile(info, dstInfo) {
+			return fmt.Errorf("'%s' and '%s' are the same file", src, dst)
+		}
+		if m.noClobber {
+			return nil
+		}
+	}
+	if info.IsDir() && isWithin(dst, src) {
+		return fmt.Errorf("cannot move '%s' to a subdirectory of itself, '%s'", src, dst)
+	}
+
+	if m.trash != nil {
+		if exists {
+			if err := m.trash.put(dst); err != nil {
+				return fmt.Errorf("cannot move '%s' to the trash: %w", dst, err)
+			}
+		}
+		err = m.trash.move(src, dst)
+	} else {
+		err = moveFile(src, dst)
+	}
+	if err != nil {
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) {
+			err = linkErr.Err
+		}
+		return fmt.Errorf("cannot move '%s' to '%s': %w", src, dst, err)
+	}
+	if m.verbose {
+		_, _ = fmt.Fprintf(out, "renamed '%s' -> '%s'\n", src, dst)
+	}
+	return nil
+}
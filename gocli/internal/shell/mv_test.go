@@ -0,0 +1,81 @@
+package shell
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMvCommand(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("a", []byte("alpha"), 0644))
+	require.NoError(t, os.WriteFile("b", []byte("beta"), 0644))
+	require.NoError(t, os.WriteFile("kept", []byte("kept"), 0644))
+	require.NoError(t, os.MkdirAll("dir/sub", 0755))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("mv a renamed; mv -v renamed b dir; mv -n kept dir/b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "renamed 'renamed' -> 'dir/renamed'\nrenamed 'b' -> 'dir/b'\n", readShellOutput(t, stdout))
+
+	content, err := os.ReadFile("dir/renamed")
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(content))
+	content, err = os.ReadFile("dir/b")
+	require.NoError(t, err)
+	assert.Equal(t, "beta", string(content), "-n keeps the existing file")
+	_, err = os.Stat("kept")
+	require.NoError(t, err)
+
+	for _, line := range []string{"mv kept", "mv missing x", "mv kept dir/b kept2", "mv dir dir/sub", "mv kept kept"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+	_, err = os.Stat("dir/sub/dir")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMvCommand_Flags(t *testing.T) {
+	tempWorkDir(t)
+	for name, content := range map[string]string{"a": "alpha", "b": "beta", "c": "gamma"} {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("mv -nf a b; mv -fvn c b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Empty(t, readShellOutput(t, stdout), "the last of -n and -f wins")
+	content, err := os.ReadFile("b")
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(content))
+	assert.FileExists(t, "c")
+}
+
+func TestMvCommand_Safety(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("new", []byte("new"), 0644))
+	require.NoError(t, os.WriteFile("old", []byte("old"), 0644))
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("set -o safety; mv new old")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	content, err := os.ReadFile("old")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	retCode, _, err = sh.Execute("undo 2 > /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	for name, want := range map[string]string{"new": "new", "old": "old"} {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), name)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+// namespaceAttrs returns process attributes that start the child in fresh
+// user, mount, PID, IPC and UTS namespaces. The current user is mapped onto
+// itself, so no privileges are required as long as unprivileged user
+// namespaces are enabled; mounts made by the child stay private to it.
+//
+// This is not a sandbox: there is no pivot_root or chroot, the mount
+// namespace starts as a copy of the parent's with /proc still showing the
+// host's processes, and no seccomp filter is installed, so the child reads
+// and writes the same files as the shell.
+func namespaceAttrs() (*syscall.SysProcAttr, error) {
+	return &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER |
+			syscall.CLONE_NEWNS |
+			syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC |
+			syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+	}, nil
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalCommand_Execute_Namespaces(t *testing.T) {
+	if data, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil && strings.TrimSpace(string(data)) == "0" {
+		t.Skip("unprivileged user namespaces are disabled")
+	}
+
+	sh, stdout := newTestShell(t)
+	_, _, err := sh.Execute("set -o namespaces")
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute(`sh -c 'echo $$'`)
+	require.NoError(t, err)
+	if retCode != 0 {
+		t.Skip("namespaces are not available in this environment")
+	}
+	assert.Equal(t, "1\n", readShellOutput(t, stdout))
+}
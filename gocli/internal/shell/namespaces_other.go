@@ -0,0 +1,12 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"syscall"
+)
+
+func namespaceAttrs() (*syscall.SysProcAttr, error) {
+	return nil, errors.New("namespaces are only supported on Linux")
+}
@@ -0,0 +1,191 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+)
+
+// netTimeout is how long port and nc wait for a connection by default.
+const netTimeout = 3 * time.Second
+
+// parseNetTimeout reads the -w value of port and nc: seconds, as for sleep.
+func parseNetTimeout(name, value string) (time.Duration, error) {
+	timeout, err := parseSleepDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%s: the timeout must be positive", name)
+	}
+	return timeout, nil
+}
+
+// portCommand checks whether TCP ports of a host accept connections.
+type portCommand struct {
+	host    string
+	ports   []string
+	timeout time.Duration
+	quiet   bool
+}
+
+func parsePortCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("port")
+	timeout := fs.String("w", "3", "give up connecting after `SECONDS`")
+	quiet := fs.Bool("q", false, "print nothing, only set the exit status")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("port: %w", err)
+	}
+	if fs.NArg() < 2 {
+		return nil, fmt.Errorf("port: usage: port [-w SECONDS] [-q] HOST PORT...")
+	}
+	c := &portCommand{host: fs.Arg(0), ports: fs.Args()[1:], quiet: *quiet}
+	for _, port := range c.ports {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("port: %s: invalid port", port)
+		}
+	}
+	var err error
+	if c.timeout, err = parseNetTimeout("port", *timeout); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Execute prints "HOST:PORT open (TIME)" or "HOST:PORT closed: REASON" per
+// port and returns 1 if any of them is closed.
+func (c *portCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	for _, port := range c.ports {
+		address := net.JoinHostPort(c.host, port)
+		started := time.Now()
+		conn, err := net.DialTimeout("tcp", address, c.timeout)
+		if err != nil {
+			retCode = 1
+			if !c.quiet {
+				_, _ = fmt.Fprintf(out, "%s closed: %v\n", address, dialReason(err))
+			}
+			continue
+		}
+		elapsed := time.Since(started)
+		_ = conn.Close()
+		if !c.quiet {
+			_, _ = fmt.Fprintf(out, "%s open (%s)\n", address, elapsed.Round(time.Millisecond/10))
+		}
+	}
+	return retCode, false
+}
+
+// dialReason drops the "dial tcp ADDRESS:" prefix the address is already printed with.
+func dialReason(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Err != nil {
+		if opErr.Timeout() {
+			return errors.New("timed out")
+		}
+		return opErr.Err
+	}
+	return err
+}
+
+// ncCommand connects to a TCP port, or with -l waits for one connection,
+// and copies its input to the connection and the connection to its output.
+type ncCommand struct {
+	listen  bool
+	address string
+	timeout time.Duration
+}
+
+func parseNcCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("nc")
+	listen := fs.Bool("l", false, "listen for a connection instead of connecting")
+	timeout := fs.String("w", "3", "give up connecting after `SECONDS`")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("nc: %w", err)
+	}
+	c := &ncCommand{listen: *listen}
+	switch {
+	case fs.NArg() == 2:
+		c.address = net.JoinHostPort(fs.Arg(0), fs.Arg(1))
+	case fs.NArg() == 1 && c.listen:
+		c.address = net.JoinHostPort("", fs.Arg(0))
+	default:
+		return nil, fmt.Errorf("nc: usage: nc [-w SECONDS] HOST PORT | nc -l [HOST] PORT")
+	}
+	var err error
+	if c.timeout, err = parseNetTimeout("nc", *timeout); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Execute streams the input, unless it is a terminal, to the connection
+// and the connection to the output until both of them end. It returns 130
+// if it is interrupted.
+func (c *ncCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 130, false
+		}
+		_, _ = fmt.Fprintf(errOut, "nc: %v\n", err)
+		return 1, false
+	}
+	defer func() { _ = conn.Close() }()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	sent := make(chan struct{})
+	if _, isTerminal := terminalWidth(in); in != nil && !isTerminal {
+		go func() {
+			defer close(sent)
+			_, _ = io.Copy(conn, in)
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				_ = tcp.CloseWrite()
+			}
+		}()
+	} else {
+		close(sent)
+	}
+	if _, err := io.Copy(out, conn); err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(errOut, "nc: %v\n", err)
+		return 1, false
+	}
+	// The other side may stop sending before it has read all the input.
+	select {
+	case <-sent:
+	case <-ctx.Done():
+	}
+	if ctx.Err() != nil {
+		return 130, false
+	}
+	return 0, false
+}
+
+func (c *ncCommand) connect(ctx context.Context) (net.Conn, error) {
+	if !c.listen {
+		dialer := net.Dialer{Timeout: c.timeout}
+		return dialer.DialContext(ctx, "tcp", c.address)
+	}
+	var config net.ListenConfig
+	listener, err := config.Listen(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	return listener.Accept()
+}
@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closedPort returns a local port that nothing listens on.
+func closedPort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, listener.Close())
+	return port
+}
+
+func TestPortCommand(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	_, open, _ := net.SplitHostPort(listener.Addr().String())
+	closed := closedPort(t)
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("port 127.0.0.1 " + open)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	retCode, _, err = sh.Execute("port -w 1 127.0.0.1 " + open + " " + closed)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	retCode, _, err = sh.Execute("port -q 127.0.0.1 " + closed)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+
+	lines := strings.Split(strings.TrimSuffix(readShellOutput(t, stdout), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "127.0.0.1:"+open+" open ("), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "127.0.0.1:"+open+" open ("), lines[1])
+	assert.Equal(t, "127.0.0.1:"+closed+" closed: connect: connection refused", lines[2])
+
+	for _, args := range [][]string{{"port", "host"}, {"port", "host", "http"}, {"port", "host", "70000"}, {"port", "-w", "0", "host", "80"}} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: PortCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
+
+func TestNcCommand(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		request, _ := io.ReadAll(conn)
+		_, _ = conn.Write([]byte(strings.ToUpper(string(request))))
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("echo hello | nc " + host + " " + port)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	free := closedPort(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		retCode, _, err := sh.Execute("echo reply | nc -l 127.0.0.1 " + free)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, retCode)
+	}()
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("tcp", "127.0.0.1:"+free)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = conn.Write([]byte("from client\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	received, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	_ = conn.Close()
+	<-done
+	assert.Equal(t, "reply\n", string(received))
+	assert.Equal(t, "HELLO\nfrom client\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("nc -w 1 127.0.0.1 " + closedPort(t) + " 2> /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	for _, args := range [][]string{{"nc"}, {"nc", "host"}, {"nc", "-l"}, {"nc", "a", "b", "c"}} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: NcCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
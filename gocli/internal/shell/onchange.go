@@ -0,0 +1,182 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// onchangeDelay is how long files must stay unchanged before the command
+// runs, so that saving several files or writing one in parts runs it once.
+const onchangeDelay = 200 * time.Millisecond
+
+// onchangeCommand runs a pipeline whenever the watched files change,
+// until it is interrupted or has run the number of times given with -n.
+type onchangeCommand struct {
+	paths     []string
+	recursive bool
+	delay     time.Duration
+	count     int
+	pipeline  []CommandDescription
+	parser    InputProcessor
+	factory   CommandFactory
+	fsys      FileSystem
+}
+
+func parseOnchangeCommand(d CommandDescription, factory CommandFactory, parser InputProcessor, fsys FileSystem) (Command, error) {
+	const usage = "onchange: usage: onchange [-r] [-d DELAY] [-n COUNT] PATH... -- COMMAND"
+	separator := slices.Index(d.arguments, "--")
+	if separator < 0 || separator == len(d.arguments)-1 {
+		return nil, fmt.Errorf(usage)
+	}
+
+	fs := newFlagSet("onchange")
+	recursive := fs.Bool("r", false, "watch directories with all their subdirectories")
+	delay := fs.String("d", "", "wait until nothing changed for `DELAY` seconds, 0.2 by default")
+	count := fs.Int("n", 0, "stop after running the command `COUNT` times")
+	if err := fs.Parse(d.arguments[1:separator]); err != nil {
+		return nil, fmt.Errorf("onchange: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf(usage)
+	}
+	c := &onchangeCommand{paths: fs.Args(), recursive: *recursive, delay: onchangeDelay, count: *count, parser: parser, factory: factory, fsys: fsys}
+	if *delay != "" {
+		var err error
+		if c.delay, err = parseSleepDuration(*delay); err != nil {
+			return nil, fmt.Errorf("onchange: %w", err)
+		}
+	}
+
+	pipeline, err := c.parser.Parse(commandLine(d, separator+1))
+	if err != nil {
+		return nil, fmt.Errorf("onchange: %w", err)
+	}
+	if len(pipeline) == 0 {
+		return nil, fmt.Errorf(usage)
+	}
+	c.pipeline = pipeline
+	return c, nil
+}
+
+// onchangeWatch is what is being watched. A file is watched through its
+// directory, so that it is still seen after an editor replaces it.
+type onchangeWatch struct {
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool
+	files   map[string]bool
+}
+
+func (w *onchangeWatch) addDir(dir string, recursive bool) error {
+	if !recursive {
+		w.dirs[dir] = true
+		return w.watcher.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		w.dirs[path] = true
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *onchangeWatch) add(path string, recursive bool) error {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return w.addDir(path, recursive)
+	}
+	w.files[path] = true
+	return w.watcher.Add(filepath.Dir(path))
+}
+
+// matches tells whether an event is about a watched file or happened in a
+// watched directory. Changes of permissions alone do not count.
+func (w *onchangeWatch) matches(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return w.files[name] || w.dirs[filepath.Dir(name)]
+}
+
+// Execute returns the status of the last run when -n is reached, or 130
+// when it is interrupted. Changes made while the command runs, such as
+// files it writes itself, do not run it again.
+func (c *onchangeCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "onchange: %v\n", err)
+		return 1, false
+	}
+	defer func() { _ = watcher.Close() }()
+	watch := &onchangeWatch{watcher: watcher, dirs: make(map[string]bool), files: make(map[string]bool)}
+	for _, path := range c.paths {
+		if err := watch.add(path, c.recursive); err != nil {
+			_, _ = fmt.Fprintf(errOut, "onchange: %v\n", err)
+			return 1, false
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	timer := time.NewTimer(c.delay)
+	timer.Stop()
+	runs := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return 130, false
+		case err := <-watcher.Errors:
+			_, _ = fmt.Fprintf(errOut, "onchange: %v\n", err)
+			return 1, false
+		case event := <-watcher.Events:
+			if name := filepath.Clean(event.Name); c.recursive && event.Has(fsnotify.Create) && watch.dirs[filepath.Dir(name)] {
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					_ = watch.addDir(name, true)
+				}
+			}
+			if watch.matches(event) {
+				timer.Reset(c.delay)
+			}
+		case <-timer.C:
+			runner := &pipelineRunner{
+				env:     env,
+				factory: c.factory,
+				parser:  c.parser,
+				stdin:   in,
+				stdout:  out,
+				stderr:  errOut,
+				fsys:    c.fsys,
+			}
+			retCode, exited = runner.Execute(c.pipeline, env)
+			runs++
+			if exited || c.count > 0 && runs >= c.count {
+				return retCode, false
+			}
+			drainEvents(watcher.Events)
+		}
+	}
+}
+
+// drainEvents drops the events that are already queued.
+func drainEvents(events <-chan fsnotify.Event) {
+	for {
+		select {
+		case <-events:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// touchUntil rewrites a file now and then until done is closed, since
+// the watcher may not be set up yet when the first change is made.
+func touchUntil(t *testing.T, path string, done <-chan struct{}) {
+	for i := 0; ; i++ {
+		require.NoError(t, os.WriteFile(path, []byte("changed\n"), 0644))
+		select {
+		case <-done:
+			return
+		case <-time.After(300 * time.Millisecond):
+		}
+		if i > 30 {
+			require.FailNow(t, "onchange did not run")
+		}
+	}
+}
+
+func TestOnchangeCommand(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.MkdirAll("src/inner", 0755))
+	require.NoError(t, os.WriteFile("watched.txt", nil, 0644))
+	sh, stdout := newTestShell(t)
+
+	for _, tc := range []struct{ line, touched string }{
+		{"onchange -n 1 -d 0.05 watched.txt -- 'cat watched.txt; echo file'", "watched.txt"},
+		{"onchange -r -n 1 -d 0.05 src -- echo tree", "src/inner/new.txt"},
+		{`onchange -n 1 -d 0.05 watched.txt -- printf "%s|" "two  words" '$PWD' > quoted.txt`, "watched.txt"},
+	} {
+		done := make(chan struct{})
+		var retCode int
+		go func() {
+			defer close(done)
+			var err error
+			retCode, _, err = sh.Execute(tc.line)
+			assert.NoError(t, err)
+		}()
+		touchUntil(t, tc.touched, done)
+		assert.Equal(t, 0, retCode, tc.line)
+	}
+	assert.Equal(t, "changed\nfile\ntree\n", readShellOutput(t, stdout))
+	quoted, err := os.ReadFile("quoted.txt")
+	require.NoError(t, err)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, "two  words|"+cwd+"|", string(quoted))
+
+	for _, line := range []string{"onchange src", "onchange -- echo", "onchange src --", "onchange -x src -- echo", "onchange -d x src -- echo"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+	retCode, _, err := sh.Execute("onchange missing -- echo 2> /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
@@ -0,0 +1,207 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the shell options toggled with "set -o NAME" / "set +o NAME".
+// Options that take a value are set with "set -o NAME=VALUE".
+const (
+	// OptionNamespaces runs external commands in separate namespaces (Linux only).
+	// It does not isolate the file system: the commands see the same root.
+	OptionNamespaces = "namespaces"
+	// OptionSudoPrompt offers to re-run commands that hit permission errors under sudo.
+	OptionSudoPrompt = "sudo-prompt"
+	// OptionSafety asks for confirmation before running commands that look destructive.
+	OptionSafety = "safety"
+	// OptionPipeView shows per-stage stderr and throughput after a pipeline finishes.
+	OptionPipeView = "pipeview"
+	// OptionTransientPrompt collapses the prompt of accepted lines to the default one.
+	OptionTransientPrompt = "transient-prompt"
+	// OptionPTY runs external commands on a pseudo-terminal when their output is not a terminal.
+	OptionPTY = "pty"
+	// OptionBugReport prints a link to a pre-filled bug report when a command panics.
+	OptionBugReport = "bug-report"
+	// OptionSanitizeEnv passes external commands only PATH, HOME, LANG and the variables in GOCLI_ENV_ALLOW.
+	OptionSanitizeEnv = "sanitize-env"
+	// OptionKeepMode makes redirections with mode=NNN keep the permissions of existing files.
+	OptionKeepMode = "keep-mode"
+	// OptionAtomicWrite makes ">" and "2>" replace their files only when the pipeline succeeds.
+	OptionAtomicWrite = "atomicwrite"
+	// OptionSyncWrites makes output redirections fsync their files before closing them.
+	OptionSyncWrites = "syncwrites"
+	// OptionURLInput lets "<", cat, grep and wc read http and https URLs.
+	OptionURLInput = "url-input"
+	// OptionExportAliases passes aliases to child gocli instances through the environment.
+	OptionExportAliases = "export-aliases"
+	// OptionExecBackend selects where external commands run, e.g. "ssh://host".
+	OptionExecBackend = "exec-backend"
+)
+
+var optionDescriptions = map[string]string{
+	OptionNamespaces:      "run external commands in new user, mount, PID, IPC and UTS namespaces, on the same file system",
+	OptionSudoPrompt:      "offer to re-run commands denied access to root-owned files with sudo",
+	OptionSafety:          "ask before dangerous commands and make rm move files to an undoable trash",
+	OptionPipeView:        "show a pane with stderr and throughput for every stage of a pipeline",
+	OptionTransientPrompt: "redraw the prompt of an accepted line as the default one-line prompt",
+	OptionPTY:             "run external commands on a pseudo-terminal when their output is not one",
+	OptionBugReport:       "offer a link to a pre-filled bug report when a command crashes",
+	OptionSanitizeEnv:     "pass external commands only PATH, HOME, LANG and the variables in " + EnvAllowVar,
+	OptionKeepMode:        "let output redirections with mode=NNN only set the permissions of new files",
+	OptionAtomicWrite:     "write redirections to a temporary file and rename it over the target on success",
+	OptionSyncWrites:      "flush files written by output redirections to disk before closing them",
+	OptionURLInput:        "let input redirections, cat, grep and wc download http and https URLs",
+	OptionExportAliases:   "pass aliases to gocli scripts and shells started from this one",
+	OptionExecBackend:     "run external commands on another host, e.g. ssh://user@host:22",
+}
+
+// valueChecks validate the values of the options that take one.
+var valueChecks = map[string]func(string) error{
+	OptionExecBackend: func(value string) error {
+		_, err := parseExecBackend(value)
+		return err
+	},
+}
+
+type shellOptions struct {
+	mu      sync.RWMutex
+	enabled map[string]bool
+	values  map[string]string
+}
+
+func newShellOptions() *shellOptions {
+	return &shellOptions{enabled: make(map[string]bool), values: make(map[string]string)}
+}
+
+func (o *shellOptions) isSet(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.enabled[name]
+}
+
+func (o *shellOptions) set(name string, on bool) error {
+	if _, ok := optionDescriptions[name]; !ok {
+		return fmt.Errorf("%s: invalid option name", name)
+	}
+	if _, ok := valueChecks[name]; ok && on {
+		return fmt.Errorf("%s: option requires a value: set -o %s=VALUE", name, name)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enabled[name] = on
+	delete(o.values, name)
+	return nil
+}
+
+// setValue turns on an option that takes a value.
+func (o *shellOptions) setValue(name, value string) error {
+	check, ok := valueChecks[name]
+	if !ok {
+		return fmt.Errorf("%s: option does not take a value", name)
+	}
+	if err := check(value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enabled[name] = true
+	o.values[name] = value
+	return nil
+}
+
+// value returns the value of an option, or "" if it is off.
+func (o *shellOptions) value(name string) string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.values[name]
+}
+
+type setCommand struct {
+	env     Env
+	options *shellOptions
+	enable  []string
+	disable []string
+	list    bool
+}
+
+func parseSetCommand(d CommandDescription, env Env, options *shellOptions) (Command, error) {
+	cmd := &setCommand{env: env, options: options}
+	args := d.arguments[1:]
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-o", "+o":
+			if i+1 == len(args) {
+				cmd.list = true
+				continue
+			}
+			if args[i] == "-o" {
+				cmd.enable = append(cmd.enable, args[i+1])
+			} else {
+				cmd.disable = append(cmd.disable, args[i+1])
+			}
+			i++
+		default:
+			return nil, fmt.Errorf("set: %s: invalid option", args[i])
+		}
+	}
+	return cmd, nil
+}
+
+// Execute toggles the requested options. Without arguments it prints all variables,
+// with a trailing "-o" it prints the state of every option.
+func (s *setCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	for _, name := range s.enable {
+		var err error
+		if name, value, ok := strings.Cut(name, "="); ok {
+			err = s.options.setValue(name, value)
+		} else {
+			err = s.options.set(name, true)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "set: %v\n", err)
+			return 1, false
+		}
+	}
+	for _, name := range s.disable {
+		if err := s.options.set(name, false); err != nil {
+			_, _ = fmt.Fprintf(errOut, "set: %v\n", err)
+			return 1, false
+		}
+	}
+
+	if s.list {
+		names := make([]string, 0, len(optionDescriptions))
+		for name := range optionDescriptions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			state := "off"
+			if value := s.options.value(name); value != "" {
+				state = value
+			} else if s.options.isSet(name) {
+				state = "on"
+			}
+			_, _ = fmt.Fprintf(out, "%-15s\t%s\n", name, state)
+		}
+		return 0, false
+	}
+
+	if len(s.enable) == 0 && len(s.disable) == 0 {
+		vars := s.env.GetAll()
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(out, "%s=%s\n", key, vars[key])
+		}
+	}
+	return 0, false
+}
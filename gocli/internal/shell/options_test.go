@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCommand_Execute_ToggleAndList(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("set -o namespaces")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.True(t, sh.factory.options.isSet(OptionNamespaces))
+
+	retCode, _, err = sh.Execute("set -o")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "namespaces     \ton\n")
+
+	retCode, _, err = sh.Execute("set +o namespaces")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.False(t, sh.factory.options.isSet(OptionNamespaces))
+}
+
+func TestSetCommand_Execute_UnknownOption(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("set -o nosuchoption")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestSetCommand_Parse_InvalidArgument(t *testing.T) {
+	_, err := parseSetCommand(CommandDescription{
+		name:      SetCommand,
+		arguments: []string{"set", "-x"},
+	}, NewEnv(), newShellOptions())
+	assert.Error(t, err)
+}
+
+func TestSetCommand_Execute_PrintsVariables(t *testing.T) {
+	env := NewEnvFromMap(map[string]string{"B": "2", "A": "1"})
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithEnv(env), WithStdout(stdout))
+
+	retCode, _, err := sh.Execute("set")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "A=1\nB=2\n", readShellOutput(t, stdout))
+}
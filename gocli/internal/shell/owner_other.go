@@ -0,0 +1,13 @@
+//go:build !unix
+
+package shell
+
+import "io/fs"
+
+func fileOwner(info fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
+
+func fileOwnership(info fs.FileInfo) (links uint64, uid, gid uint32, ok bool) {
+	return 0, 0, 0, false
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package shell
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the numeric owner of a file, if the platform exposes it.
+func fileOwner(info fs.FileInfo) (uint32, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return stat.Uid, true
+}
+
+// fileOwnership returns the number of hard links of a file and its numeric
+// owner and group, if the platform exposes them.
+func fileOwnership(info fs.FileInfo) (links uint64, uid, gid uint32, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return uint64(stat.Nlink), stat.Uid, stat.Gid, true
+}
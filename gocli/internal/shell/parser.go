@@ -0,0 +1,301 @@
+package shell
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError reports malformed input together with the position it was found at.
+// Errors about input that ends too early, such as an unclosed quote, also match
+// ErrIncompleteInput, so that the caller can read more lines and parse again.
+type SyntaxError struct {
+	Pos        Pos
+	Msg        string
+	incomplete bool
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+func (e *SyntaxError) Unwrap() error {
+	if e.incomplete {
+		return ErrIncompleteInput
+	}
+	return nil
+}
+
+// parser is a recursive-descent parser of command lines with one token of lookahead:
+//
+//	list     = { newline } [ pipeline { ( ";" | "&&" | "||" | newline ) { newline } pipeline } ]
+//	pipeline = command { "|" { newline } command } [ ( "|>" | "|>>" ) name ]
+//	command  = { assignment } { word | redirect }
+//	redirect = operator [ word [ mode ] ]
+//
+// where mode is a "mode=NNN" word with octal permissions after the target of
+// an output redirection and name is the variable a pipeline's output goes to.
+type parser struct {
+	lex *lexer
+	tok token
+	// aliases are expanded in the first word of every command.
+	aliases *aliasTable
+	// queued are the tokens of an expanded alias, read before the rest of the input.
+	queued []token
+}
+
+// parse builds the syntax tree of a command line starting at base.
+func parse(src string, base Pos) (*listNode, error) {
+	return parseWithAliases(src, base, nil)
+}
+
+// parseWithAliases is parse that also expands the given aliases.
+func parseWithAliases(src string, base Pos, aliases *aliasTable) (*listNode, error) {
+	p := &parser{lex: newLexer(src, base), aliases: aliases}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p.parseList()
+}
+
+func (p *parser) advance() error {
+	if len(p.queued) > 0 {
+		p.tok, p.queued = p.queued[0], p.queued[1:]
+		return nil
+	}
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	return &SyntaxError{Pos: p.tok.pos, Msg: "syntax error near unexpected token `" + p.tok.describe() + "'"}
+}
+
+// skipNewlines skips newlines and, when the input ends there, reports that more is needed
+// to finish what follows the operator op.
+func (p *parser) skipNewlines(op token) error {
+	for p.tok.kind == tokenNewline {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	if p.tok.kind == tokenEOF {
+		return p.lex.incomplete(len(p.lex.src), "command expected after `"+op.text+"'")
+	}
+	return nil
+}
+
+func (p *parser) parseList() (*listNode, error) {
+	list := &listNode{}
+	for {
+		for p.tok.kind == tokenNewline {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.tok.kind == tokenEOF {
+			return list, nil
+		}
+
+		pipeline, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		item := listItem{pipeline: pipeline}
+
+		op := p.tok
+		switch op.kind {
+		case tokenEOF:
+		case tokenSemicolon, tokenNewline:
+			item.next = chainAlways
+			err = p.advance()
+		case tokenAnd, tokenOr:
+			item.next = chainOnSuccess
+			if op.kind == tokenOr {
+				item.next = chainOnFailure
+			}
+			if err = p.advance(); err == nil {
+				err = p.skipNewlines(op)
+			}
+		default:
+			err = p.unexpected()
+		}
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+	}
+}
+
+func (p *parser) parsePipeline() (*pipelineNode, error) {
+	pipeline := &pipelineNode{}
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.commands = append(pipeline.commands, cmd)
+
+		if p.tok.kind == tokenCapture {
+			capture, err := p.parseCapture()
+			if err != nil {
+				return nil, err
+			}
+			pipeline.capture = capture
+			return pipeline, nil
+		}
+		if p.tok.kind != tokenPipe {
+			return pipeline, nil
+		}
+		op := p.tok
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.skipNewlines(op); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseCommand() (*commandNode, error) {
+	cmd := &commandNode{pos: p.tok.pos}
+	for {
+		switch p.tok.kind {
+		case tokenWord:
+			if len(cmd.words) == 0 && !isAssignment(p.tok) {
+				expanded, err := p.expandAlias()
+				if err != nil {
+					return nil, err
+				}
+				if expanded {
+					continue
+				}
+			}
+			word := p.tok
+			switch {
+			case len(cmd.words) == 0 && isAssignment(word):
+				cmd.assignments = append(cmd.assignments, word)
+			case len(cmd.words) > 0 && (word.group || cmd.words[0].group):
+				return nil, p.unexpected()
+			case word.group:
+				if err := checkGroup(word); err != nil {
+					return nil, err
+				}
+				cmd.words = append(cmd.words, word)
+			default:
+				cmd.words = append(cmd.words, word)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case tokenRedirect:
+			redirect, err := p.parseRedirect()
+			if err != nil {
+				return nil, err
+			}
+			cmd.redirects = append(cmd.redirects, redirect)
+		default:
+			if len(cmd.words) == 0 && len(cmd.assignments) == 0 && len(cmd.redirects) == 0 {
+				if p.tok.kind == tokenPipe {
+					return nil, &SyntaxError{Pos: p.tok.pos, Msg: "empty command before `|'"}
+				}
+				return nil, p.unexpected()
+			}
+			return cmd, nil
+		}
+	}
+}
+
+// parseCapture parses "|> NAME" or "|>> NAME"; the name must be an unquoted identifier.
+func (p *parser) parseCapture() (*captureNode, error) {
+	op := p.tok
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenWord || p.tok.group || p.tok.quoted || !isIdentifier(p.tok.text) {
+		return nil, &SyntaxError{Pos: op.pos, Msg: "variable name expected after `" + op.text + "'"}
+	}
+	capture := &captureNode{pos: op.pos, name: p.tok.text, append: op.text == "|>>"}
+	return capture, p.advance()
+}
+
+func (p *parser) parseRedirect() (redirectNode, error) {
+	redirect := redirectNode{pos: p.tok.pos, op: p.tok.text}
+	if err := p.advance(); err != nil {
+		return redirect, err
+	}
+	if redirect.op == "2>&1" {
+		return redirect, nil
+	}
+	if p.tok.kind != tokenWord || p.tok.group {
+		return redirect, &SyntaxError{Pos: redirect.pos, Msg: "missing redirection target after `" + redirect.op + "'"}
+	}
+
+	redirect.target = p.tok
+	switch redirect.op {
+	case "<<", "<<-":
+		// The body follows the end of the line, so the lexer must know
+		// about the document before it reads past the delimiter word.
+		redirect.doc = newHereDoc(redirect.op+p.tok.text, p.tok.quoted)
+		p.lex.pending = append(p.lex.pending, redirect.doc)
+	case "<<<":
+		redirect.doc = newHereString(p.tok.text, p.tok.singleQuoted)
+	}
+	if err := p.advance(); err != nil {
+		return redirect, err
+	}
+	if redirect.op == ">" || redirect.op == ">>" || redirect.op == "2>" || redirect.op == "2>>" {
+		if mode, ok := redirectMode(p.tok); ok {
+			redirect.mode = &mode
+			return redirect, p.advance()
+		}
+	}
+	return redirect, nil
+}
+
+// redirectMode parses an unquoted "mode=NNN" word with three or four octal digits.
+func redirectMode(tok token) (fs.FileMode, bool) {
+	digits, ok := strings.CutPrefix(tok.text, "mode=")
+	if tok.kind != tokenWord || tok.quoted || !ok || len(digits) < 3 || len(digits) > 4 {
+		return 0, false
+	}
+	mode, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, false
+	}
+	return fs.FileMode(mode), true
+}
+
+// checkGroup parses the commands of a "(...)" group, so that their errors are
+// reported with the rest of the line rather than when the group runs.
+func checkGroup(group token) error {
+	inner := group.text[1 : len(group.text)-1]
+	_, err := parse(inner, Pos{Line: group.pos.Line, Column: group.pos.Column + 1})
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.incomplete {
+		// The group is closed, so nothing that follows can complete it.
+		return &SyntaxError{Pos: syntaxErr.Pos, Msg: syntaxErr.Msg}
+	}
+	return err
+}
+
+// isAssignment reports whether a word is a NAME=value assignment.
+// The name and the "=" must not be quoted.
+func isAssignment(word token) bool {
+	eq := strings.IndexByte(word.text, '=')
+	return !word.group && eq > 0 && eq < word.plainPrefix && isIdentifier(word.text[:eq])
+}
+
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
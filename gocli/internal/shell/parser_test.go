@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Tree(t *testing.T) {
+	list, err := parse("A=1 B=2 cmd x > out | wc\n\nls &&\n  pwd", Pos{Line: 1, Column: 1})
+	require.NoError(t, err)
+	require.Len(t, list.items, 3)
+
+	first := list.items[0]
+	assert.Equal(t, chainAlways, first.next)
+	require.Len(t, first.pipeline.commands, 2)
+	cmd := first.pipeline.commands[0]
+	require.Len(t, cmd.assignments, 2)
+	assert.Equal(t, "B=2", cmd.assignments[1].text)
+	require.Len(t, cmd.words, 2)
+	assert.Equal(t, "cmd", cmd.words[0].text)
+	require.Len(t, cmd.redirects, 1)
+	assert.Equal(t, ">", cmd.redirects[0].op)
+	assert.Equal(t, "out", cmd.redirects[0].target.text)
+	assert.Equal(t, Pos{Line: 1, Column: 15}, cmd.redirects[0].pos)
+
+	assert.Equal(t, chainOnSuccess, list.items[1].next)
+	assert.Equal(t, Pos{Line: 4, Column: 3}, list.items[2].pipeline.commands[0].pos)
+}
+
+func TestParse_Assignments(t *testing.T) {
+	list, err := parse(`X=1 "Y=2" Z'=3' 1A=4 =5 W='a b'`, Pos{Line: 1, Column: 1})
+	require.NoError(t, err)
+
+	cmd := list.items[0].pipeline.commands[0]
+	require.Len(t, cmd.assignments, 1)
+	require.Len(t, cmd.words, 5)
+	assert.Equal(t, "W=a b", cmd.words[4].text, "assignments only come before the command name")
+}
+
+func TestParse_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		pos  Pos
+		msg  string
+	}{
+		{line: "| cat", pos: Pos{Line: 1, Column: 1}, msg: "empty command before `|'"},
+		{line: "echo a | | b", pos: Pos{Line: 1, Column: 10}, msg: "empty command before `|'"},
+		{line: "echo a && | b", pos: Pos{Line: 1, Column: 11}, msg: "empty command before `|'"},
+		{line: "true;; false", pos: Pos{Line: 1, Column: 6}, msg: "syntax error near unexpected token `;'"},
+		{line: "echo ok\necho >", pos: Pos{Line: 2, Column: 6}, msg: "missing redirection target after `>'"},
+		{line: "cat < > x", pos: Pos{Line: 1, Column: 5}, msg: "missing redirection target after `<'"},
+		{line: "cat <<< | x", pos: Pos{Line: 1, Column: 5}, msg: "missing redirection target after `<<<'"},
+		{line: "echo (a)", pos: Pos{Line: 1, Column: 6}, msg: "syntax error near unexpected token `('"},
+		{line: "echo a)", pos: Pos{Line: 1, Column: 7}, msg: "syntax error near unexpected token `)'"},
+		{line: "  (echo ;;)", pos: Pos{Line: 1, Column: 10}, msg: "syntax error near unexpected token `;'"},
+		{line: "echo a |> 'X'", pos: Pos{Line: 1, Column: 8}, msg: "variable name expected after `|>'"},
+		{line: "echo a |>> 1x", pos: Pos{Line: 1, Column: 8}, msg: "variable name expected after `|>>'"},
+		{line: "echo a |> X Y", pos: Pos{Line: 1, Column: 13}, msg: "syntax error near unexpected token `Y'"},
+		{line: "echo a |> X | cat", pos: Pos{Line: 1, Column: 13}, msg: "syntax error near unexpected token `|'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := parse(tt.line, Pos{Line: 1, Column: 1})
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.pos, syntaxErr.Pos)
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+			assert.NotErrorIs(t, err, ErrIncompleteInput)
+		})
+	}
+}
+
+func TestParse_Incomplete(t *testing.T) {
+	for _, line := range []string{"echo a |", "true &&\n", "false ||", `echo "a`, "cat <<EOF", "(echo a"} {
+		_, err := parse(line, Pos{Line: 1, Column: 1})
+		assert.ErrorIs(t, err, ErrIncompleteInput, line)
+	}
+}
+
+func TestSyntaxError_Error(t *testing.T) {
+	err := &SyntaxError{Pos: Pos{Line: 2, Column: 5}, Msg: "syntax error near unexpected token `|'"}
+	assert.Equal(t, "2:5: syntax error near unexpected token `|'", err.Error())
+}
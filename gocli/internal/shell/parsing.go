@@ -9,192 +9,126 @@ func NewInputProcessor() InputProcessor {
 }
 
 type inputProcessor struct {
+	// aliases are expanded when set.
+	aliases *aliasTable
 }
 
-func tokenizeWithQuotes(input string) ([]string, map[int]bool, map[int]bool) {
-	var tokens []string
-	singleQuoted := make(map[int]bool)
-	doubleQuoted := make(map[int]bool)
-	var current strings.Builder
-	inSingleQuote := false
-	inDoubleQuote := false
-	tokenStartedInSingle := false
-	tokenStartedInDouble := false
-
-	for i := 0; i < len(input); i++ {
-		char := input[i]
-
-		if char == '\'' && !inDoubleQuote {
-			if inSingleQuote {
-				inSingleQuote = false
-			} else {
-				inSingleQuote = true
-				if current.Len() == 0 {
-					tokenStartedInSingle = true
-				}
-			}
-			continue
-		}
-
-		if char == '"' && !inSingleQuote {
-			if inDoubleQuote {
-				inDoubleQuote = false
-			} else {
-				inDoubleQuote = true
-				if current.Len() == 0 {
-					tokenStartedInDouble = true
-				}
-			}
-			continue
-		}
-
-		if (char == ' ' || char == '\t') && !inSingleQuote && !inDoubleQuote {
-			if current.Len() > 0 {
-				idx := len(tokens)
-				tokens = append(tokens, current.String())
-				if tokenStartedInSingle && !inSingleQuote {
-					singleQuoted[idx] = true
-				}
-				if tokenStartedInDouble && !inDoubleQuote {
-					doubleQuoted[idx] = true
-				}
-				current.Reset()
-				tokenStartedInSingle = false
-				tokenStartedInDouble = false
-			}
-			continue
-		}
-
-		current.WriteByte(char)
+// Parse implements InputProcessor interface.
+// Builds the syntax tree of the input and flattens it into a list of CommandDescriptions:
+// pipelines separated by newlines and the ;, && and || operators, commands joined by pipes (|),
+// variable assignments (kept in the description of the command they prefix), I/O redirection
+// operators (<, >, 2>, 2>> and 2>&1), here-documents (<< and <<-) and here-strings (<<<),
+// and the capture of a pipeline's output into a variable (|> and |>>).
+// Comments are dropped, a line ending with a backslash continues on the next one, and the lines
+// following a command with a here-document are its body. Aliases are expanded in the first word
+// of every command. Malformed input is reported with a *SyntaxError; when the input ends too
+// early, e.g. inside quotes or before the here-document delimiter, the error also matches
+// ErrIncompleteInput.
+func (i *inputProcessor) Parse(input string) ([]CommandDescription, error) {
+	list, err := parseWithAliases(input, Pos{Line: 1, Column: 1}, i.aliases)
+	if err != nil {
+		return nil, err
 	}
 
-	if current.Len() > 0 {
-		idx := len(tokens)
-		tokens = append(tokens, current.String())
-		if tokenStartedInSingle && !inSingleQuote {
-			singleQuoted[idx] = true
+	descriptions := []CommandDescription{}
+	for _, item := range list.items {
+		start := len(descriptions)
+		for n, cmd := range item.pipeline.commands {
+			if len(cmd.words) == 0 {
+				descriptions = append(descriptions, cmd.assignmentDescriptions()...)
+				continue
+			}
+			desc := cmd.description(n < len(item.pipeline.commands)-1)
+			desc.assignments = cmd.assignmentDescriptions()
+			descriptions = append(descriptions, desc)
 		}
-		if tokenStartedInDouble && !inDoubleQuote {
-			doubleQuoted[idx] = true
+		if len(descriptions) > start {
+			descriptions[len(descriptions)-1].next = item.next
+			descriptions[len(descriptions)-1].capture = item.pipeline.capture
 		}
 	}
-
-	return tokens, singleQuoted, doubleQuoted
+	return descriptions, nil
 }
 
-// Parse implements InputProcessor interface.
-// Parses the input string into a list of CommandDescriptions by splitting on semicolons,
-// handling variable assignments, processing I/O redirection operators (< and >),
-// and detecting pipe operators (|).
-func (i *inputProcessor) Parse(input string) ([]CommandDescription, error) {
-	rawCommands := strings.Split(input, ";")
-	descriptions := []CommandDescription{}
-
-	for _, rawCmd := range rawCommands {
-		rawCmd = strings.TrimSpace(rawCmd)
-		if rawCmd == "" {
-			continue
-		}
-
-		pipedCommands := i.parsePipeline(rawCmd)
-		descriptions = append(descriptions, pipedCommands...)
+// assignmentDescriptions returns a description for every assignment of the command.
+func (c *commandNode) assignmentDescriptions() []CommandDescription {
+	descriptions := make([]CommandDescription, 0, len(c.assignments))
+	for _, word := range c.assignments {
+		name, value, _ := strings.Cut(word.text, "=")
+		descriptions = append(descriptions, CommandDescription{
+			name:         EnvAssignmentCmd,
+			arguments:    []string{name, value},
+			unquotedArgs: map[int]bool{1: !word.quoted},
+		})
 	}
-
-	return descriptions, nil
+	return descriptions
 }
 
-func (i *inputProcessor) parsePipeline(input string) []CommandDescription {
-	parts := strings.Split(input, "|")
-	descriptions := []CommandDescription{}
-
-	for cmdIndex, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
+// description returns the description of a command with words. Unquoted words
+// go through brace expansion here, everything else is expanded when the command runs.
+func (c *commandNode) description(piped bool) CommandDescription {
+	desc := CommandDescription{
+		isPiped:          piped,
+		singleQuotedArgs: make(map[int]bool),
+		doubleQuotedArgs: make(map[int]bool),
+		unquotedArgs:     make(map[int]bool),
+	}
 
-		// Use proper tokenization with quote handling
-		tokens, singleQuotedTokens, doubleQuotedTokens := tokenizeWithQuotes(part)
-		if len(tokens) == 0 {
-			continue
+	for _, word := range c.words {
+		expanded := []string{word.text}
+		if !word.quoted && !word.group {
+			expanded = expandBraces(word.text)
 		}
-
-		// Handle environment variable assignments
-		var assignments []CommandDescription
-		cmdStartIdx := 0
-
-		for i := range tokens {
-			if strings.Contains(tokens[i], "=") &&
-				!strings.HasPrefix(tokens[i], "=") && !strings.HasSuffix(tokens[i], "=") &&
-				tokens[i] != "<" && tokens[i] != ">" {
-				parts := strings.SplitN(tokens[i], "=", 2)
-				if len(parts) == 2 {
-					assignments = append(assignments, CommandDescription{
-						name:      EnvAssignmentCmd,
-						arguments: []string{parts[0], parts[1]},
-						isPiped:   len(parts) > 1,
-					})
-					cmdStartIdx = i + 1
-					continue
-				}
+		for _, arg := range expanded {
+			idx := len(desc.arguments)
+			desc.arguments = append(desc.arguments, arg)
+			if word.singleQuoted {
+				desc.singleQuotedArgs[idx] = true
 			}
-			break
-		}
-
-		if len(assignments) > 0 && cmdStartIdx >= len(tokens) {
-			descriptions = append(descriptions, assignments...)
-			continue
-		}
-
-		descriptions = append(descriptions, assignments...)
-
-		if cmdStartIdx >= len(tokens) {
-			continue
-		}
-
-		// Handle I/O redirection and command arguments
-		var inFile, outFile string
-		newArgs := []string{}
-		singleQuotedArgs := make(map[int]bool)
-		doubleQuotedArgs := make(map[int]bool)
-		argIdx := 0
-
-		for j := cmdStartIdx; j < len(tokens); j++ {
-			if tokens[j] == "<" && j+1 < len(tokens) {
-				inFile = tokens[j+1]
-				j++
-			} else if tokens[j] == ">" && j+1 < len(tokens) {
-				outFile = tokens[j+1]
-				j++
-			} else {
-				newArgs = append(newArgs, tokens[j])
-				// Track which arguments are quoted
-				if singleQuotedTokens[j] {
-					singleQuotedArgs[argIdx] = true
-				}
-				if doubleQuotedTokens[j] {
-					doubleQuotedArgs[argIdx] = true
-				}
-				argIdx++
+			if word.doubleQuoted {
+				desc.doubleQuotedArgs[idx] = true
+			}
+			if !word.quoted {
+				desc.unquotedArgs[idx] = true
 			}
 		}
+	}
 
-		if len(newArgs) == 0 {
-			continue
-		}
-
-		cmdName := CommandName(newArgs[0])
+	desc.name = CommandName(desc.arguments[0])
+	if c.words[0].group {
+		// The group is expanded when its commands run, not before.
+		desc.name = SubshellCommand
+		desc.singleQuotedArgs[0] = true
+		delete(desc.unquotedArgs, 0)
+	}
 
-		descriptions = append(descriptions, CommandDescription{
-			name:             cmdName,
-			arguments:        newArgs,
-			fileInPath:       inFile,
-			fileOutPath:      outFile,
-			isPiped:          cmdIndex < len(parts)-1, // Only set isPiped for non-last commands
-			singleQuotedArgs: singleQuotedArgs,
-			doubleQuotedArgs: doubleQuotedArgs,
-		})
+	for _, r := range c.redirects {
+		switch r.op {
+		case "<":
+			desc.fileInPath, desc.fileInQuoting = r.target.text, quotingOf(r.target)
+		case ">", ">>":
+			desc.fileOutPath, desc.fileOutQuoting = r.target.text, quotingOf(r.target)
+			desc.fileOutMode = r.mode
+			desc.appendOut = r.op == ">>"
+		case "2>", "2>>":
+			desc.fileErrPath, desc.fileErrQuoting = r.target.text, quotingOf(r.target)
+			desc.fileErrMode = r.mode
+			desc.appendErr = r.op == "2>>"
+		case "2>&1":
+			desc.errToOut = true
+		default:
+			desc.hereDoc = r.doc
+		}
 	}
+	return desc
+}
 
-	return descriptions
+// targetQuoting is how the word of a redirection target was quoted.
+type targetQuoting struct {
+	singleQuoted bool
+	unquoted     bool
+}
+
+func quotingOf(word token) targetQuoting {
+	return targetQuoting{singleQuoted: word.singleQuoted, unquoted: !word.quoted}
 }
@@ -1,6 +1,9 @@
 package shell
 
 import (
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,6 +75,58 @@ func TestInputProcessor_Parse_OutputRedirection(t *testing.T) {
 	assert.Len(t, desc.arguments, 2)
 }
 
+func TestInputProcessor_Parse_RedirectionMode(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("echo a > out mode=600 2>> err mode=0640; echo mode=600 > out 'mode=600' mode=999")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 2)
+
+	require.NotNil(t, descriptions[0].fileOutMode)
+	assert.Equal(t, fs.FileMode(0600), *descriptions[0].fileOutMode)
+	require.NotNil(t, descriptions[0].fileErrMode)
+	assert.Equal(t, fs.FileMode(0640), *descriptions[0].fileErrMode)
+	assert.Equal(t, []string{"echo", "a"}, descriptions[0].arguments)
+
+	assert.Nil(t, descriptions[1].fileOutMode)
+	assert.Equal(t, []string{"echo", "mode=600", "mode=600", "mode=999"}, descriptions[1].arguments)
+}
+
+func TestInputProcessor_Parse_Capture(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("ls | sort |> FILES && echo a|>>LOG; echo b")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 4)
+
+	assert.Nil(t, descriptions[0].capture)
+	require.NotNil(t, descriptions[1].capture)
+	assert.Equal(t, "FILES", descriptions[1].capture.name)
+	assert.False(t, descriptions[1].capture.append)
+	assert.Equal(t, chainOnSuccess, descriptions[1].next)
+	require.NotNil(t, descriptions[2].capture)
+	assert.Equal(t, "LOG", descriptions[2].capture.name)
+	assert.True(t, descriptions[2].capture.append)
+	assert.Equal(t, []string{"echo", "a"}, descriptions[2].arguments)
+	assert.Nil(t, descriptions[3].capture)
+}
+
+func TestShell_Execute_Capture(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`printf 'b\na\n\n' | sort |> OUT; echo "[$OUT]"`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	_, _, err = sh.Execute(`echo one |>> LOG; echo two |>> LOG; echo "$LOG"`)
+	require.NoError(t, err)
+
+	retCode, _, err = sh.Execute(`cat missing |> OUT || echo "failed [$OUT]"`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "[\na\nb]\none\ntwo\nfailed []\n", readShellOutput(t, stdout))
+}
+
 func TestInputProcessor_Parse_EmptyInput(t *testing.T) {
 	processor := NewInputProcessor()
 
@@ -173,3 +228,109 @@ func TestInputProcessor_Parse_SubstitutionInArgs(t *testing.T) {
 	expected := []string{"echo", `hello`}
 	assert.Equal(t, expected, desc.arguments)
 }
+
+func TestInputProcessor_Parse_StderrRedirection(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("cat missing 2> err.txt | wc 2>> wc.log; ls 2>&1")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 3)
+
+	assert.Equal(t, []string{"cat", "missing"}, descriptions[0].arguments)
+	assert.Equal(t, "err.txt", descriptions[0].fileErrPath)
+	assert.False(t, descriptions[0].appendErr)
+
+	assert.Equal(t, "wc.log", descriptions[1].fileErrPath)
+	assert.True(t, descriptions[1].appendErr)
+
+	assert.Equal(t, []string{"ls"}, descriptions[2].arguments)
+	assert.True(t, descriptions[2].errToOut)
+}
+
+func TestInputProcessor_Parse_Comments(t *testing.T) {
+	processor := NewInputProcessor()
+
+	tests := []struct {
+		line string
+		args [][]string
+	}{
+		{line: "echo hi # greeting", args: [][]string{{"echo", "hi"}}},
+		{line: "# whole line"},
+		{line: "echo a#b # c", args: [][]string{{"echo", "a#b"}}},
+		{line: `echo "# not" '# comment' $(echo "#") # yes`, args: [][]string{{"echo", "# not", "# comment", `$(echo "#")`}}},
+		{line: "true;# done", args: [][]string{{"true"}}},
+	}
+	for _, tt := range tests {
+		descriptions, err := processor.Parse(tt.line)
+		require.NoError(t, err, tt.line)
+		var args [][]string
+		for _, desc := range descriptions {
+			args = append(args, desc.arguments)
+		}
+		assert.Equal(t, tt.args, args, tt.line)
+	}
+}
+
+func TestShell_Execute_Comments(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("echo hi # greeting\n# only a comment\ncat << EOF # heredoc\n# kept\nEOF")
+	require.NoError(t, err)
+	assert.Equal(t, "hi\n# kept\n", readShellOutput(t, stdout))
+}
+
+func TestInputProcessor_Parse_LineContinuation(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("echo a \\\n  b \\\n| cat")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 2)
+	assert.Equal(t, []string{"echo", "a", "b"}, descriptions[0].arguments)
+	assert.True(t, descriptions[0].isPiped)
+
+	descriptions, err = processor.Parse("echo 'a\\'")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo", "a\\"}, descriptions[0].arguments)
+
+	_, err = processor.Parse("echo a | \\")
+	assert.ErrorIs(t, err, ErrIncompleteInput)
+	_, err = processor.Parse(`echo "it's \`)
+	assert.ErrorIs(t, err, ErrIncompleteInput)
+}
+
+func TestShell_Run_LineContinuation(t *testing.T) {
+	dir := t.TempDir()
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("echo one \\\ntwo \\\n| cat\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout))
+	assert.Equal(t, 0, sh.Run())
+	assert.Equal(t, "$ > > one two\n$ ", readShellOutput(t, stdout))
+}
+
+func TestShell_Run_SyntaxError(t *testing.T) {
+	dir := t.TempDir()
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("echo a | | b\necho >\necho ok\necho 'never closed\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+
+	sh := NewShell(WithStdin(stdin), WithStdout(stdout), WithStderr(stderr))
+	assert.Equal(t, 2, sh.Run())
+	assert.Equal(t, "$ $ $ ok\n$ > $ ", readShellOutput(t, stdout))
+	assert.Equal(t, "gocli: 1:10: empty command before `|'\n"+
+		"gocli: 1:6: missing redirection target after `>'\n"+
+		"gocli: 1:6: unexpected end of input: unclosed quote\n", readShellOutput(t, stderr))
+}
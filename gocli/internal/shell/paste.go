@@ -0,0 +1,44 @@
+package shell
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Bracketed paste escape sequences. While the mode is on, the terminal wraps
+// pasted text in pasteStart and pasteEnd.
+const (
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+	pasteStart        = "\x1b[200~"
+	pasteEnd          = "\x1b[201~"
+)
+
+// readPaste collects a bracketed paste that begins in line, reading further
+// lines until the end marker, and returns the text without the markers and
+// trailing empty lines. It reports false if the input ends inside the paste.
+func readPaste(line string, scanner *bufio.Scanner) (string, bool) {
+	text := strings.Replace(line, pasteStart, "", 1)
+	for !strings.Contains(text, pasteEnd) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		text += "\n" + scanner.Text()
+	}
+	return strings.TrimRight(strings.Replace(text, pasteEnd, "", 1), "\n"), true
+}
+
+// setBracketedPaste turns bracketed paste on or off when the shell talks to
+// a terminal. It is only on while a line is read, so commands never see the
+// markers.
+func (s *Shell) setBracketedPaste(on bool) {
+	_, inputIsTerminal := terminalWidth(s.stdin)
+	if _, isTerminal := terminalWidth(s.stdout); !isTerminal || !inputIsTerminal {
+		return
+	}
+	if on {
+		_, _ = s.stdout.WriteString(bracketedPasteOn)
+	} else {
+		_, _ = s.stdout.WriteString(bracketedPasteOff)
+	}
+}
@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPaste(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("echo b\n" + pasteEnd + "\nafter\n"))
+	text, ok := readPaste("x "+pasteStart+"echo a", scanner)
+	assert.True(t, ok)
+	assert.Equal(t, "x echo a\necho b", text)
+
+	text, ok = readPaste(pasteStart+"one line"+pasteEnd+" typed", bufio.NewScanner(strings.NewReader("")))
+	assert.True(t, ok)
+	assert.Equal(t, "one line typed", text)
+
+	_, ok = readPaste(pasteStart+"unterminated", bufio.NewScanner(strings.NewReader("more\n")))
+	assert.False(t, ok)
+}
+
+func runPasted(t *testing.T, input string, answer bool) (string, string) {
+	prevConfirm := confirm
+	defer func() {
+		confirm = prevConfirm
+	}()
+	var question string
+	confirm = func(q string) bool {
+		question = q
+		return answer
+	}
+
+	dir := t.TempDir()
+	stdin, err := os.CreateTemp(dir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString(input)
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, 0)
+	require.NoError(t, err)
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+
+	NewShell(WithStdin(stdin), WithStdout(stdout)).Run()
+	return readShellOutput(t, stdout), question
+}
+
+func TestShell_Run_MultiLinePaste(t *testing.T) {
+	input := pasteStart + "echo a\necho b\n" + pasteEnd + "\necho c\n"
+
+	output, question := runPasted(t, input, true)
+	assert.Equal(t, "gocli: run 2 pasted lines?", question)
+	assert.Equal(t, "$ a\nb\n$ c\n$ ", output)
+
+	output, _ = runPasted(t, input, false)
+	assert.Equal(t, "$ $ c\n$ ", output)
+}
+
+func TestShell_Run_SingleLinePaste(t *testing.T) {
+	output, question := runPasted(t, pasteStart+"echo a"+pasteEnd+"\n", false)
+	assert.Empty(t, question)
+	assert.Equal(t, "$ a\n$ ", output)
+}
@@ -0,0 +1,14 @@
+//go:build !(js || wasip1)
+
+package shell
+
+import "os"
+
+// pipesSupported tells whether the stages of a pipeline can run concurrently,
+// connected by pipes.
+const pipesSupported = true
+
+// newPipe connects two stages of a pipeline.
+func newPipe() (r, w *os.File, err error) {
+	return os.Pipe()
+}
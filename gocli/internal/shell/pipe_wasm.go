@@ -0,0 +1,14 @@
+//go:build js || wasip1
+
+package shell
+
+import "os"
+
+// pipesSupported is false on WebAssembly, where there are no pipes:
+// the stages of a pipeline run one after another instead.
+const pipesSupported = false
+
+// newPipe connects two stages of a pipeline through a temporary file.
+func newPipe() (r, w *os.File, err error) {
+	return newFilePipe()
+}
@@ -1,9 +1,17 @@
 package shell
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // CommandFactory creates Command instances based on CommandDescription.
@@ -15,15 +23,34 @@ type CommandFactory interface {
 // NewPipelineRunner creates a new PipelineRunner that uses the given
 // environment and command factory to execute command pipelines.
 func NewPipelineRunner(env Env, factory CommandFactory) PipelineRunner {
-	return &pipelineRunner{env: env, factory: factory}
+	return &pipelineRunner{
+		env:     env,
+		factory: factory,
+		parser:  NewInputProcessor(),
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
 }
 
 type pipelineRunner struct {
 	env     Env
 	factory CommandFactory
+	parser  InputProcessor
+	stdin   *os.File
+	stdout  *os.File
+	stderr  *os.File
+	options *shellOptions
+	// events, if set, gets the commands and pipelines the runner runs.
+	events *eventBus
+	logger *slog.Logger
+	// terminal is set for the runner of an interactive session.
+	terminal *terminalControl
+	// fsys, if set, is where redirections go instead of the OS filesystem.
+	fsys FileSystem
 }
 
-var varDollar = regexp.MustCompile(`\$(\w+)|\$\{([^}]+)\}`)
+var varDollar = regexp.MustCompile(`\$(\w+|\?)|\$\{([^}]+)\}`)
 
 func (p *pipelineRunner) expandVar(s string) string {
 	return varDollar.ReplaceAllStringFunc(s, func(match string) string {
@@ -34,6 +61,9 @@ func (p *pipelineRunner) expandVar(s string) string {
 			key = match[1:]
 		}
 
+		if status := p.exitStatus(); key == "?" && status != nil {
+			return strconv.Itoa(status.get())
+		}
 		if v, ok := p.env.Get(key); ok {
 			return v
 		}
@@ -41,29 +71,142 @@ func (p *pipelineRunner) expandVar(s string) string {
 	})
 }
 
+// exitStatus holds the status of the last pipeline a shell ran, which "$?"
+// expands to. Runners sharing a command factory share it.
+type exitStatus struct {
+	code atomic.Int64
+}
+
+func (s *exitStatus) get() int {
+	return int(s.code.Load())
+}
+
+func (s *exitStatus) set(code int) {
+	s.code.Store(int64(code))
+}
+
+// exitStatus returns the $? of the runner, or nil if its factory keeps none.
+func (p *pipelineRunner) exitStatus() *exitStatus {
+	if f, ok := p.factory.(*commandFactory); ok {
+		return f.status
+	}
+	return nil
+}
+
 // Execute implements PipelineRunner interface.
-// Processes and executes a sequence of commands in the pipeline, handling environment
-// variable substitution, I/O redirection, pipe creation, and command execution.
+// Splits the commands into pipelines at commands that are not piped into the next one
+// and runs them in order, skipping those whose && or || condition does not hold.
+// Returns the exit code of the last executed pipeline and a boolean indicating whether to exit the shell.
+func (p *pipelineRunner) Execute(commands []CommandDescription, env Env) (retCode int, exited bool) {
+	op := chainAlways
+	for start := 0; start < len(commands); {
+		end := start
+		for end < len(commands)-1 && commands[end].isPiped {
+			end++
+		}
+		pipeline := commands[start : end+1]
+		start = end + 1
+
+		if op.shouldRun(retCode) {
+			p.terminal.beginJob()
+			if capture := pipeline[len(pipeline)-1].capture; capture != nil {
+				retCode, exited = p.executeCapture(pipeline, capture, env)
+			} else {
+				retCode, exited = p.executePipeline(pipeline, env)
+			}
+			p.terminal.endJob()
+			if status := p.exitStatus(); status != nil {
+				status.set(retCode)
+			}
+			if exited {
+				return retCode, true
+			}
+		}
+		op = pipeline[len(pipeline)-1].next
+	}
+	return retCode, false
+}
+
+// executePipeline runs the commands of a single pipeline, handling environment
+// variable and command substitution, tilde and filename expansion, I/O and error redirection, pipe creation, and command execution.
 // Returns the exit code of the last command and a boolean indicating whether to exit the shell.
-func (p *pipelineRunner) Execute(pipeline []CommandDescription, env Env) (retCode int, exited bool) {
+func (p *pipelineRunner) executePipeline(pipeline []CommandDescription, env Env) (retCode int, exited bool) {
 	if len(pipeline) == 0 {
 		return 0, false
 	}
 
+	var stages []*pipeViewStage
+	pipeView := p.options != nil && p.options.isSet(OptionPipeView) && len(pipeline) > 1
+
+	pipeReads := make([]*os.File, len(pipeline))
+	pipeWrites := make([]*os.File, len(pipeline))
+
+	// The commands run concurrently, so that a stage can consume the output
+	// of the previous one while it is still being produced. Without pipes
+	// they run one after another instead.
+	var running sync.WaitGroup
+	results := make([]stageResult, len(pipeline))
+	completed := false
+	trace := startPipelineTrace(env, len(pipeline))
+	started := time.Now()
+	p.log().Debug("pipeline started", "commands", len(pipeline))
+
 	toClose := make([]*os.File, 0)
+	// outputs finish the redirections to files once the commands are done,
+	// ok tells them whether the pipeline succeeded.
+	var outputs []func(ok bool) error
 	defer func() {
+		if !completed {
+			// Nobody reads what the already started stages write any more,
+			// so they would block on a full pipe.
+			for _, r := range pipeReads {
+				if r != nil {
+					_ = r.Close()
+				}
+			}
+		}
+		running.Wait()
+		ok := completed && retCode == 0
+		for _, finish := range outputs {
+			if err := finish(ok); err != nil {
+				p.log().Warn("cannot finish output", "error", err)
+				if ok {
+					retCode = 1
+				}
+			}
+		}
+		trace.finish(retCode)
+		p.events.publish(Event{Kind: EventPipelineFinished, Status: retCode, Duration: time.Since(started)})
+		p.log().Debug("pipeline finished", "status", retCode, "duration", time.Since(started))
 		for _, f := range toClose {
 			_ = f.Close()
 		}
+		if pipeView {
+			renderPipeView(p.stderr, stages)
+		}
 	}()
 
-	pipeReads := make([]*os.File, len(pipeline))
-	pipeWrites := make([]*os.File, len(pipeline))
+	// A stage that changes the working directory runs in a copy of the
+	// environment and the directory stack, like every stage of a pipeline in
+	// other shells. The directory belongs to the whole process, so the stages
+	// then run one after another and it is restored after each of them.
+	serial := !pipesSupported
+	var startDir string
+	if len(pipeline) > 1 && changesDir(pipeline) {
+		if dir, err := os.Getwd(); err == nil {
+			serial, startDir = true, dir
+		}
+	}
+	pipe := newPipe
+	if serial {
+		pipe = newFilePipe
+	}
 
 	// Create pipes between consecutive commands in pipeline
 	for i := 0; i < len(pipeline)-1; i++ {
-		r, w, err := os.Pipe()
+		r, w, err := pipe()
 		if err != nil {
+			p.log().Error("cannot create pipe", "error", err)
 			return -1, false
 		}
 		pipeWrites[i] = w
@@ -73,17 +216,47 @@ func (p *pipelineRunner) Execute(pipeline []CommandDescription, env Env) (retCod
 
 	for i, desc := range pipeline {
 		substitutedArgs := make([]string, 0, len(desc.arguments))
+		// The quoting of the arguments is kept for builtins like defer that
+		// run them later; their indexes move as words are split.
+		singleQuoted, doubleQuoted, unquotedArgs := make(map[int]bool), make(map[int]bool), make(map[int]bool)
 		for argIndex, arg := range desc.arguments {
 			// Skip substitution only for single quoted args (like bash)
 			if desc.singleQuotedArgs != nil && desc.singleQuotedArgs[argIndex] {
-				substitutedArgs = append(substitutedArgs, arg)
+				singleQuoted[len(substitutedArgs)] = true
+				substitutedArgs = append(substitutedArgs, restoreDollars(arg))
 				continue
 			}
 
-			substituted := p.expandVar(arg)
-			substitutedArgs = append(substitutedArgs, substituted)
+			unquoted := desc.unquotedArgs[argIndex]
+			if unquoted {
+				arg = expandTilde(arg, env)
+			}
+			splitWords := desc.name != EnvAssignmentCmd && !desc.doubleQuotedArgs[argIndex]
+			words := p.expandArg(arg, env, splitWords)
+			if unquoted && desc.name != EnvAssignmentCmd {
+				words = expandGlobs(words)
+			}
+			for _, word := range words {
+				doubleQuoted[len(substitutedArgs)] = desc.doubleQuotedArgs[argIndex]
+				unquotedArgs[len(substitutedArgs)] = unquoted
+				substitutedArgs = append(substitutedArgs, word)
+			}
+		}
+		if len(substitutedArgs) == 0 {
+			// Unquoted substitutions with empty output leave nothing to run.
+			if pipeWrites[i] != nil {
+				_ = pipeWrites[i].Close()
+			}
+			continue
+		}
+		if desc.name != EnvAssignmentCmd && substitutedArgs[0] != desc.arguments[0] {
+			desc.name = CommandName(substitutedArgs[0])
 		}
 		desc.arguments = substitutedArgs
+		desc.singleQuotedArgs, desc.doubleQuotedArgs, desc.unquotedArgs = singleQuoted, doubleQuoted, unquotedArgs
+		desc.fileInPath = p.expandTarget(desc.fileInPath, desc.fileInQuoting, env)
+		desc.fileOutPath = p.expandTarget(desc.fileOutPath, desc.fileOutQuoting, env)
+		desc.fileErrPath = p.expandTarget(desc.fileErrPath, desc.fileErrQuoting, env)
 
 		if desc.name == ExitCommand {
 			isLastCommand := i == len(pipeline)-1
@@ -95,22 +268,60 @@ func (p *pipelineRunner) Execute(pipeline []CommandDescription, env Env) (retCod
 			}
 		}
 
-		cmd, err := p.factory.GetCommand(desc)
+		factory, stageEnv := p.factory, env
+		if startDir != "" && dirChangingCommands[desc.name] {
+			factory, stageEnv = isolateStage(p.factory, env)
+		}
+		cmd, err := factory.GetCommand(desc)
 		if err != nil || cmd == nil {
+			p.log().Warn("cannot create command", "args", desc.arguments, "error", err)
 			if pipeWrites[i] != nil {
 				_ = pipeWrites[i].Close()
 			}
+			if err != nil {
+				// Invalid arguments, as for usage errors of POSIX utilities.
+				p.reportError(desc, err)
+				return 2, false
+			}
 			return 127, false
 		}
+		if len(desc.assignments) > 0 {
+			cmd = &envCommand{assignments: p.expandAssignments(desc.assignments, env), inner: cmd}
+		}
 
 		var (
-			inDescriptor  = os.Stdin
-			outDescriptor = os.Stdout
+			inDescriptor  = p.stdin
+			outDescriptor = p.stdout
+			errDescriptor = p.stderr
 		)
 
 		if desc.fileInPath != "" {
-			file, err := os.Open(desc.fileInPath)
+			file, err := inputFS(p.fileSystem(), p.options).Open(desc.fileInPath)
+			if err == nil {
+				inDescriptor, err = inputFile(file)
+			}
 			if err != nil {
+				p.log().Warn("cannot open input", "path", desc.fileInPath, "error", err)
+				p.reportRedirectError(desc.fileInPath, err)
+				if pipeWrites[i] != nil {
+					_ = pipeWrites[i].Close()
+				}
+				return 1, false
+			}
+			toClose = append(toClose, inDescriptor)
+		} else if pipeReads[i] != nil {
+			inDescriptor = pipeReads[i]
+		}
+		if desc.hereDoc != nil {
+			text := desc.hereDoc.text
+			if desc.hereDoc.expand {
+				text = strings.Join(p.expandArg(text, env, false), "")
+			} else {
+				text = restoreDollars(text)
+			}
+			file, err := hereDocInput(text)
+			if err != nil {
+				p.log().Error("cannot create here-document input", "error", err)
 				if pipeWrites[i] != nil {
 					_ = pipeWrites[i].Close()
 				}
@@ -118,41 +329,258 @@ func (p *pipelineRunner) Execute(pipeline []CommandDescription, env Env) (retCod
 			}
 			inDescriptor = file
 			toClose = append(toClose, file)
-		} else if pipeReads[i] != nil {
-			inDescriptor = pipeReads[i]
 		}
 
 		if desc.fileOutPath != "" {
-			file, err := os.Create(desc.fileOutPath)
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if desc.appendOut {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			file, err := p.openOutput(desc.fileOutPath, flags, desc.fileOutMode, &outputs)
 			if err != nil {
+				p.log().Warn("cannot open output", "path", desc.fileOutPath, "error", err)
+				p.reportRedirectError(desc.fileOutPath, err)
 				if pipeWrites[i] != nil {
 					_ = pipeWrites[i].Close()
 				}
-				return -1, false
+				return 1, false
 			}
 			outDescriptor = file
-			toClose = append(toClose, file)
 		} else if pipeWrites[i] != nil {
 			outDescriptor = pipeWrites[i]
 		}
 
-		code, shouldExit := cmd.Execute(inDescriptor, outDescriptor, env)
+		if desc.fileErrPath != "" {
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if desc.appendErr {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			file, err := p.openOutput(desc.fileErrPath, flags, desc.fileErrMode, &outputs)
+			if err != nil {
+				p.log().Warn("cannot open error output", "path", desc.fileErrPath, "error", err)
+				p.reportRedirectError(desc.fileErrPath, err)
+				if pipeWrites[i] != nil {
+					_ = pipeWrites[i].Close()
+				}
+				return 1, false
+			}
+			errDescriptor = file
+		}
+		if desc.errToOut {
+			errDescriptor = outDescriptor
+		}
 
-		if pipeWrites[i] != nil && outDescriptor == pipeWrites[i] {
-			_ = pipeWrites[i].Close()
+		var stage *pipeViewStage
+		if pipeView {
+			if stage, err = tapStage(desc.arguments, outDescriptor, errDescriptor, desc.errToOut); err == nil {
+				stages = append(stages, stage)
+				outDescriptor, errDescriptor = stage.streams()
+			}
 		}
 
-		if shouldExit {
-			isLastCommand := i == len(pipeline)-1
-			if isLastCommand {
-				return code, true
+		span := trace.stage(i, desc.arguments)
+		args := desc.arguments
+		running.Add(1)
+		go func(i int, in, out, errOut *os.File, closeOut bool) {
+			defer running.Done()
+			span.begin()
+			p.events.publish(Event{Kind: EventCommandStarted, Args: args})
+			p.log().Debug("command started", "args", args)
+			commandStarted := time.Now()
+			code, shouldExit := p.runCommand(cmd, args, in, out, errOut, stageEnv)
+			p.log().Debug("command finished", "args", args, "status", code, "duration", time.Since(commandStarted))
+			p.events.publish(Event{Kind: EventCommandFinished, Args: args, Status: code, Duration: time.Since(commandStarted)})
+			span.finish(code)
+
+			if stage != nil {
+				stage.finish(code)
+			}
+			if closeOut {
+				_ = pipeWrites[i].Close()
+			}
+			if pipeReads[i] != nil {
+				// Commands like head stop reading early; the previous stage then
+				// fails to write instead of blocking on a full pipe.
+				_ = pipeReads[i].Close()
+			}
+			results[i] = stageResult{code: code, exited: shouldExit}
+		}(i, inDescriptor, outDescriptor, errDescriptor, pipeWrites[i] != nil && desc.fileOutPath == "")
+		if serial {
+			running.Wait()
+			if startDir != "" {
+				if err := os.Chdir(startDir); err != nil {
+					p.log().Warn("cannot restore working directory", "dir", startDir, "error", err)
+				}
 			}
 		}
+	}
+
+	completed = true
+	running.Wait()
+
+	// Only the last command can make the shell exit.
+	last := results[len(results)-1]
+	return last.code, last.exited
+}
+
+// dirChangingCommands are the builtins that can change the working
+// directory of the shell, directly or through the lines they run. Streaming
+// ones like each are left out, so that "tail -f log | each ..." keeps working.
+var dirChangingCommands = map[CommandName]bool{
+	CDCommand: true, MkcdCommand: true, UpCommand: true, BackCommand: true,
+	SourceCommand: true, DotCommand: true, SubshellCommand: true,
+}
 
-		if i == len(pipeline)-1 {
-			retCode = code
+// changesDir reports whether a stage of pipeline may change the working directory.
+func changesDir(pipeline []CommandDescription) bool {
+	for _, desc := range pipeline {
+		if dirChangingCommands[desc.name] {
+			return true
 		}
 	}
+	return false
+}
 
-	return retCode, false
+// isolateStage returns the factory and environment for a pipeline stage that
+// must not change the variables or the directory stack of the shell.
+func isolateStage(factory CommandFactory, env Env) (CommandFactory, Env) {
+	scope := cloneEnv(env)
+	f, ok := factory.(*commandFactory)
+	if !ok {
+		return factory, scope
+	}
+	isolated := *f
+	isolated.env = scope
+	isolated.dirs = f.dirs.clone()
+	return &isolated, scope
+}
+
+// expandAssignments expands the values of prefix assignments into NAME=VALUE
+// strings. As in an assignment on its own, values are not split into words.
+func (p *pipelineRunner) expandAssignments(assignments []CommandDescription, env Env) []string {
+	expanded := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		value := a.arguments[1]
+		if a.unquotedArgs[1] {
+			value = expandTilde(value, env)
+		}
+		value = strings.Join(p.expandArg(value, env, false), "")
+		expanded = append(expanded, a.arguments[0]+"="+value)
+	}
+	return expanded
+}
+
+// stageResult is the outcome of one command of a pipeline.
+type stageResult struct {
+	code   int
+	exited bool
+}
+
+// isRegularFile reports whether f is a regular file, as opposed to a device
+// like /dev/null, which cannot be synced.
+func isRegularFile(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// defaultOutputPerm is what files created by redirections get before the umask, as in POSIX shells.
+const defaultOutputPerm fs.FileMode = 0666
+
+// openOutput opens a redirection target on the filesystem of the runner
+// and adds what finishes the redirection to outputs. New files get the
+// permissions 0666 less the umask, unless mode is set: then the file gets
+// exactly mode, whether it is new or not. With the keep-mode option an
+// existing file keeps its permissions regardless of mode. With the
+// atomicwrite option a truncating redirection to an OS file writes to a
+// temporary file that replaces the target only if the pipeline succeeds.
+// With the syncwrites option the file is flushed to stable storage before
+// it is closed, and a failure to do so fails the pipeline.
+func (p *pipelineRunner) openOutput(path string, flag int, mode *fs.FileMode, outputs *[]func(ok bool) error) (*os.File, error) {
+	perm := defaultOutputPerm
+	if mode != nil {
+		perm = *mode
+		if p.options != nil && p.options.isSet(OptionKeepMode) {
+			if _, err := p.fileSystem().Stat(path); err == nil {
+				mode = nil
+			}
+		}
+	}
+	if flag&os.O_TRUNC != 0 && p.options != nil && p.options.isSet(OptionAtomicWrite) && p.fileSystem() == OSFileSystem {
+		file, commit, err := openAtomic(path, perm, mode)
+		if err != nil {
+			return nil, err
+		}
+		*outputs = append(*outputs, commit)
+		return file, nil
+	}
+	dst, err := p.fileSystem().OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := dst.(interface{ Chmod(fs.FileMode) error }); ok && mode != nil {
+		if err := f.Chmod(*mode); err != nil {
+			_ = dst.Close()
+			return nil, err
+		}
+	}
+	file, finish, err := outputFile(dst)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := dst.(*os.File); ok && p.options != nil && p.options.isSet(OptionSyncWrites) && isRegularFile(f) {
+		*outputs = append(*outputs, func(bool) error {
+			err := f.Sync()
+			finish()
+			return err
+		})
+		return file, nil
+	}
+	*outputs = append(*outputs, func(bool) error {
+		finish()
+		return nil
+	})
+	return file, nil
+}
+
+// expandTarget expands the word of a redirection target like an argument,
+// but without splitting it into fields or expanding globs.
+func (p *pipelineRunner) expandTarget(path string, quoting targetQuoting, env Env) string {
+	if path == "" || quoting.singleQuoted {
+		return restoreDollars(path)
+	}
+	if quoting.unquoted {
+		path = expandTilde(path, env)
+	}
+	return strings.Join(p.expandArg(path, env, false), "")
+}
+
+// reportRedirectError writes why the redirection target path could not be
+// opened to the stderr of the pipeline, the way shells do.
+func (p *pipelineRunner) reportRedirectError(path string, err error) {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	_, _ = fmt.Fprintf(p.stderr, "gocli: %s: %v\n", path, err)
+}
+
+// reportError writes why the command of desc could not be created, such as
+// a usage error, to its error output: the file of a 2> redirection, if it
+// has one, or the stderr of the pipeline.
+func (p *pipelineRunner) reportError(desc CommandDescription, err error) {
+	if desc.fileErrPath == "" {
+		_, _ = fmt.Fprintln(p.stderr, err)
+		return
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if desc.appendErr {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, openErr := p.fileSystem().OpenFile(desc.fileErrPath, flags, defaultOutputPerm)
+	if openErr != nil {
+		_, _ = fmt.Fprintln(p.stderr, err)
+		return
+	}
+	_, _ = fmt.Fprintln(file, err)
+	_ = file.Close()
 }
@@ -2,10 +2,12 @@ package shell
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -163,6 +165,35 @@ func TestPipelineRunner_Execute_ExitAtEnd(t *testing.T) {
 	assert.True(t, exited)
 }
 
+func TestPipelineRunner_Execute_StagesRunConcurrently(t *testing.T) {
+	env := NewEnv()
+	factory := NewCommandFactory(env)
+	runner := NewPipelineRunner(env, factory)
+	out := filepath.Join(t.TempDir(), "out.txt")
+
+	// seq writes more than a pipe buffer holds, so it only finishes
+	// when grep reads its output at the same time.
+	processor := NewInputProcessor()
+	descriptions, err := processor.Parse("seq 1 200000 | grep '^199999$' > " + out)
+	require.NoError(t, err)
+
+	done := make(chan int)
+	go func() {
+		retCode, _ := runner.Execute(descriptions, env)
+		done <- retCode
+	}()
+	select {
+	case retCode := <-done:
+		assert.Equal(t, 0, retCode)
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline did not finish")
+	}
+
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "199999\n", string(content))
+}
+
 func TestPipelineRunner_Execute_PipeWithFileRedirection(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "test.txt")
@@ -417,3 +448,154 @@ func TestPipelineRunner_Execute_GrepWithFlagsInPipe(t *testing.T) {
 	outputStr := strings.TrimSpace(string(output))
 	assert.Equal(t, "Line Two", outputStr)
 }
+
+func TestShell_Execute_StderrRedirection(t *testing.T) {
+	dir := t.TempDir()
+	errFile := filepath.Join(dir, "err.txt")
+
+	stderr, err := os.CreateTemp(dir, "stderr")
+	require.NoError(t, err)
+	defer func() {
+		_ = stderr.Close()
+	}()
+	stdout, err := os.CreateTemp(dir, "stdout")
+	require.NoError(t, err)
+	defer func() {
+		_ = stdout.Close()
+	}()
+	sh := NewShell(WithStdout(stdout), WithStderr(stderr))
+
+	retCode, _, err := sh.Execute("cat /nonexistent/file 2> " + errFile)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	_, _, err = sh.Execute("ls /nonexistent/dir 2>> " + errFile)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(errFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "cat: "))
+	assert.True(t, strings.HasPrefix(lines[1], "ls: "))
+
+	_, _, err = sh.Execute("sh -c 'echo out && echo err >&2' 2>&1 | grep err")
+	require.NoError(t, err)
+	assert.Equal(t, "err\n", readShellOutput(t, stdout))
+
+	_, _, err = sh.Execute("cat /nonexistent/file")
+	require.NoError(t, err)
+	assert.Equal(t, "cat: open /nonexistent/file: no such file or directory\n", readShellOutput(t, stderr))
+}
+
+func TestShell_Execute_ReportsUsageErrors(t *testing.T) {
+	dir := tempWorkDir(t)
+	stderr, err := os.CreateTemp(dir, "stderr")
+	require.NoError(t, err)
+	defer func() {
+		_ = stderr.Close()
+	}()
+	sh := NewShell(WithStderr(stderr))
+
+	retCode, _, err := sh.Execute("cp onlysource")
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+	assert.Equal(t, "cp: missing destination file operand after 'onlysource'\n", readShellOutput(t, stderr))
+
+	retCode, _, err = sh.Execute("cp onlysource 2> err.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+	content, err := os.ReadFile("err.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "cp: missing destination file operand after 'onlysource'\n", string(content))
+
+	retCode, _, err = sh.Execute("json -to yaml")
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+	assert.Equal(t, "cp: missing destination file operand after 'onlysource'\njson: flag provided but not defined: -to\n", readShellOutput(t, stderr), "the error is printed once")
+}
+
+func TestShell_Execute_LastStatus(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`echo $?; false; echo $? ${?} "$?" '$?'; echo $?`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "0\n1 1 1 $?\n0\n", readShellOutput(t, stdout))
+
+	_, _, err = sh.Execute("false")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("(echo $?) && echo $?")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("| echo")
+	require.Error(t, err)
+	_, _, err = sh.Execute("echo $?")
+	require.NoError(t, err)
+	assert.Equal(t, "0\n1 1 1 $?\n0\n1\n0\n2\n", readShellOutput(t, stdout), "$? carries over lines and syntax errors set it to 2")
+}
+
+func TestShell_Execute_RedirectionMode(t *testing.T) {
+	tempWorkDir(t)
+	sh, _ := newTestShell(t)
+	mode := func(name string) fs.FileMode {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		return info.Mode().Perm()
+	}
+
+	_, _, err := sh.Execute("echo secret > creds mode=600; echo log 2> log mode=640")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), mode("creds"))
+	assert.Equal(t, fs.FileMode(0640), mode("log"))
+
+	require.NoError(t, os.Chmod("creds", 0644))
+	_, _, err = sh.Execute("echo again > creds mode=400")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0400), mode("creds"))
+
+	require.NoError(t, os.Chmod("creds", 0644))
+	require.NoError(t, sh.SetOption(OptionKeepMode, true))
+	_, _, err = sh.Execute("echo kept > creds mode=600; echo new > other mode=600")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0644), mode("creds"))
+	assert.Equal(t, fs.FileMode(0600), mode("other"))
+	content, err := os.ReadFile("creds")
+	require.NoError(t, err)
+	assert.Equal(t, "kept\n", string(content))
+}
+
+func TestOptionSyncWrites(t *testing.T) {
+	tempWorkDir(t)
+	sh, _ := newTestShell(t)
+	require.NoError(t, sh.SetOption(OptionSyncWrites, true))
+
+	retCode, _, err := sh.Execute("echo data > out; echo more 2>> out; echo discarded > /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	content, err := os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "data\n", string(content))
+
+	require.NoError(t, sh.SetOption(OptionAtomicWrite, true))
+	retCode, _, err = sh.Execute("echo replaced > out")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	content, err = os.ReadFile("out")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced\n", string(content))
+}
+
+func TestShell_Execute_BackslashEscapesAndAppend(t *testing.T) {
+	dir := tempWorkDir(t)
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute(`X=1; echo \$X "\$X" 'a'\$X a\ b "q\"q" \\ "a\b"`)
+	require.NoError(t, err)
+	assert.Equal(t, `$X $X a$X a b q"q \ a\b`+"\n", readShellOutput(t, stdout))
+
+	retCode, _, err := sh.Execute("echo 1 > f; echo 2 >> f; echo 3 >>f")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	data, err := os.ReadFile(filepath.Join(dir, "f"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n", string(data))
+}
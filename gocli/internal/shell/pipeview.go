@@ -0,0 +1,153 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// pipeViewStderrLines is the number of trailing stderr lines shown in a stage pane.
+	pipeViewStderrLines = 3
+	// pipeViewPaneWidth is used when the width of the terminal is unknown.
+	pipeViewPaneWidth = 60
+)
+
+// stageTap stands in for a file descriptor of a pipeline stage: everything
+// written to w is copied to the original file while being counted.
+type stageTap struct {
+	w     *os.File
+	bytes int64
+	tail  *tailBuffer
+	done  chan struct{}
+}
+
+func newStageTap(dst *os.File, keepTail bool) (*stageTap, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &stageTap{w: w, done: make(chan struct{})}
+	var sink io.Writer = dst
+	if keepTail {
+		t.tail = &tailBuffer{limit: stderrTailSize}
+		sink = io.MultiWriter(dst, t.tail)
+	}
+	go func() {
+		t.bytes, _ = io.Copy(sink, r)
+		_ = r.Close()
+		close(t.done)
+	}()
+	return t, nil
+}
+
+// close closes the write end and waits until all data has reached the original file.
+func (t *stageTap) close() {
+	_ = t.w.Close()
+	<-t.done
+}
+
+// pipeViewStage collects what the pipeview option shows about one pipeline stage.
+type pipeViewStage struct {
+	command  string
+	out      *stageTap
+	errs     *stageTap
+	started  time.Time
+	elapsed  time.Duration
+	exitCode int
+}
+
+// tapStage wraps the output and error streams of a stage. When stderr is
+// redirected to stdout the stage gets no separate stderr tap.
+func tapStage(args []string, out, errOut *os.File, errToOut bool) (*pipeViewStage, error) {
+	stage := &pipeViewStage{command: strings.Join(args, " ")}
+
+	var err error
+	if stage.out, err = newStageTap(out, false); err != nil {
+		return nil, err
+	}
+	if !errToOut {
+		if stage.errs, err = newStageTap(errOut, true); err != nil {
+			stage.out.close()
+			return nil, err
+		}
+	}
+	stage.started = time.Now()
+	return stage, nil
+}
+
+func (s *pipeViewStage) streams() (out, errOut *os.File) {
+	if s.errs == nil {
+		return s.out.w, s.out.w
+	}
+	return s.out.w, s.errs.w
+}
+
+func (s *pipeViewStage) finish(exitCode int) {
+	s.elapsed = time.Since(s.started)
+	s.exitCode = exitCode
+	s.out.close()
+	if s.errs != nil {
+		s.errs.close()
+	}
+}
+
+// renderPipeView prints one pane per stage with its exit status, the amount
+// of data written and its throughput, and the last lines it wrote to stderr.
+func renderPipeView(w *os.File, stages []*pipeViewStage) {
+	width, ok := terminalWidth(w)
+	if !ok || width <= 0 {
+		width = pipeViewPaneWidth
+	}
+
+	var sb strings.Builder
+	for i, stage := range stages {
+		title := fmt.Sprintf("-- [%d] %s ", i+1, stage.command)
+		sb.WriteString(title)
+		if pad := width - len(title); pad > 0 {
+			sb.WriteString(strings.Repeat("-", pad))
+		}
+		sb.WriteByte('\n')
+
+		fmt.Fprintf(&sb, "   exit %d, %s out, %s/s, %s\n",
+			stage.exitCode, formatByteCount(float64(stage.out.bytes)),
+			formatByteCount(throughput(stage.out.bytes, stage.elapsed)), stage.elapsed.Round(time.Millisecond))
+
+		if stage.errs == nil || stage.errs.tail.String() == "" {
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(stage.errs.tail.String(), "\n"), "\n")
+		if len(lines) > pipeViewStderrLines {
+			lines = lines[len(lines)-pipeViewStderrLines:]
+		}
+		for _, line := range lines {
+			fmt.Fprintf(&sb, "   | %s\n", line)
+		}
+	}
+	_, _ = w.WriteString(sb.String())
+}
+
+func throughput(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(bytes) / elapsed.Seconds()
+}
+
+// formatByteCount formats a number of bytes with a binary unit suffix, the
+// inverse of parseHumanSize.
+func formatByteCount(n float64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	unit := -1
+	for n >= 1024 && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %ciB", n, units[unit])
+}
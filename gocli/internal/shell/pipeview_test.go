@@ -0,0 +1,64 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_Execute_PipeView(t *testing.T) {
+	_, stdout := newTestShell(t)
+	_, stderr := newTestShell(t)
+	sh := NewShell(WithStdout(stdout), WithStderr(stderr))
+
+	_, _, err := sh.Execute("set -o pipeview")
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("printf 'a\\nb\\n' | sh -c 'cat; echo oops >&2' | grep b")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "b\n", readShellOutput(t, stdout))
+
+	view := readShellOutput(t, stderr)
+	assert.Contains(t, view, "oops\n")
+	assert.Contains(t, view, "-- [1] printf a\\nb\\n ")
+	assert.Contains(t, view, "   exit 0, 4 B out")
+	assert.Contains(t, view, "-- [2] sh -c cat; echo oops >&2 ")
+	assert.Contains(t, view, "   | oops\n")
+	assert.Contains(t, view, "-- [3] grep b ")
+}
+
+func TestShell_Execute_PipeViewOff(t *testing.T) {
+	_, stdout := newTestShell(t)
+	_, stderr := newTestShell(t)
+	sh := NewShell(WithStdout(stdout), WithStderr(stderr))
+
+	_, _, err := sh.Execute("echo a | cat")
+	require.NoError(t, err)
+	assert.Equal(t, "a\n", readShellOutput(t, stdout))
+	assert.Empty(t, readShellOutput(t, stderr))
+}
+
+func TestStageTap_CountsBytes(t *testing.T) {
+	dst, err := os.CreateTemp(t.TempDir(), "dst")
+	require.NoError(t, err)
+	defer func() { _ = dst.Close() }()
+
+	tap, err := newStageTap(dst, true)
+	require.NoError(t, err)
+	_, err = tap.w.WriteString("hello\n")
+	require.NoError(t, err)
+	tap.close()
+
+	assert.Equal(t, int64(6), tap.bytes)
+	assert.Equal(t, "hello\n", tap.tail.String())
+	assert.Equal(t, "hello\n", readShellOutput(t, dst))
+}
+
+func TestFormatByteCount(t *testing.T) {
+	assert.Equal(t, "512 B", formatByteCount(512))
+	assert.Equal(t, "1.5 KiB", formatByteCount(1536))
+	assert.Equal(t, "3.0 GiB", formatByteCount(3*1024*1024*1024))
+}
@@ -0,0 +1,253 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pluginCookieKey and pluginCookieValue are set in the environment of
+	// process plugins, so that a helper started by hand can tell it is not
+	// talking to the shell.
+	pluginCookieKey   = "GOCLI_PLUGIN"
+	pluginCookieValue = "1"
+	// pluginHandshake starts the line a process plugin prints when it is run
+	// without arguments, followed by the protocol version and its commands.
+	pluginHandshake = "gocli-plugin"
+	pluginProtocol  = "1"
+	// pluginHandshakeTimeout limits how long a process plugin may take to introduce itself.
+	pluginHandshakeTimeout = 5 * time.Second
+	// pluginsDir is the directory in the config directory whose plugins LoadPlugins loads.
+	pluginsDir = "plugins"
+)
+
+// GoPluginSymbol is the variable a Go plugin (built with -buildmode=plugin)
+// exports to provide commands. It has the type
+//
+//	map[string]func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error)
+//
+// so that plugins need nothing but the standard library; the functions
+// work as those registered with RegisterFunc.
+const GoPluginSymbol = "Commands"
+
+// LoadPlugin adds the commands of the plugin at path. A file ending in ".so"
+// is opened as a Go plugin, anything else is run as a process plugin:
+// with GOCLI_PLUGIN=1 in its environment and no arguments it must print
+// "gocli-plugin 1 NAME..." and exit, and every call of one of the NAMEs
+// then runs it again with NAME and the arguments, the standard streams
+// and the exit status being those of the command. Plugins cannot replace builtins.
+func (s *Shell) LoadPlugin(path string) error {
+	return s.factory.plugins.load(path, s.factory.funcs)
+}
+
+// LoadPlugins loads every plugin in the plugins directory of the gocli
+// config directory. A plugin that fails to load does not stop the others.
+func (s *Shell) LoadPlugins() error {
+	base, err := configDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, pluginsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		errs = append(errs, s.LoadPlugin(filepath.Join(base, pluginsDir, entry.Name())))
+	}
+	return errors.Join(errs...)
+}
+
+// pluginTable remembers which commands every loaded plugin added.
+type pluginTable struct {
+	mu      sync.Mutex
+	plugins map[string][]string
+}
+
+func newPluginTable() *pluginTable {
+	return &pluginTable{plugins: make(map[string][]string)}
+}
+
+func (t *pluginTable) load(path string, funcs *funcRegistry) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	var commands map[string]funcBody
+	if strings.HasSuffix(path, ".so") {
+		commands, err = openGoPlugin(path)
+	} else {
+		commands, err = openProcessPlugin(path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		if builtins[CommandName(name)] {
+			return fmt.Errorf("%s: %s is a shell builtin", path, name)
+		}
+		if !isAliasName(name) {
+			return fmt.Errorf("%s: invalid command name %q", path, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		funcs.set(name, commands[name])
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.plugins[path] = names
+	return nil
+}
+
+// list returns the paths of the loaded plugins, sorted, with their commands.
+func (t *pluginTable) list() ([]string, map[string][]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	paths := make([]string, 0, len(t.plugins))
+	plugins := make(map[string][]string, len(t.plugins))
+	for path, names := range t.plugins {
+		paths = append(paths, path)
+		plugins[path] = names
+	}
+	sort.Strings(paths)
+	return paths, plugins
+}
+
+func openGoPlugin(path string) (map[string]funcBody, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup(GoPluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return goPluginCommands(symbol)
+}
+
+// goPluginCommands converts the GoPluginSymbol variable of a plugin, which
+// Lookup returns as a pointer, into command bodies.
+func goPluginCommands(symbol any) (map[string]funcBody, error) {
+	funcs, ok := symbol.(*map[string]func(context.Context, []string, io.Reader, io.Writer) (int, error))
+	if !ok || funcs == nil {
+		return nil, fmt.Errorf("%s has type %T, want map[string]func(context.Context, []string, io.Reader, io.Writer) (int, error)", GoPluginSymbol, symbol)
+	}
+	commands := make(map[string]funcBody, len(*funcs))
+	for name, fn := range *funcs {
+		commands[name] = func(ctx context.Context, args []string, stdin io.Reader, stdout, _ io.Writer, _ Env) (int, error) {
+			return fn(ctx, args, stdin, stdout)
+		}
+	}
+	return commands, nil
+}
+
+// openProcessPlugin runs the plugin for the handshake and returns its commands.
+func openProcessPlugin(path string) (map[string]funcBody, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginHandshakeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), pluginCookieKey+"="+pluginCookieValue)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+
+	line, _, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != pluginHandshake {
+		return nil, fmt.Errorf("handshake failed: got %q, want %q", line, pluginHandshake+" "+pluginProtocol+" NAME...")
+	}
+	if fields[1] != pluginProtocol {
+		return nil, fmt.Errorf("unsupported protocol version %s, want %s", fields[1], pluginProtocol)
+	}
+
+	commands := make(map[string]funcBody, len(fields)-2)
+	for _, name := range fields[2:] {
+		commands[name] = processPluginBody(path, name)
+	}
+	return commands, nil
+}
+
+// processPluginBody runs the process plugin at path for the command name.
+// The process gets the exported variables of the shell.
+func processPluginBody(path, name string) funcBody {
+	return func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, env Env) (int, error) {
+		cmd := exec.CommandContext(ctx, path, append([]string{name}, args...)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+		for k, v := range env.Exported() {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+		cmd.Env = append(cmd.Env, pluginCookieKey+"="+pluginCookieValue)
+
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, err
+	}
+}
+
+// pluginCommand loads plugins, or lists the loaded ones and their commands.
+type pluginCommand struct {
+	plugins *pluginTable
+	funcs   *funcRegistry
+	paths   []string
+}
+
+func parsePluginCommand(d CommandDescription, plugins *pluginTable, funcs *funcRegistry) (Command, error) {
+	args := d.arguments[1:]
+	switch {
+	case len(args) == 0:
+		return &pluginCommand{plugins: plugins, funcs: funcs}, nil
+	case args[0] == "load" && len(args) > 1:
+		return &pluginCommand{plugins: plugins, funcs: funcs, paths: args[1:]}, nil
+	default:
+		return nil, fmt.Errorf("plugin: usage: plugin [load PATH...]")
+	}
+}
+
+// Execute returns 1 if any of the plugins failed to load.
+func (p *pluginCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if len(p.paths) == 0 {
+		paths, plugins := p.plugins.list()
+		w := bufio.NewWriter(out)
+		for _, path := range paths {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", path, strings.Join(plugins[path], " "))
+		}
+		_ = w.Flush()
+		return 0, false
+	}
+
+	for _, path := range p.paths {
+		if err := p.plugins.load(path, p.funcs); err != nil {
+			_, _ = fmt.Fprintf(errOut, "plugin: %v\n", err)
+			retCode = 1
+		}
+	}
+	return retCode, false
+}
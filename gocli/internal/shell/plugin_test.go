@@ -0,0 +1,106 @@
+package shell
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPluginScript = `#!/bin/sh
+[ "$GOCLI_PLUGIN" = 1 ] || { echo "run me from gocli" >&2; exit 1; }
+if [ $# -eq 0 ]; then echo "gocli-plugin 1 greet shout"; exit 0; fi
+cmd=$1; shift
+case $cmd in
+greet) echo "hello $* from $GREETER" ;;
+shout) tr a-z A-Z; exit 3 ;;
+esac
+`
+
+func writeTestPlugin(t *testing.T, dir, script string) string {
+	path := filepath.Join(dir, "helper")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestShell_LoadPlugin_Process(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	path := writeTestPlugin(t, t.TempDir(), testPluginScript)
+
+	require.NoError(t, sh.LoadPlugin(path))
+
+	retCode, _, err := sh.Execute("GREETER=me greet you; echo quiet | shout")
+	require.NoError(t, err)
+	assert.Equal(t, 3, retCode)
+	assert.Equal(t, "hello you from me\nQUIET\n", readShellOutput(t, stdout))
+}
+
+func TestPluginCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	path := writeTestPlugin(t, t.TempDir(), testPluginScript)
+
+	retCode, _, err := sh.Execute("plugin load " + path + "; plugin; which greet")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, path+": greet shout\ngreet: function\n", readShellOutput(t, stdout))
+}
+
+func TestShell_LoadPlugin_Invalid(t *testing.T) {
+	sh, _ := newTestShell(t)
+	dir := t.TempDir()
+
+	for _, script := range []string{
+		"#!/bin/sh\necho hello\n",
+		"#!/bin/sh\necho gocli-plugin 2 greet\n",
+		"#!/bin/sh\necho gocli-plugin 1 cat\n",
+		"#!/bin/sh\nexit 1\n",
+	} {
+		err := sh.LoadPlugin(writeTestPlugin(t, dir, script))
+		assert.Error(t, err, script)
+	}
+	assert.Error(t, sh.LoadPlugin(filepath.Join(dir, "missing.so")))
+}
+
+func TestShell_LoadPlugins(t *testing.T) {
+	isolateConfig(t)
+	sh, stdout := newTestShell(t)
+	require.NoError(t, sh.LoadPlugins())
+
+	base, err := configDir()
+	require.NoError(t, err)
+	dir := filepath.Join(base, pluginsDir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	writeTestPlugin(t, dir, testPluginScript)
+	require.NoError(t, sh.LoadPlugins())
+
+	retCode, _, err := sh.Execute("greet all")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "hello all from \n", readShellOutput(t, stdout))
+}
+
+func TestGoPluginCommands(t *testing.T) {
+	exported := map[string]func(context.Context, []string, io.Reader, io.Writer) (int, error){
+		"join": func(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+			_, err := io.WriteString(stdout, strings.Join(args, "+"))
+			return 0, err
+		},
+	}
+
+	commands, err := goPluginCommands(&exported)
+	require.NoError(t, err)
+	require.Contains(t, commands, "join")
+	var out strings.Builder
+	code, err := commands["join"](context.Background(), []string{"a", "b"}, nil, &out, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a+b", out.String())
+
+	_, err = goPluginCommands(&map[string]func(){})
+	assert.Error(t, err)
+}
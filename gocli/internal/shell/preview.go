@@ -0,0 +1,241 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// previewLines is how many lines of output a preview shows by default.
+	previewLines = 10
+	// previewSample is how many lines of the first stage's output the
+	// following stages are previewed against by default.
+	previewSample = 1000
+)
+
+// previewSafeCommands are the commands a preview may run: they only read
+// their input and files and write to their standard output.
+var previewSafeCommands = map[CommandName]bool{
+	CatCommand: true, EchoCommand: true, WCCommand: true, GrepCommand: true,
+	SortCommand: true, HeadCommand: true, CutCommand: true, SedCommand: true,
+	LsCommand: true, TreeCommand: true, CmpCommand: true, PWDCommand: true,
+	WhichCommand: true, WhereCommand: true, SelectCommand: true, GroupByCommand: true,
+	BasenameCommand: true, DirnameCommand: true, DateCommand: true, JSONCommand: true,
+	YAMLCommand: true, TOMLCommand: true, SeqCommand: true,
+	"tr": true, "rev": true, "tac": true, "nl": true, "fold": true, "column": true, "jq": true,
+}
+
+// previewCommand builds a pipeline stage by stage. After every stage it
+// shows what the pipeline typed so far prints for a sample of the output of
+// its first stage, so that a filter can be tried out before the whole input
+// goes through it. An empty line runs the finished pipeline.
+type previewCommand struct {
+	lines   int
+	sample  int
+	factory *commandFactory
+}
+
+func parsePreviewCommand(d CommandDescription, factory *commandFactory) (Command, error) {
+	fs := newFlagSet("preview")
+	lines := fs.Int("n", previewLines, "show `N` lines of the preview")
+	sample := fs.Int("s", previewSample, "preview against the first `N` lines of the first stage")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("preview: %w", err)
+	}
+	if fs.NArg() > 0 || *lines < 1 || *sample < 1 {
+		return nil, fmt.Errorf("preview: usage: preview [-n N] [-s N]")
+	}
+	return &previewCommand{lines: *lines, sample: *sample, factory: factory}, nil
+}
+
+// Execute reads stages from in until an empty line, then runs the pipeline
+// in the shell and records it in the history. It returns the status of the
+// pipeline, 0 if no stage was given and 1 if the input ends first.
+// A stage that is not read-only, see previewSafeCommands, or has
+// redirections or substitutions is added without a preview, as are all that
+// follow it.
+func (c *previewCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	parser := &inputProcessor{aliases: c.factory.aliases}
+	reader := bufio.NewReader(in)
+	var stages []string
+	var sample *os.File
+	defer func() {
+		if sample != nil {
+			_ = sample.Close()
+		}
+	}()
+
+	for {
+		if len(stages) == 0 {
+			_, _ = fmt.Fprint(out, "pipe> ")
+		} else {
+			_, _ = fmt.Fprint(out, "    | ")
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			_, _ = fmt.Fprintln(out)
+			return 1, false
+		}
+
+		stage := strings.TrimSpace(line)
+		if stage == "" {
+			if len(stages) == 0 {
+				return 0, false
+			}
+			return c.run(strings.Join(stages, " | "), parser, in, out, errOut, env)
+		}
+		descriptions, err := parser.Parse(strings.Join(append(stages[:len(stages):len(stages)], stage), " | "))
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "preview: %v\n", err)
+			continue
+		}
+		stages = append(stages, stage)
+
+		if reason := previewUnsafe(c.factory, stages, descriptions); reason != "" {
+			_, _ = fmt.Fprintf(out, "-- no preview: %s --\n", reason)
+			continue
+		}
+		if sample == nil {
+			source := stages[0] + " | " + string(HeadCommand) + " -n " + strconv.Itoa(c.sample)
+			if sample, err = c.capture(source, parser, nil, env); err != nil {
+				_, _ = fmt.Fprintf(errOut, "preview: %v\n", err)
+				continue
+			}
+		}
+		if err := c.show(out, stages[1:], parser, sample, env); err != nil {
+			_, _ = fmt.Fprintf(errOut, "preview: %v\n", err)
+		}
+	}
+}
+
+// previewUnsafe returns why the pipeline of stages, parsed into
+// descriptions, cannot be previewed, or "" if it can.
+func previewUnsafe(factory *commandFactory, stages []string, descriptions []CommandDescription) string {
+	for _, stage := range stages {
+		if strings.Contains(stage, "$(") || strings.Contains(stage, "`") {
+			return "command substitution"
+		}
+	}
+	for _, d := range descriptions {
+		if d.fileOutPath != "" || d.fileErrPath != "" {
+			return "output redirection"
+		}
+		if !previewSafeCommands[d.name] {
+			return fmt.Sprintf("%s is not read-only", d.name)
+		}
+		cmd, err := factory.newCommand(d)
+		if errors.Is(err, errUnsupportedFlag) {
+			// The system program would run instead, with options that may write.
+			return fmt.Sprintf("%s: %v", d.name, err)
+		}
+		if sed, ok := cmd.(*sedCommand); ok && sed.inPlace {
+			return "sed -i is not read-only"
+		}
+	}
+	return ""
+}
+
+// show prints the first lines that the stages print for the sample and how
+// many lines there are in all.
+func (c *previewCommand) show(out *os.File, stages []string, parser InputProcessor, sample *os.File, env Env) error {
+	if _, err := sample.Seek(0, 0); err != nil {
+		return err
+	}
+	output := sample
+	if len(stages) > 0 {
+		var err error
+		if output, err = c.capture(strings.Join(stages, " | "), parser, sample, env); err != nil {
+			return err
+		}
+		defer func() { _ = output.Close() }()
+	}
+
+	w := bufio.NewWriter(out)
+	scanner := bufio.NewScanner(output)
+	total := 0
+	for scanner.Scan() {
+		if total < c.lines {
+			_, _ = fmt.Fprintln(w, scanner.Text())
+		}
+		total++
+	}
+	if total > c.lines {
+		_, _ = fmt.Fprintf(w, "-- %d of %d lines --\n", c.lines, total)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+// capture runs line in a copy of env with stdin as its input, or no input
+// if it is nil, and returns a file with its output and errors, positioned
+// at the start.
+func (c *previewCommand) capture(line string, parser InputProcessor, stdin *os.File, env Env) (*os.File, error) {
+	descriptions, err := parser.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+	if stdin == nil {
+		if stdin, err = previewTempFile(); err != nil {
+			return nil, err
+		}
+		defer func() { _ = stdin.Close() }()
+	}
+	output, err := previewTempFile()
+	if err != nil {
+		return nil, err
+	}
+
+	runner := &pipelineRunner{
+		env:     env,
+		factory: c.factory,
+		parser:  parser,
+		stdin:   stdin,
+		stdout:  output,
+		stderr:  output,
+		options: c.factory.options,
+		fsys:    c.factory.fsys,
+	}
+	runner.Execute(descriptions, cloneEnv(env))
+	if _, err := output.Seek(0, 0); err != nil {
+		_ = output.Close()
+		return nil, err
+	}
+	return output, nil
+}
+
+// run runs the finished pipeline like a line typed at the prompt.
+func (c *previewCommand) run(line string, parser InputProcessor, in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	c.factory.history.add(line)
+	descriptions, err := parser.Parse(line)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "preview: %v\n", err)
+		return 2, false
+	}
+	runner := &pipelineRunner{
+		env:     env,
+		factory: c.factory,
+		parser:  parser,
+		stdin:   in,
+		stdout:  out,
+		stderr:  errOut,
+		options: c.factory.options,
+		fsys:    c.factory.fsys,
+	}
+	return runner.Execute(descriptions, env)
+}
+
+// previewTempFile returns an empty read-write file that is gone once closed.
+func previewTempFile() (*os.File, error) {
+	f, err := os.CreateTemp("", "gocli-preview-")
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(f.Name())
+	return f, nil
+}
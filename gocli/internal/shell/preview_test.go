@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewCommand(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("fruits", []byte("apple\nbanana\ncherry\navocado\napricot\n"), 0644))
+	require.NoError(t, os.WriteFile("stages", []byte("cat fruits\ngrep a\nsort -r\n\n"), 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("preview -n 2 -s 4 < stages")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "pipe> apple\nbanana\n-- 2 of 4 lines --\n"+
+		"    | apple\nbanana\n-- 2 of 3 lines --\n"+
+		"    | banana\navocado\n-- 2 of 3 lines --\n"+
+		"    | banana\navocado\napricot\napple\n", readShellOutput(t, stdout))
+
+	_, lines := sh.factory.history.last(1)
+	assert.Equal(t, []string{"cat fruits | grep a | sort -r"}, lines)
+}
+
+func TestPreviewCommand_Unsafe(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("stages", []byte("echo hi\nrm -f victim\ncat\n"), 0644))
+	require.NoError(t, os.WriteFile("victim", nil, 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("preview < stages")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "pipe> hi\n"+
+		"    | -- no preview: rm is not read-only --\n"+
+		"    | -- no preview: rm is not read-only --\n"+
+		"    | \n", readShellOutput(t, stdout))
+	assert.FileExists(t, "victim")
+
+	for _, stage := range []string{"echo $(rm victim)", "echo hi > out", "sed -i d victim", "sort -o out victim"} {
+		require.NoError(t, os.WriteFile("victim", []byte("data"), 0644))
+		require.NoError(t, os.WriteFile("stages", []byte(stage+"\n"), 0644))
+		_, _, err := sh.Execute("preview < stages")
+		require.NoError(t, err)
+		content, err := os.ReadFile("victim")
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(content), stage)
+		assert.NoFileExists(t, "out")
+	}
+}
+
+func TestParsePreviewCommand_Errors(t *testing.T) {
+	for _, args := range [][]string{{"preview", "cat"}, {"preview", "-n", "0"}, {"preview", "-x"}} {
+		_, err := parsePreviewCommand(CommandDescription{name: PreviewCommand, arguments: args}, nil)
+		assert.Error(t, err, args)
+	}
+}
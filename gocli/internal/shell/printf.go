@@ -0,0 +1,290 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// printfCommand formats its arguments like printf(1). The format is reused
+// while arguments are left, missing ones count as empty strings or zero.
+type printfCommand struct {
+	format string
+	args   []string
+}
+
+func parsePrintfCommand(d CommandDescription) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("printf: usage: printf FORMAT [ARGUMENT...]")
+	}
+	return &printfCommand{format: args[0], args: args[1:]}, nil
+}
+
+// Execute returns 1 if an argument is not a valid number, which is
+// printed as 0, or the format has an invalid directive, where the output stops.
+func (p *printfCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	w := bufio.NewWriter(out)
+	f := &printfFormatter{w: w, errOut: errOut, args: p.args}
+	for {
+		start := f.next
+		if stop := f.format(p.format); stop || f.next == start || f.next >= len(f.args) {
+			break
+		}
+	}
+	_ = w.Flush()
+	if f.failed {
+		return 1, false
+	}
+	return 0, false
+}
+
+// printfFormatter writes one pass of a format, taking the arguments it needs.
+type printfFormatter struct {
+	w      io.Writer
+	errOut io.Writer
+	args   []string
+	next   int
+	failed bool
+}
+
+// arg returns the next argument, or "" when there are none left.
+func (f *printfFormatter) arg() string {
+	if f.next >= len(f.args) {
+		return ""
+	}
+	f.next++
+	return f.args[f.next-1]
+}
+
+func (f *printfFormatter) fail(format string, a ...any) {
+	_, _ = fmt.Fprintf(f.errOut, "printf: "+format+"\n", a...)
+	f.failed = true
+}
+
+// format writes format once and reports whether the output must stop
+// there, after \c in a %b argument or an invalid directive.
+func (f *printfFormatter) format(format string) (stop bool) {
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; {
+		case c == '\\':
+			text, next, _ := printfEscape(format, i, false)
+			_, _ = io.WriteString(f.w, text)
+			i = next - 1
+		case c == '%' && i+1 < len(format) && format[i+1] == '%':
+			_, _ = io.WriteString(f.w, "%")
+			i++
+		case c == '%':
+			next, stop := f.directive(format, i)
+			if stop {
+				return true
+			}
+			i = next - 1
+		default:
+			_, _ = f.w.Write([]byte{c})
+		}
+	}
+	return false
+}
+
+// directive writes the conversion starting with the "%" at format[start]
+// and returns the index after it.
+func (f *printfFormatter) directive(format string, start int) (next int, stop bool) {
+	i := start + 1
+	flags := ""
+	for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+		flags += format[i : i+1]
+		i++
+	}
+	width, i := f.number(format, i)
+	if strings.HasPrefix(width, "-") {
+		flags, width = flags+"-", width[1:]
+	}
+	precision := ""
+	if i < len(format) && format[i] == '.' {
+		precision, i = f.number(format, i+1)
+		if strings.HasPrefix(precision, "-") {
+			precision = ""
+		} else {
+			precision = "." + precision
+		}
+	}
+	if i >= len(format) {
+		f.fail("%s: missing conversion", format[start:])
+		return len(format), true
+	}
+
+	spec := "%" + flags + width + precision
+	switch verb := format[i]; verb {
+	case 's':
+		_, _ = fmt.Fprintf(f.w, spec+"s", f.arg())
+	case 'b':
+		text, stop := printfExpand(f.arg())
+		_, _ = fmt.Fprintf(f.w, spec+"s", text)
+		if stop {
+			return i + 1, true
+		}
+	case 'c':
+		arg := f.arg()
+		if arg != "" {
+			arg = arg[:1]
+		}
+		_, _ = fmt.Fprintf(f.w, "%"+flags+width+"s", arg)
+	case 'd', 'i':
+		_, _ = fmt.Fprintf(f.w, spec+"d", f.integer(f.arg()))
+	case 'u':
+		_, _ = fmt.Fprintf(f.w, spec+"d", uint64(f.integer(f.arg())))
+	case 'o', 'x', 'X':
+		_, _ = fmt.Fprintf(f.w, spec+string(verb), uint64(f.integer(f.arg())))
+	case 'g', 'G':
+		// Go prints the shortest representation by default, C six significant digits.
+		if precision == "" {
+			spec += ".6"
+		}
+		_, _ = fmt.Fprintf(f.w, spec+string(verb), f.float(f.arg()))
+	case 'e', 'E', 'f', 'F':
+		_, _ = fmt.Fprintf(f.w, spec+string(verb), f.float(f.arg()))
+	default:
+		f.fail("%%%c: invalid directive", verb)
+		return i + 1, true
+	}
+	return i + 1, false
+}
+
+// number reads a width or precision at format[i], either digits or "*" for
+// the next argument, and returns it with the index after it.
+func (f *printfFormatter) number(format string, i int) (string, int) {
+	if i < len(format) && format[i] == '*' {
+		return strconv.FormatInt(f.integer(f.arg()), 10), i + 1
+	}
+	start := i
+	for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+		i++
+	}
+	return format[start:i], i
+}
+
+// integer converts a numeric argument: decimal, octal with a leading 0,
+// hexadecimal with 0x, or a quote followed by a character, which stands
+// for the character's code.
+func (f *printfFormatter) integer(arg string) int64 {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return 0
+	}
+	if arg[0] == '\'' || arg[0] == '"' {
+		if len(arg) == 1 {
+			return 0
+		}
+		return int64([]rune(arg[1:])[0])
+	}
+	n, err := strconv.ParseInt(arg, 0, 64)
+	if err != nil {
+		f.fail("%s: invalid number", arg)
+		return 0
+	}
+	return n
+}
+
+func (f *printfFormatter) float(arg string) float64 {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return 0
+	}
+	if arg[0] == '\'' || arg[0] == '"' {
+		return float64(f.integer(arg))
+	}
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		f.fail("%s: invalid number", arg)
+		return 0
+	}
+	return n
+}
+
+// printfExpand replaces the escape sequences of a %b argument and reports
+// whether it contains \c, which ends the output.
+func printfExpand(s string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		text, next, stop := printfEscape(s, i, true)
+		if stop {
+			return sb.String(), true
+		}
+		sb.WriteString(text)
+		i = next - 1
+	}
+	return sb.String(), false
+}
+
+// printfEscape decodes the escape sequence starting with the backslash at
+// s[i] and returns its text and the index after it. Octal escapes are \NNN
+// in a format and \0NNN in a %b argument, where \c reports stop.
+// An unknown sequence stands for itself.
+func printfEscape(s string, i int, inArg bool) (text string, next int, stop bool) {
+	if i+1 >= len(s) {
+		return "\\", i + 1, false
+	}
+	switch c := s[i+1]; c {
+	case 'a':
+		return "\a", i + 2, false
+	case 'b':
+		return "\b", i + 2, false
+	case 'f':
+		return "\f", i + 2, false
+	case 'n':
+		return "\n", i + 2, false
+	case 'r':
+		return "\r", i + 2, false
+	case 't':
+		return "\t", i + 2, false
+	case 'v':
+		return "\v", i + 2, false
+	case '\\', '"', '\'':
+		return string(c), i + 2, false
+	case 'c':
+		if inArg {
+			return "", i + 2, true
+		}
+	case 'x':
+		end := i + 2
+		for end < len(s) && end < i+4 && isHexDigit(s[end]) {
+			end++
+		}
+		if end > i+2 {
+			n, _ := strconv.ParseUint(s[i+2:end], 16, 8)
+			return string([]byte{byte(n)}), end, false
+		}
+	default:
+		start := i + 1
+		if inArg {
+			if c != '0' {
+				break
+			}
+			start++
+		}
+		end := start
+		for end < len(s) && end < start+3 && s[end] >= '0' && s[end] <= '7' {
+			end++
+		}
+		if end > start || inArg {
+			n, _ := strconv.ParseUint("0"+s[start:end], 8, 16)
+			return string([]byte{byte(n)}), end, false
+		}
+	}
+	return s[i : i+2], i + 2, false
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runPrintf(t *testing.T, args ...string) (string, int) {
+	cmd, err := parsePrintfCommand(CommandDescription{name: PrintfCommand, arguments: append([]string{"printf"}, args...)})
+	require.NoError(t, err)
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer func() { _ = devNull.Close() }()
+
+	retCode, _ := cmd.Execute(nil, out, devNull, nil)
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	return string(content), retCode
+}
+
+func TestPrintfCommand_Execute(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"hello\\n"}, "hello\n"},
+		{[]string{"%s-%s\\n", "a", "b", "c"}, "a-b\nc-\n"},
+		{[]string{"%d %i %u\\n", "42", "-7", "0x10"}, "42 -7 16\n"},
+		{[]string{"[%5s|%-5s|%.2s]", "ab", "cd", "xyz"}, "[   ab|cd   |xy]"},
+		{[]string{"[%05d|%+d|% d|%-4d]", "42", "5", "5", "7"}, "[00042|+5| 5|7   ]"},
+		{[]string{"%x %X %#x %o %#o", "255", "255", "255", "8", "8"}, "ff FF 0xff 10 010"},
+		{[]string{"%.2f %8.3f %e %g %g", "3.14159", "2.5", "1234.5", "0.0001", "123456789"}, "3.14    2.500 1.234500e+03 0.0001 1.23457e+08"},
+		{[]string{"[%*d|%-*s|%.*f]", "4", "7", "3", "a", "1", "2.25"}, "[   7|a  |2.2]"},
+		{[]string{"%c%c|%d", "hello", "", "'A"}, "h|65"},
+		{[]string{"%b|%s", "a\\tb\\0101", "a\\tb"}, "a\tbA|a\\tb"},
+		{[]string{"%b%s", "stop\\chere", "never"}, "stop"},
+		{[]string{"100%% \\x41\\101\\\\ \\q"}, "100% AA\\ \\q"},
+		{[]string{"%s\\n"}, "\n"},
+		{[]string{"--", "%d\\n", "1"}, "1\n"},
+	}
+	for _, tt := range tests {
+		got, retCode := runPrintf(t, tt.args...)
+		assert.Equal(t, 0, retCode, tt.args)
+		assert.Equal(t, tt.want, got, tt.args)
+	}
+}
+
+func TestPrintfCommand_Execute_Errors(t *testing.T) {
+	got, retCode := runPrintf(t, "%d|%d\\n", "12abc", "3")
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "0|3\n", got)
+
+	got, retCode = runPrintf(t, "a%zb%s", "x")
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "a", got)
+
+	got, retCode = runPrintf(t, "a%5")
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "a", got)
+
+	_, err := parsePrintfCommand(CommandDescription{name: PrintfCommand, arguments: []string{"printf"}})
+	assert.Error(t, err)
+}
+
+func TestPrintfCommand_Shell(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`printf '%-4s|%3d\n' a 1 bb 22 | cat`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "a   |  1\nbb  | 22\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// systemProfile is sourced first by login shells.
+var systemProfile = "/etc/profile"
+
+// userProfiles are looked up in the home directory after the system profile;
+// only the first one that exists is sourced.
+var userProfiles = []string{".gocli_profile", ".profile"}
+
+// Source runs the commands of the file at path in the session, as if they
+// were typed at the prompt, but without adding them to the history.
+// Syntax errors are reported with the file name.
+func (s *Shell) Source(path string) (retCode int, exited bool, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 1, false, err
+	}
+	s.busy.Lock()
+	defer s.busy.Unlock()
+	retCode, exited, err = s.execute(string(content), false)
+	if err != nil {
+		return 2, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return retCode, exited, nil
+}
+
+// StartLogin prepares the session of a login shell: SHELL is set to the
+// gocli executable, then the system profile and the first user profile
+// that exists are sourced. Errors in the profiles are reported on stderr
+// and do not stop the session unless a profile runs exit.
+func (s *Shell) StartLogin() (retCode int, exited bool) {
+	if executable, err := os.Executable(); err == nil {
+		s.env.Set("SHELL", executable)
+		s.env.Export("SHELL")
+	}
+
+	profiles := []string{systemProfile}
+	if home := expandTilde("~", s.env); home != "~" {
+		for _, name := range userProfiles {
+			path := filepath.Join(home, name)
+			if _, err := os.Stat(path); err == nil {
+				profiles = append(profiles, path)
+				break
+			}
+		}
+	}
+
+	for _, path := range profiles {
+		s.logger.Info("sourcing profile", "path", path)
+		retCode, exited, err := s.Source(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(s.stderr, "gocli: %v\n", err)
+		}
+		if exited {
+			return retCode, true
+		}
+	}
+	return 0, false
+}
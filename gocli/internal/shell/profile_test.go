@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProfiles(t *testing.T, system string, user map[string]string) string {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	require.NoError(t, os.Mkdir(home, 0755))
+	t.Setenv("HOME", home)
+
+	oldSystem := systemProfile
+	systemProfile = filepath.Join(dir, "profile")
+	t.Cleanup(func() { systemProfile = oldSystem })
+	if system != "" {
+		require.NoError(t, os.WriteFile(systemProfile, []byte(system), 0644))
+	}
+	for name, content := range user {
+		require.NoError(t, os.WriteFile(filepath.Join(home, name), []byte(content), 0644))
+	}
+	return home
+}
+
+func TestShell_StartLogin(t *testing.T) {
+	setupProfiles(t, "ORDER=system\n", map[string]string{
+		".gocli_profile": "ORDER=${ORDER},gocli\n",
+		".profile":       "ORDER=${ORDER},profile\n",
+	})
+	sh, _ := newTestShell(t)
+
+	retCode, exited := sh.StartLogin()
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	order, _ := sh.env.Get("ORDER")
+	assert.Equal(t, "system,gocli", order)
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	shell, _ := sh.env.Get("SHELL")
+	assert.Equal(t, executable, shell)
+}
+
+func TestShell_StartLogin_FallbackAndErrors(t *testing.T) {
+	setupProfiles(t, "", map[string]string{
+		".profile": "echo 'unclosed\n",
+	})
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+	sh := NewShell(WithStdout(stderr), WithStderr(stderr))
+
+	_, exited := sh.StartLogin()
+	assert.False(t, exited)
+	output, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(output), ".profile: 1:6: unexpected end of input: unclosed quote")
+}
+
+func TestShell_StartLogin_Exit(t *testing.T) {
+	setupProfiles(t, "exit\n", nil)
+	sh, _ := newTestShell(t)
+
+	_, exited := sh.StartLogin()
+	assert.True(t, exited)
+}
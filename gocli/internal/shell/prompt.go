@@ -0,0 +1,284 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPrompt is shown when PS1 is not set.
+	defaultPrompt = "$ "
+	// promptThemeRef marks a PS1 value that names a theme, like "@powerline".
+	promptThemeRef = "@"
+	themesFile     = "themes.json"
+)
+
+// Names of the prompt segments. In a PS1 template they are written in
+// braces, e.g. "{cwd} $ ".
+const (
+	segmentCwd    = "cwd"
+	segmentGit    = "git"
+	segmentStatus = "status"
+	segmentTime   = "time"
+)
+
+// promptTheme joins the non-empty segments with Separator and ends the
+// prompt with Suffix.
+type promptTheme struct {
+	Segments  []string `json:"segments"`
+	Separator string   `json:"separator"`
+	Suffix    string   `json:"suffix"`
+}
+
+var builtinThemes = map[string]promptTheme{
+	"minimal": {
+		Segments: []string{segmentCwd},
+		Suffix:   " $ ",
+	},
+	"powerline": {
+		Segments:  []string{segmentCwd, segmentGit},
+		Separator: " ❯ ",
+		Suffix:    " ❯ ",
+	},
+	"informative": {
+		Segments:  []string{segmentTime, segmentStatus, segmentCwd, segmentGit},
+		Separator: " | ",
+		Suffix:    "\n$ ",
+	},
+}
+
+// loadThemes returns the built-in themes together with the ones defined in
+// themes.json in the config directory, which take precedence.
+func loadThemes() (map[string]promptTheme, error) {
+	themes := make(map[string]promptTheme, len(builtinThemes))
+	for name, theme := range builtinThemes {
+		themes[name] = theme
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, themesFile))
+	if os.IsNotExist(err) {
+		return themes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var custom map[string]promptTheme
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, err
+	}
+	for name, theme := range custom {
+		themes[name] = theme
+	}
+	return themes, nil
+}
+
+// segmentSource renders segments defined outside the shell, like those of
+// extensions, and reports whether it knows the name.
+type segmentSource func(name string) (string, bool)
+
+// promptSegments holds the values segments are rendered from.
+type promptSegments struct {
+	status int
+	now    time.Time
+	// custom renders the segments that are not built in; it may be nil.
+	custom segmentSource
+}
+
+func (p promptSegments) value(name string) string {
+	if value, ok := p.lookupCustom(name); ok {
+		return value
+	}
+	switch name {
+	case segmentCwd:
+		return currentDirForPrompt()
+	case segmentGit:
+		return gitBranch()
+	case segmentStatus:
+		return strconv.Itoa(p.status)
+	case segmentTime:
+		return p.now.Format(time.TimeOnly)
+	}
+	return ""
+}
+
+func (p promptSegments) lookupCustom(name string) (string, bool) {
+	if p.custom == nil {
+		return "", false
+	}
+	return p.custom(name)
+}
+
+func (p promptSegments) renderTheme(theme promptTheme) string {
+	values := make([]string, 0, len(theme.Segments))
+	for _, name := range theme.Segments {
+		if value := p.value(name); value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, theme.Separator) + theme.Suffix
+}
+
+// renderTemplate replaces "{segment}" placeholders in template. Unknown
+// names are left as they are.
+func (p promptSegments) renderTemplate(template string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		name := template[start+1 : end]
+		sb.WriteString(template[:start])
+		if value, ok := p.lookupCustom(name); ok {
+			sb.WriteString(value)
+		} else {
+			switch name {
+			case segmentCwd, segmentGit, segmentStatus, segmentTime:
+				sb.WriteString(p.value(name))
+			default:
+				sb.WriteString(template[start : end+1])
+			}
+		}
+		template = template[end+1:]
+	}
+	sb.WriteString(template)
+	return sb.String()
+}
+
+// renderPrompt builds the prompt from the PS1 variable: "@NAME" selects a
+// theme, anything else is a template. Without PS1, or if the theme cannot be
+// found, the default prompt is used. custom renders the segments of extensions.
+func renderPrompt(env Env, status int, custom segmentSource) string {
+	ps1, _ := env.Get("PS1")
+	if prompt, ok := expandPrompt(ps1, status, custom); ok {
+		return prompt
+	}
+	return defaultPrompt
+}
+
+// renderRightPrompt builds the right-hand side prompt from RPROMPT or, if it
+// is not set, RPS1. Both accept the same values as PS1.
+func renderRightPrompt(env Env, status int, custom segmentSource) string {
+	rps1, ok := env.Get("RPROMPT")
+	if !ok || rps1 == "" {
+		rps1, _ = env.Get("RPS1")
+	}
+	prompt, _ := expandPrompt(rps1, status, custom)
+	return prompt
+}
+
+func expandPrompt(value string, status int, custom segmentSource) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+
+	segments := promptSegments{status: status, now: time.Now(), custom: custom}
+	if !strings.HasPrefix(value, promptThemeRef) {
+		return segments.renderTemplate(value), true
+	}
+
+	themes, err := loadThemes()
+	if err != nil {
+		return "", false
+	}
+	theme, ok := themes[strings.TrimPrefix(value, promptThemeRef)]
+	if !ok {
+		return "", false
+	}
+	return segments.renderTheme(theme), true
+}
+
+// withRightPrompt prepends to prompt the escape sequences that draw right
+// flush against the right edge of a terminal width columns wide, on the line
+// where input starts, and return the cursor to the start of that line.
+// When the two prompts do not fit on the line the right one is dropped.
+func withRightPrompt(prompt, right string, width int) string {
+	right = strings.TrimRight(right, "\n")
+	if right == "" || strings.Contains(right, "\n") {
+		return prompt
+	}
+
+	head, last := "", prompt
+	if i := strings.LastIndexByte(prompt, '\n'); i >= 0 {
+		head, last = prompt[:i+1], prompt[i+1:]
+	}
+
+	column := width - displayWidth(right) + 1
+	if column <= displayWidth(last)+1 {
+		return prompt
+	}
+	return fmt.Sprintf("%s\x1b[%dG%s\r%s", head, column, right, last)
+}
+
+// currentDirForPrompt returns the working directory with the home directory
+// shortened to "~".
+func currentDirForPrompt() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rel, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rel
+	}
+	return dir
+}
+
+// gitBranch returns the branch checked out in the git repository containing
+// the working directory, the short commit hash for a detached HEAD, or ""
+// outside a repository. It reads .git/HEAD directly instead of running git.
+func gitBranch() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		head, err := os.ReadFile(filepath.Join(dir, ".git", "HEAD"))
+		if err == nil {
+			ref := strings.TrimSpace(string(head))
+			if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+				return branch
+			}
+			return ref[:min(len(ref), 7)]
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// transientPrompt returns the escape sequences that move the cursor back over
+// prompt and the line typed after it, which the terminal has already echoed
+// and ended with a newline, clear them and print line after the default prompt.
+func transientPrompt(prompt, line string, width int) string {
+	rows := 0
+	for _, text := range strings.Split(prompt+line, "\n") {
+		rows += max(1, (displayWidth(text)+width-1)/max(width, 1))
+	}
+	return fmt.Sprintf("\x1b[%dF\x1b[J%s%s\n", rows, defaultPrompt, line)
+}
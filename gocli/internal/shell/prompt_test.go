@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPrompt_Default(t *testing.T) {
+	assert.Equal(t, "$ ", renderPrompt(NewEnvFromMap(nil), 0, nil))
+	assert.Equal(t, "$ ", renderPrompt(NewEnvFromMap(map[string]string{"PS1": "@nosuchtheme"}), 0, nil))
+}
+
+func TestRenderPrompt_Template(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+
+	env := NewEnvFromMap(map[string]string{"PS1": "{cwd} [{status}] {unknown} {git}> "})
+	assert.Equal(t, root+" [2] {unknown} > ", renderPrompt(env, 2, nil))
+}
+
+func TestRenderPrompt_HomeAndGit(t *testing.T) {
+	root := tempWorkDir(t)
+	t.Setenv("HOME", root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("ref: refs/heads/feature\n"), 0644))
+	require.NoError(t, os.Mkdir("src", 0755))
+	t.Chdir("src")
+
+	env := NewEnvFromMap(map[string]string{"PS1": "@powerline"})
+	assert.Equal(t, filepath.Join("~", "src")+" ❯ feature ❯ ", renderPrompt(env, 0, nil))
+}
+
+func TestShell_Execute_Theme(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("theme")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "  informative\n  minimal\n  powerline\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("theme minimal")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	ps1, _ := sh.env.Get("PS1")
+	assert.Equal(t, "@minimal", ps1)
+	assert.Equal(t, root+" $ ", renderPrompt(sh.env, 0, nil))
+
+	retCode, _, err = sh.Execute("theme nosuchtheme")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestShell_Execute_ThemeCustomAndPreview(t *testing.T) {
+	isolateConfig(t)
+	tempWorkDir(t)
+	dir, err := configDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, themesFile),
+		[]byte(`{"mine": {"segments": ["status", "git"], "separator": "/", "suffix": "% "}}`), 0644))
+
+	sh, stdout := newTestShell(t)
+	_, _, err = sh.Execute("theme mine; theme; theme -p mine")
+	require.NoError(t, err)
+	assert.Equal(t, "  informative\n* mine\n  minimal\n  powerline\n0% \n", readShellOutput(t, stdout))
+}
+
+func TestRenderRightPrompt(t *testing.T) {
+	assert.Empty(t, renderRightPrompt(NewEnvFromMap(nil), 0, nil))
+	assert.Equal(t, "[1]", renderRightPrompt(NewEnvFromMap(map[string]string{"RPS1": "[{status}]"}), 1, nil))
+	assert.Equal(t, "1", renderRightPrompt(NewEnvFromMap(map[string]string{
+		"RPS1":    "[{status}]",
+		"RPROMPT": "{status}",
+	}), 1, nil))
+}
+
+func TestWithRightPrompt(t *testing.T) {
+	assert.Equal(t, "\x1b[18G[0]\r$ ", withRightPrompt("$ ", "[0]", 20))
+	assert.Equal(t, "12:00 | ~\n\x1b[18G[0]\r$ ", withRightPrompt("12:00 | ~\n$ ", "[0]", 20))
+	assert.Equal(t, "$ ", withRightPrompt("$ ", "", 20))
+	assert.Equal(t, "long prompt $ ", withRightPrompt("long prompt $ ", "right side", 20))
+}
+
+func TestTransientPrompt(t *testing.T) {
+	assert.Equal(t, "\x1b[1F\x1b[J$ ls\n", transientPrompt("~ ❯ main ❯ ", "ls", 80))
+	assert.Equal(t, "\x1b[2F\x1b[J$ ls\n", transientPrompt("12:00 | 0 | ~\n$ ", "ls", 80))
+	assert.Equal(t, "\x1b[3F\x1b[J$ echo 0123456789\n", transientPrompt("~ $ ", "echo 0123456789", 8))
+}
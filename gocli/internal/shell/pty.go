@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Size of a pseudo-terminal opened when the shell itself is not attached to one.
+const (
+	defaultPTYCols = 80
+	defaultPTYRows = 24
+)
+
+// interactivePrograms are run on a pseudo-terminal even without the pty
+// option, since they change their behavior or refuse to work when their
+// output is not a terminal.
+var interactivePrograms = map[string]bool{
+	"htop":    true,
+	"less":    true,
+	"man":     true,
+	"more":    true,
+	"mysql":   true,
+	"nano":    true,
+	"node":    true,
+	"nvim":    true,
+	"psql":    true,
+	"python":  true,
+	"python3": true,
+	"sqlite3": true,
+	"ssh":     true,
+	"top":     true,
+	"vi":      true,
+	"vim":     true,
+	"watch":   true,
+}
+
+func isInteractiveProgram(name string) bool {
+	return interactivePrograms[filepath.Base(name)]
+}
+
+// attachPTY makes cmd write its standard output to a new pseudo-terminal and
+// copies what appears on it to out. The terminal gets the size of sizeFrom,
+// and follows it when it is resized. The returned function must be called
+// after the command has finished: it waits until all output reaches out.
+func attachPTY(cmd *exec.Cmd, out, sizeFrom *os.File) (func(), error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	cols, rows, ok := terminalSize(sizeFrom)
+	if !ok {
+		cols, rows = defaultPTYCols, defaultPTYRows
+	}
+	_ = setPTYSize(master, cols, rows)
+	stopResize := followResize(master, sizeFrom)
+
+	cmd.Stdout = slave
+	copied := make(chan struct{})
+	go func() {
+		// Reading the master fails with EIO once the slave is closed everywhere,
+		// which is the end of the output.
+		_, _ = io.Copy(out, master)
+		close(copied)
+	}()
+
+	return func() {
+		stopResize()
+		_ = slave.Close()
+		<-copied
+		_ = master.Close()
+	}, nil
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requirePTY(t *testing.T) {
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	_ = slave.Close()
+	_ = master.Close()
+}
+
+func TestShell_Execute_PTY(t *testing.T) {
+	requirePTY(t)
+	sh, stdout := newTestShell(t)
+
+	check := `sh -c 'test -t 1 && echo tty || echo notty'`
+	_, _, err := sh.Execute(check)
+	require.NoError(t, err)
+
+	require.NoError(t, sh.SetOption(OptionPTY, true))
+	_, _, err = sh.Execute(check + " | cat")
+	require.NoError(t, err)
+	assert.Equal(t, "notty\ntty\n", readShellOutput(t, stdout))
+}
+
+func TestOpenPTY_Size(t *testing.T) {
+	requirePTY(t)
+	master, slave, err := openPTY()
+	require.NoError(t, err)
+	defer func() {
+		_ = slave.Close()
+		_ = master.Close()
+	}()
+
+	require.NoError(t, setPTYSize(master, 100, 40))
+	cols, rows, ok := terminalSize(slave)
+	assert.True(t, ok)
+	assert.Equal(t, 100, cols)
+	assert.Equal(t, 40, rows)
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os"
+)
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("pseudo-terminals are only supported on Linux")
+}
+
+func setPTYSize(master *os.File, cols, rows int) error {
+	return nil
+}
+
+func followResize(master, source *os.File) func() {
+	return func() {}
+}
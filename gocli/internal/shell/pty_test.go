@@ -0,0 +1,13 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsInteractiveProgram(t *testing.T) {
+	assert.True(t, isInteractiveProgram("top"))
+	assert.True(t, isInteractiveProgram("/usr/bin/less"))
+	assert.False(t, isInteractiveProgram("cat"))
+}
@@ -0,0 +1,407 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Records are JSON objects, one per line, that builtins like "ls --records"
+// write instead of text, so that where, select, group-by and "sort --by" can
+// work on their fields by name. A field name may be a dotted path into nested
+// objects.
+
+// parseRecord decodes a line of JSON lines input into a record.
+func parseRecord(line string) (map[string]any, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil || record == nil {
+		return nil, fmt.Errorf("not a JSON object: %.40q", line)
+	}
+	return record, nil
+}
+
+// recordField returns the value of a field, following dots into nested objects.
+func recordField(record map[string]any, name string) (any, bool) {
+	value, ok := record[name]
+	if ok {
+		return value, true
+	}
+	head, rest, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := record[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return recordField(nested, rest)
+}
+
+// humanNumber matches the strings recordNumber reads: numbers with an optional size suffix.
+var humanNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([KMGTPEZYkmgtpezy](i?B)?|B)?$`)
+
+// recordNumber returns a field value as a number: JSON numbers as they are,
+// strings like "42", "1.5K" or "2MiB" in bytes, as sort -h reads them.
+func recordNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		if !humanNumber.MatchString(v) {
+			return 0, false
+		}
+		return parseHumanSize(v), true
+	}
+	return 0, false
+}
+
+// recordString returns a field value as text: strings without quotes,
+// everything else as JSON.
+func recordString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// compareRecordValues orders values numerically if both are numbers and as text otherwise.
+func compareRecordValues(a, b any) int {
+	x, xOK := recordNumber(a)
+	y, yOK := recordNumber(b)
+	if xOK && yOK {
+		return compareFloats(x, y)
+	}
+	return strings.Compare(recordString(a), recordString(b))
+}
+
+// writeRecord writes fields in the given order as one line of JSON.
+func writeRecord(w io.Writer, names []string, values []any) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// recordFormat is how where, select and group-by read and write rows:
+// JSON lines by default, or with -d lines of fields separated by a
+// delimiter, the first of them naming the columns unless -no-header is set.
+// A blank delimiter splits on runs of blanks, like awk does.
+type recordFormat struct {
+	delim    string
+	noHeader bool
+}
+
+func (f *recordFormat) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.delim, "d", "", "read and write fields separated by `DELIM` instead of JSON lines")
+	fs.BoolVar(&f.noHeader, "no-header", false, "with -d, there is no header line and columns are named 1, 2, ...")
+}
+
+// recordGetter returns the value of a field of a row.
+type recordGetter func(field string) (any, bool)
+
+// each calls fn with every line of in and the fields of its row. With -d,
+// header is called with the column names before the first row. Lines that
+// are not JSON objects are reported on errOut as name's errors and make the
+// result 1; header or fn returning an error stops the loop.
+func (f recordFormat) each(name string, in io.Reader, errOut io.Writer, header func(columns []string) error, fn func(line string, get recordGetter) error) int {
+	retCode := 0
+	var columns map[string]int
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if f.delim == "" {
+			record, err := parseRecord(line)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "%s: line %d: %v\n", name, lineNo, err)
+				retCode = 1
+				continue
+			}
+			if err := fn(line, func(field string) (any, bool) { return recordField(record, field) }); err != nil {
+				return 1
+			}
+			continue
+		}
+
+		fields := f.split(line)
+		if columns == nil {
+			columns = make(map[string]int)
+			if !f.noHeader {
+				for i, column := range fields {
+					columns[column] = i
+				}
+				if err := header(fields); err != nil {
+					return 1
+				}
+				continue
+			}
+		}
+		get := func(field string) (any, bool) {
+			i, ok := columns[field]
+			if !ok {
+				n, err := strconv.Atoi(field)
+				if err != nil || n < 1 {
+					return nil, false
+				}
+				i = n - 1
+			}
+			if i >= len(fields) {
+				return nil, false
+			}
+			return fields[i], true
+		}
+		if err := fn(line, get); err != nil {
+			return 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", name, err)
+		return 1
+	}
+	return retCode
+}
+
+func (f recordFormat) split(line string) []string {
+	if strings.TrimSpace(f.delim) == "" {
+		return strings.Fields(line)
+	}
+	return strings.Split(line, f.delim)
+}
+
+// writeHeader writes the column names of delimited output.
+func (f recordFormat) writeHeader(w io.Writer, names []string) error {
+	if f.delim == "" || f.noHeader {
+		return nil
+	}
+	_, err := fmt.Fprintln(w, strings.Join(names, f.delim))
+	return err
+}
+
+// writeRow writes a row as a JSON object or as delimited fields.
+func (f recordFormat) writeRow(w io.Writer, names []string, values []any) error {
+	if f.delim == "" {
+		return writeRecord(w, names, values)
+	}
+	fields := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			fields[i] = recordString(value)
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(fields, f.delim))
+	return err
+}
+
+// fileRecord describes a file as a record.
+func fileRecord(name, path string, info fs.FileInfo) ([]string, []any) {
+	kind := "file"
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		kind = "dir"
+	case mode&fs.ModeSymlink != 0:
+		kind = "symlink"
+	case mode&fs.ModeNamedPipe != 0:
+		kind = "pipe"
+	case mode&fs.ModeSocket != 0:
+		kind = "socket"
+	case mode&fs.ModeDevice != 0:
+		kind = "device"
+	}
+	return []string{"name", "path", "type", "size", "mode", "modified"},
+		[]any{name, path, kind, info.Size(), info.Mode().String(), info.ModTime().Format(time.RFC3339)}
+}
+
+// whereOperators compare a field with the operand of where.
+var whereOperators = map[string]func(field any, operand string) bool{
+	"-eq": whereNumeric(func(c int) bool { return c == 0 }),
+	"-ne": whereNumeric(func(c int) bool { return c != 0 }),
+	"-gt": whereNumeric(func(c int) bool { return c > 0 }),
+	"-ge": whereNumeric(func(c int) bool { return c >= 0 }),
+	"-lt": whereNumeric(func(c int) bool { return c < 0 }),
+	"-le": whereNumeric(func(c int) bool { return c <= 0 }),
+	"==":  func(field any, operand string) bool { return recordString(field) == operand },
+	"!=":  func(field any, operand string) bool { return recordString(field) != operand },
+	"-contains": func(field any, operand string) bool {
+		return strings.Contains(recordString(field), operand)
+	},
+}
+
+func whereNumeric(accept func(int) bool) func(any, string) bool {
+	return func(field any, operand string) bool {
+		x, ok := recordNumber(field)
+		if !ok {
+			return false
+		}
+		y, ok := recordNumber(operand)
+		return ok && accept(compareFloats(x, y))
+	}
+}
+
+// whereCommand passes on the rows whose field satisfies a condition.
+type whereCommand struct {
+	format recordFormat
+	field  string
+	match  func(field any) bool
+}
+
+func parseWhereCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("where")
+	var format recordFormat
+	format.addFlags(fs)
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("where: %w", err)
+	}
+	if fs.NArg() != 3 {
+		return nil, fmt.Errorf("where: usage: where [-d DELIM [-no-header]] FIELD OPERATOR VALUE")
+	}
+	field, op, operand := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile(operand)
+		if err != nil {
+			return nil, fmt.Errorf("where: %w", err)
+		}
+		negate := op == "!~"
+		return &whereCommand{format: format, field: field, match: func(value any) bool {
+			return re.MatchString(recordString(value)) != negate
+		}}, nil
+	}
+	compare, ok := whereOperators[op]
+	if !ok {
+		return nil, fmt.Errorf("where: %s: unknown operator", op)
+	}
+	if strings.HasPrefix(op, "-") && op != "-contains" {
+		if _, ok := recordNumber(operand); !ok {
+			return nil, fmt.Errorf("where: %s: not a number", operand)
+		}
+	}
+	return &whereCommand{format: format, field: field, match: func(value any) bool {
+		return compare(value, operand)
+	}}, nil
+}
+
+// Execute drops rows without the field; they match no condition.
+// The header line of delimited input is passed on as it is.
+func (w *whereCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	writer := bufio.NewWriter(out)
+	defer func() { _ = writer.Flush() }()
+	header := func(columns []string) error {
+		return w.format.writeHeader(writer, columns)
+	}
+	return w.format.each("where", in, errOut, header, func(line string, get recordGetter) error {
+		if value, ok := get(w.field); ok && w.match(value) {
+			_, err := fmt.Fprintln(writer, line)
+			return err
+		}
+		return nil
+	}), false
+}
+
+// selectCommand keeps the given fields of every row, in the given order.
+type selectCommand struct {
+	format recordFormat
+	fields []string
+}
+
+func parseSelectCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("select")
+	var format recordFormat
+	format.addFlags(fs)
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("select: %w", err)
+	}
+	fields := splitFieldList(fs.Args())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("select: usage: select [-d DELIM [-no-header]] FIELD[,FIELD...]")
+	}
+	return &selectCommand{format: format, fields: fields}, nil
+}
+
+// splitFieldList returns the fields of arguments like "name,size" "mode".
+func splitFieldList(args []string) []string {
+	var fields []string
+	for _, arg := range args {
+		for _, field := range strings.Split(arg, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// Execute writes a missing field as null, or an empty one with -d.
+func (s *selectCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	writer := bufio.NewWriter(out)
+	defer func() { _ = writer.Flush() }()
+	header := func([]string) error {
+		return s.format.writeHeader(writer, s.fields)
+	}
+	values := make([]any, len(s.fields))
+	return s.format.each("select", in, errOut, header, func(_ string, get recordGetter) error {
+		for i, field := range s.fields {
+			values[i], _ = get(field)
+		}
+		return s.format.writeRow(writer, s.fields, values)
+	}), false
+}
+
+// recordFieldComparator compares lines of JSON lines by a field, for "sort --by".
+// Lines that are not records or lack the field sort first.
+func recordFieldComparator(field string) func(a, b string) int {
+	key := func(line string) (any, bool) {
+		record, err := parseRecord(line)
+		if err != nil {
+			return nil, false
+		}
+		return recordField(record, field)
+	}
+	return func(a, b string) int {
+		x, xOK := key(a)
+		y, yOK := key(b)
+		if !xOK || !yOK {
+			return compareBools(xOK, yOK)
+		}
+		return compareRecordValues(x, y)
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,114 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordNumber(t *testing.T) {
+	tests := []struct {
+		value any
+		want  float64
+		ok    bool
+	}{
+		{"42", 42, true},
+		{"1.5K", 1536, true},
+		{"2MiB", 2 << 20, true},
+		{"-3", -3, true},
+		{"10-file", 0, false},
+		{"K", 0, false},
+		{true, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := recordNumber(tt.value)
+		assert.Equal(t, tt.ok, ok, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func TestWhereSelect(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	input := `{"name":"a","size":10,"user":{"id":1}}
+{"name":"b","size":2048,"user":{"id":2}}
+{"name":"c","size":"3M"}
+not json
+`
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/in.jsonl", []byte(input), 0644))
+
+	retCode, _, err := sh.Execute("cat " + dir + "/in.jsonl | where size -gt 1K 2> /dev/null | select name,user.id")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	retCode, _, err = sh.Execute("cat " + dir + "/in.jsonl | where name =~ '^[ab]$' 2> /dev/null")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode, "the line that is not a record is reported")
+	assert.Equal(t, `{"name":"b","user.id":2}
+{"name":"c","user.id":null}
+{"name":"a","size":10,"user":{"id":1}}
+{"name":"b","size":2048,"user":{"id":2}}
+`, readShellOutput(t, stdout))
+
+	for _, line := range []string{"where size", "where size -gt big", "where size ~ 1", "where a =~ (", "select"} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: CommandName(strings.Fields(line)[0]), arguments: strings.Fields(line)})
+		assert.Error(t, err, line)
+	}
+}
+
+func TestLsRecords(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("small", []byte("x"), 0644))
+	require.NoError(t, os.WriteFile("big", []byte(strings.Repeat("x", 4096)), 0644))
+	require.NoError(t, os.Mkdir("sub", 0755))
+	require.NoError(t, os.WriteFile("sub/inner", []byte("xy"), 0644))
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("ls -R --records | where type == file | sort --by size -r | select name,path,size")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"big","path":"big","size":4096}
+{"name":"inner","path":"sub/inner","size":2}
+{"name":"small","path":"small","size":1}
+`, readShellOutput(t, stdout))
+}
+
+func TestGroupBy(t *testing.T) {
+	dir := t.TempDir()
+	records := `{"type":"file","size":10}
+{"type":"dir","size":4096}
+{"type":"file","size":30}
+{"type":"link"}
+`
+	csv := "region,product,amount\neu,a,10\nus,b,5\neu,b,2.5\n"
+	require.NoError(t, os.WriteFile(dir+"/in.jsonl", []byte(records), 0644))
+	require.NoError(t, os.WriteFile(dir+"/sales.csv", []byte(csv), 0644))
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("group-by type count sum:size max:size < " + dir + "/in.jsonl")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("group-by -d , region sum:amount avg:amount < " + dir + "/sales.csv")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("where -d , amount -lt 6 < " + dir + "/sales.csv | select -d , 2,amount")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("printf 'x 1\\ny 2\\nx 3\\n' | where -d ' ' -no-header 2 -ge 1 | group-by -d ' ' -no-header 1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"file","count":2,"sum_size":40,"max_size":30}
+{"type":"dir","count":1,"sum_size":4096,"max_size":4096}
+{"type":"link","count":1,"sum_size":0,"max_size":null}
+region,sum_amount,avg_amount
+eu,12.5,6.25
+us,5,5
+2,amount
+b,5
+b,2.5
+x 2
+y 1
+`, readShellOutput(t, stdout))
+
+	for _, line := range []string{"group-by", "group-by type sum", "group-by type median:size"} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: GroupByCommand, arguments: strings.Fields(line)})
+		assert.Error(t, err, line)
+	}
+}
@@ -2,8 +2,16 @@ package shell
 
 import (
 	"bufio"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // CommandName represents the name of a shell command.
@@ -24,18 +32,174 @@ const (
 	WCCommand = CommandName("wc")
 	// GrepCommand searches for patterns in files using regular expressions.
 	GrepCommand = CommandName("grep")
+	// SortCommand sorts lines of text files or standard input.
+	SortCommand = CommandName("sort")
+	// HeadCommand prints the first lines or bytes of files or standard input.
+	HeadCommand = CommandName("head")
+	// CutCommand prints selected fields or characters of every line.
+	CutCommand = CommandName("cut")
+	// SedCommand edits lines with substitutions, like a small subset of sed.
+	SedCommand = CommandName("sed")
+	// LsCommand lists directory contents.
+	LsCommand = CommandName("ls")
+	// TreeCommand prints a directory hierarchy as a tree.
+	TreeCommand = CommandName("tree")
+	// CmpCommand compares two files byte by byte.
+	CmpCommand = CommandName("cmp")
+	// DedupeCommand finds files with identical contents in a directory.
+	DedupeCommand = CommandName("dedupe")
+	// SyncCommand mirrors a file or directory, copying only changed files.
+	SyncCommand = CommandName("sync")
+	// AssertCommand runs a command and checks its exit status and output.
+	AssertCommand = CommandName("assert")
+	// MockCommand shadows an external command with a scripted fake.
+	MockCommand = CommandName("mock")
+	// SetCommand toggles shell options and lists variables.
+	SetCommand = CommandName("set")
+	// NiceCommand runs an external command with an adjusted scheduling priority.
+	NiceCommand = CommandName("nice")
+	// LimitCommand runs an external command with memory and CPU time limits.
+	LimitCommand = CommandName("limit")
+	// UnbufferCommand runs an external command with line-buffered output.
+	UnbufferCommand = CommandName("unbuffer")
+	// RmCommand removes files and directories.
+	RmCommand = CommandName("rm")
+	// TrashCommand lists and restores files removed while the safety option is on.
+	TrashCommand = CommandName("trash")
+	// UndoCommand restores the most recently trashed files.
+	UndoCommand = CommandName("undo")
+	// DeferCommand queues a cleanup command to run when the session ends.
+	DeferCommand = CommandName("defer")
+	// EachCommand runs a command template for every input line.
+	EachCommand = CommandName("each")
+	// FilterCommand prints the input lines for which a command template succeeds.
+	FilterCommand = CommandName("filter")
+	// SpongeCommand soaks up all of its input before writing it to a file.
+	SpongeCommand = CommandName("sponge")
+	// CDCommand changes the working directory.
+	CDCommand = CommandName("cd")
+	// MkcdCommand creates a directory and makes it the working directory.
+	MkcdCommand = CommandName("mkcd")
+	// UpCommand changes the working directory N levels up.
+	UpCommand = CommandName("up")
+	// BackCommand returns to the previous directory on the directory stack.
+	BackCommand = CommandName("back")
+	// BookmarkCommand saves and lists named directories usable as "@NAME".
+	BookmarkCommand = CommandName("bookmark")
+	// EnvSnapshotCommand saves the variable set and shows what changed since.
+	EnvSnapshotCommand = CommandName("env-snapshot")
+	// ThemeCommand lists, previews and switches prompt themes.
+	ThemeCommand = CommandName("theme")
+	// ExportCommand marks variables to be passed to external commands.
+	ExportCommand = CommandName("export")
+	// UnsetCommand removes variables, or restores the ones removed last.
+	UnsetCommand = CommandName("unset")
+	// CexecCommand runs a command in a running Docker or Podman container.
+	CexecCommand = CommandName("cexec")
+	// KexecCommand runs a command in a container of a Kubernetes pod.
+	KexecCommand = CommandName("kexec")
+	// AliasCommand defines or prints aliases.
+	AliasCommand = CommandName("alias")
+	// UnaliasCommand removes aliases.
+	UnaliasCommand = CommandName("unalias")
+	// PluginCommand loads plugins with more commands or lists the loaded ones.
+	PluginCommand = CommandName("plugin")
+	// HistoryCommand prints or clears the lines run in the session.
+	HistoryCommand = CommandName("history")
+	// SourceCommand runs the commands of a file in the current shell.
+	SourceCommand = CommandName("source")
+	// DotCommand is the POSIX name of SourceCommand.
+	DotCommand = CommandName(".")
+	// PortCommand checks whether TCP ports accept connections.
+	PortCommand = CommandName("port")
+	// NcCommand pipes data over a TCP connection.
+	NcCommand = CommandName("nc")
+	// OnchangeCommand runs a command line whenever watched files change.
+	OnchangeCommand = CommandName("onchange")
+	// SeqCommand prints sequences of numbers.
+	SeqCommand = CommandName("seq")
+	// EveryCommand schedules a command line to run repeatedly in the session.
+	EveryCommand = CommandName("every")
+	// AtCommand schedules a command line to run once at a given time.
+	AtCommand = CommandName("at")
+	// ScheduleCommand lists and cancels scheduled command lines.
+	ScheduleCommand = CommandName("schedule")
+	// JSONCommand queries JSON documents and converts them to YAML or TOML.
+	JSONCommand = CommandName("json")
+	// YAMLCommand queries YAML documents and converts them to JSON or TOML.
+	YAMLCommand = CommandName("yaml")
+	// TOMLCommand queries TOML documents and converts them to JSON or YAML.
+	TOMLCommand = CommandName("toml")
+	// YesCommand repeats a line until its output is closed.
+	YesCommand = CommandName("yes")
+	// DateCommand prints the current time in a given format.
+	DateCommand = CommandName("date")
+	// TplCommand renders text/template files with the shell variables.
+	TplCommand = CommandName("tpl")
+	// BasenameCommand prints paths without their directories.
+	BasenameCommand = CommandName("basename")
+	// DirnameCommand prints the directories of paths.
+	DirnameCommand = CommandName("dirname")
+	// SQLCommand runs SQL queries on CSV and JSON files and SQLite databases.
+	SQLCommand = CommandName("sql")
+	// MvCommand moves and renames files and directories.
+	MvCommand = CommandName("mv")
+	// GroupByCommand aggregates the rows of JSON records or delimited text by fields.
+	GroupByCommand = CommandName("group-by")
+	// CpCommand copies files and directories.
+	CpCommand = CommandName("cp")
+	// WhereCommand passes on the JSON records whose field satisfies a condition.
+	WhereCommand = CommandName("where")
+	// SelectCommand keeps the named fields of JSON records.
+	SelectCommand = CommandName("select")
+	// PrintfCommand formats and prints its arguments.
+	PrintfCommand = CommandName("printf")
+	// SleepCommand waits for the given time.
+	SleepCommand = CommandName("sleep")
+	// SuggestCommand prints earlier command lines with a prefix, the ones usual in the current directory first.
+	SuggestCommand = CommandName("suggest")
+	// PreviewCommand builds a pipeline stage by stage, previewing its output on a sample.
+	PreviewCommand = CommandName("preview")
+	// EnvCommand prints the environment or runs a command with variables set only for it.
+	EnvCommand = CommandName("env")
+	// WhichCommand shows whether a name is a builtin or which program in PATH it runs.
+	WhichCommand = CommandName("which")
+	// SubshellCommand runs a "(...)" group of commands in a copy of the environment.
+	SubshellCommand = CommandName("()")
 )
 
 // CommandDescription contains all information needed to execute a command,
 // including its name, arguments, and I/O redirection paths.
 type CommandDescription struct {
-	name             CommandName
-	arguments        []string
-	fileInPath       string
-	fileOutPath      string
+	name        CommandName
+	arguments   []string
+	fileInPath  string
+	fileOutPath string
+	fileErrPath string
+	// fileInQuoting, fileOutQuoting and fileErrQuoting tell how the words
+	// of the redirection targets were quoted, for expanding them.
+	fileInQuoting  targetQuoting
+	fileOutQuoting targetQuoting
+	fileErrQuoting targetQuoting
+	// fileOutMode and fileErrMode, if set, are the permissions given to
+	// the redirection targets with "mode=NNN".
+	fileOutMode      *fs.FileMode
+	fileErrMode      *fs.FileMode
+	appendOut        bool
+	appendErr        bool
+	errToOut         bool
+	hereDoc          *hereDoc
 	isPiped          bool
+	next             chainOperator
 	singleQuotedArgs map[int]bool
 	doubleQuotedArgs map[int]bool
+	unquotedArgs     map[int]bool
+	// assignments are the EnvAssignmentCmd descriptions of the variables
+	// set in front of the command, which only it sees.
+	assignments []CommandDescription
+	// capture, if set on the last command of a pipeline, is the variable
+	// the output of the pipeline goes to instead of stdout.
+	capture *captureNode
 }
 
 // Env provides an interface for managing environment variables.
@@ -47,6 +211,12 @@ type Env interface {
 	Set(key, value string)
 	// GetAll returns all environment variables as a map.
 	GetAll() map[string]string
+	// Export marks a variable, set now or later, to be passed to external commands.
+	Export(key string)
+	// Exported returns the variables that external commands receive.
+	Exported() map[string]string
+	// Unset removes a variable together with its export mark.
+	Unset(key string)
 }
 
 // InputProcessor parses user input into command descriptions.
@@ -68,48 +238,227 @@ type Shell struct {
 	inputProcessor InputProcessor
 	runner         PipelineRunner
 	env            Env
+	factory        *commandFactory
+	stdin          *os.File
+	stdout         *os.File
+	stderr         *os.File
+	metrics        *shellMetrics
+	logger         *slog.Logger
+	fsys           FileSystem
+	// importAliases defines the aliases in the AliasVarPrefix variables.
+	importAliases bool
+	// ctx is cancelled by Shutdown, which stops Run.
+	ctx  context.Context
+	stop context.CancelCauseFunc
+	// busy is held while a command line runs, so that scheduled jobs run between them.
+	busy sync.Mutex
+}
+
+// Option customizes a Shell created by NewShell.
+type Option func(*Shell)
+
+// WithStdin makes the shell read user input and feed commands from f instead of os.Stdin.
+func WithStdin(f *os.File) Option {
+	return func(s *Shell) {
+		s.stdin = f
+	}
+}
+
+// WithStdout makes the shell write prompts and command output to f instead of os.Stdout.
+func WithStdout(f *os.File) Option {
+	return func(s *Shell) {
+		s.stdout = f
+	}
+}
+
+// WithStderr makes the shell write command errors to f instead of os.Stderr.
+func WithStderr(f *os.File) Option {
+	return func(s *Shell) {
+		s.stderr = f
+	}
 }
 
 // Command represents an executable command that can read from input
-// and write to output files.
+// and write to output and error files.
 type Command interface {
-	// Execute runs the command with the given input/output files and environment.
+	// Execute runs the command with the given input/output/error files and environment.
 	// Returns the exit code and a boolean indicating if the shell should exit.
-	Execute(in *os.File, out *os.File, env Env) (retCode int, exited bool)
+	Execute(in *os.File, out *os.File, errOut *os.File, env Env) (retCode int, exited bool)
+}
+
+// WithFileSystem makes builtins that read files, like cat, grep, wc and ls,
+// and redirections work on fsys instead of the OS filesystem.
+func WithFileSystem(fsys FileSystem) Option {
+	return func(s *Shell) {
+		s.fsys = fsys
+	}
+}
+
+// WithEnv makes the shell use env instead of a copy of the process environment.
+func WithEnv(env Env) Option {
+	return func(s *Shell) {
+		s.env = env
+	}
+}
+
+// WithImportedAliases makes the shell define the aliases that a parent gocli
+// passed in AliasVarPrefix variables and pass them on to its own children.
+// Without it the variables are ignored.
+func WithImportedAliases() Option {
+	return func(s *Shell) {
+		s.importAliases = true
+	}
 }
 
 // NewShell creates and initializes a new Shell instance with
 // default input processor, pipeline runner, and environment.
-func NewShell() *Shell {
-	env := NewEnv()
-	return &Shell{
-		inputProcessor: NewInputProcessor(),
-		env:            env,
-		runner:         NewPipelineRunner(env, NewCommandFactory(env)),
+// Options are applied on top of the defaults.
+func NewShell(opts ...Option) *Shell {
+	s := &Shell{
+		env:     NewEnv(),
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		metrics: newShellMetrics(),
+		logger:  discardLogger,
+		fsys:    OSFileSystem,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.ctx, s.stop = context.WithCancelCause(context.Background())
+	s.factory = newCommandFactory(s.env)
+	if s.importAliases && importAliases(s.env, s.factory.aliases) {
+		// Pass them on to the next level, as the parent did.
+		_ = s.factory.options.set(OptionExportAliases, true)
+	}
+	s.inputProcessor = &inputProcessor{aliases: s.factory.aliases}
+	s.factory.fsys = s.fsys
+	s.factory.schedule.run = s.runScheduled
+	s.metrics.subscribe(s.factory.events)
+	if env, ok := s.env.(*envMap); ok {
+		env.events = s.factory.events
+	}
+	s.runner = &pipelineRunner{
+		env:     s.env,
+		factory: s.factory,
+		parser:  s.inputProcessor,
+		stdin:   s.stdin,
+		stdout:  s.stdout,
+		stderr:  s.stderr,
+		options: s.factory.options,
+		events:  s.factory.events,
+		logger:  s.logger,
+		fsys:    s.fsys,
+	}
+	return s
+}
+
+// SetOption turns the shell option name (one of the Option* constants) on or off,
+// like "set -o NAME" and "set +o NAME".
+func (s *Shell) SetOption(name string, on bool) error {
+	return s.factory.options.set(name, on)
 }
 
 // Run starts the shell's main read-eval-print loop.
-// Reads user input, parses and executes commands until exit or EOF.
+// Shows the prompt built from PS1, reads user input, parses and executes commands until exit or EOF,
+// then runs the commands queued with defer. Input that needs more lines,
+// such as a here-document, is completed after a "> " prompt; a line that cannot be
+// parsed is reported on stderr and sets the status to 2. Text pasted into
+// a terminal is read as a whole and, if it has several lines, only runs
+// after a confirmation. COLUMNS and LINES follow the size of the terminal.
+// If no line arrives within TMOUT seconds, the session ends as on EOF.
+// In a terminal, pipelines run in the foreground process group.
+// Shutdown ends the session once the current line is over.
 // Returns the exit code of the last executed command or 0 on normal termination.
 func (s *Shell) Run() int {
-	scanner := bufio.NewScanner(os.Stdin)
+	defer flushTraces()
+	defer s.RunDeferred()
+	defer s.factory.schedule.stop()
+
+	resized, stopResize := notifyResize()
+	defer stopResize()
+	s.updateTerminalSize()
+	defer s.controlTerminal()()
+
+	s.logger.Info("session started", "pid", os.Getpid())
+	scanner := bufio.NewScanner(s.stdin)
 	lastRetCode := 0
+	defer func() {
+		s.logger.Info("session finished", "status", lastRetCode)
+	}()
 	for {
-		_, _ = os.Stdout.WriteString("$ ")
-		_ = os.Stdout.Sync()
+		if status, ok := s.shutdownStatus(); ok {
+			lastRetCode = status
+			return status
+		}
+		if resized.Swap(false) {
+			s.updateTerminalSize()
+			columns, _ := s.env.Get("COLUMNS")
+			lines, _ := s.env.Get("LINES")
+			s.logger.Debug("terminal resized", "columns", columns, "lines", lines)
+		}
+		prompt := renderPrompt(s.env, lastRetCode, s.factory.segments.render)
+		_, _ = s.stdout.WriteString(s.withRightPrompt(prompt, lastRetCode))
+		s.setBracketedPaste(true)
+		_ = s.stdout.Sync()
 
-		if !scanner.Scan() {
+		ok, timedOut := scanWithTimeout(scanner, idleTimeout(s.env), s.ctx.Done())
+		if timedOut {
+			s.setBracketedPaste(false)
+			s.logger.Info("idle timeout")
+			_, _ = fmt.Fprintln(s.stderr, "\ngocli: timed out waiting for input: auto-logout")
+			break
+		}
+		if !ok {
+			s.setBracketedPaste(false)
+			if s.ctx.Err() != nil {
+				continue
+			}
 			break
 		}
 
 		line := scanner.Text()
-		cmds, err := s.inputProcessor.Parse(line)
+		if strings.Contains(line, pasteStart) {
+			var ok bool
+			if line, ok = readPaste(line, scanner); !ok {
+				s.setBracketedPaste(false)
+				break
+			}
+		}
+		s.setBracketedPaste(false)
+		if lines := strings.Count(line, "\n") + 1; lines > 1 &&
+			!confirm(fmt.Sprintf("gocli: run %d pasted lines?", lines)) {
+			continue
+		}
+		if s.factory.options.isSet(OptionTransientPrompt) {
+			s.collapsePrompt(prompt, line)
+		}
+		s.busy.Lock()
+		retCode, isExited, err := s.Execute(line)
+		s.busy.Unlock()
+		for errors.Is(err, ErrIncompleteInput) {
+			_, _ = s.stdout.WriteString("> ")
+			_ = s.stdout.Sync()
+			if ok, _ := scanWithTimeout(scanner, 0, s.ctx.Done()); !ok {
+				break
+			}
+			line += "\n" + scanner.Text()
+			s.busy.Lock()
+			retCode, isExited, err = s.Execute(line)
+			s.busy.Unlock()
+		}
+		if s.ctx.Err() != nil {
+			// Shutdown: the status for the signal replaces that of the line.
+			continue
+		}
 		if err != nil {
-			log.Fatal("Unable to process user input", err)
+			// A malformed line does not end the session, only fails like a command would.
+			s.logger.Warn("parse error", "error", err, "input", line)
+			_, _ = fmt.Fprintf(s.stderr, "gocli: %v\n", err)
+			retCode = 2
 		}
 
-		retCode, isExited := s.runner.Execute(cmds, s.env)
 		lastRetCode = retCode
 		if isExited {
 			return retCode
@@ -117,3 +466,107 @@ func (s *Shell) Run() int {
 	}
 	return lastRetCode
 }
+
+// updateTerminalSize sets COLUMNS and LINES to the size of the terminal the
+// shell writes to, so that external commands see the current size.
+func (s *Shell) updateTerminalSize() {
+	cols, rows, ok := terminalSize(s.stdout)
+	if !ok {
+		return
+	}
+	s.env.Set("COLUMNS", strconv.Itoa(cols))
+	s.env.Set("LINES", strconv.Itoa(rows))
+	s.env.Export("COLUMNS")
+	s.env.Export("LINES")
+}
+
+// controlTerminal enables job control when the shell reads commands from
+// the terminal it runs in the foreground of, and fills in the SHELL and TERM
+// variables that programs started from an interactive shell expect.
+// The returned function turns job control off again.
+func (s *Shell) controlTerminal() func() {
+	runner, ok := s.runner.(*pipelineRunner)
+	terminal := newTerminalControl(s.stdin)
+	if !ok || terminal == nil {
+		return func() {}
+	}
+	s.factory.terminal, runner.terminal = terminal, terminal
+
+	if value, ok := s.env.Get("SHELL"); !ok || value == "" {
+		if executable, err := os.Executable(); err == nil {
+			s.env.Set("SHELL", executable)
+			s.env.Export("SHELL")
+		}
+	}
+	if value, ok := s.env.Get("TERM"); !ok || value == "" {
+		s.env.Set("TERM", "dumb")
+		s.env.Export("TERM")
+	}
+	return func() {
+		s.factory.terminal, runner.terminal = nil, nil
+	}
+}
+
+// withRightPrompt adds the right-hand side prompt from RPROMPT or RPS1 to
+// prompt when the output is a terminal.
+func (s *Shell) withRightPrompt(prompt string, status int) string {
+	width, isTerminal := terminalWidth(s.stdout)
+	if !isTerminal {
+		return prompt
+	}
+	return withRightPrompt(prompt, renderRightPrompt(s.env, status, s.factory.segments.render), width)
+}
+
+// collapsePrompt replaces the prompt and the line the terminal echoed after
+// it with the default one-line prompt followed by the same line.
+func (s *Shell) collapsePrompt(prompt, line string) {
+	width, isTerminal := terminalWidth(s.stdout)
+	if _, inputIsTerminal := terminalWidth(s.stdin); !isTerminal || !inputIsTerminal {
+		return
+	}
+	_, _ = s.stdout.WriteString(transientPrompt(prompt, line, width))
+}
+
+// Execute parses and runs a single line of input in the shell session.
+// Returns the exit code, a boolean indicating if the shell should exit,
+// and an error if the line could not be parsed. A panic while handling
+// the line is reported like one of a command and yields CrashStatus.
+// The line is added to the history unless it is incomplete.
+func (s *Shell) Execute(line string) (retCode int, exited bool, err error) {
+	return s.execute(line, true)
+}
+
+// execute is Execute that records the line in the history only if asked to.
+func (s *Shell) execute(line string, record bool) (retCode int, exited bool, err error) {
+	defer func() {
+		// The status of a crash or a syntax error is the next $? too.
+		switch {
+		case err == nil:
+			s.factory.status.set(retCode)
+		case !errors.Is(err, ErrIncompleteInput):
+			s.factory.status.set(2)
+		}
+	}()
+	defer func() {
+		if value := recover(); value != nil {
+			s.logger.Error("panic", "input", line, "panic", value)
+			c := crash{value: value, stack: debug.Stack()}
+			c.report(s.stderr, s.factory.options.isSet(OptionBugReport))
+			retCode, exited, err = CrashStatus, false, nil
+		}
+	}()
+
+	cmds, err := s.inputProcessor.Parse(line)
+	if record && !errors.Is(err, ErrIncompleteInput) {
+		s.factory.history.add(line)
+		if dir, err := os.Getwd(); err == nil {
+			s.factory.stats.record(dir, line)
+		}
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	retCode, exited = s.runner.Execute(cmds, s.env)
+	return retCode, exited, nil
+}
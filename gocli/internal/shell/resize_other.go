@@ -0,0 +1,11 @@
+//go:build !unix
+
+package shell
+
+import "sync/atomic"
+
+// notifyResize returns a flag that is never raised: there is no SIGWINCH,
+// and the terminal size is looked up again before every prompt anyway.
+func notifyResize() (*atomic.Bool, func()) {
+	return &atomic.Bool{}, func() {}
+}
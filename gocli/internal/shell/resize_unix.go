@@ -0,0 +1,35 @@
+//go:build unix
+
+package shell
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// notifyResize returns a flag that is raised whenever the terminal is
+// resized (SIGWINCH), and a function to stop watching.
+func notifyResize() (*atomic.Bool, func()) {
+	resized := &atomic.Bool{}
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				resized.Store(true)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return resized, func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package shell
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyResize(t *testing.T) {
+	resized, stop := notifyResize()
+	defer stop()
+	assert.False(t, resized.Load())
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	assert.Eventually(t, resized.Load, time.Second, 10*time.Millisecond)
+}
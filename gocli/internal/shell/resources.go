@@ -0,0 +1,108 @@
+package shell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// resourceLimits are applied to an external command before its program runs.
+type resourceLimits struct {
+	// niceness is added to the scheduling priority of the shell.
+	niceness int
+	// memoryBytes caps the address space of the process (RLIMIT_AS), 0 means unlimited.
+	memoryBytes uint64
+	// cpuSeconds caps the consumed CPU time (RLIMIT_CPU), 0 means unlimited.
+	cpuSeconds uint64
+}
+
+func (r resourceLimits) isZero() bool {
+	return r == resourceLimits{}
+}
+
+func parseNiceCommand(d CommandDescription, factory CommandFactory) (Command, error) {
+	fs := newFlagSet("nice")
+	adjustment := fs.Int("n", 10, "add N to the niceness")
+
+	if err := parseFlags(fs, niceArgs(d.arguments[1:])); err != nil {
+		return nil, fmt.Errorf("nice: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("nice: command required")
+	}
+
+	return limitedCommand("nice", fs.Args(), d, factory, resourceLimits{niceness: *adjustment})
+}
+
+// niceArgs rewrites the other forms of the adjustment that nice accepts,
+// the historical "-N" ("--N" for a negative one) and GNU "--adjustment=N",
+// into "-n N".
+func niceArgs(args []string) []string {
+	rewritten := make([]string, 0, len(args)+1)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			return append(rewritten, args[i:]...)
+		}
+		if value, ok := strings.CutPrefix(arg, "--adjustment="); ok {
+			rewritten = append(rewritten, "-n", value)
+		} else if _, err := strconv.Atoi(arg[1:]); err == nil {
+			rewritten = append(rewritten, "-n", arg[1:])
+		} else if (arg == "--adjustment" || arg == "-n") && i+1 < len(args) {
+			// The value may be negative, so it must not be rewritten.
+			rewritten = append(rewritten, "-n", args[i+1])
+			i++
+		} else {
+			rewritten = append(rewritten, arg)
+		}
+	}
+	return rewritten
+}
+
+func parseLimitCommand(d CommandDescription, factory CommandFactory) (Command, error) {
+	fs := newFlagSet("limit")
+	memory := fs.String("m", "", "maximum memory (address space) size, e.g. 512M or 2G")
+	cpu := fs.Uint64("t", 0, "maximum CPU time in seconds")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("limit: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("limit: command required")
+	}
+
+	limits := resourceLimits{cpuSeconds: *cpu}
+	if *memory != "" {
+		size := parseHumanSize(*memory)
+		if size <= 0 {
+			return nil, fmt.Errorf("limit: invalid memory size %q", *memory)
+		}
+		limits.memoryBytes = uint64(size)
+	}
+
+	return limitedCommand("limit", fs.Args(), d, factory, limits)
+}
+
+// limitedCommand resolves args into an external command that runs with the given limits.
+// Builtins run inside the shell process, so they cannot be limited separately.
+func limitedCommand(wrapper string, args []string, d CommandDescription, factory CommandFactory, limits resourceLimits) (Command, error) {
+	inner := d
+	inner.name = CommandName(args[0])
+	inner.arguments = args
+
+	cmd, err := factory.GetCommand(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	target := cmd
+	if guarded, ok := cmd.(*guardedCommand); ok {
+		target = guarded.inner
+	}
+	external, ok := target.(*externalCommand)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: cannot limit a builtin command", wrapper, args[0])
+	}
+	external.limits = limits
+	return cmd, nil
+}
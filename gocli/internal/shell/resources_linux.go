@@ -0,0 +1,105 @@
+//go:build linux
+
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// limitHelper is the program name under which the executable of the shell
+// starts as a helper that sets its own niceness and resource limits and then
+// executes the limited program in its place. The helper is run by every
+// program that uses this package, gocli, an embedding program or a test.
+const limitHelper = "gocli-limit-helper"
+
+func init() {
+	if len(os.Args) > 0 && os.Args[0] == limitHelper {
+		err := execLimited(os.Args[1:])
+		_, _ = fmt.Fprintf(os.Stderr, "gocli: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+// startWithLimits starts cmd with the requested niceness and resource limits.
+//
+// Both must be in place before the program runs its first instruction, and
+// Go offers no way to run code in the child between fork and exec. So the
+// child is the shell's own executable started as limitHelper: it applies
+// the limits to itself and executes the program, which keeps them. Unlike
+// tracing the child, this works for setuid programs and where ptrace is
+// restricted.
+func startWithLimits(cmd *exec.Cmd, limits resourceLimits) error {
+	if cmd.Err != nil {
+		// Start reports that the program was not found.
+		return cmd.Start()
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{
+		limitHelper,
+		strconv.Itoa(limits.niceness),
+		strconv.FormatUint(limits.memoryBytes, 10),
+		strconv.FormatUint(limits.cpuSeconds, 10),
+		cmd.Path,
+	}
+	cmd.Args = append(args, cmd.Args...)
+	cmd.Path = self
+	return cmd.Start()
+}
+
+// execLimited is the limitHelper: args are the niceness, the memory and CPU
+// limits, the path of the program and its command line. It only returns if
+// the limits cannot be applied or the program cannot be executed.
+func execLimited(args []string) error {
+	if len(args) < 5 {
+		return errors.New("limit helper: missing arguments")
+	}
+	niceness, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("limit helper: %w", err)
+	}
+	memoryBytes, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("limit helper: %w", err)
+	}
+	cpuSeconds, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("limit helper: %w", err)
+	}
+
+	// The nice value belongs to a thread on Linux, and the program inherits
+	// that of the thread that executes it.
+	runtime.LockOSThread()
+	if niceness != 0 {
+		// The raw syscall reports 20 - nice, so convert it back to the nice value.
+		prio, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+		if err != nil {
+			return err
+		}
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, 20-prio+niceness); err != nil {
+			return err
+		}
+	}
+	if memoryBytes != 0 {
+		limit := unix.Rlimit{Cur: memoryBytes, Max: memoryBytes}
+		if err := unix.Setrlimit(unix.RLIMIT_AS, &limit); err != nil {
+			return err
+		}
+	}
+	if cpuSeconds != 0 {
+		limit := unix.Rlimit{Cur: cpuSeconds, Max: cpuSeconds}
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, &limit); err != nil {
+			return err
+		}
+	}
+	return unix.Exec(args[3], args[4:], os.Environ())
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNiceCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("nice -n 3 /bin/cat /proc/self/stat")
+	require.NoError(t, err)
+	require.Equal(t, 0, retCode)
+
+	// The niceness is the 19th field of /proc/PID/stat.
+	fields := strings.Fields(readShellOutput(t, stdout))
+	require.Greater(t, len(fields), 18)
+	assert.Equal(t, "3", fields[18])
+}
+
+func TestLimitCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("limit -m 64M -t 2 sh -c 'ulimit -v && ulimit -t'")
+	require.NoError(t, err)
+	require.Equal(t, 0, retCode)
+	assert.Equal(t, "65536\n2\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("limit -t 3 /bin/cat /proc/self/limits | grep 'Max cpu time'")
+	require.NoError(t, err)
+	require.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "3                    3                    seconds", "the program starts with the limits set")
+}
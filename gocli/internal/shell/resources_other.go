@@ -0,0 +1,12 @@
+//go:build !linux
+
+package shell
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func startWithLimits(cmd *exec.Cmd, limits resourceLimits) error {
+	return errors.New("resource limits are only supported on Linux")
+}
@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNiceCommand_Parse(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	cmd, err := parseNiceCommand(CommandDescription{
+		name:      NiceCommand,
+		arguments: []string{"nice", "-n", "7", "gzip", "big.log"},
+	}, factory)
+	require.NoError(t, err)
+
+	external, ok := cmd.(*externalCommand)
+	require.True(t, ok)
+	assert.Equal(t, []string{"gzip", "big.log"}, external.args)
+	assert.Equal(t, resourceLimits{niceness: 7}, external.limits)
+}
+
+func TestNiceCommand_Parse_Errors(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	_, err := parseNiceCommand(CommandDescription{name: NiceCommand, arguments: []string{"nice"}}, factory)
+	assert.Error(t, err)
+
+	_, err = parseNiceCommand(CommandDescription{
+		name:      NiceCommand,
+		arguments: []string{"nice", "echo", "hi"},
+	}, factory)
+	assert.Error(t, err, "builtins cannot be limited")
+}
+
+func TestLimitCommand_Parse(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	cmd, err := parseLimitCommand(CommandDescription{
+		name:      LimitCommand,
+		arguments: []string{"limit", "-m", "512M", "-t", "30", "make"},
+	}, factory)
+	require.NoError(t, err)
+
+	external, ok := cmd.(*externalCommand)
+	require.True(t, ok)
+	assert.Equal(t, resourceLimits{memoryBytes: 512 << 20, cpuSeconds: 30}, external.limits)
+
+	_, err = parseLimitCommand(CommandDescription{
+		name:      LimitCommand,
+		arguments: []string{"limit", "-m", "lots", "make"},
+	}, factory)
+	assert.Error(t, err)
+}
+
+func TestNiceCommand_Parse_AdjustmentForms(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	for _, tc := range []struct {
+		args     []string
+		niceness int
+	}{
+		{[]string{"nice", "gzip"}, 10},
+		{[]string{"nice", "-n5", "gzip"}, 5},
+		{[]string{"nice", "-5", "gzip"}, 5},
+		{[]string{"nice", "--5", "gzip"}, -5},
+		{[]string{"nice", "--adjustment=7", "gzip"}, 7},
+		{[]string{"nice", "--adjustment", "-3", "gzip"}, -3},
+		{[]string{"nice", "-n", "4", "--", "gzip", "-9"}, 4},
+	} {
+		cmd, err := parseNiceCommand(CommandDescription{name: NiceCommand, arguments: tc.args}, factory)
+		require.NoError(t, err, tc.args)
+		external, ok := cmd.(*externalCommand)
+		require.True(t, ok, tc.args)
+		assert.Equal(t, tc.niceness, external.limits.niceness, tc.args)
+		assert.Equal(t, "gzip", external.args[0], tc.args)
+	}
+}
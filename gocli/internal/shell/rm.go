@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+type rmCommand struct {
+	paths     []string
+	recursive bool
+	force     bool
+	trash     *trashBin
+}
+
+func parseRmCommand(d CommandDescription, trash *trashBin) (Command, error) {
+	fs := newFlagSet("rm")
+	recursive := fs.Bool("r", false, "remove directories and their contents recursively")
+	fs.BoolVar(recursive, "R", false, "same as -r")
+	force := fs.Bool("f", false, "ignore nonexistent files, never fail on them")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("rm: %w", err)
+	}
+	if fs.NArg() == 0 && !*force {
+		return nil, fmt.Errorf("rm: missing operand")
+	}
+
+	return &rmCommand{
+		paths:     fs.Args(),
+		recursive: *recursive,
+		force:     *force,
+		trash:     trash,
+	}, nil
+}
+
+// Execute removes the given paths. When a trash bin is attached (the safety
+// option is on), paths are moved into it instead so "undo" can bring them back.
+func (r *rmCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	for _, path := range r.paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			if !(r.force && os.IsNotExist(err)) {
+				_, _ = fmt.Fprintf(errOut, "rm: cannot remove '%s': %v\n", path, err)
+				retCode = 1
+			}
+			continue
+		}
+		if info.IsDir() && !r.recursive {
+			_, _ = fmt.Fprintf(errOut, "rm: cannot remove '%s': is a directory\n", path)
+			retCode = 1
+			continue
+		}
+
+		switch {
+		case r.trash != nil:
+			err = r.trash.put(path)
+		case info.IsDir():
+			err = os.RemoveAll(path)
+		default:
+			err = os.Remove(path)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "rm: cannot remove '%s': %v\n", path, err)
+			retCode = 1
+		}
+	}
+	return retCode, false
+}
@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRmCommand_Execute(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "file")
+	dir := filepath.Join(tmpDir, "dir")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0755))
+
+	cmd := &rmCommand{paths: []string{file, dir}}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 1, retCode, "directory without -r must fail")
+	assert.False(t, exited)
+	assert.NoFileExists(t, file)
+	assert.DirExists(t, dir)
+
+	cmd = &rmCommand{paths: []string{dir}, recursive: true}
+	retCode, _ = cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 0, retCode)
+	assert.NoDirExists(t, dir)
+}
+
+func TestRmCommand_Execute_Force(t *testing.T) {
+	cmd := &rmCommand{paths: []string{"/nonexistent/file"}}
+	retCode, _ := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 1, retCode)
+
+	cmd.force = true
+	retCode, _ = cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 0, retCode)
+}
+
+func TestRmCommand_Parse_MissingOperand(t *testing.T) {
+	_, err := parseRmCommand(CommandDescription{name: RmCommand, arguments: []string{"rm"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestShell_Execute_RmBundledFlags(t *testing.T) {
+	dir := tempWorkDir(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tree", "nested"), 0755))
+	sh, _ := newTestShell(t)
+
+	retCode, _, err := sh.Execute("rm -rf tree missing")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.NoDirExists(t, filepath.Join(dir, "tree"))
+
+	_, err = parseRmCommand(CommandDescription{name: RmCommand, arguments: []string{"rm", "-rv", "x"}}, nil)
+	assert.ErrorIs(t, err, errUnsupportedFlag)
+}
+
+func TestShell_Execute_RmFallsBackToSystemProgram(t *testing.T) {
+	if _, err := exec.LookPath("rm"); err != nil {
+		t.Skip("no system rm")
+	}
+	dir := tempWorkDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("rm -v file")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.NoFileExists(t, filepath.Join(dir, "file"))
+	assert.Contains(t, readShellOutput(t, stdout), "file")
+}
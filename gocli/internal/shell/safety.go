@@ -0,0 +1,113 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SafetyMaxArgsVar names the variable overriding how many arguments
+// a command may receive before the safety option asks for confirmation.
+const SafetyMaxArgsVar = "GOCLI_SAFETY_MAX_ARGS"
+
+const defaultSafetyMaxArgs = 1000
+
+var blockDevicePrefixes = []string{
+	"/dev/sd", "/dev/hd", "/dev/vd", "/dev/xvd", "/dev/nvme", "/dev/mmcblk", "/dev/disk",
+}
+
+// dangerReason explains why running d needs confirmation, or returns "" if it looks harmless.
+func dangerReason(d CommandDescription, env Env) string {
+	if isBlockDevice(d.fileOutPath) {
+		return "redirects output to block device " + d.fileOutPath
+	}
+
+	name := string(d.name)
+	args := d.arguments[1:]
+
+	if strings.HasPrefix(filepath.Base(name), "mkfs") {
+		return "formats a filesystem"
+	}
+	if filepath.Base(name) == "dd" {
+		for _, arg := range args {
+			if target, ok := strings.CutPrefix(arg, "of="); ok && isBlockDevice(target) {
+				return "writes to block device " + target
+			}
+		}
+	}
+	if filepath.Base(name) == "rm" && hasRecursiveFlag(args) {
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") && isCriticalPath(arg, env) {
+				return "recursively removes " + arg
+			}
+		}
+	}
+
+	if maxArgs := safetyMaxArgs(env); len(args) > maxArgs {
+		return fmt.Sprintf("receives %d arguments (more than %d)", len(args), maxArgs)
+	}
+	return ""
+}
+
+func isBlockDevice(path string) bool {
+	for _, prefix := range blockDevicePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRecursiveFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--recursive" {
+			return true
+		}
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rR") {
+			return true
+		}
+	}
+	return false
+}
+
+// isCriticalPath reports whether path is the filesystem root, a top-level
+// directory such as /etc or /usr, or the home directory.
+func isCriticalPath(path string, env Env) bool {
+	path = strings.TrimSuffix(path, "/*")
+	if path == "" || path == "~" {
+		return true
+	}
+	clean := filepath.Clean(path)
+	if filepath.Dir(clean) == "/" || clean == "/" {
+		return true
+	}
+	if home, ok := env.Get("HOME"); ok && home != "" && clean == filepath.Clean(home) {
+		return true
+	}
+	return false
+}
+
+func safetyMaxArgs(env Env) int {
+	if value, ok := env.Get(SafetyMaxArgsVar); ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultSafetyMaxArgs
+}
+
+// guardedCommand asks the user before running a potentially destructive command.
+type guardedCommand struct {
+	inner  Command
+	reason string
+}
+
+func (g *guardedCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if !confirm("gocli: this command " + g.reason + ". Run it anyway?") {
+		_, _ = fmt.Fprintln(errOut, "gocli: command cancelled")
+		return 1, false
+	}
+	return g.inner.Execute(in, out, errOut, env)
+}
@@ -0,0 +1,74 @@
+package shell
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDangerReason(t *testing.T) {
+	env := NewEnvFromMap(map[string]string{"HOME": "/home/user", SafetyMaxArgsVar: "3"})
+
+	tests := []struct {
+		name      string
+		desc      CommandDescription
+		dangerous bool
+	}{
+		{"rm -rf root", CommandDescription{name: "rm", arguments: []string{"rm", "-rf", "/"}}, true},
+		{"rm -r system dir", CommandDescription{name: "rm", arguments: []string{"rm", "-r", "/etc/"}}, true},
+		{"rm -r home", CommandDescription{name: "rm", arguments: []string{"rm", "--recursive", "/home/user"}}, true},
+		{"rm -r project dir", CommandDescription{name: "rm", arguments: []string{"rm", "-rf", "/home/user/project"}}, false},
+		{"rm without -r", CommandDescription{name: "rm", arguments: []string{"rm", "-f", "/etc"}}, false},
+		{"redirect to disk", CommandDescription{name: EchoCommand, arguments: []string{"echo"}, fileOutPath: "/dev/sda"}, true},
+		{"redirect to null", CommandDescription{name: EchoCommand, arguments: []string{"echo"}, fileOutPath: "/dev/null"}, false},
+		{"dd to disk", CommandDescription{name: "dd", arguments: []string{"dd", "if=img", "of=/dev/nvme0n1"}}, true},
+		{"mkfs", CommandDescription{name: "mkfs.ext4", arguments: []string{"mkfs.ext4", "disk.img"}}, true},
+		{"too many args", CommandDescription{name: "touch", arguments: []string{"touch", "a", "b", "c", "d"}}, true},
+		{"few args", CommandDescription{name: "touch", arguments: []string{"touch", "a", "b", "c"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.dangerous, dangerReason(tt.desc, env) != "")
+		})
+	}
+}
+
+func TestCommandFactory_GetCommand_Safety(t *testing.T) {
+	prevConfirm := confirm
+	defer func() {
+		confirm = prevConfirm
+	}()
+
+	env := NewEnv()
+	env.Set(SafetyMaxArgsVar, "2")
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithEnv(env), WithStdout(stdout))
+	_, _, err := sh.Execute("set -o safety")
+	require.NoError(t, err)
+
+	confirm = func(question string) bool { return false }
+	retCode, _, err := sh.Execute("echo a b c")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Empty(t, readShellOutput(t, stdout))
+
+	confirm = func(question string) bool { return true }
+	retCode, _, err = sh.Execute("echo a b c")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "a b c\n", readShellOutput(t, stdout))
+}
+
+func TestSafetyMaxArgs(t *testing.T) {
+	env := NewEnvFromMap(map[string]string{})
+	assert.Equal(t, defaultSafetyMaxArgs, safetyMaxArgs(env))
+
+	env.Set(SafetyMaxArgsVar, strconv.Itoa(10))
+	assert.Equal(t, 10, safetyMaxArgs(env))
+
+	env.Set(SafetyMaxArgsVar, "garbage")
+	assert.Equal(t, defaultSafetyMaxArgs, safetyMaxArgs(env))
+}
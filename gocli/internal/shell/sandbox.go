@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"os"
+)
+
+// sandboxInheritedVars lists the process variables passed into a sandboxed session;
+// everything else (tokens, credentials, custom settings) is dropped.
+var sandboxInheritedVars = []string{"PATH", "TERM", "LANG", "LC_ALL", "USER", "LOGNAME"}
+
+// Sandbox is a throwaway working directory for an experimental shell session.
+type Sandbox struct {
+	// Dir is the temporary directory the session runs in.
+	Dir     string
+	prevDir string
+}
+
+// NewSandbox creates a fresh temporary directory and makes it the working directory of the process.
+func NewSandbox() (*Sandbox, error) {
+	prevDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "gocli-sandbox-")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &Sandbox{Dir: dir, prevDir: prevDir}, nil
+}
+
+// Env returns a scrubbed environment for the sandboxed session:
+// a small allowlist of process variables, with HOME and PWD pointing into the sandbox.
+func (s *Sandbox) Env() Env {
+	vars := make(map[string]string)
+	for _, key := range sandboxInheritedVars {
+		if value, ok := os.LookupEnv(key); ok {
+			vars[key] = value
+		}
+	}
+	vars["HOME"] = s.Dir
+	vars["PWD"] = s.Dir
+	return NewEnvFromMap(vars)
+}
+
+// Close restores the original working directory and, unless keep is set,
+// deletes the sandbox directory with everything created inside it.
+func (s *Sandbox) Close(keep bool) error {
+	if err := os.Chdir(s.prevDir); err != nil {
+		return err
+	}
+	if keep {
+		return nil
+	}
+	return os.RemoveAll(s.Dir)
+}
@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox(t *testing.T) {
+	t.Setenv("GOCLI_SECRET_TOKEN", "hunter2")
+	prevDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	sandbox, err := NewSandbox()
+	require.NoError(t, err)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, sandbox.Dir, cwd)
+
+	env := sandbox.Env()
+	_, ok := env.Get("GOCLI_SECRET_TOKEN")
+	assert.False(t, ok)
+	home, _ := env.Get("HOME")
+	assert.Equal(t, sandbox.Dir, home)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox.Dir, "scratch"), nil, 0644))
+	require.NoError(t, sandbox.Close(false))
+
+	cwd, err = os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, prevDir, cwd)
+	assert.NoDirExists(t, sandbox.Dir)
+}
+
+func TestSandbox_Keep(t *testing.T) {
+	sandbox, err := NewSandbox()
+	require.NoError(t, err)
+	require.NoError(t, sandbox.Close(true))
+
+	assert.DirExists(t, sandbox.Dir)
+	require.NoError(t, os.RemoveAll(sandbox.Dir))
+}
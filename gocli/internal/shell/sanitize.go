@@ -0,0 +1,34 @@
+package shell
+
+import (
+	"path"
+	"strings"
+)
+
+// EnvAllowVar lists the variables, separated by spaces or commas, that
+// commands run with a sanitized environment get besides PATH, HOME and LANG.
+// Entries may be patterns, like LC_*.
+const EnvAllowVar = "GOCLI_ENV_ALLOW"
+
+// sanitizedEnvBase are the variables a sanitized environment always keeps.
+var sanitizedEnvBase = []string{"PATH", "HOME", "LANG"}
+
+// sanitizeEnv returns the variables of vars that are in sanitizedEnvBase or
+// match an entry of allow, the value of EnvAllowVar, so that tokens and
+// other secrets in the environment do not leak to programs that do not need them.
+func sanitizeEnv(vars map[string]string, allow string) map[string]string {
+	patterns := append(strings.FieldsFunc(allow, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	}), sanitizedEnvBase...)
+
+	kept := make(map[string]string)
+	for name, value := range vars {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				kept[name] = value
+				break
+			}
+		}
+	}
+	return kept
+}
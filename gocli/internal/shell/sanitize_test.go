@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeEnv(t *testing.T) {
+	vars := map[string]string{
+		"PATH": "/bin", "HOME": "/home/u", "LANG": "C",
+		"LC_ALL": "C", "LC_TIME": "C", "TERM": "xterm",
+		"AWS_SECRET_ACCESS_KEY": "secret", "GITHUB_TOKEN": "token",
+	}
+
+	assert.Equal(t, map[string]string{"PATH": "/bin", "HOME": "/home/u", "LANG": "C"}, sanitizeEnv(vars, ""))
+	assert.Equal(t, map[string]string{
+		"PATH": "/bin", "HOME": "/home/u", "LANG": "C",
+		"LC_ALL": "C", "LC_TIME": "C", "TERM": "xterm",
+	}, sanitizeEnv(vars, "LC_*, TERM MISSING"))
+}
+
+func newSanitizeTestShell(t *testing.T) (*Shell, *os.File) {
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stdout.Close() })
+	env := NewEnvFromMap(map[string]string{
+		"PATH":         os.Getenv("PATH"),
+		"LANG":         "C",
+		"GITHUB_TOKEN": "token",
+		"TERM":         "dumb",
+	})
+	return NewShell(WithEnv(env), WithStdout(stdout)), stdout
+}
+
+func TestEnvCommand_Sanitized(t *testing.T) {
+	sh, stdout := newSanitizeTestShell(t)
+
+	_, _, err := sh.Execute("env --sanitized X=1")
+	require.NoError(t, err)
+	assert.Equal(t, "LANG=C\nPATH="+os.Getenv("PATH")+"\nX=1\n", readShellOutput(t, stdout))
+
+	retCode, _, err := sh.Execute(`GOCLI_ENV_ALLOW=TERM; env --sanitized sh -c 'echo "${GITHUB_TOKEN:-unset} $TERM"'`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Contains(t, readShellOutput(t, stdout), "\nunset dumb\n")
+}
+
+func TestOptionSanitizeEnv(t *testing.T) {
+	sh, stdout := newSanitizeTestShell(t)
+
+	_, _, err := sh.Execute(`sh -c 'echo "${GITHUB_TOKEN:-unset}"'`)
+	require.NoError(t, err)
+	require.NoError(t, sh.SetOption(OptionSanitizeEnv, true))
+	_, _, err = sh.Execute(`sh -c 'echo "${GITHUB_TOKEN:-unset} $LANG"'`)
+	require.NoError(t, err)
+	assert.Equal(t, "token\nunset C\n", readShellOutput(t, stdout))
+}
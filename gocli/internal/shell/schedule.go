@@ -0,0 +1,358 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scheduleTimeLayout is how the scheduler prints the next run of a job.
+const scheduleTimeLayout = "2006-01-02 15:04:05"
+
+// scheduledJob is a command line the session runs at a given time, once
+// for at or repeatedly for every. It is saved with the session.
+type scheduledJob struct {
+	ID   int    `json:"id"`
+	Line string `json:"line"`
+	// Dir is the working directory the job was scheduled in, and runs in.
+	Dir  string    `json:"dir"`
+	Next time.Time `json:"next"`
+	// Every is the interval of a repeating job, zero for a one-shot one.
+	Every time.Duration `json:"every,omitempty"`
+}
+
+// scheduler keeps the jobs of the session and runs each one when it is
+// due, from its own goroutine, through run.
+type scheduler struct {
+	mu     sync.Mutex
+	jobs   []*scheduledJob
+	nextID int
+	// run executes a due job; the loop only starts once it is set.
+	run     func(job scheduledJob)
+	running bool
+	wake    chan struct{}
+	done    chan struct{}
+	now     func() time.Time
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		nextID: 1,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+// add schedules a job and returns it with its ID.
+func (s *scheduler) add(job scheduledJob) scheduledJob {
+	s.mu.Lock()
+	job.ID = s.nextID
+	s.nextID++
+	s.jobs = append(s.jobs, &job)
+	s.mu.Unlock()
+	s.changed()
+	return job
+}
+
+// cancel removes a job and reports whether it existed.
+func (s *scheduler) cancel(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, job := range s.jobs {
+		if job.ID == id {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the jobs in the order they were scheduled.
+func (s *scheduler) list() []scheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]scheduledJob, len(s.jobs))
+	for i, job := range s.jobs {
+		jobs[i] = *job
+	}
+	return jobs
+}
+
+// restore adds the jobs of a saved session under their IDs. Repeating jobs
+// skip the runs missed while no session was running; one-shot jobs whose
+// time has passed run right away, like those of at(1).
+func (s *scheduler) restore(jobs []scheduledJob) {
+	now := s.now()
+	s.mu.Lock()
+	for _, job := range jobs {
+		if job.Every > 0 && job.Next.Before(now) {
+			job.Next = job.Next.Add((now.Sub(job.Next)/job.Every + 1) * job.Every)
+		}
+		s.jobs = append(s.jobs, &job)
+		s.nextID = max(s.nextID, job.ID+1)
+	}
+	sort.Slice(s.jobs, func(i, j int) bool { return s.jobs[i].ID < s.jobs[j].ID })
+	s.mu.Unlock()
+	s.changed()
+}
+
+// due takes the jobs whose time has come: one-shot jobs are removed and
+// repeating ones move on to their next run after now.
+func (s *scheduler) due(now time.Time) []scheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []scheduledJob
+	kept := s.jobs[:0]
+	for _, job := range s.jobs {
+		if job.Next.After(now) {
+			kept = append(kept, job)
+			continue
+		}
+		due = append(due, *job)
+		if job.Every > 0 {
+			for !job.Next.After(now) {
+				job.Next = job.Next.Add(job.Every)
+			}
+			kept = append(kept, job)
+		}
+	}
+	s.jobs = kept
+	return due
+}
+
+// nextRun returns the earliest time a job is due, if there are jobs.
+func (s *scheduler) nextRun() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, job := range s.jobs {
+		if next.IsZero() || job.Next.Before(next) {
+			next = job.Next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// changed starts the loop if it is not running yet and makes it look at the jobs again.
+func (s *scheduler) changed() {
+	s.mu.Lock()
+	start := !s.running && s.run != nil
+	s.running = s.running || start
+	s.mu.Unlock()
+	if start {
+		go s.loop()
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the loop; jobs are not run after the session ends.
+func (s *scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+func (s *scheduler) loop() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := time.Hour
+		if next, ok := s.nextRun(); ok {
+			wait = max(0, next.Sub(s.now()))
+		}
+		timer.Reset(wait)
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+			continue
+		case <-timer.C:
+		}
+		for _, job := range s.due(s.now()) {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.run(job)
+		}
+	}
+}
+
+// runScheduled runs a job between the command lines of the session, never
+// during one, as a subshell in the directory of the job. Its output goes
+// where the output of the session does.
+func (s *Shell) runScheduled(job scheduledJob) {
+	s.busy.Lock()
+	defer s.busy.Unlock()
+
+	cwd, err := os.Getwd()
+	if err == nil {
+		err = os.Chdir(job.Dir)
+	}
+	if err != nil {
+		s.logger.Warn("scheduled job failed", "id", job.ID, "error", err)
+		_, _ = fmt.Fprintf(s.stderr, "schedule: job %d: %v\n", job.ID, err)
+		return
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+
+	retCode, _, err := s.execute("("+job.Line+")", false)
+	if err != nil {
+		_, _ = fmt.Fprintf(s.stderr, "schedule: job %d: %v\n", job.ID, err)
+	}
+	s.logger.Info("scheduled job finished", "id", job.ID, "status", retCode)
+}
+
+// formatInterval prints an interval without zero units at the end, like "5m" or "1h30m".
+func formatInterval(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// scheduleAddCommand is every or at: it schedules the rest of the command
+// line in the current directory. Like defer, arguments are expanded when
+// the job is scheduled unless they are single-quoted.
+type scheduleAddCommand struct {
+	name     string
+	every    time.Duration
+	at       string
+	line     string
+	schedule *scheduler
+}
+
+func parseEveryCommand(d CommandDescription, schedule *scheduler) (Command, error) {
+	if len(d.arguments) < 3 {
+		return nil, fmt.Errorf("every: usage: every INTERVAL COMMAND")
+	}
+	interval, err := parseSleepDuration(d.arguments[1])
+	if err != nil {
+		return nil, fmt.Errorf("every: %w", err)
+	}
+	if interval < time.Second {
+		return nil, fmt.Errorf("every: the interval must be at least one second")
+	}
+	return &scheduleAddCommand{name: "every", every: interval, line: commandLine(d, 2), schedule: schedule}, nil
+}
+
+func parseAtCommand(d CommandDescription, schedule *scheduler) (Command, error) {
+	if len(d.arguments) < 3 {
+		return nil, fmt.Errorf("at: usage: at HH:MM[:SS] | DATE COMMAND")
+	}
+	return &scheduleAddCommand{name: "at", at: d.arguments[1], line: commandLine(d, 2), schedule: schedule}, nil
+}
+
+// nextClockTime returns the next time the clock shows HH:MM[:SS]: today,
+// or tomorrow if that time has passed.
+func nextClockTime(value string, now time.Time) (time.Time, bool) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, true
+	}
+	return time.Time{}, false
+}
+
+// Execute reports the job like at(1) does, on stderr: "job 1 at 2006-01-02 15:04:05".
+func (c *scheduleAddCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", c.name, err)
+		return 1, false
+	}
+
+	now := c.schedule.now()
+	job := scheduledJob{Line: c.line, Dir: dir, Every: c.every, Next: now.Add(c.every)}
+	if c.at != "" {
+		next, ok := nextClockTime(c.at, now)
+		if !ok {
+			if next, err = parseDate(c.at, time.Local); err != nil {
+				_, _ = fmt.Fprintf(errOut, "at: %v\n", err)
+				return 1, false
+			}
+			if !next.After(now) {
+				_, _ = fmt.Fprintf(errOut, "at: %s is in the past\n", c.at)
+				return 1, false
+			}
+		}
+		job.Next = next
+	}
+
+	job = c.schedule.add(job)
+	_, _ = fmt.Fprintf(errOut, "job %d at %s\n", job.ID, job.Next.Local().Format(scheduleTimeLayout))
+	return 0, false
+}
+
+// scheduleCommand lists the jobs of the session or cancels them.
+type scheduleCommand struct {
+	cancel   []int
+	schedule *scheduler
+}
+
+func parseScheduleCommand(d CommandDescription, schedule *scheduler) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) == 0 || len(args) == 1 && args[0] == "list" {
+		return &scheduleCommand{schedule: schedule}, nil
+	}
+	if args[0] != "cancel" || len(args) == 1 {
+		return nil, fmt.Errorf("schedule: usage: schedule [list] | schedule cancel ID...")
+	}
+	c := &scheduleCommand{schedule: schedule}
+	for _, arg := range args[1:] {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("schedule: %s: invalid job ID", arg)
+		}
+		c.cancel = append(c.cancel, id)
+	}
+	return c, nil
+}
+
+// Execute prints a line per job: its ID, next run, interval or "once",
+// directory and command line.
+func (c *scheduleCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if len(c.cancel) > 0 {
+		for _, id := range c.cancel {
+			if !c.schedule.cancel(id) {
+				_, _ = fmt.Fprintf(errOut, "schedule: no job %d\n", id)
+				retCode = 1
+			}
+		}
+		return retCode, false
+	}
+
+	var sb strings.Builder
+	for _, job := range c.schedule.list() {
+		repeat := "once"
+		if job.Every > 0 {
+			repeat = "every " + formatInterval(job.Every)
+		}
+		_, _ = fmt.Fprintf(&sb, "%d\t%s\t%s\t%s\t%s\n", job.ID, job.Next.Local().Format(scheduleTimeLayout), repeat, job.Dir, job.Line)
+	}
+	_, _ = out.WriteString(sb.String())
+	return 0, false
+}
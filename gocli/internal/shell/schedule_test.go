@@ -0,0 +1,126 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Due(t *testing.T) {
+	start := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newScheduler()
+	repeating := s.add(scheduledJob{Line: "tick", Next: start.Add(5 * time.Minute), Every: 5 * time.Minute})
+	once := s.add(scheduledJob{Line: "once", Next: start.Add(time.Hour)})
+	assert.Equal(t, []int{1, 2}, []int{repeating.ID, once.ID})
+
+	assert.Empty(t, s.due(start))
+	due := s.due(start.Add(12 * time.Minute))
+	require.Len(t, due, 1)
+	assert.Equal(t, "tick", due[0].Line)
+	next, ok := s.nextRun()
+	require.True(t, ok)
+	assert.Equal(t, start.Add(15*time.Minute), next, "missed runs are skipped")
+
+	due = s.due(start.Add(time.Hour))
+	assert.Len(t, due, 2)
+	jobs := s.list()
+	require.Len(t, jobs, 1, "one-shot jobs are removed once run")
+	assert.Equal(t, 1, jobs[0].ID)
+
+	assert.True(t, s.cancel(1))
+	assert.False(t, s.cancel(1))
+	_, ok = s.nextRun()
+	assert.False(t, ok)
+}
+
+func TestScheduler_Restore(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	s := newScheduler()
+	s.now = func() time.Time { return now }
+	s.restore([]scheduledJob{
+		{ID: 4, Line: "late", Next: now.Add(-time.Hour)},
+		{ID: 2, Line: "tick", Next: now.Add(-7 * time.Minute), Every: 5 * time.Minute},
+	})
+
+	jobs := s.list()
+	require.Len(t, jobs, 2)
+	assert.Equal(t, 2, jobs[0].ID)
+	assert.Equal(t, now.Add(3*time.Minute), jobs[0].Next)
+	assert.Equal(t, now.Add(-time.Hour), jobs[1].Next, "missed one-shot jobs run at once")
+	assert.Equal(t, 5, s.add(scheduledJob{Line: "new"}).ID)
+}
+
+func TestNextClockTime(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	next, ok := nextClockTime("18:30", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC), next)
+	next, ok = nextClockTime("09:15:30", now)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 6, 9, 15, 30, 0, time.UTC), next)
+	_, ok = nextClockTime("2024-03-06", now)
+	assert.False(t, ok)
+}
+
+func TestScheduleCommands(t *testing.T) {
+	isolateConfig(t)
+	dir := tempWorkDir(t)
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("every 90m 'echo $HOME' 2> /dev/null; at 2999-01-01 'echo later' 2> /dev/null; every 5s 'x' 2> /dev/null; " +
+		`at 2999-01-01 rm "my file" '$F' 2> /dev/null; schedule cancel 3; schedule`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	lines := strings.Split(strings.TrimSuffix(readShellOutput(t, stdout), "\n"), "\n")
+	require.Len(t, lines, 3)
+	fields := strings.Split(lines[0], "\t")
+	require.Len(t, fields, 5)
+	assert.Equal(t, []string{"1", "every 1h30m", dir, "echo $HOME"}, []string{fields[0], fields[2], fields[3], fields[4]})
+	assert.Equal(t, "2\t2999-01-01 00:00:00\tonce\t"+dir+"\techo later", lines[1])
+	assert.Equal(t, "4\t2999-01-01 00:00:00\tonce\t"+dir+"\trm 'my file' $F", lines[2], "quoting is kept")
+
+	for _, line := range []string{"every 0.5 x", "every 5m", "at 25:00 x", "at 2000-01-01 x", "schedule cancel 9", "schedule cancel x", "schedule stop"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+
+	require.NoError(t, sh.SaveSession())
+	restored := NewShell(WithEnv(NewEnvFromMap(nil)))
+	require.NoError(t, restored.RestoreSession())
+	defer restored.factory.schedule.stop()
+	saved, loaded := sh.factory.schedule.list(), restored.factory.schedule.list()
+	require.Len(t, loaded, len(saved))
+	for i := range saved {
+		assert.True(t, saved[i].Next.Equal(loaded[i].Next))
+		saved[i].Next, loaded[i].Next = time.Time{}, time.Time{}
+	}
+	assert.Equal(t, saved, loaded)
+}
+
+func TestShell_RunScheduled(t *testing.T) {
+	dir := tempWorkDir(t)
+	require.NoError(t, os.Mkdir("jobs", 0755))
+	sh, _ := newTestShell(t)
+	defer sh.factory.schedule.stop()
+
+	sh.factory.schedule.restore([]scheduledJob{{ID: 1, Line: "cd ..; LEAKED=yes; pwd > jobs/ran", Dir: filepath.Join(dir, "jobs"), Next: time.Now().Add(-time.Second)}})
+	require.Eventually(t, func() bool {
+		_, err := os.Stat("jobs/ran")
+		return err == nil && len(sh.factory.schedule.list()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sh.busy.Lock()
+	defer sh.busy.Unlock()
+	content, err := os.ReadFile("jobs/ran")
+	require.NoError(t, err)
+	assert.Equal(t, dir+"\n", string(content))
+	assert.Equal(t, dir, currentDir(t), "the job does not change the directory of the session")
+	_, ok := sh.env.Get("LEAKED")
+	assert.False(t, ok, "the job runs in a subshell")
+}
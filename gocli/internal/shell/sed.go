@@ -0,0 +1,334 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// sedInstruction is one command of a sed script: "s/RE/REPLACEMENT/FLAGS", "p" or "d".
+type sedInstruction struct {
+	op          byte
+	re          *regexp.Regexp
+	replacement string
+	global      bool
+	print       bool
+}
+
+// sedCommand is a stream editor supporting a subset of sed: substitutions with
+// Go regular expressions, printing and deleting lines.
+type sedCommand struct {
+	script    []sedInstruction
+	quiet     bool
+	filePaths []string
+	// inPlace writes the output back to each file instead of to stdout,
+	// keeping a copy of the original under its name plus backupSuffix if set.
+	inPlace      bool
+	backupSuffix string
+}
+
+func parseSedCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("sed")
+	quiet := fs.Bool("n", false, "print only the lines printed by the script")
+	var scripts []string
+	fs.Func("e", "add the `SCRIPT` to the commands to run", func(s string) error {
+		scripts = append(scripts, s)
+		return nil
+	})
+	// Patterns are always extended regular expressions; -E is accepted for compatibility.
+	_ = fs.Bool("E", false, "use extended regular expressions (always on)")
+	inPlace := fs.Bool("i", false, "edit files in place, with -iSUFFIX keeping a backup")
+
+	// The backup suffix is attached to -i, so it is taken off before parsing.
+	var suffix string
+	args := append([]string(nil), d.arguments[1:]...)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		if arg == "-e" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-i") && len(arg) > 2 {
+			args[i], suffix = "-i", arg[2:]
+		}
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return nil, fmt.Errorf("sed: %w", err)
+	}
+	args = fs.Args()
+	if len(scripts) == 0 {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("sed: usage: sed [-n] [-i[SUFFIX]] SCRIPT [FILE...]")
+		}
+		scripts, args = args[:1], args[1:]
+	}
+	if *inPlace && len(args) == 0 {
+		return nil, fmt.Errorf("sed: no input files")
+	}
+
+	var script []sedInstruction
+	for _, text := range scripts {
+		instructions, err := parseSedScript(text)
+		if err != nil {
+			return nil, fmt.Errorf("sed: %w", err)
+		}
+		script = append(script, instructions...)
+	}
+
+	if len(args) == 0 && d.fileInPath != "" {
+		args = []string{d.fileInPath}
+	}
+	return &sedCommand{script: script, quiet: *quiet, filePaths: args, inPlace: *inPlace, backupSuffix: suffix}, nil
+}
+
+// parseSedScript parses commands separated by ";" or newlines.
+func parseSedScript(text string) ([]sedInstruction, error) {
+	var script []sedInstruction
+	for i := 0; i < len(text); {
+		switch c := text[i]; c {
+		case ' ', '\t', '\n', ';':
+			i++
+		case 'p', 'd':
+			script = append(script, sedInstruction{op: c})
+			i++
+		case 's':
+			instruction, end, err := parseSedSubstitution(text, i+1)
+			if err != nil {
+				return nil, err
+			}
+			script = append(script, instruction)
+			i = end
+		default:
+			return nil, fmt.Errorf("unknown command: `%c'", c)
+		}
+	}
+	return script, nil
+}
+
+// parseSedSubstitution parses the part of "s/RE/REPLACEMENT/FLAGS" that starts
+// with the delimiter at text[start] and returns the offset just after it.
+func parseSedSubstitution(text string, start int) (sedInstruction, int, error) {
+	if start >= len(text) || text[start] == '\\' || text[start] == '\n' {
+		return sedInstruction{}, 0, fmt.Errorf("unterminated `s' command")
+	}
+	delim := text[start]
+	pattern, i, ok := readSedPart(text, start+1, delim)
+	if !ok {
+		return sedInstruction{}, 0, fmt.Errorf("unterminated `s' command")
+	}
+	replacement, i, ok := readSedPart(text, i, delim)
+	if !ok {
+		return sedInstruction{}, 0, fmt.Errorf("unterminated `s' command")
+	}
+
+	instruction := sedInstruction{op: 's', replacement: replacement}
+	caseInsensitive := false
+	for ; i < len(text) && !strings.ContainsRune(" \t\n;", rune(text[i])); i++ {
+		switch text[i] {
+		case 'g':
+			instruction.global = true
+		case 'i', 'I':
+			caseInsensitive = true
+		case 'p':
+			instruction.print = true
+		default:
+			return sedInstruction{}, 0, fmt.Errorf("unknown option to `s': `%c'", text[i])
+		}
+	}
+
+	if caseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return sedInstruction{}, 0, err
+	}
+	instruction.re = re
+	return instruction, i, nil
+}
+
+// readSedPart reads up to the next unescaped delim. An escaped delimiter
+// stands for the character itself, other escapes are kept.
+func readSedPart(text string, start int, delim byte) (string, int, bool) {
+	var sb strings.Builder
+	for i := start; i < len(text); i++ {
+		switch {
+		case text[i] == delim:
+			return sb.String(), i + 1, true
+		case text[i] == '\\' && i+1 < len(text):
+			if text[i+1] != delim {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(text[i+1])
+			i++
+		default:
+			sb.WriteByte(text[i])
+		}
+	}
+	return "", 0, false
+}
+
+// substitute applies a substitution to line and reports whether anything was replaced.
+func (s *sedInstruction) substitute(line string) (string, bool) {
+	n := 1
+	if s.global {
+		n = -1
+	}
+	matches := s.re.FindAllStringSubmatchIndex(line, n)
+	if len(matches) == 0 {
+		return line, false
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, match := range matches {
+		sb.WriteString(line[last:match[0]])
+		s.expandReplacement(&sb, line, match)
+		last = match[1]
+	}
+	sb.WriteString(line[last:])
+	return sb.String(), true
+}
+
+// expandReplacement writes the replacement for match: "&" is the matched text,
+// "\1".."\9" are groups, "\n" is a newline and other escaped characters stand for themselves.
+func (s *sedInstruction) expandReplacement(sb *strings.Builder, line string, match []int) {
+	rep := s.replacement
+	for i := 0; i < len(rep); i++ {
+		c := rep[i]
+		switch {
+		case c == '&':
+			sb.WriteString(line[match[0]:match[1]])
+		case c == '\\' && i+1 < len(rep):
+			i++
+			switch next := rep[i]; {
+			case next >= '0' && next <= '9':
+				group := int(next - '0')
+				if 2*group+1 < len(match) && match[2*group] >= 0 {
+					sb.WriteString(line[match[2*group]:match[2*group+1]])
+				}
+			case next == 'n':
+				sb.WriteByte('\n')
+			default:
+				sb.WriteByte(next)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (s *sedCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if s.inPlace {
+		return s.editInPlace(errOut), false
+	}
+	sources := s.filePaths
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+
+	writer := bufio.NewWriter(out)
+	defer func() { _ = writer.Flush() }()
+	for _, path := range sources {
+		source := in
+		if path != "" {
+			file, err := os.Open(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "sed: %v\n", err)
+				retCode = 2
+				continue
+			}
+			source = file
+		}
+
+		scanner := bufio.NewScanner(source)
+		for scanner.Scan() {
+			s.editLine(writer, scanner.Text())
+		}
+		err := scanner.Err()
+
+		if path != "" {
+			_ = source.Close()
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "sed: %v\n", err)
+			return 2, false
+		}
+	}
+	return retCode, false
+}
+
+// editInPlace replaces every file with the output of the script on it. The
+// output goes to a temporary file that is renamed over the original, so an
+// error leaves the file as it was.
+func (s *sedCommand) editInPlace(errOut *os.File) (retCode int) {
+	for _, path := range s.filePaths {
+		if err := s.editFile(path); err != nil {
+			_, _ = fmt.Fprintf(errOut, "sed: %v\n", err)
+			retCode = 2
+		}
+	}
+	return retCode
+}
+
+func (s *sedCommand) editFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Close() }()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if s.backupSuffix != "" {
+		if err := copyFile(path, path+s.backupSuffix, info.Mode()); err != nil {
+			return err
+		}
+	}
+
+	target, commit, err := openAtomic(path, 0666, nil)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(target)
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		s.editLine(writer, scanner.Text())
+	}
+	err = scanner.Err()
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if commitErr := commit(err == nil); err == nil {
+		err = commitErr
+	}
+	return err
+}
+
+// editLine runs the script on line and writes what it prints.
+func (s *sedCommand) editLine(w *bufio.Writer, line string) {
+	for i := range s.script {
+		instruction := &s.script[i]
+		switch instruction.op {
+		case 'p':
+			_, _ = w.WriteString(line + "\n")
+		case 'd':
+			return
+		case 's':
+			var replaced bool
+			line, replaced = instruction.substitute(line)
+			if replaced && instruction.print {
+				_, _ = w.WriteString(line + "\n")
+			}
+		}
+	}
+	if !s.quiet {
+		_, _ = w.WriteString(line + "\n")
+	}
+}
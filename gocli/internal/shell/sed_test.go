@@ -0,0 +1,90 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSed(t *testing.T, content string, args ...string) string {
+	testFile := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte(content), 0644))
+
+	cmd, err := parseSedCommand(CommandDescription{
+		name:      SedCommand,
+		arguments: append(append([]string{"sed"}, args...), testFile),
+	})
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestSedCommand_Execute_Substitute(t *testing.T) {
+	input := "foo foo\nbar\nFOO\n"
+	assert.Equal(t, "baz foo\nbar\nFOO\n", runSed(t, input, "s/foo/baz/"))
+	assert.Equal(t, "baz baz\nbar\nFOO\n", runSed(t, input, "s/foo/baz/g"))
+	assert.Equal(t, "baz baz\nbar\nbaz\n", runSed(t, input, "s/foo/baz/gi"))
+	assert.Equal(t, "[foo] foo\n", runSed(t, "foo foo\n", "s/fo+/[&]/"))
+	assert.Equal(t, "b=a\n", runSed(t, "a=b\n", `s/(\w)=(\w)/\2=\1/`))
+	assert.Equal(t, "/usr/bin\n", runSed(t, "/usr/local/bin\n", `s|/local||`))
+	assert.Equal(t, "a/b\n", runSed(t, "a-b\n", `s/-/\//`))
+	assert.Equal(t, "a\nb &\n", runSed(t, "a b\n", `s/ /\n/;s/$/ \&/`))
+}
+
+func TestSedCommand_Execute_Print(t *testing.T) {
+	input := "one\ntwo\nthree\n"
+	assert.Equal(t, "2\n", runSed(t, input, "-n", "s/two/2/p"))
+	assert.Equal(t, "one\none\ntwo\ntwo\nthree\nthree\n", runSed(t, input, "p"))
+	assert.Equal(t, "one\ntwo\nthree\n", runSed(t, input, "-n", "p"))
+	assert.Equal(t, "", runSed(t, input, "d"))
+	assert.Equal(t, "TWO\n", runSed(t, input, "-n", "-e", "s/two/TWO/", "-e", "s/O$/O/p"))
+}
+
+func TestSedCommand_Execute_InPlace(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("a.txt", []byte("one\ntwo\n"), 0600))
+	require.NoError(t, os.WriteFile("b.txt", []byte("two\n"), 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("sed -i s/two/2/ a.txt b.txt; sed -ni.bak -e 's/one/1/p' a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Empty(t, readShellOutput(t, stdout))
+
+	for name, want := range map[string]string{"a.txt": "1\n", "a.txt.bak": "one\n2\n", "b.txt": "2\n"} {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), name)
+	}
+	info, err := os.Stat("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestParseSedCommand_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"sed"},
+		{"sed", "-i", "p"},
+		{"sed", "s/a/b"},
+		{"sed", "s/a/b/x"},
+		{"sed", "s/(/b/"},
+		{"sed", "y/a/b/"},
+	} {
+		_, err := parseSedCommand(CommandDescription{name: SedCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
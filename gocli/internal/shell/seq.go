@@ -0,0 +1,154 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// seqCommand prints numbers from first to last by increment, like seq(1).
+// Numbers may be fractional; they are printed with as many decimals as
+// first or the increment has.
+type seqCommand struct {
+	first, incr, last float64
+	decimals          int
+	separator         string
+	equalWidth        bool
+}
+
+// parseSeqCommand reads options by hand, since "seq -5 5" starts with a
+// negative number rather than an option.
+func parseSeqCommand(d CommandDescription) (Command, error) {
+	s := &seqCommand{separator: "\n"}
+	args := d.arguments[1:]
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			args = args[1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || isSeqNumber(arg) {
+			break
+		}
+		switch {
+		case arg == "-w":
+			s.equalWidth = true
+		case arg == "-s":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("seq: option requires an argument -- 's'")
+			}
+			s.separator = args[1]
+			args = args[1:]
+		case strings.HasPrefix(arg, "-s"):
+			s.separator = arg[2:]
+		default:
+			return nil, fmt.Errorf("seq: invalid option '%s'", arg)
+		}
+		args = args[1:]
+	}
+
+	numbers := make([]float64, len(args))
+	for i, arg := range args {
+		n, err := strconv.ParseFloat(arg, 64)
+		if !isSeqNumber(arg) || err != nil {
+			return nil, fmt.Errorf("seq: invalid floating point argument: '%s'", arg)
+		}
+		numbers[i] = n
+	}
+	s.first, s.incr = 1, 1
+	switch len(numbers) {
+	case 1:
+		s.last = numbers[0]
+	case 2:
+		s.first, s.last = numbers[0], numbers[1]
+	case 3:
+		s.first, s.incr, s.last = numbers[0], numbers[1], numbers[2]
+		if s.incr == 0 {
+			return nil, fmt.Errorf("seq: invalid Zero increment value: '%s'", args[1])
+		}
+	default:
+		return nil, fmt.Errorf("seq: usage: seq [-s SEPARATOR] [-w] [FIRST [INCREMENT]] LAST")
+	}
+	if len(numbers) > 1 {
+		s.decimals = decimalPlaces(args[0])
+	}
+	if len(numbers) == 3 {
+		s.decimals = max(s.decimals, decimalPlaces(args[1]))
+	}
+	return s, nil
+}
+
+// isSeqNumber tells a decimal number apart from an option and from the
+// words like "inf" that strconv accepts as well.
+func isSeqNumber(arg string) bool {
+	arg = strings.TrimLeft(arg, "+-")
+	return arg != "" && strings.Trim(arg, "0123456789.eE+-") == "" && arg[0] != 'e' && arg[0] != 'E'
+}
+
+// decimalPlaces counts the digits after the decimal point of a number.
+func decimalPlaces(number string) int {
+	number, _, _ = strings.Cut(strings.ToLower(number), "e")
+	if _, fraction, ok := strings.Cut(number, "."); ok {
+		return len(fraction)
+	}
+	return 0
+}
+
+func (s *seqCommand) format(value float64) string {
+	return strconv.FormatFloat(value, 'f', s.decimals, 64)
+}
+
+// Execute computes each number from first rather than by adding up the
+// increment, so that fractions do not drift. It stops quietly with 1 when
+// the output pipe is closed, like yes, and with 130 when interrupted.
+func (s *seqCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	steps := math.Floor((s.last-s.first)/s.incr + 1e-9)
+	if steps < 0 || math.IsNaN(steps) {
+		return 0, false
+	}
+
+	width := 0
+	if s.equalWidth {
+		width = max(len(s.format(s.first)), len(s.format(s.first+steps*s.incr)))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := bufio.NewWriter(out)
+	for i := 0.0; i <= steps; i++ {
+		if math.Mod(i, 1024) == 0 && ctx.Err() != nil {
+			_ = w.Flush()
+			return 130, false
+		}
+		text := s.format(s.first + i*s.incr)
+		if sign, digits, negative := strings.Cut(text, "-"); negative && sign == "" {
+			text = "-" + strings.Repeat("0", max(0, width-len(text))) + digits
+		} else {
+			text = strings.Repeat("0", max(0, width-len(text))) + text
+		}
+		if i > 0 {
+			_, _ = w.WriteString(s.separator)
+		}
+		if _, err := w.WriteString(text); err != nil {
+			return s.writeFailed(err, errOut)
+		}
+	}
+	_ = w.WriteByte('\n')
+	if err := w.Flush(); err != nil {
+		return s.writeFailed(err, errOut)
+	}
+	return 0, false
+}
+
+func (s *seqCommand) writeFailed(err error, errOut *os.File) (retCode int, exited bool) {
+	if !errors.Is(err, syscall.EPIPE) {
+		_, _ = fmt.Fprintf(errOut, "seq: %v\n", err)
+	}
+	return 1, false
+}
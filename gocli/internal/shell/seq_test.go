@@ -0,0 +1,23 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeqCommand(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("seq 3; seq -s , 2 4; seq 5 -2 0; seq -s ' ' 0 0.25 1; seq -w 8 10; seq -w -s , -- -2 1; " +
+		"seq 5 1; echo $(seq 1 3); seq 1 1000000000 | head -n 2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "1\n2\n3\n2,3,4\n5\n3\n1\n0.00 0.25 0.50 0.75 1.00\n08\n09\n10\n-2,-1,00,01\n1 2 3\n1\n2\n", readShellOutput(t, stdout))
+
+	for _, args := range [][]string{{"seq"}, {"seq", "1", "0", "5"}, {"seq", "x"}, {"seq", "inf"}, {"seq", "-x", "3"}, {"seq", "1", "2", "3", "4"}, {"seq", "-s"}} {
+		_, err := sh.factory.GetCommand(CommandDescription{name: SeqCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const sessionFile = "session.json"
+
+// sessionState is what a session leaves behind for "gocli --resume".
+type sessionState struct {
+	Dir      string            `json:"dir"`
+	DirStack []string          `json:"dir_stack,omitempty"`
+	Vars     map[string]string `json:"vars,omitempty"`
+	// Exported lists the variables of Vars that are exported.
+	Exported []string `json:"exported,omitempty"`
+	// Schedule holds the jobs scheduled with every and at that have not run out.
+	Schedule []scheduledJob `json:"schedule,omitempty"`
+}
+
+func sessionPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionFile), nil
+}
+
+// SaveSession writes the working directory, the directory stack and the
+// variables set during the session (those missing from or differing from
+// the process environment) to the session file in the config directory.
+func (s *Shell) SaveSession() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	state := sessionState{
+		Dir:      dir,
+		DirStack: s.factory.dirs.list(),
+		Vars:     make(map[string]string),
+		Schedule: s.factory.schedule.list(),
+	}
+	exported := s.env.Exported()
+	for key, value := range s.env.GetAll() {
+		if inherited, ok := os.LookupEnv(key); !ok || inherited != value {
+			state.Vars[key] = value
+			if _, ok := exported[key]; ok {
+				state.Exported = append(state.Exported, key)
+			}
+		}
+	}
+	sort.Strings(state.Exported)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// RestoreSession loads the state written by SaveSession. Variables and the
+// directory stack are restored even if the saved directory no longer exists,
+// in which case the error is returned after everything else is applied.
+func (s *Shell) RestoreSession() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	for key, value := range state.Vars {
+		s.env.Set(key, value)
+	}
+	for _, key := range state.Exported {
+		s.env.Export(key)
+	}
+	for _, dir := range state.DirStack {
+		s.factory.dirs.push(dir)
+	}
+	s.factory.schedule.restore(state.Schedule)
+	return changeDir(state.Dir, s.env, nil, s.factory.events)
+}
@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_SaveRestoreSession(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+	t.Setenv("INHERITED", "from process")
+
+	sh, _ := newTestShell(t)
+	for _, line := range []string{"mkcd a", "mkcd b", "GREETING=hello", "INHERITED=changed", "export LOUD=yes"} {
+		_, _, err := sh.Execute(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, sh.SaveSession())
+	require.NoError(t, os.Chdir(root))
+
+	restored := NewShell(WithEnv(NewEnvFromMap(nil)))
+	require.NoError(t, restored.RestoreSession())
+
+	assert.Equal(t, filepath.Join(root, "a", "b"), currentDir(t))
+	assert.Equal(t, []string{root, filepath.Join(root, "a")}, restored.factory.dirs.list())
+	greeting, _ := restored.env.Get("GREETING")
+	assert.Equal(t, "hello", greeting)
+	inherited, _ := restored.env.Get("INHERITED")
+	assert.Equal(t, "changed", inherited)
+	exported := restored.env.Exported()
+	assert.Equal(t, "yes", exported["LOUD"])
+	assert.Equal(t, "changed", exported["INHERITED"])
+	_, ok := exported["GREETING"]
+	assert.False(t, ok, "variables stay unexported")
+	_, ok = restored.env.Get("HOME")
+	assert.False(t, ok, "unchanged process variables are not saved")
+}
+
+func TestShell_RestoreSession_MissingDirectory(t *testing.T) {
+	isolateConfig(t)
+	root := tempWorkDir(t)
+
+	sh, _ := newTestShell(t)
+	_, _, err := sh.Execute("mkcd gone")
+	require.NoError(t, err)
+	_, _, err = sh.Execute("X=1")
+	require.NoError(t, err)
+	require.NoError(t, sh.SaveSession())
+	require.NoError(t, os.Chdir(root))
+	require.NoError(t, os.Remove("gone"))
+
+	restored, _ := newTestShell(t)
+	assert.Error(t, restored.RestoreSession())
+	x, _ := restored.env.Get("X")
+	assert.Equal(t, "1", x)
+	assert.Equal(t, root, currentDir(t))
+}
+
+func TestShell_RestoreSession_NoFile(t *testing.T) {
+	isolateConfig(t)
+
+	sh, _ := newTestShell(t)
+	assert.Error(t, sh.RestoreSession())
+}
@@ -0,0 +1,42 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// shutdownCause is the cause of the cancelled context of a shell that
+// Shutdown was called on.
+type shutdownCause struct {
+	sig os.Signal
+}
+
+func (c shutdownCause) Error() string {
+	return "shutdown on " + c.sig.String()
+}
+
+// Shutdown asks the session to end because the shell received sig,
+// typically SIGTERM or SIGHUP. It may be called from any goroutine, such as
+// a signal handler. Running external commands are sent SIGHUP, like a
+// terminal hang-up would; the rest of the current line runs to its end.
+// Run then returns the exit status for the signal, 128 plus its number,
+// after running the commands queued with defer on its own goroutine.
+func (s *Shell) Shutdown(sig os.Signal) {
+	hungUp := s.factory.children.signal(hangUpSignal)
+	s.logger.Info("shutting down", "signal", sig, "commands_hung_up", hungUp)
+	s.stop(shutdownCause{sig: sig})
+}
+
+// shutdownStatus returns the exit status of a session ended by Shutdown
+// and reports whether it was.
+func (s *Shell) shutdownStatus() (int, bool) {
+	cause, ok := context.Cause(s.ctx).(shutdownCause)
+	if !ok {
+		return 0, false
+	}
+	if num, ok := cause.sig.(syscall.Signal); ok {
+		return 128 + int(num), true
+	}
+	return 128, true
+}
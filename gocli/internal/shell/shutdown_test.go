@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runShell starts sh.Run on a goroutine and returns the channel it sends
+// the exit status to.
+func runShell(sh *Shell) <-chan int {
+	finished := make(chan int, 1)
+	go func() {
+		finished <- sh.Run()
+	}()
+	return finished
+}
+
+func TestShell_Shutdown(t *testing.T) {
+	input, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = input.Close()
+		_ = w.Close()
+	}()
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithStdin(input), WithStdout(stdout))
+
+	_, err = w.WriteString("defer echo cleanup\nsh -c 'exec sleep 30'; echo after\n")
+	require.NoError(t, err)
+	finished := runShell(sh)
+	require.Eventually(t, func() bool {
+		sh.factory.children.mu.Lock()
+		defer sh.factory.children.mu.Unlock()
+		return len(sh.factory.children.procs) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sh.Shutdown(syscall.SIGTERM)
+	select {
+	case retCode := <-finished:
+		assert.Equal(t, 128+int(syscall.SIGTERM), retCode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("running command was not hung up")
+	}
+	output := readShellOutput(t, stdout)
+	assert.Contains(t, output, "after\n", "the rest of the line runs")
+	assert.Contains(t, output, "cleanup\n", "deferred commands run on shutdown")
+}
+
+func TestShell_Shutdown_WaitingForInput(t *testing.T) {
+	input, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = input.Close()
+		_ = w.Close()
+	}()
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithStdin(input), WithStdout(stdout))
+
+	finished := runShell(sh)
+	sh.Shutdown(syscall.SIGHUP)
+	select {
+	case retCode := <-finished:
+		assert.Equal(t, 128+int(syscall.SIGHUP), retCode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the shell kept waiting for input")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !js
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+// ShutdownSignals are the signals that end the session through Shell.Shutdown.
+var ShutdownSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// hangUpSignal is sent to the running external commands on shutdown.
+var hangUpSignal os.Signal = syscall.SIGHUP
@@ -0,0 +1,10 @@
+package shell
+
+import "os"
+
+// ShutdownSignals is empty: a WebAssembly module in a browser or Node.js
+// receives no signals.
+var ShutdownSignals []os.Signal
+
+// hangUpSignal is never used, since no external commands can be started.
+var hangUpSignal = os.Kill
@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sleepUnits are the suffixes a sleep duration may have; a number without one is in seconds.
+var sleepUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+}
+
+// sleepCommand waits without starting a process, so it also works where
+// there is no sleep program, and Ctrl-C stops it like any other builtin.
+type sleepCommand struct {
+	duration time.Duration
+}
+
+func parseSleepCommand(d CommandDescription) (Command, error) {
+	if len(d.arguments) < 2 {
+		return nil, fmt.Errorf("sleep: usage: sleep NUMBER[SUFFIX]...")
+	}
+	var total time.Duration
+	for _, arg := range d.arguments[1:] {
+		duration, err := parseSleepDuration(arg)
+		if err != nil {
+			return nil, fmt.Errorf("sleep: %w", err)
+		}
+		total += duration
+	}
+	return &sleepCommand{duration: total}, nil
+}
+
+// parseSleepDuration parses a non-negative, possibly fractional number with
+// an optional s, m, h or d suffix, like "1.5", "0.2s" or "2m".
+func parseSleepDuration(arg string) (time.Duration, error) {
+	number, unit := arg, time.Second
+	if n := len(arg); n > 0 {
+		if u, ok := sleepUnits[arg[n-1]]; ok {
+			number, unit = arg[:n-1], u
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) ||
+		strings.ContainsAny(number, "xXpP_") {
+		return 0, fmt.Errorf("invalid time interval %q", arg)
+	}
+	if value*float64(unit) > math.MaxInt64 {
+		return 0, fmt.Errorf("time interval %q is too long", arg)
+	}
+	return time.Duration(value * float64(unit)), nil
+}
+
+// Execute returns 130 if it is interrupted.
+func (s *sleepCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	timer := time.NewTimer(s.duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return 0, false
+	case <-ctx.Done():
+		return 130, false
+	}
+}
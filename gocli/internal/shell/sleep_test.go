@@ -0,0 +1,45 @@
+package shell
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSleepDuration(t *testing.T) {
+	for arg, want := range map[string]time.Duration{
+		"0":    0,
+		"2":    2 * time.Second,
+		"1.5":  1500 * time.Millisecond,
+		".25s": 250 * time.Millisecond,
+		"2m":   2 * time.Minute,
+		"0.5h": 30 * time.Minute,
+		"1d":   24 * time.Hour,
+	} {
+		got, err := parseSleepDuration(arg)
+		require.NoError(t, err, arg)
+		assert.Equal(t, want, got, arg)
+	}
+
+	for _, arg := range []string{"", "s", "-1", "1x", "1ms", "inf", "nan", "0x10", "1e300d"} {
+		_, err := parseSleepDuration(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestSleepCommand_Execute(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	started := time.Now()
+	retCode, _, err := sh.Execute("sleep 0.02 0.03s")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+
+	_, err = parseSleepCommand(CommandDescription{name: SleepCommand, arguments: []string{"sleep"}})
+	assert.Error(t, err)
+	_, err = parseSleepCommand(CommandDescription{name: SleepCommand, arguments: []string{"sleep", "1", "soon"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type sortCommand struct {
+	filePaths []string
+	reverse   bool
+	numeric   bool
+	human     bool
+	version   bool
+	unique    bool
+	// by is the record field to sort JSON lines by.
+	by string
+}
+
+func parseSortCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("sort")
+	reverse := fs.Bool("r", false, "reverse the result of comparisons")
+	numeric := fs.Bool("n", false, "compare according to string numerical value")
+	human := fs.Bool("h", false, "compare human readable numbers (e.g., 2K 1G)")
+	version := fs.Bool("V", false, "natural sort of (version) numbers within text")
+	unique := fs.Bool("u", false, "output only the first of an equal run")
+	by := fs.String("by", "", "sort JSON records by the named field")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("sort: %w", err)
+	}
+
+	filePaths := fs.Args()
+	if len(filePaths) == 0 && d.fileInPath != "" {
+		filePaths = []string{d.fileInPath}
+	}
+
+	return &sortCommand{
+		filePaths: filePaths,
+		reverse:   *reverse,
+		numeric:   *numeric,
+		human:     *human,
+		version:   *version,
+		unique:    *unique,
+		by:        *by,
+	}, nil
+}
+
+func (s *sortCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	var lines []string
+
+	sources := s.filePaths
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+
+	for _, path := range sources {
+		source := in
+		if path != "" {
+			file, err := os.Open(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "sort: %v\n", err)
+				return 2, false
+			}
+			source = file
+		}
+
+		scanner := bufio.NewScanner(source)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		err := scanner.Err()
+
+		if path != "" {
+			_ = source.Close()
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "sort: %v\n", err)
+			return 2, false
+		}
+	}
+
+	compare := s.comparator()
+	sort.SliceStable(lines, func(i, j int) bool {
+		c := compare(lines[i], lines[j])
+		if s.reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	for i, line := range lines {
+		if s.unique && i > 0 && compare(lines[i-1], line) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintln(out, line)
+	}
+
+	return 0, false
+}
+
+// comparator returns a three-way comparison function for the selected sort mode.
+// Keys that compare equal fall back to a byte-wise comparison unless -u is set,
+// so that the output is deterministic like in GNU sort.
+func (s *sortCommand) comparator() func(a, b string) int {
+	var key func(a, b string) int
+	switch {
+	case s.by != "":
+		key = recordFieldComparator(s.by)
+	case s.human:
+		key = func(a, b string) int { return compareFloats(parseHumanSize(a), parseHumanSize(b)) }
+	case s.numeric:
+		key = func(a, b string) int { return compareFloats(parseLeadingNumber(a), parseLeadingNumber(b)) }
+	case s.version:
+		key = compareVersions
+	default:
+		return strings.Compare
+	}
+
+	if s.unique {
+		return key
+	}
+	return func(a, b string) int {
+		if c := key(a, b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// leadingNumber returns the longest prefix of s (after leading blanks)
+// that looks like a decimal number, and the rest of the string.
+func leadingNumber(s string) (number, rest string) {
+	s = strings.TrimLeft(s, " \t")
+	end := 0
+	if end < len(s) && (s[end] == '-' || s[end] == '+') {
+		end++
+	}
+	seenDot := false
+	for end < len(s) {
+		c := s[end]
+		if c == '.' && !seenDot {
+			seenDot = true
+		} else if c < '0' || c > '9' {
+			break
+		}
+		end++
+	}
+	return s[:end], s[end:]
+}
+
+func parseLeadingNumber(s string) float64 {
+	number, _ := leadingNumber(s)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// parseHumanSize converts strings like "1K", "2.3G" or "512" into a number of bytes.
+// Suffixes are powers of 1024; a trailing "B" or "iB" is accepted and ignored.
+func parseHumanSize(s string) float64 {
+	number, rest := leadingNumber(s)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	if rest == "" {
+		return value
+	}
+
+	const suffixes = "KMGTPEZY"
+	idx := strings.IndexByte(suffixes, byte(unicode.ToUpper(rune(rest[0]))))
+	if idx < 0 {
+		return value
+	}
+	for i := 0; i <= idx; i++ {
+		value *= 1024
+	}
+	return value
+}
+
+// compareVersions compares strings by splitting them into runs of digits and non-digits:
+// digit runs are compared numerically, everything else byte-wise ("v1.10" > "v1.9").
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		aChunk, aDigits := nextVersionChunk(a)
+		bChunk, bDigits := nextVersionChunk(b)
+		a, b = a[len(aChunk):], b[len(bChunk):]
+
+		var c int
+		if aDigits && bDigits {
+			c = compareDigitRuns(aChunk, bChunk)
+		} else {
+			c = strings.Compare(aChunk, bChunk)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareFloats(float64(len(a)), float64(len(b)))
+}
+
+func nextVersionChunk(s string) (chunk string, digits bool) {
+	digits = s[0] >= '0' && s[0] <= '9'
+	end := 1
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9') == digits {
+		end++
+	}
+	return s[:end], digits
+}
+
+func compareDigitRuns(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareFloats(float64(len(a)), float64(len(b)))
+	}
+	return strings.Compare(a, b)
+}
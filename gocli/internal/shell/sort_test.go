@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSort(t *testing.T, content string, args ...string) []string {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "input.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte(content), 0644))
+
+	cmd, err := parseSortCommand(CommandDescription{
+		name:      SortCommand,
+		arguments: append(append([]string{"sort"}, args...), testFile),
+	})
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+}
+
+func TestSortCommand_Execute_Lexicographic(t *testing.T) {
+	output := runSort(t, "banana\napple\ncherry\n")
+	assert.Equal(t, []string{"apple", "banana", "cherry"}, output)
+}
+
+func TestSortCommand_Execute_NumericReverse(t *testing.T) {
+	output := runSort(t, "10\n9\n100\n-1\n", "-n", "-r")
+	assert.Equal(t, []string{"100", "10", "9", "-1"}, output)
+
+	output = runSort(t, "10\n9\n100\n-1\n", "-rn")
+	assert.Equal(t, []string{"100", "10", "9", "-1"}, output)
+}
+
+func TestSortCommand_Execute_HumanSizes(t *testing.T) {
+	output := runSort(t, "2.3G\tbig\n1K\tsmall\n512\ttiny\n10M\tmedium\n1.5K\tsmall2\n", "-h")
+	assert.Equal(t, []string{"512\ttiny", "1K\tsmall", "1.5K\tsmall2", "10M\tmedium", "2.3G\tbig"}, output)
+}
+
+func TestSortCommand_Execute_Version(t *testing.T) {
+	output := runSort(t, "v1.10.0\nv1.2.0\nv1.9.3\nv1.2.10\n", "-V")
+	assert.Equal(t, []string{"v1.2.0", "v1.2.10", "v1.9.3", "v1.10.0"}, output)
+}
+
+func TestSortCommand_Execute_Unique(t *testing.T) {
+	output := runSort(t, "b\na\nb\na\n", "-u")
+	assert.Equal(t, []string{"a", "b"}, output)
+}
+
+func TestSortCommand_Execute_NonexistentFile(t *testing.T) {
+	cmd := &sortCommand{filePaths: []string{"/nonexistent/file.txt"}}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 2, retCode)
+	assert.False(t, exited)
+}
+
+func TestParseHumanSize(t *testing.T) {
+	assert.Equal(t, 512.0, parseHumanSize("512"))
+	assert.Equal(t, 1024.0, parseHumanSize("1K"))
+	assert.Equal(t, 1536.0, parseHumanSize("1.5KiB"))
+	assert.Equal(t, 3.0*1024*1024*1024, parseHumanSize("3G"))
+	assert.Equal(t, 0.0, parseHumanSize("abc"))
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// sourceCommand runs the commands of a file in the current shell, so that
+// they can change its variables, aliases and working directory, unlike a
+// script run as a separate program.
+type sourceCommand struct {
+	name    string
+	path    string
+	factory *commandFactory
+}
+
+func parseSourceCommand(d CommandDescription, factory *commandFactory) (Command, error) {
+	name := string(d.name)
+	if len(d.arguments) != 2 {
+		return nil, fmt.Errorf("%s: usage: %s FILE", name, name)
+	}
+	return &sourceCommand{name: name, path: d.arguments[1], factory: factory}, nil
+}
+
+// Execute returns the status of the last command of the file. An "exit"
+// in the file ends the shell, as if it was typed at the prompt.
+func (c *sourceCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	content, err := fs.ReadFile(c.factory.fsys, c.path)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %v\n", c.name, err)
+		return 1, false
+	}
+
+	parser := &inputProcessor{aliases: c.factory.aliases}
+	descriptions, err := parser.Parse(string(content))
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "%s: %s: %v\n", c.name, c.path, err)
+		return 2, false
+	}
+
+	runner := &pipelineRunner{
+		env:     env,
+		factory: c.factory,
+		parser:  parser,
+		stdin:   in,
+		stdout:  out,
+		stderr:  errOut,
+		options: c.factory.options,
+		fsys:    c.factory.fsys,
+	}
+	return runner.Execute(descriptions, env)
+}
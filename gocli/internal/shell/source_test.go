@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceCommand_Execute(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	dir := tempWorkDir(t)
+	script := "GREETING=hello\nexport TARGET=world\nalias greet='echo $GREETING'\ncd sub\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "setup.sh"), []byte(script), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	retCode, _, err := sh.Execute("source setup.sh")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	retCode, _, err = sh.Execute("greet $TARGET; pwd")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "hello world\n"+filepath.Join(dir, "sub")+"\n", readShellOutput(t, stdout))
+	assert.Equal(t, "world", sh.env.Exported()["TARGET"])
+}
+
+func TestSourceCommand_Execute_Dot(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	script := filepath.Join(t.TempDir(), "script")
+	require.NoError(t, os.WriteFile(script, []byte("echo first\nfalse\n"), 0644))
+
+	retCode, _, err := sh.Execute(". " + script + " | cat")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	retCode, _, err = sh.Execute(". " + script)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "first\nfirst\n", readShellOutput(t, stdout))
+}
+
+func TestSourceCommand_Execute_Exit(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	script := filepath.Join(t.TempDir(), "script")
+	require.NoError(t, os.WriteFile(script, []byte("echo before\nexit\necho unreachable\n"), 0644))
+
+	retCode, exited, err := sh.Execute("source " + script)
+	require.NoError(t, err)
+	assert.True(t, exited)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "before\n", readShellOutput(t, stdout))
+}
+
+func TestSourceCommand_Execute_Errors(t *testing.T) {
+	sh, _ := newTestShell(t)
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken")
+	require.NoError(t, os.WriteFile(broken, []byte("echo 'unclosed\n"), 0644))
+	errPath := filepath.Join(dir, "err")
+
+	retCode, _, err := sh.Execute("source " + broken + " 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retCode)
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(stderr), "source: "+broken+": ")
+
+	retCode, _, err = sh.Execute("source " + filepath.Join(dir, "missing") + " 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+
+	_, err = parseSourceCommand(CommandDescription{name: SourceCommand, arguments: []string{"source"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spongeMemoryLimit is the amount of input sponge keeps in memory
+// before spilling the rest into a temporary file.
+const spongeMemoryLimit = 8 << 20
+
+type spongeCommand struct {
+	path        string
+	append      bool
+	memoryLimit int
+}
+
+func parseSpongeCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("sponge")
+	appendMode := fs.Bool("a", false, "append to the file instead of overwriting it")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("sponge: %w", err)
+	}
+	if fs.NArg() > 1 {
+		return nil, fmt.Errorf("sponge: expected at most one FILE")
+	}
+
+	return &spongeCommand{
+		path:        fs.Arg(0),
+		append:      *appendMode,
+		memoryLimit: spongeMemoryLimit,
+	}, nil
+}
+
+// Execute reads the whole input before opening the output file, so a pipeline
+// may read and write the same file. Without a file the input goes to out.
+func (s *spongeCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	soaked, err := s.soak(in)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "sponge: %v\n", err)
+		return 1, false
+	}
+	defer func() {
+		_ = soaked.Close()
+	}()
+
+	dst := out
+	if s.path != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if s.append {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(s.path, flags, 0644)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "sponge: %v\n", err)
+			return 1, false
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		dst = file
+	}
+
+	if _, err := io.Copy(dst, soaked); err != nil {
+		_, _ = fmt.Fprintf(errOut, "sponge: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
+
+// soak reads in until EOF. Input up to memoryLimit stays in memory; anything
+// larger is spilled into a temporary file removed on Close.
+func (s *spongeCommand) soak(in io.Reader) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	_, err := io.CopyN(&buf, in, int64(s.memoryLimit)+1)
+	if err == io.EOF {
+		return io.NopCloser(&buf), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "gocli-sponge-")
+	if err != nil {
+		return nil, err
+	}
+	spill := &spillFile{File: file}
+
+	if _, err := io.Copy(spill, io.MultiReader(&buf, in)); err != nil {
+		_ = spill.Close()
+		return nil, err
+	}
+	if _, err := spill.Seek(0, io.SeekStart); err != nil {
+		_ = spill.Close()
+		return nil, err
+	}
+	return spill, nil
+}
+
+// spillFile is a temporary file that is deleted once closed.
+type spillFile struct {
+	*os.File
+}
+
+func (f *spillFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
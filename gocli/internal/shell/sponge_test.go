@@ -0,0 +1,62 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell_Execute_SpongeSameFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(file, []byte("keep 1\ndrop\nkeep 2\n"), 0644))
+
+	sh, _ := newTestShell(t)
+	retCode, _, err := sh.Execute("grep keep " + file + " | sponge " + file)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "keep 1\nkeep 2\n", string(content))
+}
+
+func TestSpongeCommand_Execute_SpillsToDisk(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	data := strings.Repeat("0123456789\n", 100)
+	require.NoError(t, os.WriteFile(input, []byte(data), 0644))
+	output := filepath.Join(dir, "output.txt")
+	require.NoError(t, os.WriteFile(output, []byte("old\n"), 0644))
+
+	in, err := os.Open(input)
+	require.NoError(t, err)
+	defer func() {
+		_ = in.Close()
+	}()
+
+	cmd := &spongeCommand{path: output, append: true, memoryLimit: 16}
+	retCode, exited := cmd.Execute(in, nil, os.Stderr, nil)
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "old\n"+data, string(content))
+}
+
+func TestSpongeCommand_Execute_Stdout(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("echo soaked | sponge")
+	require.NoError(t, err)
+	assert.Equal(t, "soaked\n", readShellOutput(t, stdout))
+}
+
+func TestParseSpongeCommand_TooManyFiles(t *testing.T) {
+	_, err := parseSpongeCommand(CommandDescription{name: SpongeCommand, arguments: []string{"sponge", "a", "b"}})
+	assert.Error(t, err)
+}
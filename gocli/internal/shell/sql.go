@@ -0,0 +1,314 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqlCommand runs an SQL query on CSV and JSON data loaded into tables,
+// or on an SQLite database file. The query runs in the SQLite engine,
+// which is only part of builds with the "sqlite" tag.
+type sqlCommand struct {
+	query string
+	// database is the SQLite file the query runs on, if any.
+	database string
+	// files are the data files loaded into tables; "-" is stdin.
+	files   []string
+	jsonOut bool
+}
+
+func parseSQLCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("sql")
+	jsonOut := fs.Bool("json", false, "print rows as JSON records")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("sql: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("sql: usage: sql [-json] QUERY [FILE...]")
+	}
+
+	s := &sqlCommand{query: fs.Arg(0), jsonOut: *jsonOut}
+	for _, file := range fs.Args()[1:] {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".db", ".sqlite", ".sqlite3":
+			if s.database != "" {
+				return nil, fmt.Errorf("sql: %s: only one database file can be queried", file)
+			}
+			s.database = file
+		default:
+			s.files = append(s.files, file)
+		}
+	}
+	if s.database == "" && len(s.files) == 0 {
+		s.files = []string{"-"}
+	}
+	return s, nil
+}
+
+// sqlTable is data loaded from a file, with columns in the order they first appear.
+type sqlTable struct {
+	name    string
+	columns []string
+	rows    [][]any
+}
+
+// sqlTableName derives the table of a data file from its name: "sales.csv"
+// is table sales. Stdin is table stdin.
+func sqlTableName(file string) string {
+	if file == "-" {
+		return "stdin"
+	}
+	base := filepath.Base(file)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	name := []byte(base)
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte("t_"), name...)
+	}
+	return string(name)
+}
+
+// loadSQLTable reads CSV with a header line, or JSON: records one per line
+// or an array of objects. Files are JSON by their extension, stdin if it
+// starts with "{" or "[".
+func loadSQLTable(file string, r io.Reader) (*sqlTable, error) {
+	reader := bufio.NewReader(r)
+	isJSON := false
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json", ".jsonl", ".ndjson":
+		isJSON = true
+	case ".csv":
+	default:
+		for {
+			c, _, err := reader.ReadRune()
+			if err != nil || !strings.ContainsRune(" \t\r\n", c) {
+				isJSON = c == '{' || c == '['
+				if err == nil {
+					_ = reader.UnreadRune()
+				}
+				break
+			}
+		}
+	}
+
+	table := &sqlTable{name: sqlTableName(file)}
+	var err error
+	if isJSON {
+		err = table.loadJSON(reader)
+	} else {
+		err = table.loadCSV(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return table, nil
+}
+
+func (t *sqlTable) loadCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t.columns = header
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := make([]any, len(t.columns))
+		for i := range row {
+			if i < len(fields) {
+				row[i] = sqlValue(fields[i])
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+}
+
+func (t *sqlTable) loadJSON(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	index := make(map[string]int)
+	add := func(raw json.RawMessage) error {
+		keys, values, err := decodeSQLObject(raw)
+		if err != nil {
+			return err
+		}
+		row := make([]any, len(t.columns))
+		for i, key := range keys {
+			column, ok := index[key]
+			if !ok {
+				column = len(t.columns)
+				index[key] = column
+				t.columns = append(t.columns, key)
+				row = append(row, nil)
+			}
+			row[column] = sqlJSONValue(values[i])
+		}
+		t.rows = append(t.rows, row)
+		return nil
+	}
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if raw[0] == '[' {
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := add(item); err != nil {
+					return err
+				}
+			}
+		} else if err := add(raw); err != nil {
+			return err
+		}
+	}
+	// Rows added before a column appeared are shorter.
+	for i, row := range t.rows {
+		for len(row) < len(t.columns) {
+			row = append(row, nil)
+		}
+		t.rows[i] = row
+	}
+	return nil
+}
+
+// decodeSQLObject decodes a JSON object keeping the order of its keys,
+// which becomes the order of the columns.
+func decodeSQLObject(raw json.RawMessage) (keys []string, values []any, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected JSON objects")
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, token.(string))
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
+
+// sqlNumber matches decimal numbers. Leading zeros, as in zip codes, keep a field text.
+var sqlNumber = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][-+]?\d+)?$`)
+
+// sqlValue stores fields that look like numbers as numbers, so that they
+// compare and sum as such, and everything else as text.
+func sqlValue(field string) any {
+	if !sqlNumber.MatchString(field) {
+		return field
+	}
+	if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(field, 64); err == nil {
+		return f
+	}
+	return field
+}
+
+// sqlJSONValue converts a decoded JSON value into one SQLite can store;
+// nested arrays and objects are stored as JSON text.
+func sqlJSONValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		return sqlValue(v.String())
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	case string, nil:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// writeSQLResult prints rows as a table with a header, or as JSON records.
+func writeSQLResult(w io.Writer, columns []string, rows [][]any, jsonOut bool) error {
+	if jsonOut {
+		for _, row := range rows {
+			if err := writeRecord(w, columns, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cells := make([][]string, 0, len(rows)+1)
+	cells = append(cells, columns)
+	for _, row := range rows {
+		line := make([]string, len(row))
+		for i, value := range row {
+			line[i] = sqlText(value)
+		}
+		cells = append(cells, line)
+	}
+	widths := make([]int, len(columns))
+	for _, line := range cells {
+		for i, cell := range line {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, line := range cells {
+		for i, cell := range line {
+			buf.WriteString(cell)
+			if i < len(line)-1 {
+				buf.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+columnSpacing))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// sqlText formats a value of a result for the table output; NULL is empty.
+func sqlText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,137 @@
+//go:build !js && !wasip1
+
+package shell
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Execute loads the data files into temporary tables of an in-memory
+// database, or of the database file, which is opened read-only.
+func (s *sqlCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if err := s.run(in, out); err != nil {
+		_, _ = fmt.Fprintf(errOut, "sql: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
+
+func (s *sqlCommand) run(in io.Reader, out io.Writer) error {
+	source := ":memory:"
+	if s.database != "" {
+		if _, err := os.Stat(s.database); err != nil {
+			return err
+		}
+		source = (&url.URL{Scheme: "file", Opaque: s.database, RawQuery: "mode=ro"}).String()
+	}
+	db, err := sql.Open("sqlite", source)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	// Temporary tables belong to a connection, so there must be only one.
+	db.SetMaxOpenConns(1)
+
+	for _, file := range s.files {
+		table, err := s.load(file, in)
+		if err != nil {
+			return err
+		}
+		if err := createSQLTable(db, table); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	rows, err := db.Query(s.query)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var result [][]any
+	for rows.Next() {
+		row := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, value := range row {
+			if data, ok := value.([]byte); ok {
+				row[i] = string(data)
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(out)
+	if err := writeSQLResult(writer, columns, result, s.jsonOut); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (s *sqlCommand) load(file string, in io.Reader) (*sqlTable, error) {
+	if file == "-" {
+		return loadSQLTable(file, in)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return loadSQLTable(file, f)
+}
+
+func createSQLTable(db *sql.DB, table *sqlTable) error {
+	if len(table.columns) == 0 {
+		return fmt.Errorf("no columns")
+	}
+	columns := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		columns[i] = quoteSQLIdentifier(column)
+	}
+	name := quoteSQLIdentifier(table.name)
+	create := fmt.Sprintf("CREATE TEMP TABLE %s (%s)", name, strings.Join(columns, ", "))
+	if _, err := db.Exec(create); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", name, placeholders))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+	for _, row := range table.rows {
+		if _, err := insert.Exec(row...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func quoteSQLIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
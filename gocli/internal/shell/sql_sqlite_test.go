@@ -0,0 +1,41 @@
+//go:build !js && !wasip1
+
+package shell
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLCommand(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("orders.json", []byte(`{"name": "alice", "total": 12}
+{"name": "bob", "total": 5}
+{"name": "alice", "total": 3}
+`), 0644))
+	db, err := sql.Open("sqlite", "shop.db")
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (name TEXT, city TEXT); INSERT INTO users VALUES ('alice', 'Paris'), ('bob', 'Oslo')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`printf 'n,sq\n2,4\n10,100\n' | sql "SELECT n FROM stdin WHERE sq > 5"; ` +
+		`sql -json "SELECT name, SUM(total) AS total FROM orders GROUP BY name ORDER BY total DESC" orders.json; ` +
+		`sql "SELECT o.name, city FROM orders o JOIN users u ON o.name = u.name WHERE total > 4 ORDER BY total" shop.db orders.json`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "n\n10\n"+
+		`{"name":"alice","total":15}`+"\n"+`{"name":"bob","total":5}`+"\n"+
+		"name   city\nbob    Oslo\nalice  Paris\n", readShellOutput(t, stdout))
+
+	for _, line := range []string{`sql "SELECT nope FROM stdin" < orders.json`, `sql "DELETE FROM users" shop.db`, `sql "SELECT 1" missing.csv`} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.Equal(t, 1, retCode, line)
+	}
+}
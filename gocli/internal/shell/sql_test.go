@@ -0,0 +1,66 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQLCommand(t *testing.T) {
+	c, err := parseSQLCommand(CommandDescription{name: SQLCommand, arguments: []string{"sql", "-json", "SELECT 1", "a.csv", "shop.db", "b.json"}})
+	require.NoError(t, err)
+	assert.Equal(t, &sqlCommand{query: "SELECT 1", database: "shop.db", files: []string{"a.csv", "b.json"}, jsonOut: true}, c)
+
+	c, err = parseSQLCommand(CommandDescription{name: SQLCommand, arguments: []string{"sql", "SELECT 1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-"}, c.(*sqlCommand).files)
+
+	for _, args := range [][]string{{"sql"}, {"sql", "SELECT 1", "a.db", "b.sqlite"}, {"sql", "-x", "SELECT 1"}} {
+		_, err := parseSQLCommand(CommandDescription{name: SQLCommand, arguments: args})
+		assert.Error(t, err, args)
+	}
+}
+
+func TestSQLTableName(t *testing.T) {
+	for file, name := range map[string]string{
+		"-":                 "stdin",
+		"data/sales.csv":    "sales",
+		"my-report.v2.json": "my_report_v2",
+		"2024.csv":          "t_2024",
+	} {
+		assert.Equal(t, name, sqlTableName(file), file)
+	}
+}
+
+func TestLoadSQLTable(t *testing.T) {
+	table, err := loadSQLTable("-", strings.NewReader("name,size,zip\na,10,0123\nb,2.5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "size", "zip"}, table.columns)
+	assert.Equal(t, [][]any{{"a", int64(10), "0123"}, {"b", 2.5, nil}}, table.rows)
+
+	table, err = loadSQLTable("-", strings.NewReader(`  {"b": 1, "tags": ["x"]}`+"\n"+`{"a": true, "b": "y"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "tags", "a"}, table.columns)
+	assert.Equal(t, [][]any{{int64(1), `["x"]`, nil}, {"y", nil, int64(1)}}, table.rows)
+
+	table, err = loadSQLTable("items.json", strings.NewReader(`[{"n": 1}, {"n": 2}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "items", table.name)
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}}, table.rows)
+
+	_, err = loadSQLTable("items.json", strings.NewReader(`[{"n": 1}, 2]`))
+	assert.ErrorContains(t, err, "items.json")
+}
+
+func TestWriteSQLResult(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeSQLResult(&buf, []string{"name", "total"}, [][]any{{"alice", int64(12)}, {"bob", nil}, {"carol", 1.5}}, false))
+	assert.Equal(t, "name   total\nalice  12\nbob    \ncarol  1.5\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeSQLResult(&buf, []string{"name", "total"}, [][]any{{"alice", int64(12)}}, true))
+	assert.Equal(t, `{"name":"alice","total":12}`+"\n", buf.String())
+}
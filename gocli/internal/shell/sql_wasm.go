@@ -0,0 +1,13 @@
+//go:build js || wasip1
+
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+func (s *sqlCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	_, _ = fmt.Fprintln(errOut, "sql: SQLite is not available on this platform")
+	return 1, false
+}
@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxStatsLines is how many different lines the stats keep per directory;
+	// beyond that the least used one is dropped.
+	maxStatsLines = 200
+	// suggestCount is how many suggestions the suggest builtin prints by default.
+	suggestCount = 10
+)
+
+// commandStats counts how often every line was run in every directory,
+// so that suggestions can put first what the user usually runs there.
+type commandStats struct {
+	mu   sync.Mutex
+	dirs map[string]map[string]*lineStats
+	// seq numbers the recorded runs, so that the newer of equally used lines wins.
+	seq int
+}
+
+type lineStats struct {
+	count int
+	last  int
+}
+
+func newCommandStats() *commandStats {
+	return &commandStats{dirs: make(map[string]map[string]*lineStats)}
+}
+
+// record counts a run of line in dir, unless it is blank.
+func (s *commandStats) record(dir, line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	lines := s.dirs[dir]
+	if lines == nil {
+		lines = make(map[string]*lineStats)
+		s.dirs[dir] = lines
+	}
+	stats := lines[line]
+	if stats == nil {
+		stats = &lineStats{}
+		lines[line] = stats
+	}
+	stats.count++
+	stats.last = s.seq
+
+	if len(lines) > maxStatsLines {
+		var victim string
+		var least *lineStats
+		for candidate, stats := range lines {
+			if candidate != line && (least == nil || stats.count < least.count ||
+				stats.count == least.count && stats.last < least.last) {
+				victim, least = candidate, stats
+			}
+		}
+		delete(lines, victim)
+	}
+}
+
+// suggest returns up to n lines that start with prefix, most run in dir
+// first, then most run anywhere, then most recent.
+func (s *commandStats) suggest(dir, prefix string, n int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	here := make(map[string]int)
+	total := make(map[string]int)
+	last := make(map[string]int)
+	for d, lines := range s.dirs {
+		for line, stats := range lines {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			if d == dir {
+				here[line] = stats.count
+			}
+			total[line] += stats.count
+			last[line] = max(last[line], stats.last)
+		}
+	}
+
+	suggestions := make([]string, 0, len(total))
+	for line := range total {
+		suggestions = append(suggestions, line)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if here[a] != here[b] {
+			return here[a] > here[b]
+		}
+		if total[a] != total[b] {
+			return total[a] > total[b]
+		}
+		return last[a] > last[b]
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// suggestCommand prints the lines run before that start with a prefix,
+// ranked by how often they were run in the current directory.
+type suggestCommand struct {
+	stats  *commandStats
+	prefix string
+	count  int
+}
+
+func parseSuggestCommand(d CommandDescription, stats *commandStats) (Command, error) {
+	fs := newFlagSet("suggest")
+	count := fs.Int("n", suggestCount, "print at most `N` suggestions")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("suggest: %w", err)
+	}
+	if *count < 1 {
+		return nil, fmt.Errorf("suggest: invalid number of suggestions: %d", *count)
+	}
+	return &suggestCommand{stats: stats, prefix: strings.Join(fs.Args(), " "), count: *count}, nil
+}
+
+// Execute returns 1 if there is nothing to suggest, like grep without a match.
+func (c *suggestCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "suggest: %v\n", err)
+		return 1, false
+	}
+	suggestions := c.stats.suggest(dir, c.prefix, c.count)
+	if len(suggestions) == 0 {
+		return 1, false
+	}
+	_, _ = fmt.Fprintln(out, strings.Join(suggestions, "\n"))
+	return 0, false
+}
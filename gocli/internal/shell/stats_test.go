@@ -0,0 +1,69 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandStats_Suggest(t *testing.T) {
+	stats := newCommandStats()
+	for _, run := range []struct{ dir, line string }{
+		{"/proj", "make test"},
+		{"/proj", "make build"},
+		{"/proj", "make test"},
+		{"/other", "make lint"},
+		{"/other", "make lint"},
+		{"/other", "make lint"},
+		{"/other", "ls"},
+		{"/proj", "   "},
+	} {
+		stats.record(run.dir, run.line)
+	}
+
+	assert.Equal(t, []string{"make test", "make build", "make lint"}, stats.suggest("/proj", "make", 10))
+	assert.Equal(t, []string{"make lint", "make test"}, stats.suggest("/other", "make", 2))
+	assert.Equal(t, []string{"make lint", "make test", "ls", "make build"}, stats.suggest("/new", "", 10))
+	assert.Empty(t, stats.suggest("/proj", "git", 10))
+}
+
+func TestCommandStats_Limit(t *testing.T) {
+	stats := newCommandStats()
+	stats.record("/", "kept")
+	stats.record("/", "kept")
+	for i := 0; i < maxStatsLines; i++ {
+		stats.record("/", fmt.Sprintf("line %d", i))
+	}
+
+	suggestions := stats.suggest("/", "", 2*maxStatsLines)
+	assert.Len(t, suggestions, maxStatsLines)
+	assert.Equal(t, "kept", suggestions[0])
+	assert.NotContains(t, suggestions, "line 0")
+	assert.Contains(t, suggestions, fmt.Sprintf("line %d", maxStatsLines-1))
+}
+
+func TestSuggestCommand_Execute(t *testing.T) {
+	dir := tempWorkDir(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	sh, stdout := newTestShell(t)
+
+	for _, line := range []string{"echo a", "echo b", "echo b", "cd sub", "echo c", "cd .."} {
+		_, _, err := sh.Execute(line)
+		require.NoError(t, err)
+	}
+	retCode, _, err := sh.Execute("suggest -n 2 echo")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "a\nb\nb\nc\necho b\necho a\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("suggest nothing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+
+	_, err = parseSuggestCommand(CommandDescription{name: SuggestCommand, arguments: []string{"suggest", "-n", "0"}}, nil)
+	assert.Error(t, err)
+}
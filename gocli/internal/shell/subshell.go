@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+// subshellCommand runs the commands of a "(...)" group with a copy of the
+// environment and the directory stack. The working directory is restored
+// afterwards, so neither variables nor "cd"-like commands leak out of it.
+type subshellCommand struct {
+	line    string
+	factory *commandFactory
+}
+
+func parseSubshellCommand(d CommandDescription, factory *commandFactory) (Command, error) {
+	if len(d.arguments) > 1 {
+		return nil, fmt.Errorf("syntax error near unexpected token '%s'", d.arguments[1])
+	}
+	group := d.arguments[0]
+	return &subshellCommand{line: group[1 : len(group)-1], factory: factory}, nil
+}
+
+// Execute runs the group. An "exit" inside it only ends the subshell.
+func (c *subshellCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	parser := &inputProcessor{aliases: c.factory.aliases}
+	descriptions, err := parser.Parse(c.line)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "subshell: %v\n", err)
+		return 2, false
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "subshell: %v\n", err)
+		return 1, false
+	}
+	defer func() {
+		if err := os.Chdir(cwd); err != nil {
+			_, _ = fmt.Fprintf(errOut, "subshell: %v\n", err)
+		}
+	}()
+
+	scope := cloneEnv(env)
+	factory := *c.factory
+	factory.env = scope
+	factory.dirs = c.factory.dirs.clone()
+
+	runner := &pipelineRunner{
+		env:     scope,
+		factory: &factory,
+		parser:  parser,
+		stdin:   in,
+		stdout:  out,
+		stderr:  errOut,
+		options: c.factory.options,
+		fsys:    c.factory.fsys,
+	}
+	retCode, _ = runner.Execute(descriptions, scope)
+	return retCode, false
+}
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputProcessor_Parse_SubshellGroup(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("(X=1; echo $X | cat) | grep 1 && echo '(x)'")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 3)
+
+	assert.Equal(t, SubshellCommand, descriptions[0].name)
+	assert.Equal(t, []string{"(X=1; echo $X | cat)"}, descriptions[0].arguments)
+	assert.True(t, descriptions[0].singleQuotedArgs[0])
+	assert.True(t, descriptions[0].isPiped)
+	assert.Equal(t, CommandName("grep"), descriptions[1].name)
+	assert.Equal(t, []string{"echo", "(x)"}, descriptions[2].arguments)
+}
+
+func TestShell_Execute_SubshellWorkingDirectory(t *testing.T) {
+	root := tempWorkDir(t)
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("(mkcd sub && pwd) | cat; pwd")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, filepath.Join(root, "sub")+"\n"+root+"\n", readShellOutput(t, stdout))
+	assert.Equal(t, root, currentDir(t))
+
+	_, _, err = sh.Execute("back")
+	require.NoError(t, err)
+	assert.Equal(t, root, currentDir(t))
+}
+
+func TestShell_Execute_DirectoryChangeInPipeline(t *testing.T) {
+	root := tempWorkDir(t)
+	require.NoError(t, os.WriteFile("a", nil, 0644))
+	require.NoError(t, os.WriteFile("b", nil, 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("(cd /; sleep 0.5) | (sleep 0.2; ls | head -n 2)")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "a\nb\n", readShellOutput(t, stdout), "other stages keep their directory")
+
+	retCode, _, err = sh.Execute("cd / | echo; mkcd sub | echo")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, root, currentDir(t), "cd in a pipeline does not move the shell")
+	assert.DirExists(t, filepath.Join(root, "sub"))
+	pwd, _ := sh.env.Get("PWD")
+	assert.NotEqual(t, "/", pwd)
+	assert.Empty(t, sh.factory.dirs.list())
+}
+
+func TestShell_Execute_SubshellEnvironment(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("X=outer; (X=inner; echo $X); echo $X")
+	require.NoError(t, err)
+	assert.Equal(t, "inner\nouter\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_SubshellExit(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, exited, err := sh.Execute("(exit; echo unreachable) && echo after; (false) || echo failed")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+	assert.Equal(t, "after\nfailed\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_SubshellSyntaxError(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	_, _, err := sh.Execute("(echo a) b")
+	var syntaxErr *SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, Pos{Line: 1, Column: 10}, syntaxErr.Pos)
+
+	_, _, err = sh.Execute("echo x; (echo a |)")
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, Pos{Line: 1, Column: 18}, syntaxErr.Pos)
+	assert.NotErrorIs(t, err, ErrIncompleteInput)
+}
@@ -0,0 +1,238 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// substitutionEnd returns the index of the parenthesis closing the command
+// substitution that starts with "$(" at s[start], or -1 if it is unterminated.
+func substitutionEnd(s string, start int) int {
+	return parenEnd(s, start+1)
+}
+
+// parenEnd returns the index of the parenthesis matching the one at s[open],
+// or -1 if there is none. Quotes and nested parentheses are skipped.
+func parenEnd(s string, open int) int {
+	depth := 0
+	inSingleQuote, inDoubleQuote := false, false
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' && !inDoubleQuote:
+			inSingleQuote = !inSingleQuote
+		case c == '"' && !inSingleQuote:
+			inDoubleQuote = !inDoubleQuote
+		case inSingleQuote || inDoubleQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isSubstitutionStart(s string, i int) bool {
+	return s[i] == '$' && i+1 < len(s) && s[i+1] == '('
+}
+
+// backtickEnd returns the index of the backtick closing the legacy `...`
+// substitution that starts at s[start], or -1 if it is unterminated.
+// Nested backticks are not supported.
+func backtickEnd(s string, start int) int {
+	end := strings.IndexByte(s[start+1:], '`')
+	if end < 0 {
+		return -1
+	}
+	return start + 1 + end
+}
+
+// skipNested returns the index of the last byte of the quoted string,
+// "$(...)" or backtick substitution or "(...)" group starting at s[i], or i itself when none
+// starts there or it is unterminated. Braces and commas inside such runs belong
+// to them and are left to the command line that is parsed when they run.
+func skipNested(s string, i int) int {
+	switch s[i] {
+	case '$':
+		if isSubstitutionStart(s, i) {
+			if end := substitutionEnd(s, i); end > 0 {
+				return end
+			}
+		}
+	case '`':
+		if end := backtickEnd(s, i); end > 0 {
+			return end
+		}
+	case '(':
+		if end := parenEnd(s, i); end > 0 {
+			return end
+		}
+	case '\'':
+		if end := strings.IndexByte(s[i+1:], '\''); end >= 0 {
+			return i + 1 + end
+		}
+	case '"':
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '"':
+				return j
+			case '$', '`':
+				j = skipNested(s, j)
+			}
+		}
+	}
+	return i
+}
+
+// escapedDollar stands for a backslash-escaped "$" in the text of a word
+// until expansion is done; restoreDollars turns it back into "$".
+const escapedDollar = "\uE000"
+
+func restoreDollars(s string) string {
+	return strings.ReplaceAll(s, escapedDollar, "$")
+}
+
+// expandArg performs variable expansion and command substitution on a single
+// argument. Unless splitWords is false, the output of every substitution is
+// split into separate words on blanks and newlines, so one argument may expand
+// into several or none at all.
+func (p *pipelineRunner) expandArg(arg string, env Env, splitWords bool) []string {
+	var words []string
+	var current strings.Builder
+	hasCurrent := false
+	flush := func() {
+		if hasCurrent {
+			words = append(words, restoreDollars(current.String()))
+			current.Reset()
+			hasCurrent = false
+		}
+	}
+
+	last := 0
+	for i := 0; i < len(arg); i++ {
+		if !isSubstitutionStart(arg, i) {
+			continue
+		}
+		end := substitutionEnd(arg, i)
+		if end < 0 {
+			break
+		}
+
+		if i > last {
+			current.WriteString(p.expandVar(arg[last:i]))
+			hasCurrent = true
+		}
+		output := p.substitute(arg[i+2:end], env)
+		i, last = end, end+1
+
+		if !splitWords {
+			current.WriteString(output)
+			hasCurrent = true
+			continue
+		}
+
+		fields := strings.Fields(output)
+		if len(fields) == 0 {
+			continue
+		}
+		if strings.TrimLeft(output, " \t\n") != output {
+			flush()
+		}
+		for j, field := range fields {
+			if j > 0 {
+				flush()
+			}
+			current.WriteString(field)
+			hasCurrent = true
+		}
+		if strings.TrimRight(output, " \t\n") != output {
+			flush()
+		}
+	}
+
+	if last < len(arg) {
+		current.WriteString(p.expandVar(arg[last:]))
+		hasCurrent = true
+	}
+	if last == 0 {
+		hasCurrent = true
+	}
+	flush()
+	return words
+}
+
+// substitute runs the command line with its standard output captured and
+// returns that output without trailing newlines, like $(...) in POSIX shells.
+// The exit status is ignored, as is an "exit" inside the substitution.
+func (p *pipelineRunner) substitute(line string, env Env) string {
+	output, _, err := p.capture(line, env)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(output, "\n")
+}
+
+// capture runs the command line with its standard output captured and
+// returns that output and the exit status of the line.
+func (p *pipelineRunner) capture(line string, env Env) (output string, status int, err error) {
+	descriptions, err := p.parser.Parse(line)
+	if err != nil {
+		return "", 0, err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", 0, err
+	}
+
+	captured := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		_ = r.Close()
+		captured <- string(data)
+	}()
+
+	inner := *p
+	inner.stdout = w
+	status, _ = inner.Execute(descriptions, env)
+	_ = w.Close()
+
+	return <-captured, status, nil
+}
+
+// executeCapture runs a pipeline ended by "|> NAME" or "|>> NAME" with its
+// standard output going to the variable NAME. As with $(...), trailing
+// newlines are dropped; "|>>" adds the output to the value on a new line.
+func (p *pipelineRunner) executeCapture(pipeline []CommandDescription, capture *captureNode, env Env) (retCode int, exited bool) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		p.log().Error("cannot create pipe", "error", err)
+		return -1, false
+	}
+
+	captured := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		_ = r.Close()
+		captured <- string(data)
+	}()
+
+	inner := *p
+	inner.stdout = w
+	retCode, exited = inner.executePipeline(pipeline, env)
+	_ = w.Close()
+
+	output := strings.TrimRight(<-captured, "\n")
+	if old, ok := env.Get(capture.name); ok && capture.append && old != "" {
+		if output != "" {
+			old += "\n"
+		}
+		output = old + output
+	}
+	env.Set(capture.name, output)
+	return retCode, exited
+}
@@ -0,0 +1,137 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputProcessor_Parse_CommandSubstitution(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("echo $(echo a | cat; pwd)/file x")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+	assert.Equal(t, []string{"echo", "$(echo a | cat; pwd)/file", "x"}, descriptions[0].arguments)
+}
+
+func TestInputProcessor_Parse_CommandSubstitutionKeepsInnerQuotes(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse(`echo "$(echo 'a )  b')" '$(pwd)'`)
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+
+	desc := descriptions[0]
+	assert.Equal(t, []string{"echo", "$(echo 'a )  b')", "$(pwd)"}, desc.arguments)
+	assert.True(t, desc.doubleQuotedArgs[1])
+	assert.True(t, desc.singleQuotedArgs[2])
+}
+
+func TestInputProcessor_Parse_Backticks(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("echo `echo a | cat`/file \"x `pwd`\" '`pwd`'")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 1)
+
+	desc := descriptions[0]
+	assert.Equal(t, []string{"echo", "$(echo a | cat)/file", "x $(pwd)", "`pwd`"}, desc.arguments)
+	assert.True(t, desc.doubleQuotedArgs[2])
+	assert.True(t, desc.singleQuotedArgs[3])
+}
+
+func TestInputProcessor_Parse_PipesInSubstitutions(t *testing.T) {
+	processor := NewInputProcessor()
+
+	descriptions, err := processor.Parse("a | b $(c | d) ")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 2)
+	assert.Equal(t, []string{"b", "$(c | d)"}, descriptions[1].arguments)
+
+	descriptions, err = processor.Parse("a `b | c` | d")
+	require.NoError(t, err)
+	require.Len(t, descriptions, 2)
+	assert.Equal(t, []string{"a", "$(b | c)"}, descriptions[0].arguments)
+
+	_, err = processor.Parse("echo $(unterminated | x")
+	assert.ErrorIs(t, err, ErrIncompleteInput)
+}
+
+func TestShell_Execute_CommandSubstitution(t *testing.T) {
+	sh, stdout := newTestShell(t)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	retCode, _, err := sh.Execute("echo $(pwd)/file")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, wd+"/file\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_BacktickSubstitution(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("printf '[%s]' \"`printf 'x  y'`\" `printf 'x  y' | cat`")
+	require.NoError(t, err)
+	assert.Equal(t, "[x  y][x][y]", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_CommandSubstitutionWordSplitting(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute(`printf '[%s]' a$(printf ' b  c\n\n')d "$(printf ' b  c\n\n')"`)
+	require.NoError(t, err)
+	assert.Equal(t, "[a][b][cd][ b  c]", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_CommandSubstitutionEmptyOutput(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute(`printf '[%s]' $(true) x "$(true)"`)
+	require.NoError(t, err)
+	assert.Equal(t, "[x][]", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_CommandSubstitutionNested(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("X=$(echo $(echo inner) outer); echo $X")
+	require.NoError(t, err)
+	assert.Equal(t, "inner outer\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_CommandSubstitutionAsCommandName(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("$(echo echo) hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", readShellOutput(t, stdout))
+
+	_, exited, err := sh.Execute("$(echo exit)")
+	require.NoError(t, err)
+	assert.True(t, exited)
+}
+
+func TestShell_Execute_ExitInsideSubstitution(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	_, exited, err := sh.Execute("echo before $(exit) after")
+	require.NoError(t, err)
+	assert.False(t, exited)
+	assert.Equal(t, "before after\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_CommandSubstitutionKeepsOptions(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("f", []byte("old\n"), 0644))
+	sh, _ := newTestShell(t)
+
+	_, _, err := sh.Execute("set -o atomicwrite; echo $(sh -c 'echo new; exit 1' > f) > /dev/null")
+	require.NoError(t, err)
+	content, err := os.ReadFile("f")
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(content), "atomicwrite applies inside $(...)")
+}
@@ -0,0 +1,123 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stderrTailSize is how much of a command's stderr is kept to look for permission errors.
+const stderrTailSize = 4096
+
+var permissionErrorMarkers = []string{
+	"permission denied",
+	"operation not permitted",
+}
+
+// tailBuffer keeps only the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	data  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.data)
+}
+
+func isPermissionError(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, marker := range permissionErrorMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// touchesRootOwnedPath reports whether any argument refers to a path owned by root.
+// For paths that do not exist yet the parent directory is checked instead.
+func touchesRootOwnedPath(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		for _, candidate := range []string{arg, filepath.Dir(arg)} {
+			info, err := os.Stat(candidate)
+			if err != nil {
+				continue
+			}
+			if uid, ok := fileOwner(info); ok && uid == 0 {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// rewind prepares the standard input of a failed command to be consumed again.
+// Only terminals and seekable files can be replayed; pipes have already been drained.
+func rewind(in *os.File) bool {
+	if in == nil {
+		return true
+	}
+	info, err := in.Stat()
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return true
+	}
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	_, err = in.Seek(0, io.SeekStart)
+	return err == nil
+}
+
+// shouldRetryWithSudo decides whether to offer re-running a failed command under sudo.
+func (e *externalCommand) shouldRetryWithSudo(in *os.File, stderr string) bool {
+	if !e.offerSudo || os.Geteuid() == 0 || e.args[0] == "sudo" {
+		return false
+	}
+	if !isPermissionError(stderr) || !touchesRootOwnedPath(e.args[1:]) {
+		return false
+	}
+	if !rewind(in) {
+		return false
+	}
+	return confirm("gocli: " + e.args[0] + ": permission denied on a root-owned path, re-run with sudo?")
+}
+
+// outputSize returns the size of the file a command's output is redirected
+// to before the command runs, or -1 if out is not a regular file.
+func outputSize(out *os.File) int64 {
+	info, err := out.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	return info.Size()
+}
+
+// restoreOutput cuts what a failed command wrote off the file its output
+// is redirected to, size being what outputSize returned before the command
+// ran, so that a retry's output replaces it rather than follows it.
+func restoreOutput(out *os.File, size int64) error {
+	if size < 0 {
+		return nil
+	}
+	if err := out.Truncate(size); err != nil {
+		return err
+	}
+	_, err := out.Seek(size, io.SeekStart)
+	return err
+}
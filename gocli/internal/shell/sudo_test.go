@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailBuffer_Write(t *testing.T) {
+	buf := &tailBuffer{limit: 5}
+	_, _ = buf.Write([]byte("abc"))
+	_, _ = buf.Write([]byte("defgh"))
+	assert.Equal(t, "defgh", buf.String())
+}
+
+func TestIsPermissionError(t *testing.T) {
+	assert.True(t, isPermissionError("touch: cannot touch '/etc/x': Permission denied\n"))
+	assert.True(t, isPermissionError("chown: changing ownership: Operation not permitted"))
+	assert.False(t, isPermissionError("ls: cannot access 'x': No such file or directory"))
+}
+
+func TestTouchesRootOwnedPath(t *testing.T) {
+	if info, err := os.Stat("/"); err != nil {
+		t.Skip("no root directory")
+	} else if uid, ok := fileOwner(info); !ok || uid != 0 {
+		t.Skip("root directory is not owned by root")
+	}
+
+	assert.True(t, touchesRootOwnedPath([]string{"-f", "/"}))
+	assert.True(t, touchesRootOwnedPath([]string{"/nonexistent-gocli-file"}))
+
+	if os.Geteuid() != 0 {
+		assert.False(t, touchesRootOwnedPath([]string{filepath.Join(t.TempDir(), "mine")}))
+	}
+}
+
+func TestRewind(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "input")
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	_, err = file.WriteString("data")
+	require.NoError(t, err)
+
+	assert.True(t, rewind(file))
+	offset, err := file.Seek(0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), offset)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = r.Close()
+		_ = w.Close()
+	}()
+	assert.False(t, rewind(r))
+}
+
+func TestRestoreOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.WriteFile(path, []byte("kept\n"), 0644))
+
+	for _, flag := range []int{os.O_TRUNC, os.O_APPEND} {
+		out, err := os.OpenFile(path, os.O_WRONLY|flag, 0644)
+		require.NoError(t, err)
+		size := outputSize(out)
+		_, err = out.WriteString("partial output of the failed run\n")
+		require.NoError(t, err)
+
+		require.NoError(t, restoreOutput(out, size))
+		_, err = out.WriteString("retry\n")
+		require.NoError(t, err)
+		require.NoError(t, out.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		if flag == os.O_TRUNC {
+			assert.Equal(t, "retry\n", string(content))
+		} else {
+			assert.Equal(t, "retry\nretry\n", string(content))
+		}
+	}
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = r.Close()
+		_ = w.Close()
+	}()
+	assert.Equal(t, int64(-1), outputSize(w))
+	assert.NoError(t, restoreOutput(w, -1))
+}
+
+func TestExternalCommand_ShouldRetryWithSudo(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("sudo is never offered to root")
+	}
+
+	asked := false
+	prevConfirm := confirm
+	confirm = func(question string) bool {
+		asked = true
+		return true
+	}
+	defer func() {
+		confirm = prevConfirm
+	}()
+
+	cmd := &externalCommand{args: []string{"touch", "/"}, offerSudo: true}
+	assert.True(t, cmd.shouldRetryWithSudo(nil, "touch: /: Permission denied"))
+	assert.True(t, asked)
+
+	asked = false
+	cmd.offerSudo = false
+	assert.False(t, cmd.shouldRetryWithSudo(nil, "touch: /: Permission denied"))
+	assert.False(t, asked)
+}
+
+func TestExternalCommand_WithSudo(t *testing.T) {
+	cmd := &externalCommand{args: []string{"rm", "/etc/x"}, offerSudo: true, limits: resourceLimits{niceness: 1}}
+	retry := cmd.withSudo()
+
+	assert.Equal(t, []string{"sudo", "rm", "/etc/x"}, retry.args)
+	assert.False(t, retry.offerSudo)
+	assert.Equal(t, cmd.limits, retry.limits)
+	assert.Equal(t, []string{"rm", "/etc/x"}, cmd.args)
+}
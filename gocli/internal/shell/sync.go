@@ -0,0 +1,197 @@
+package shell
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type syncCommand struct {
+	src      string
+	dst      string
+	delete   bool
+	dryRun   bool
+	checksum bool
+	verbose  bool
+}
+
+func parseSyncCommand(d CommandDescription) (Command, error) {
+	fs := newFlagSet("sync")
+	deleteExtra := fs.Bool("delete", false, "delete files in DST that do not exist in SRC")
+	dryRun := fs.Bool("dry-run", false, "only show what would be done")
+	checksum := fs.Bool("c", false, "compare file contents instead of size and modification time")
+	verbose := fs.Bool("v", false, "print every performed action")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+	if len(d.arguments) == 1 {
+		// A bare sync flushes the file system buffers like the coreutils one.
+		return &syncCommand{}, nil
+	}
+	if fs.NArg() != 2 {
+		return nil, fmt.Errorf("sync: expected SRC DST")
+	}
+
+	return &syncCommand{
+		src:      fs.Arg(0),
+		dst:      fs.Arg(1),
+		delete:   *deleteExtra,
+		dryRun:   *dryRun,
+		checksum: *checksum,
+		verbose:  *verbose,
+	}, nil
+}
+
+// Execute mirrors SRC into DST. When SRC is a directory its contents are
+// synchronized into DST (like "rsync -r SRC/ DST"), otherwise a single file is copied.
+// Without SRC and DST it flushes the file system buffers.
+func (s *syncCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if s.src == "" {
+		flushFilesystems()
+		return 0, false
+	}
+	srcInfo, err := os.Stat(s.src)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "sync: %v\n", err)
+		return 1, false
+	}
+
+	if !srcInfo.IsDir() {
+		if err := s.syncFile(out, s.src, s.dst, filepath.Base(s.src), srcInfo); err != nil {
+			_, _ = fmt.Fprintf(errOut, "sync: %v\n", err)
+			return 1, false
+		}
+		return 0, false
+	}
+
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(s.src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.src, path)
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		target := filepath.Join(s.dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				s.report(out, "mkdir", rel)
+				if !s.dryRun {
+					return os.MkdirAll(target, info.Mode().Perm())
+				}
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return s.syncFile(out, path, target, rel, info)
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "sync: %v\n", err)
+		return 1, false
+	}
+
+	if s.delete {
+		if err := s.deleteExtra(out, seen); err != nil {
+			_, _ = fmt.Fprintf(errOut, "sync: %v\n", err)
+			return 1, false
+		}
+	}
+
+	return 0, false
+}
+
+func (s *syncCommand) syncFile(out *os.File, src, dst, rel string, srcInfo fs.FileInfo) error {
+	changed, err := s.needsCopy(src, dst, srcInfo)
+	if err != nil || !changed {
+		return err
+	}
+	s.report(out, "copy", rel)
+	if s.dryRun {
+		return nil
+	}
+	return copyFile(src, dst, srcInfo.Mode())
+}
+
+func (s *syncCommand) needsCopy(src, dst string, srcInfo fs.FileInfo) (bool, error) {
+	dstInfo, err := os.Stat(dst)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if dstInfo.Size() != srcInfo.Size() {
+		return true, nil
+	}
+	if !s.checksum {
+		return !dstInfo.ModTime().Equal(srcInfo.ModTime()), nil
+	}
+
+	srcSum, err := fileMD5(src)
+	if err != nil {
+		return false, err
+	}
+	dstSum, err := fileMD5(dst)
+	if err != nil {
+		return false, err
+	}
+	return srcSum != dstSum, nil
+}
+
+func (s *syncCommand) deleteExtra(out *os.File, seen map[string]bool) error {
+	if _, err := os.Lstat(s.dst); os.IsNotExist(err) {
+		// Only a dry run gets here without DST: there is nothing to delete.
+		return nil
+	}
+	var extra []string
+	err := filepath.WalkDir(s.dst, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.dst, path)
+		if err != nil {
+			return err
+		}
+		if !seen[rel] {
+			extra = append(extra, rel)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(extra)
+	for _, rel := range extra {
+		s.report(out, "delete", rel)
+		if s.dryRun {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dst, rel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncCommand) report(out *os.File, action, rel string) {
+	if s.verbose || s.dryRun {
+		_, _ = fmt.Fprintf(out, "%s %s\n", action, rel)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package shell
+
+// flushFilesystems does nothing: there is no system-wide buffer cache to
+// flush here.
+func flushFilesystems() {}
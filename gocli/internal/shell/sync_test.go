@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSync(t *testing.T, args ...string) string {
+	cmd, err := parseSyncCommand(CommandDescription{
+		name:      SyncCommand,
+		arguments: append([]string{"sync"}, args...),
+	})
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestSyncCommand_Execute_CopiesOnlyChanged(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0600))
+
+	assert.Equal(t, "copy a.txt\nmkdir sub\ncopy sub/b.txt\n", runSync(t, "-v", src, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(content))
+	info, err := os.Stat(filepath.Join(dst, "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.Equal(t, "", runSync(t, "-v", src, dst))
+
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(src, "a.txt"), later, later))
+	assert.Equal(t, "copy a.txt\n", runSync(t, "-v", src, dst))
+	assert.Equal(t, "", runSync(t, "-v", "-c", src, dst))
+}
+
+func TestSyncCommand_Execute_DeleteDryRun(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "keep"), []byte("k"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dst, "stale"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "stale", "old"), []byte("o"), 0644))
+
+	assert.Equal(t, "copy keep\ndelete stale\n", runSync(t, "--delete", "--dry-run", src, dst))
+	assert.NoFileExists(t, filepath.Join(dst, "keep"))
+	assert.DirExists(t, filepath.Join(dst, "stale"))
+
+	runSync(t, "--delete", src, dst)
+	assert.FileExists(t, filepath.Join(dst, "keep"))
+	assert.NoDirExists(t, filepath.Join(dst, "stale"))
+}
+
+func TestSyncCommand_Execute_DeleteDryRunMissingDst(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "keep"), []byte("k"), 0644))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	assert.Equal(t, "mkdir .\ncopy keep\n", runSync(t, "--delete", "--dry-run", src, missing))
+	assert.NoDirExists(t, missing)
+}
+
+func TestSyncCommand_Execute_FlushesWithoutArgs(t *testing.T) {
+	assert.Equal(t, "", runSync(t))
+}
+
+func TestSyncCommand_Parse_MissingArgs(t *testing.T) {
+	_, err := parseSyncCommand(CommandDescription{
+		name:      SyncCommand,
+		arguments: []string{"sync", "only-src"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package shell
+
+import "golang.org/x/sys/unix"
+
+// flushFilesystems writes all buffered file data to disk, as sync(1) does.
+func flushFilesystems() {
+	unix.Sync()
+}
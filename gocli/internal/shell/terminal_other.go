@@ -0,0 +1,16 @@
+//go:build !unix
+
+package shell
+
+import "os"
+
+// terminalWidth reports that the width is unknown on platforms without TIOCGWINSZ,
+// which makes callers fall back to their non-interactive output format.
+func terminalWidth(f *os.File) (int, bool) {
+	return 0, false
+}
+
+// terminalSize reports that the size is unknown, like terminalWidth.
+func terminalSize(f *os.File) (cols, rows int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package shell
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalWidth returns the number of columns of the terminal attached to f.
+// The second result is false when f is not a terminal (e.g. a pipe or a regular file).
+func terminalWidth(f *os.File) (int, bool) {
+	cols, _, ok := terminalSize(f)
+	return cols, ok
+}
+
+// terminalSize returns the number of columns and rows of the terminal attached to f.
+func terminalSize(f *os.File) (cols, rows int, ok bool) {
+	if f == nil {
+		return 0, 0, false
+	}
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return 0, 0, false
+	}
+	return int(ws.Col), int(ws.Row), true
+}
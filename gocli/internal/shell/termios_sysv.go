@@ -0,0 +1,10 @@
+//go:build unix && !(darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package shell
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
@@ -0,0 +1,165 @@
+package shell
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner    = '\u200d'
+	emojiPresentation  = '\ufe0f'
+	regionalIndicatorA = 0x1f1e6
+	regionalIndicatorZ = 0x1f1ff
+)
+
+// wideRanges are the East Asian Wide and Fullwidth blocks and the emoji
+// blocks that terminals draw two columns wide.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x23e9, 0x23ec},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f5},
+	{0x26fa, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f1e6, 0x1f1ff},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+func isWideRune(r rune) bool {
+	for _, rng := range wideRanges {
+		if r < rng.lo {
+			return false
+		}
+		if r <= rng.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroWidthRune reports whether r takes no column of its own: combining
+// marks, format characters such as the zero width joiner, and variation selectors.
+func isZeroWidthRune(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		unicode.Is(unicode.Variation_Selector, r) || (r >= 0x1f3fb && r <= 0x1f3ff)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// nextGrapheme returns the length in bytes of the grapheme cluster at the
+// start of s. It is a simplified form of the Unicode rules that keeps
+// together CR LF, a character with its combining marks, variation selectors
+// and skin tone modifiers, emoji joined with ZWJ, and flag pairs.
+func nextGrapheme(s string) int {
+	first, size := utf8.DecodeRuneInString(s)
+	if first == '\r' && size < len(s) && s[size] == '\n' {
+		return size + 1
+	}
+
+	n := size
+	pairedFlag := false
+	for n < len(s) {
+		r, rsize := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case r == zeroWidthJoiner:
+			n += rsize
+			if n < len(s) {
+				_, next := utf8.DecodeRuneInString(s[n:])
+				n += next
+			}
+		case isZeroWidthRune(r) || unicode.Is(unicode.Mc, r):
+			n += rsize
+		case isRegionalIndicator(first) && isRegionalIndicator(r) && !pairedFlag:
+			n += rsize
+			pairedFlag = true
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// graphemeCount returns the number of user-perceived characters in s.
+func graphemeCount(s string) int {
+	count := 0
+	for s != "" {
+		s = s[nextGrapheme(s):]
+		count++
+	}
+	return count
+}
+
+// displayWidth returns the number of terminal columns s occupies: wide
+// characters and emoji take two, combining marks none.
+func displayWidth(s string) int {
+	width := 0
+	for s != "" {
+		size := nextGrapheme(s)
+		width += clusterWidth(s[:size])
+		s = s[size:]
+	}
+	return width
+}
+
+func clusterWidth(cluster string) int {
+	first, size := utf8.DecodeRuneInString(cluster)
+	switch {
+	case isWideRune(first):
+		return 2
+	case first < 0x20 || first == 0x7f || isZeroWidthRune(first):
+		return 0
+	}
+	for _, r := range cluster[size:] {
+		if r == emojiPresentation {
+			return 2
+		}
+	}
+	return 1
+}
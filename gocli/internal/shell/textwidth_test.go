@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphemeCount(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "ascii", text: "hello", want: 5},
+		{name: "cyrillic", text: "привет", want: 6},
+		{name: "combining accent", text: "e\u0301te\u0301", want: 3},
+		{name: "cjk", text: "日本語", want: 3},
+		{name: "flag", text: "\U0001F1F7\U0001F1FA\U0001F1EB\U0001F1F7", want: 2},
+		{name: "zwj family", text: "\U0001F468\u200d\U0001F469\u200d\U0001F467", want: 1},
+		{name: "skin tone", text: "\U0001F44D\U0001F3FD!", want: 2},
+		{name: "crlf", text: "a\r\nb", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, graphemeCount(tt.text))
+		})
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 5, displayWidth("hello"))
+	assert.Equal(t, 6, displayWidth("日本語"))
+	assert.Equal(t, 3, displayWidth("e\u0301te\u0301"))
+	assert.Equal(t, 2, displayWidth("\U0001F468\u200d\U0001F469\u200d\U0001F467"))
+	assert.Equal(t, 2, displayWidth("\u2764\ufe0f"))
+	assert.Equal(t, 4, displayWidth("ｆｕ"))
+	assert.Equal(t, 0, displayWidth("\u200b"))
+}
+
+func TestFormatColumns_WideNames(t *testing.T) {
+	assert.Equal(t, "日本  c\nab\n", formatColumns([]string{"日本", "ab", "c"}, 7))
+}
+
+func TestShell_Execute_WcChars(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "text.txt")
+	require.NoError(t, os.WriteFile(file, []byte("cafe\u0301\n日本\n"), 0644))
+	sh, stdout := newTestShell(t)
+
+	_, _, err := sh.Execute("wc -m " + file + "; cat " + file + " | wc -m")
+	require.NoError(t, err)
+	assert.Equal(t, "8 "+file+"\n8\n", readShellOutput(t, stdout))
+
+	require.NoError(t, os.WriteFile(file, []byte("cafe\u0301\n日本"), 0644))
+	_, _, err = sh.Execute("wc -m " + file + "; cat " + file + " | wc -m; cat " + file + " | wc")
+	require.NoError(t, err)
+	assert.Equal(t, "8 "+file+"\n8\n7 "+file+"\n7\n2 2 13\n", readShellOutput(t, stdout))
+}
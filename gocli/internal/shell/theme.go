@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type themeCommand struct {
+	name    string
+	preview bool
+	custom  segmentSource
+}
+
+func parseThemeCommand(d CommandDescription, custom segmentSource) (Command, error) {
+	fs := newFlagSet("theme")
+	preview := fs.Bool("p", false, "print the prompt the theme renders instead of switching to it")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+	if fs.NArg() > 1 || (*preview && fs.NArg() == 0) {
+		return nil, fmt.Errorf("theme: usage: theme [-p] [NAME]")
+	}
+	return &themeCommand{name: fs.Arg(0), preview: *preview, custom: custom}, nil
+}
+
+// Execute lists the available themes, marking the active one with "*",
+// previews a theme with -p, or switches the prompt to it by setting PS1 to "@NAME".
+func (c *themeCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	themes, err := loadThemes()
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "theme: %v\n", err)
+		return 1, false
+	}
+
+	if c.name == "" {
+		ps1, _ := env.Get("PS1")
+		active := strings.TrimPrefix(ps1, promptThemeRef)
+		names := make([]string, 0, len(themes))
+		for name := range themes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			mark := " "
+			if strings.HasPrefix(ps1, promptThemeRef) && name == active {
+				mark = "*"
+			}
+			_, _ = fmt.Fprintf(out, "%s %s\n", mark, name)
+		}
+		return 0, false
+	}
+
+	theme, ok := themes[c.name]
+	if !ok {
+		_, _ = fmt.Fprintf(errOut, "theme: %s: no such theme\n", c.name)
+		return 1, false
+	}
+	if c.preview {
+		_, _ = fmt.Fprintln(out, promptSegments{now: time.Now(), custom: c.custom}.renderTheme(theme))
+		return 0, false
+	}
+	env.Set("PS1", promptThemeRef+c.name)
+	return 0, false
+}
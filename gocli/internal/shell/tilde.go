@@ -0,0 +1,36 @@
+package shell
+
+import (
+	"os"
+	"os/user"
+	"strings"
+)
+
+// expandTilde replaces a leading "~" with the home directory (HOME, or the
+// current user's home if it is unset) and a leading "~user" with that user's
+// home directory. Words naming unknown users are left unchanged.
+func expandTilde(word string, env Env) string {
+	if !strings.HasPrefix(word, "~") {
+		return word
+	}
+
+	name, rest, hasSlash := strings.Cut(word[1:], "/")
+	var home string
+	if name == "" {
+		if value, ok := env.Get("HOME"); ok && value != "" {
+			home = value
+		} else if dir, err := os.UserHomeDir(); err == nil {
+			home = dir
+		}
+	} else if u, err := user.Lookup(name); err == nil {
+		home = u.HomeDir
+	}
+	if home == "" {
+		return word
+	}
+
+	if !hasSlash {
+		return home
+	}
+	return strings.TrimSuffix(home, "/") + "/" + rest
+}
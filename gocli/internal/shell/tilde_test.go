@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTilde(t *testing.T) {
+	env := NewEnvFromMap(map[string]string{"HOME": "/home/me"})
+
+	assert.Equal(t, "/home/me", expandTilde("~", env))
+	assert.Equal(t, "/home/me/notes.txt", expandTilde("~/notes.txt", env))
+	assert.Equal(t, "a~b", expandTilde("a~b", env))
+	assert.Equal(t, "~nosuchuser-gocli/x", expandTilde("~nosuchuser-gocli/x", env))
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	assert.Equal(t, current.HomeDir+"/x", expandTilde("~"+current.Username+"/x", env))
+}
+
+func TestShell_Execute_TildeExpansion(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, "notes.txt"), []byte("remember\n"), 0644))
+
+	_, stdout := newTestShell(t)
+	sh := NewShell(WithEnv(NewEnvFromMap(map[string]string{"HOME": home})), WithStdout(stdout))
+
+	retCode, _, err := sh.Execute(`cat ~/notes.txt; echo "~" '~/x'; D=~/docs; echo $D`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "remember\n~ ~/x\n"+home+"/docs\n", readShellOutput(t, stdout))
+}
+
+func TestShell_Execute_ExpandsRedirectionTargets(t *testing.T) {
+	home := t.TempDir()
+	dir := tempWorkDir(t)
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	defer func() {
+		_ = stderr.Close()
+	}()
+	sh := NewShell(WithEnv(NewEnvFromMap(map[string]string{"HOME": home, "X": "a b"})), WithStderr(stderr))
+
+	retCode, _, err := sh.Execute(`echo hi > ~/x; echo a > $X.txt; echo b > "$X-2.txt"; echo c > '$X'; echo e 2> ~/err`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	for path, content := range map[string]string{
+		filepath.Join(home, "x"):        "hi\n",
+		filepath.Join(dir, "a b.txt"):   "a\n",
+		filepath.Join(dir, "a b-2.txt"): "b\n",
+		filepath.Join(dir, "$X"):        "c\n",
+		filepath.Join(home, "err"):      "",
+	} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, content, string(data), path)
+	}
+
+	retCode, _, err = sh.Execute("echo hi > /nonexistent/dir/out; cat < missing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, "gocli: /nonexistent/dir/out: no such file or directory\ngocli: missing: no such file or directory\n", readShellOutput(t, stderr))
+}
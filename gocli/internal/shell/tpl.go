@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// tplCommand renders a Go text/template. The template sees the shell
+// variables as .Env and, with -json, the JSON value read from stdin as .Data.
+type tplCommand struct {
+	file     string
+	jsonData bool
+	strict   bool
+	fsys     FileSystem
+}
+
+func parseTplCommand(d CommandDescription, fsys FileSystem) (Command, error) {
+	fs := newFlagSet("tpl")
+	jsonData := fs.Bool("json", false, "read a JSON value from stdin as .Data")
+	strict := fs.Bool("strict", false, "fail on variables that are not set")
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("tpl: %w", err)
+	}
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("tpl: usage: tpl [-json] [-strict] TEMPLATE")
+	}
+	return &tplCommand{file: fs.Arg(0), jsonData: *jsonData, strict: *strict, fsys: fsys}, nil
+}
+
+// tplFuncs are the functions templates have besides the text/template builtins.
+func tplFuncs(env Env) template.FuncMap {
+	return template.FuncMap{
+		// env returns a variable, "" if it is not set, for names that are not identifiers.
+		"env": func(name string) string {
+			value, _ := env.Get(name)
+			return value
+		},
+		// default returns value, or def if value is empty: {{ .Env.PORT | default "8080" }}.
+		"default": func(def, value any) any {
+			if value == nil || value == "" {
+				return def
+			}
+			return value
+		},
+		"required": func(message string, value any) (any, error) {
+			if value == nil || value == "" {
+				return nil, fmt.Errorf("%s", message)
+			}
+			return value, nil
+		},
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"split":   func(sep, s string) []string { return strings.Split(s, sep) },
+		"join": func(sep string, items []any) string {
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = fmt.Sprint(item)
+			}
+			return strings.Join(parts, sep)
+		},
+		"quote": strconv.Quote,
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}
+}
+
+func (t *tplCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if err := t.render(in, out, env); err != nil {
+		_, _ = fmt.Fprintf(errOut, "tpl: %v\n", err)
+		return 1, false
+	}
+	return 0, false
+}
+
+func (t *tplCommand) render(in io.Reader, out io.Writer, env Env) error {
+	file, err := t.fsys.Open(t.file)
+	if err != nil {
+		return err
+	}
+	text, err := io.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+
+	missingKey := "missingkey=zero"
+	if t.strict {
+		missingKey = "missingkey=error"
+	}
+	tmpl := template.New(filepath.Base(t.file)).Funcs(tplFuncs(env)).Option(missingKey)
+	if tmpl, err = tmpl.Parse(string(text)); err != nil {
+		return err
+	}
+
+	data := map[string]any{"Env": env.GetAll()}
+	if t.jsonData {
+		var value any
+		decoder := json.NewDecoder(in)
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil && err != io.EOF {
+			return fmt.Errorf("stdin: %w", err)
+		}
+		data["Data"] = value
+	}
+
+	// Nothing is written if the template fails halfway.
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	_, _ = w.WriteString(buf.String())
+	return w.Flush()
+}
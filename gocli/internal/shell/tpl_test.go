@@ -0,0 +1,32 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTplCommand(t *testing.T) {
+	tempWorkDir(t)
+	require.NoError(t, os.WriteFile("app.tpl", []byte(`name={{ .Env.NAME | upper }}
+port={{ .Env.PORT | default "80" }}
+{{ range .Data.hosts }}host={{ . }}
+{{ end }}tags={{ join "," .Data.tags }} {{ json .Data.limits }}
+`), 0644))
+	require.NoError(t, os.WriteFile("strict.tpl", []byte(`{{ .Env.MISSING }}`), 0644))
+	require.NoError(t, os.WriteFile("required.tpl", []byte(`partial {{ env "MISSING" | required "MISSING is not set" }}`), 0644))
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute(`NAME=web; echo '{"hosts": ["a", "b"], "tags": ["x", 1], "limits": {"cpu": 2}}' | tpl -json app.tpl; tpl strict.tpl`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	for _, line := range []string{"tpl -strict strict.tpl", "tpl required.tpl", "echo '{' | tpl -json app.tpl", "tpl missing.tpl", "tpl"} {
+		retCode, _, err := sh.Execute(line + " 2> /dev/null")
+		require.NoError(t, err)
+		assert.NotEqual(t, 0, retCode, line)
+	}
+	assert.Equal(t, "name=WEB\nport=80\nhost=a\nhost=b\ntags=x,1 {\"cpu\":2}\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,297 @@
+package shell
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// otelEndpointVar names the OTLP/HTTP collector that pipeline traces are sent to,
+// e.g. "http://localhost:4318". Tracing is off while it is unset.
+const otelEndpointVar = "GOCLI_OTEL_ENDPOINT"
+
+// otelExportTimeout bounds how long an export waits for the collector.
+const otelExportTimeout = 2 * time.Second
+
+const (
+	// otelQueueSize is how many finished traces may wait for the collector;
+	// while the queue is full, further traces are dropped.
+	otelQueueSize = 256
+	// otelBatchSize is the most traces sent in one request.
+	otelBatchSize = 64
+)
+
+// traceExporter sends finished traces to their collectors in the background,
+// so that a slow or unreachable collector never delays a pipeline.
+type traceExporter struct {
+	queue   chan *pipelineTrace
+	start   sync.Once
+	pending sync.WaitGroup
+}
+
+var traceQueue = &traceExporter{queue: make(chan *pipelineTrace, otelQueueSize)}
+
+// enqueue queues t for export, or drops it if the queue is full.
+func (e *traceExporter) enqueue(t *pipelineTrace) {
+	e.start.Do(func() { go e.run() })
+	e.pending.Add(1)
+	select {
+	case e.queue <- t:
+	default:
+		e.pending.Done()
+	}
+}
+
+func (e *traceExporter) run() {
+	for t := range e.queue {
+		batch := []*pipelineTrace{t}
+	collect:
+		for len(batch) < otelBatchSize {
+			select {
+			case t := <-e.queue:
+				batch = append(batch, t)
+			default:
+				break collect
+			}
+		}
+		// Export errors are ignored: tracing must not change how commands run.
+		for _, group := range groupByEndpoint(batch) {
+			_ = exportTraces(group[0].endpoint, group)
+		}
+		for range batch {
+			e.pending.Done()
+		}
+	}
+}
+
+// flush waits until the queued traces are exported, but not longer than timeout.
+func (e *traceExporter) flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		e.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// flushTraces gives the traces of the last pipelines of a session a chance
+// to reach the collector before the process exits.
+func flushTraces() {
+	traceQueue.flush(otelExportTimeout)
+}
+
+// groupByEndpoint splits batch by collector, keeping the order of the traces.
+func groupByEndpoint(batch []*pipelineTrace) [][]*pipelineTrace {
+	var groups [][]*pipelineTrace
+	index := make(map[string]int)
+	for _, t := range batch {
+		i, ok := index[t.endpoint]
+		if !ok {
+			i = len(groups)
+			index[t.endpoint] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	return groups
+}
+
+// pipelineTrace records one pipeline as a trace: a root span for the pipeline
+// and a child span for every command that was started.
+type pipelineTrace struct {
+	endpoint string
+	traceID  [16]byte
+	root     traceSpan
+	stages   []*traceSpan
+}
+
+// traceSpan is a finished or running span of a pipelineTrace.
+type traceSpan struct {
+	id    [8]byte
+	name  string
+	args  []string
+	start time.Time
+	end   time.Time
+	code  int
+}
+
+// startPipelineTrace starts the trace of a pipeline of n commands,
+// or returns nil when tracing is off. All methods accept a nil trace.
+func startPipelineTrace(env Env, n int) *pipelineTrace {
+	endpoint, ok := env.Get(otelEndpointVar)
+	if !ok || endpoint == "" {
+		return nil
+	}
+	t := &pipelineTrace{
+		endpoint: endpoint,
+		root:     traceSpan{name: "pipeline", start: time.Now()},
+		stages:   make([]*traceSpan, n),
+	}
+	_, _ = rand.Read(t.traceID[:])
+	_, _ = rand.Read(t.root.id[:])
+	return t
+}
+
+// stage creates the span of the i-th command, which runs with args.
+func (t *pipelineTrace) stage(i int, args []string) *traceSpan {
+	if t == nil {
+		return nil
+	}
+	span := &traceSpan{name: args[0], args: args}
+	_, _ = rand.Read(span.id[:])
+	t.stages[i] = span
+	return span
+}
+
+func (s *traceSpan) begin() {
+	if s != nil {
+		s.start = time.Now()
+	}
+}
+
+func (s *traceSpan) finish(code int) {
+	if s != nil {
+		s.end, s.code = time.Now(), code
+	}
+}
+
+// finish ends the pipeline with the status of its last command and queues
+// the trace for export.
+func (t *pipelineTrace) finish(code int) {
+	if t == nil {
+		return
+	}
+	t.root.finish(code)
+	var names []string
+	for _, stage := range t.stages {
+		if stage != nil {
+			names = append(names, stage.name)
+		}
+	}
+	t.root.args = names
+	traceQueue.enqueue(t)
+}
+
+// exportTraces posts traces to the collector at endpoint in the OTLP/HTTP
+// JSON encoding.
+func exportTraces(endpoint string, traces []*pipelineTrace) error {
+	body, err := json.Marshal(tracesPayload(traces))
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+
+	client := http.Client{Timeout: otelExportTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otel: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpAttribute is a key-value pair of the OTLP JSON encoding.
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]any{"stringValue": value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	// 64-bit integers are encoded as strings in OTLP JSON.
+	return otlpAttribute{Key: key, Value: map[string]any{"intValue": strconv.FormatInt(value, 10)}}
+}
+
+func stringsAttribute(key string, values []string) otlpAttribute {
+	list := make([]map[string]any, 0, len(values))
+	for _, v := range values {
+		list = append(list, map[string]any{"stringValue": v})
+	}
+	return otlpAttribute{Key: key, Value: map[string]any{"arrayValue": map[string]any{"values": list}}}
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            map[string]int  `json:"status"`
+}
+
+// Span kinds and status codes of the OTLP protocol.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+func (s *traceSpan) otlp(traceID [16]byte, parent *traceSpan, argsKey string) otlpSpan {
+	status := otlpStatusOK
+	if s.code != 0 {
+		status = otlpStatusError
+	}
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(traceID[:]),
+		SpanID:            hex.EncodeToString(s.id[:]),
+		Name:              s.name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes: []otlpAttribute{
+			stringsAttribute(argsKey, s.args),
+			intAttribute("process.exit.code", int64(s.code)),
+			intAttribute("gocli.duration_ms", s.end.Sub(s.start).Milliseconds()),
+		},
+		Status: map[string]int{"code": status},
+	}
+	if parent != nil {
+		span.ParentSpanID = hex.EncodeToString(parent.id[:])
+	}
+	return span
+}
+
+func tracesPayload(traces []*pipelineTrace) map[string]any {
+	var spans []otlpSpan
+	for _, t := range traces {
+		spans = append(spans, t.root.otlp(t.traceID, nil, "gocli.pipeline.commands"))
+		for _, stage := range t.stages {
+			if stage != nil {
+				spans = append(spans, stage.otlp(t.traceID, &t.root, "process.command_args"))
+			}
+		}
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{stringAttribute("service.name", "gocli")},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "gocli"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
@@ -0,0 +1,81 @@
+package shell
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineRunner_Execute_Tracing(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	sh, _ := newTestShell(t)
+	sh.env.Set(otelEndpointVar, collector.URL)
+	_, _, err := sh.Execute("echo hi | grep nothing")
+	require.NoError(t, err)
+
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &payload))
+	spans := payload.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 3)
+
+	root := spans[0]
+	assert.Equal(t, "pipeline", root.Name)
+	assert.Empty(t, root.ParentSpanID)
+	assert.Equal(t, otlpStatusError, root.Status["code"])
+	for _, span := range spans[1:] {
+		assert.Equal(t, root.TraceID, span.TraceID)
+		assert.Equal(t, root.SpanID, span.ParentSpanID)
+	}
+	assert.Equal(t, "echo", spans[1].Name)
+	assert.Equal(t, "process.command_args", spans[1].Attributes[0].Key)
+	assert.Equal(t, map[string]any{"intValue": "0"}, spans[1].Attributes[1].Value)
+	assert.Equal(t, "grep", spans[2].Name)
+	assert.Equal(t, map[string]any{"intValue": "1"}, spans[2].Attributes[1].Value)
+}
+
+func TestStartPipelineTrace_Disabled(t *testing.T) {
+	trace := startPipelineTrace(NewEnvFromMap(nil), 2)
+	assert.Nil(t, trace)
+
+	// A disabled trace accepts every call.
+	span := trace.stage(0, []string{"echo"})
+	span.begin()
+	span.finish(0)
+	trace.finish(0)
+}
+
+func TestPipelineRunner_Execute_TracingDoesNotWaitForCollector(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	sh, _ := newTestShell(t)
+	sh.env.Set(otelEndpointVar, collector.URL)
+	start := time.Now()
+	_, _, err := sh.Execute("echo hi")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), otelExportTimeout/2, "the trace is sent in the background")
+}
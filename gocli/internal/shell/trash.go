@@ -0,0 +1,179 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// trashEntry records a single file operation that can be undone.
+type trashEntry struct {
+	original string
+	trashed  string
+	at       time.Time
+}
+
+// trashBin is a per-session directory that receives files removed while the
+// safety option is on, together with a journal used to restore them.
+type trashBin struct {
+	mu      sync.Mutex
+	dir     string
+	entries []trashEntry
+}
+
+func newTrashBin() *trashBin {
+	return &trashBin{}
+}
+
+// put moves path into the trash and records the operation.
+func (t *trashBin) put(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	original, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if t.dir == "" {
+		dir, err := os.MkdirTemp("", "gocli-trash-")
+		if err != nil {
+			return err
+		}
+		t.dir = dir
+	}
+
+	trashed := filepath.Join(t.dir, fmt.Sprintf("%d-%s", len(t.entries), filepath.Base(original)))
+	if err := moveFile(original, trashed); err != nil {
+		return err
+	}
+	t.entries = append(t.entries, trashEntry{original: original, trashed: trashed, at: time.Now()})
+	return nil
+}
+
+// move renames src to dst and records the operation, so that restore moves
+// the file back like it brings back a trashed one.
+func (t *trashBin) move(src, dst string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	original, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	moved, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if err := moveFile(original, moved); err != nil {
+		return err
+	}
+	t.entries = append(t.entries, trashEntry{original: original, trashed: moved, at: time.Now()})
+	return nil
+}
+
+// restore moves the last n trashed files back, most recent first.
+// It stops at the first entry that cannot be restored and returns the restored paths.
+func (t *trashBin) restore(n int) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var restored []string
+	for ; n > 0 && len(t.entries) > 0; n-- {
+		last := t.entries[len(t.entries)-1]
+		if _, err := os.Lstat(last.original); err == nil {
+			return restored, fmt.Errorf("%s: already exists", last.original)
+		}
+		if err := moveFile(last.trashed, last.original); err != nil {
+			return restored, err
+		}
+		t.entries = t.entries[:len(t.entries)-1]
+		restored = append(restored, last.original)
+	}
+	return restored, nil
+}
+
+// list returns the journal, most recent entry first.
+func (t *trashBin) list() []trashEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]trashEntry, 0, len(t.entries))
+	for i := len(t.entries) - 1; i >= 0; i-- {
+		entries = append(entries, t.entries[i])
+	}
+	return entries
+}
+
+type trashCommand struct {
+	trash   *trashBin
+	restore bool
+	count   int
+}
+
+func parseTrashCommand(d CommandDescription, trash *trashBin) (Command, error) {
+	args := d.arguments[1:]
+	cmd := &trashCommand{trash: trash, count: 1}
+
+	if d.name == UndoCommand {
+		cmd.restore = true
+	} else {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("trash: expected list or restore")
+		}
+		switch args[0] {
+		case "list":
+			if len(args) > 1 {
+				return nil, fmt.Errorf("trash: list takes no arguments")
+			}
+			return cmd, nil
+		case "restore":
+			cmd.restore = true
+			args = args[1:]
+		default:
+			return nil, fmt.Errorf("trash: unknown subcommand %q", args[0])
+		}
+	}
+
+	fs := newFlagSet(string(d.name))
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%s: %w", d.name, err)
+	}
+	if fs.NArg() > 1 {
+		return nil, fmt.Errorf("%s: expected at most one count", d.name)
+	}
+	if fs.NArg() == 1 {
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s: invalid count %q", d.name, fs.Arg(0))
+		}
+		cmd.count = n
+	}
+	return cmd, nil
+}
+
+func (c *trashCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if !c.restore {
+		for i, entry := range c.trash.list() {
+			_, _ = fmt.Fprintf(out, "%d\t%s\t%s\n", i+1, entry.at.Format(time.DateTime), entry.original)
+		}
+		return 0, false
+	}
+
+	restored, err := c.trash.restore(c.count)
+	for _, path := range restored {
+		_, _ = fmt.Fprintf(out, "restored %s\n", path)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "trash: %v\n", err)
+		return 1, false
+	}
+	if len(restored) == 0 {
+		_, _ = fmt.Fprintln(errOut, "trash: nothing to restore")
+		return 1, false
+	}
+	return 0, false
+}
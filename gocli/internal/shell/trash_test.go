@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashBin_PutAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "first")
+	second := filepath.Join(tmpDir, "second")
+	require.NoError(t, os.WriteFile(first, []byte("1"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(second, "inner"), 0755))
+
+	trash := newTrashBin()
+	t.Cleanup(func() {
+		_ = os.RemoveAll(trash.dir)
+	})
+	require.NoError(t, trash.put(first))
+	require.NoError(t, trash.put(second))
+	assert.NoFileExists(t, first)
+	assert.NoDirExists(t, second)
+
+	entries := trash.list()
+	require.Len(t, entries, 2)
+	assert.Equal(t, second, entries[0].original)
+
+	restored, err := trash.restore(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second}, restored)
+	assert.DirExists(t, filepath.Join(second, "inner"))
+	assert.NoFileExists(t, first)
+
+	require.NoError(t, os.WriteFile(first, []byte("new"), 0644))
+	_, err = trash.restore(1)
+	assert.Error(t, err, "restore must not overwrite existing files")
+}
+
+func TestRmCommand_Execute_SafetyUsesTrash(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "precious.txt")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0644))
+
+	sh, stdout := newTestShell(t)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(sh.factory.trash.dir)
+	})
+	for _, line := range []string{"set -o safety", "rm " + file} {
+		retCode, _, err := sh.Execute(line)
+		require.NoError(t, err)
+		require.Equal(t, 0, retCode, line)
+	}
+	assert.NoFileExists(t, file)
+
+	retCode, _, err := sh.Execute("undo")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+	assert.Equal(t, "restored "+file+"\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("trash restore")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode, "nothing left to restore")
+}
+
+func TestTrashCommand_Parse(t *testing.T) {
+	trash := newTrashBin()
+
+	cmd, err := parseTrashCommand(CommandDescription{name: TrashCommand, arguments: []string{"trash", "restore", "3"}}, trash)
+	require.NoError(t, err)
+	assert.Equal(t, &trashCommand{trash: trash, restore: true, count: 3}, cmd)
+
+	cmd, err = parseTrashCommand(CommandDescription{name: UndoCommand, arguments: []string{"undo"}}, trash)
+	require.NoError(t, err)
+	assert.Equal(t, &trashCommand{trash: trash, restore: true, count: 1}, cmd)
+
+	_, err = parseTrashCommand(CommandDescription{name: TrashCommand, arguments: []string{"trash", "empty"}}, trash)
+	assert.Error(t, err)
+
+	_, err = parseTrashCommand(CommandDescription{name: UndoCommand, arguments: []string{"undo", "0"}}, trash)
+	assert.Error(t, err)
+}
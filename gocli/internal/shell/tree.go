@@ -0,0 +1,120 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type treeCommand struct {
+	root     string
+	maxDepth int
+	showAll  bool
+	// gitignore hides the files matched by the .gitignore files in the tree.
+	gitignore bool
+	fsys      FileSystem
+}
+
+func parseTreeCommand(d CommandDescription, fsys FileSystem) (Command, error) {
+	fs := newFlagSet("tree")
+	maxDepth := fs.Int("L", 0, "descend only level directories deep")
+	showAll := fs.Bool("a", false, "list hidden files too")
+	gitignore := fs.Bool("gitignore", false, "leave out files matched by .gitignore files")
+
+	if err := parseFlags(fs, d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("tree: %w", err)
+	}
+	if *maxDepth < 0 {
+		return nil, fmt.Errorf("tree: invalid level, must be 0 (no limit) or greater")
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+
+	return &treeCommand{
+		root:      root,
+		maxDepth:  *maxDepth,
+		showAll:   *showAll,
+		gitignore: *gitignore,
+		fsys:      fsys,
+	}, nil
+}
+
+type treeCounts struct {
+	dirs  int
+	files int
+}
+
+func (t *treeCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	info, err := t.fsys.Stat(t.root)
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "tree: %v\n", err)
+		return 2, false
+	}
+	if !info.IsDir() {
+		_, _ = fmt.Fprintf(errOut, "tree: %s: not a directory\n", t.root)
+		return 2, false
+	}
+
+	_, _ = fmt.Fprintln(out, t.root)
+	var counts treeCounts
+	t.walk(out, t.root, ".", "", 1, nil, &counts)
+
+	_, _ = fmt.Fprintf(out, "\n%s, %s\n",
+		pluralize(counts.dirs, "directory", "directories"),
+		pluralize(counts.files, "file", "files"))
+
+	return 0, false
+}
+
+// walk prints the entries of dir, whose path relative to the root is rel.
+// ignore holds the .gitignore rules of the directories above it.
+func (t *treeCommand) walk(out *os.File, dir, rel, prefix string, depth int, ignore *gitignore, counts *treeCounts) {
+	entries, err := t.fsys.ReadDir(dir)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "%s└── [error opening dir]\n", prefix)
+		return
+	}
+	if t.gitignore {
+		ignore = ignore.load(t.fsys, dir, rel)
+	}
+
+	visible := entries[:0]
+	for _, entry := range entries {
+		if !t.showAll && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if ignore.ignored(path.Join(rel, entry.Name()), entry.IsDir()) {
+			continue
+		}
+		visible = append(visible, entry)
+	}
+
+	for i, entry := range visible {
+		branch, indent := "├── ", "│   "
+		if i == len(visible)-1 {
+			branch, indent = "└── ", "    "
+		}
+		_, _ = fmt.Fprintf(out, "%s%s%s\n", prefix, branch, entry.Name())
+
+		if !entry.IsDir() {
+			counts.files++
+			continue
+		}
+		counts.dirs++
+		if t.maxDepth == 0 || depth < t.maxDepth {
+			t.walk(out, filepath.Join(dir, entry.Name()), path.Join(rel, entry.Name()), prefix+indent, depth+1, ignore, counts)
+		}
+	}
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
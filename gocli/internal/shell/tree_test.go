@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTreeFixture(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "pkg", "lib.go"), nil, 0644))
+	return dir
+}
+
+func runTree(t *testing.T, args ...string) string {
+	cmd, err := parseTreeCommand(CommandDescription{
+		name:      TreeCommand,
+		arguments: append([]string{"tree"}, args...),
+	}, OSFileSystem)
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, w, os.Stderr, nil)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 0, retCode)
+	assert.False(t, exited)
+
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestTreeCommand_Execute(t *testing.T) {
+	dir := makeTreeFixture(t)
+
+	expected := dir + "\n" +
+		"├── README\n" +
+		"└── src\n" +
+		"    ├── main.go\n" +
+		"    └── pkg\n" +
+		"        └── lib.go\n" +
+		"\n2 directories, 3 files\n"
+	assert.Equal(t, expected, runTree(t, dir))
+}
+
+func TestTreeCommand_Execute_DepthAndHidden(t *testing.T) {
+	dir := makeTreeFixture(t)
+
+	expected := dir + "\n" +
+		"├── .env\n" +
+		"├── README\n" +
+		"└── src\n" +
+		"\n1 directory, 2 files\n"
+	assert.Equal(t, expected, runTree(t, "-a", "-L", "1", dir))
+}
+
+func TestTreeCommand_Execute_Gitignore(t *testing.T) {
+	dir := makeTreeFixture(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "build"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build", "out"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main_test.go"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# output\nbuild/\n*_test.go\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", ".gitignore"), []byte("/pkg\n!main_test.go\n"), 0644))
+
+	expected := dir + "\n" +
+		"├── README\n" +
+		"└── src\n" +
+		"    ├── main.go\n" +
+		"    └── main_test.go\n" +
+		"\n1 directory, 3 files\n"
+	assert.Equal(t, expected, runTree(t, "--gitignore", dir))
+	assert.Contains(t, runTree(t, dir), "build", "without --gitignore nothing is left out")
+}
+
+func TestTreeCommand_Execute_BundledFlags(t *testing.T) {
+	dir := makeTreeFixture(t)
+
+	output := runTree(t, "-aL", "1", dir)
+	assert.Equal(t, runTree(t, "-a", "-L", "1", dir), output)
+	assert.Equal(t, output, runTree(t, "-aL1", dir))
+	assert.Contains(t, output, ".env")
+	assert.NotContains(t, output, "main.go")
+}
+
+func TestParseTreeCommand_InvalidLevel(t *testing.T) {
+	_, err := parseTreeCommand(CommandDescription{name: TreeCommand, arguments: []string{"tree", "-L", "-1"}}, OSFileSystem)
+	assert.EqualError(t, err, "tree: invalid level, must be 0 (no limit) or greater")
+}
+
+func TestTreeCommand_Execute_NotADirectory(t *testing.T) {
+	cmd := &treeCommand{root: "/nonexistent/dir", fsys: OSFileSystem}
+	retCode, exited := cmd.Execute(nil, nil, os.Stderr, nil)
+	assert.Equal(t, 2, retCode)
+	assert.False(t, exited)
+}
@@ -0,0 +1,34 @@
+package shell
+
+import "fmt"
+
+// parseUnbufferCommand resolves "unbuffer COMMAND [ARGS...]" into an external command
+// whose standard output is a pseudo-terminal. The C library flushes a terminal on
+// every newline, so the command's output reaches the next stage of a pipeline line
+// by line instead of in blocks, as in "tail -f log | unbuffer tr a-z A-Z | grep ERROR".
+func parseUnbufferCommand(d CommandDescription, factory CommandFactory) (Command, error) {
+	if len(d.arguments) < 2 {
+		return nil, fmt.Errorf("unbuffer: usage: unbuffer COMMAND [ARGS...]")
+	}
+
+	inner := d
+	inner.name = CommandName(d.arguments[1])
+	inner.arguments = d.arguments[1:]
+
+	cmd, err := factory.GetCommand(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	target := cmd
+	if guarded, ok := cmd.(*guardedCommand); ok {
+		target = guarded.inner
+	}
+	external, ok := target.(*externalCommand)
+	if !ok {
+		// Builtins write straight to their output without buffering.
+		return cmd, nil
+	}
+	external.pty = true
+	return cmd, nil
+}
@@ -0,0 +1,38 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnbufferCommand_Parse(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	cmd, err := parseUnbufferCommand(CommandDescription{
+		name:      UnbufferCommand,
+		arguments: []string{"unbuffer", "tr", "a", "b"},
+	}, factory)
+	require.NoError(t, err)
+
+	external, ok := cmd.(*externalCommand)
+	require.True(t, ok)
+	assert.Equal(t, []string{"tr", "a", "b"}, external.args)
+	assert.True(t, external.pty)
+}
+
+func TestUnbufferCommand_Parse_Builtin(t *testing.T) {
+	factory := NewCommandFactory(NewEnv())
+
+	cmd, err := parseUnbufferCommand(CommandDescription{
+		name:      UnbufferCommand,
+		arguments: []string{"unbuffer", "echo", "hi"},
+	}, factory)
+	require.NoError(t, err)
+	_, external := cmd.(*externalCommand)
+	assert.False(t, external)
+
+	_, err = parseUnbufferCommand(CommandDescription{name: UnbufferCommand, arguments: []string{"unbuffer"}}, factory)
+	assert.Error(t, err)
+}
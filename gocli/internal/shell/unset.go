@@ -0,0 +1,101 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// unsetVar is a variable as it was before unset removed it.
+type unsetVar struct {
+	key, value string
+	exported   bool
+}
+
+// unsetHistory remembers what every unset of the session removed,
+// so that a mistake like "unset PATH" can be taken back.
+type unsetHistory struct {
+	mu      sync.Mutex
+	batches [][]unsetVar
+}
+
+func newUnsetHistory() *unsetHistory {
+	return &unsetHistory{}
+}
+
+func (h *unsetHistory) push(batch []unsetVar) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.batches = append(h.batches, batch)
+}
+
+func (h *unsetHistory) pop() ([]unsetVar, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.batches) == 0 {
+		return nil, false
+	}
+	batch := h.batches[len(h.batches)-1]
+	h.batches = h.batches[:len(h.batches)-1]
+	return batch, true
+}
+
+type unsetCommand struct {
+	names   []string
+	restore bool
+	history *unsetHistory
+}
+
+func parseUnsetCommand(d CommandDescription, history *unsetHistory) (Command, error) {
+	args := d.arguments[1:]
+	if len(args) == 1 && args[0] == "-u" {
+		return &unsetCommand{restore: true, history: history}, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("unset: usage: unset NAME... | unset -u")
+	}
+	for _, name := range args {
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("unset: %s: not a valid identifier", name)
+		}
+	}
+	return &unsetCommand{names: args, history: history}, nil
+}
+
+// Execute removes the variables, or with -u sets back the ones removed by the last unset.
+// Removing PATH prints a reminder of how to get it back.
+func (u *unsetCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	if u.restore {
+		batch, ok := u.history.pop()
+		if !ok {
+			_, _ = fmt.Fprintln(errOut, "unset: nothing to restore")
+			return 1, false
+		}
+		for _, v := range batch {
+			env.Set(v.key, v.value)
+			if v.exported {
+				env.Export(v.key)
+			}
+		}
+		return 0, false
+	}
+
+	exported := env.Exported()
+	var batch []unsetVar
+	for _, name := range u.names {
+		value, ok := env.Get(name)
+		if !ok {
+			continue
+		}
+		_, isExported := exported[name]
+		batch = append(batch, unsetVar{key: name, value: value, exported: isExported})
+		env.Unset(name)
+		if name == "PATH" {
+			_, _ = fmt.Fprintln(errOut, "unset: PATH removed, external commands will not be found; 'unset -u' restores it")
+		}
+	}
+	if len(batch) > 0 {
+		u.history.push(batch)
+	}
+	return 0, false
+}
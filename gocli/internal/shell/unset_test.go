@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsetCommand_Execute(t *testing.T) {
+	_, stdout := newTestShell(t)
+	env := NewEnvFromMap(map[string]string{"PATH": "/usr/bin:/bin"})
+	sh := NewShell(WithEnv(env), WithStdout(stdout))
+	errPath := filepath.Join(t.TempDir(), "err")
+
+	retCode, _, err := sh.Execute("LOCAL=1; unset LOCAL PATH MISSING 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Empty(t, env.GetAll())
+	assert.Empty(t, env.Exported())
+	stderr, err := os.ReadFile(errPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(stderr), "'unset -u' restores it")
+
+	retCode, _, err = sh.Execute("unset -u")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, map[string]string{"LOCAL": "1", "PATH": "/usr/bin:/bin"}, env.GetAll())
+	assert.Equal(t, map[string]string{"PATH": "/usr/bin:/bin"}, env.Exported())
+
+	retCode, _, err = sh.Execute("unset -u 2> " + errPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestUnsetCommand_Execute_ThenSet(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("export GOCLI_UNSET=1; unset GOCLI_UNSET; GOCLI_UNSET=2; export -p")
+	require.NoError(t, err)
+	assert.Equal(t, 0, retCode)
+	assert.NotContains(t, readShellOutput(t, stdout), "GOCLI_UNSET")
+}
+
+func TestParseUnsetCommand_Invalid(t *testing.T) {
+	for _, args := range [][]string{{"unset"}, {"unset", "A-B"}, {"unset", "-u", "X"}} {
+		_, err := parseUnsetCommand(CommandDescription{name: UnsetCommand, arguments: args}, newUnsetHistory())
+		assert.Error(t, err, args)
+	}
+}
@@ -0,0 +1,133 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// urlInputTimeout bounds the whole download of a URL, body included.
+	urlInputTimeout = 30 * time.Second
+	// urlInputMaxSize is how much of a URL is read before the download fails.
+	urlInputMaxSize = 64 << 20
+)
+
+// errURLTooLarge is returned by reads past urlInputMaxSize.
+var errURLTooLarge = fmt.Errorf("response larger than %d MiB", urlInputMaxSize>>20)
+
+var urlInputClient = &http.Client{Timeout: urlInputTimeout}
+
+// isURL reports whether name is an http or https URL rather than a path.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// inputFS returns the filesystem that input redirections and file-reading
+// builtins open files on: fsys, or with the url-input option fsys extended
+// with http and https URLs.
+func inputFS(fsys FileSystem, options *shellOptions) FileSystem {
+	if options == nil || !options.isSet(OptionURLInput) {
+		return fsys
+	}
+	return urlFileSystem{FileSystem: fsys}
+}
+
+// urlFileSystem opens http and https URLs by downloading them and
+// passes other names to the FileSystem it wraps.
+type urlFileSystem struct {
+	FileSystem
+}
+
+func (u urlFileSystem) Open(name string) (fs.File, error) {
+	if !isURL(name) {
+		return u.FileSystem.Open(name)
+	}
+	resp, err := urlInputClient.Get(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapURLError(err)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_ = resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New(resp.Status)}
+	}
+	if resp.ContentLength > urlInputMaxSize {
+		_ = resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errURLTooLarge}
+	}
+	return &urlFile{resp: resp, left: urlInputMaxSize}, nil
+}
+
+// unwrapURLError drops the method and URL that *url.Error repeats,
+// since the path error around it names the URL already.
+func unwrapURLError(err error) error {
+	if inner := errors.Unwrap(err); inner != nil {
+		return inner
+	}
+	return err
+}
+
+// urlFile is the body of a response, limited to urlInputMaxSize.
+type urlFile struct {
+	resp *http.Response
+	left int64
+}
+
+func (f *urlFile) Read(p []byte) (int, error) {
+	if f.left <= 0 {
+		// Tell a body that ends right at the limit from one that goes on.
+		var probe [1]byte
+		if n, _ := f.resp.Body.Read(probe[:]); n > 0 {
+			return 0, errURLTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > f.left {
+		p = p[:f.left]
+	}
+	n, err := f.resp.Body.Read(p)
+	f.left -= int64(n)
+	return n, err
+}
+
+func (f *urlFile) Close() error {
+	return f.resp.Body.Close()
+}
+
+func (f *urlFile) Stat() (fs.FileInfo, error) {
+	return urlFileInfo{resp: f.resp}, nil
+}
+
+type urlFileInfo struct {
+	resp *http.Response
+}
+
+func (i urlFileInfo) Name() string {
+	return path.Base(i.resp.Request.URL.Path)
+}
+
+func (i urlFileInfo) Size() int64 {
+	return max(i.resp.ContentLength, 0)
+}
+
+func (i urlFileInfo) Mode() fs.FileMode {
+	return 0444
+}
+
+func (i urlFileInfo) ModTime() time.Time {
+	modified, _ := http.ParseTime(i.resp.Header.Get("Last-Modified"))
+	return modified
+}
+
+func (i urlFileInfo) IsDir() bool {
+	return false
+}
+
+func (i urlFileInfo) Sys() any {
+	return nil
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionURLInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, "alpha\nbeta\ngamma\n")
+	}))
+	defer server.Close()
+	url := server.URL + "/data.txt"
+	sh, stdout := newTestShell(t)
+
+	retCode, _, err := sh.Execute("cat " + url)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode, "URLs are only read with url-input")
+
+	require.NoError(t, sh.SetOption(OptionURLInput, true))
+	_, _, err = sh.Execute("cat " + url + "; grep -i BETA " + url + "; grep a < " + url + " | wc; wc < " + url)
+	require.NoError(t, err)
+	assert.Equal(t, "alpha\nbeta\ngamma\nbeta\n3 3 17\n3 3 17\n", readShellOutput(t, stdout))
+
+	retCode, _, err = sh.Execute("cat " + server.URL + "/missing")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retCode)
+}
+
+func TestURLFile_SizeLimit(t *testing.T) {
+	open := func(body string, limit int64) *urlFile {
+		return &urlFile{resp: &http.Response{Body: io.NopCloser(strings.NewReader(body))}, left: limit}
+	}
+
+	data, err := io.ReadAll(open("abcdef", 6))
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", string(data))
+
+	data, err = io.ReadAll(open("abcdef", 4))
+	assert.ErrorIs(t, err, errURLTooLarge)
+	assert.Equal(t, "abcd", string(data))
+}
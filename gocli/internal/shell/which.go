@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// builtins are the commands the shell runs itself rather than looking them up in PATH.
+var builtins = map[CommandName]bool{
+	ExitCommand: true, PWDCommand: true, CatCommand: true, EchoCommand: true,
+	WCCommand: true, GrepCommand: true, SortCommand: true, HeadCommand: true,
+	CutCommand: true, SedCommand: true, LsCommand: true, TreeCommand: true,
+	CmpCommand: true, DedupeCommand: true, SyncCommand: true, AssertCommand: true,
+	MockCommand: true, SetCommand: true, NiceCommand: true, LimitCommand: true,
+	UnbufferCommand: true, RmCommand: true, TrashCommand: true, UndoCommand: true,
+	DeferCommand: true, EachCommand: true, FilterCommand: true, SpongeCommand: true,
+	CDCommand: true, MkcdCommand: true, UpCommand: true, BackCommand: true,
+	BookmarkCommand: true, EnvSnapshotCommand: true, ThemeCommand: true, ExportCommand: true,
+	UnsetCommand: true, CexecCommand: true, KexecCommand: true, AliasCommand: true,
+	UnaliasCommand: true, PluginCommand: true, HistoryCommand: true, EnvCommand: true,
+	SourceCommand: true, DotCommand: true, PortCommand: true, NcCommand: true,
+	OnchangeCommand: true, SeqCommand: true, EveryCommand: true, AtCommand: true,
+	ScheduleCommand: true, JSONCommand: true, YAMLCommand: true, TOMLCommand: true,
+	YesCommand: true, DateCommand: true, TplCommand: true,
+	BasenameCommand: true, DirnameCommand: true, SQLCommand: true, MvCommand: true,
+	GroupByCommand: true, CpCommand: true, WhereCommand: true, SelectCommand: true,
+	PrintfCommand: true, SleepCommand: true, SuggestCommand: true, PreviewCommand: true,
+	WhichCommand: true,
+}
+
+// whichCommand tells what runs for each name: a builtin, a registered
+// function, a mock, or a program found in PATH of the shell environment.
+type whichCommand struct {
+	names []string
+	all   bool
+	mocks *mockRegistry
+	funcs *funcRegistry
+}
+
+func parseWhichCommand(d CommandDescription, mocks *mockRegistry, funcs *funcRegistry) (Command, error) {
+	fs := newFlagSet("which")
+	all := fs.Bool("a", false, "print all matches, not only the first one")
+
+	if err := fs.Parse(d.arguments[1:]); err != nil {
+		return nil, fmt.Errorf("which: %w", err)
+	}
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("which: usage: which [-a] NAME...")
+	}
+
+	return &whichCommand{
+		names: fs.Args(),
+		all:   *all,
+		mocks: mocks,
+		funcs: funcs,
+	}, nil
+}
+
+// Execute returns 1 if any of the names was not found.
+func (w *whichCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	path, _ := env.Get("PATH")
+	for _, name := range w.names {
+		var found []string
+		if builtins[CommandName(name)] {
+			found = append(found, name+": shell builtin")
+		}
+		if _, ok := w.funcs.get(name); ok && len(found) == 0 {
+			found = append(found, name+": function")
+		}
+		if _, ok := w.mocks.get(name); ok && len(found) == 0 {
+			found = append(found, name+": mock")
+		}
+		if len(found) == 0 || w.all {
+			found = append(found, lookPath(name, path, w.all)...)
+		}
+
+		if len(found) == 0 {
+			_, _ = fmt.Fprintf(errOut, "which: no %s in (%s)\n", name, path)
+			retCode = 1
+			continue
+		}
+		if !w.all {
+			found = found[:1]
+		}
+		for _, line := range found {
+			_, _ = fmt.Fprintln(out, line)
+		}
+	}
+	return retCode, false
+}
+
+// lookPath finds the executables called name in the directories of path,
+// a PATH value, stopping at the first one unless all is set.
+// A name with a slash is only checked itself.
+func lookPath(name, path string, all bool) []string {
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		if found, err := exec.LookPath(name); err == nil {
+			return []string{found}
+		}
+		return nil
+	}
+
+	var found []string
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			// An empty entry means the working directory.
+			dir = "."
+		}
+		candidate := filepath.Join(dir, name)
+		if !strings.ContainsRune(candidate, filepath.Separator) {
+			// Without a separator exec.LookPath would search the process PATH.
+			candidate = "." + string(filepath.Separator) + candidate
+		}
+		if executable, err := exec.LookPath(candidate); err == nil {
+			found = append(found, executable)
+			if !all {
+				break
+			}
+		}
+	}
+	return found
+}
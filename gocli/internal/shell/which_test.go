@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runWhich(t *testing.T, env Env, mocks *mockRegistry, args ...string) (string, string, int) {
+	cmd, err := parseWhichCommand(CommandDescription{
+		name:      WhichCommand,
+		arguments: append([]string{"which"}, args...),
+	}, mocks, newFuncRegistry())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+	errOut, err := os.Create(filepath.Join(dir, "err"))
+	require.NoError(t, err)
+
+	retCode, exited := cmd.Execute(nil, out, errOut, env)
+	assert.False(t, exited)
+	require.NoError(t, out.Close())
+	require.NoError(t, errOut.Close())
+
+	stdout, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	stderr, err := os.ReadFile(errOut.Name())
+	require.NoError(t, err)
+	return string(stdout), string(stderr), retCode
+}
+
+func writeExecutable(t *testing.T, dir, name string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	return path
+}
+
+func TestWhichCommand_Execute_UsesShellPath(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	tool := writeExecutable(t, first, "gocli-tool")
+	other := writeExecutable(t, second, "gocli-tool")
+	require.NoError(t, os.WriteFile(filepath.Join(first, "gocli-data"), nil, 0644))
+	env := NewEnvFromMap(map[string]string{"PATH": strings.Join([]string{first, second}, string(os.PathListSeparator))})
+
+	stdout, _, retCode := runWhich(t, env, newMockRegistry(), "gocli-tool")
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, tool+"\n", stdout)
+
+	stdout, _, retCode = runWhich(t, env, newMockRegistry(), "-a", "gocli-tool")
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, tool+"\n"+other+"\n", stdout)
+
+	stdout, stderr, retCode := runWhich(t, env, newMockRegistry(), "gocli-data", "gocli-tool")
+	assert.Equal(t, 1, retCode)
+	assert.Equal(t, tool+"\n", stdout)
+	assert.Equal(t, "which: no gocli-data in ("+first+string(os.PathListSeparator)+second+")\n", stderr)
+}
+
+func TestWhichCommand_Execute_BuiltinsAndMocks(t *testing.T) {
+	mocks := newMockRegistry()
+	mocks.set("git", &Mock{})
+	env := NewEnvFromMap(map[string]string{"PATH": t.TempDir()})
+
+	stdout, _, retCode := runWhich(t, env, mocks, "cat", "which", "git")
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, "cat: shell builtin\nwhich: shell builtin\ngit: mock\n", stdout)
+}
+
+func TestWhichCommand_Execute_PathWithSlash(t *testing.T) {
+	tool := writeExecutable(t, t.TempDir(), "tool")
+
+	stdout, _, retCode := runWhich(t, NewEnvFromMap(nil), newMockRegistry(), tool)
+	assert.Equal(t, 0, retCode)
+	assert.Equal(t, tool+"\n", stdout)
+}
+
+func TestParseWhichCommand_RequiresName(t *testing.T) {
+	_, err := parseWhichCommand(CommandDescription{name: WhichCommand, arguments: []string{"which"}}, nil, nil)
+	assert.Error(t, err)
+}
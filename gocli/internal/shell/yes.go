@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// yesCommand writes a line over and over until the reader of its output
+// goes away, like head in "yes | head -n 3", or it is interrupted.
+type yesCommand struct {
+	line string
+}
+
+func parseYesCommand(d CommandDescription) (Command, error) {
+	line := "y"
+	if len(d.arguments) > 1 {
+		line = strings.Join(d.arguments[1:], " ")
+	}
+	return &yesCommand{line: line}, nil
+}
+
+// yesBufferSize is about how much is written at once, so that a fast reader
+// does not wait on a write per line.
+const yesBufferSize = 8 << 10
+
+// Execute returns 1 without a message when the output pipe is closed, like
+// cat, and 130 if it is interrupted.
+func (y *yesCommand) Execute(in, out, errOut *os.File, env Env) (retCode int, exited bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	line := y.line + "\n"
+	buf := bytes.Repeat([]byte(line), max(1, yesBufferSize/len(line)))
+	for ctx.Err() == nil {
+		if _, err := out.Write(buf); err != nil {
+			if !errors.Is(err, syscall.EPIPE) {
+				_, _ = fmt.Fprintf(errOut, "yes: %v\n", err)
+			}
+			return 1, false
+		}
+	}
+	return 130, false
+}
@@ -0,0 +1,27 @@
+package shell
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYesCommand(t *testing.T) {
+	sh, stdout := newTestShell(t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		retCode, _, err := sh.Execute("yes | head -n 2; yes hello world | head -n 1; yes | head -n 100000 | wc")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, retCode)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "yes did not stop when head went away")
+	}
+	assert.Equal(t, "y\ny\nhello world\n100000 100000 200000\n", readShellOutput(t, stdout))
+}
@@ -0,0 +1,377 @@
+// Package testrunner implements "gocli test": a bats-style runner for
+// *.t.sh files whose @test blocks are executed by isolated shell instances.
+package testrunner
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/art22m/MHS-Software-Design-F25/gocli/internal/shell"
+)
+
+// TestFileSuffix is the suffix of files discovered by the runner.
+const TestFileSuffix = ".t.sh"
+
+var testHeader = regexp.MustCompile(`^@test\s+"([^"]*)"\s*\{\s*$`)
+
+// Case is a single @test block of a test file.
+type Case struct {
+	Name string
+	Line int
+	Body []string
+}
+
+// Suite is a parsed test file. Preamble holds the lines outside of @test blocks,
+// they are executed before the body of every case.
+type Suite struct {
+	File     string
+	Preamble []string
+	Cases    []Case
+}
+
+// Result describes the outcome of running a single case.
+type Result struct {
+	File    string
+	Case    Case
+	Passed  bool
+	Failure string
+	Output  string
+	// Errors is what the case wrote to standard error, such as the
+	// diagnostics of a failed assert.
+	Errors   string
+	Duration time.Duration
+}
+
+// Discover returns all test files under root in lexical order.
+// If root is a file it is returned as is.
+func Discover(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), TestFileSuffix) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ParseFile reads a test file and splits it into the preamble and @test cases.
+func ParseFile(path string) (*Suite, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return Parse(path, file)
+}
+
+// Parse splits the contents of a test file into the preamble and @test cases.
+func Parse(name string, r io.Reader) (*Suite, error) {
+	suite := &Suite{File: name}
+	var current *Case
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if current != nil {
+			if trimmed == "}" {
+				suite.Cases = append(suite.Cases, *current)
+				current = nil
+				continue
+			}
+			current.Body = append(current.Body, line)
+			continue
+		}
+
+		if m := testHeader.FindStringSubmatch(trimmed); m != nil {
+			current = &Case{Name: m[1], Line: lineNo}
+			continue
+		}
+		suite.Preamble = append(suite.Preamble, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("%s:%d: unterminated @test %q", name, current.Line, current.Name)
+	}
+	return suite, nil
+}
+
+// RunCase executes the preamble and the body of c in a fresh shell with its own
+// environment. Standard input is empty, standard output and standard error
+// are captured.
+// The case fails at the first command exiting with a non-zero status;
+// commands queued with defer run when the case finishes either way, and
+// the working directory is restored afterwards, so a "cd" stays in its case.
+func RunCase(suite *Suite, c Case) Result {
+	result := Result{File: suite.File, Case: c}
+	start := time.Now()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	defer func() {
+		_ = os.Chdir(cwd)
+	}()
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	defer func() {
+		_ = stdin.Close()
+	}()
+
+	stdout, err := os.CreateTemp("", "gocli-test-*")
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	defer func() {
+		_ = stdout.Close()
+		_ = os.Remove(stdout.Name())
+	}()
+	stderr, err := os.CreateTemp("", "gocli-test-*")
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	defer func() {
+		_ = stderr.Close()
+		_ = os.Remove(stderr.Name())
+	}()
+
+	sh := shell.NewShell(shell.WithStdin(stdin), shell.WithStdout(stdout), shell.WithStderr(stderr))
+	result.Passed, result.Failure = runLines(sh, append(append([]string{}, suite.Preamble...), c.Body...))
+	sh.RunDeferred()
+
+	if output, err := os.ReadFile(stdout.Name()); err == nil {
+		result.Output = string(output)
+	}
+	if data, err := os.ReadFile(stderr.Name()); err == nil {
+		result.Errors = string(data)
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// runLines executes lines one by one. A command that needs more input, such as
+// a here-document, takes the following lines with the command's indentation removed.
+func runLines(sh *shell.Shell, lines []string) (passed bool, failure string) {
+	for n := 0; n < len(lines); n++ {
+		trimmed := strings.TrimSpace(lines[n])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		retCode, exited, err := sh.Execute(trimmed)
+		indent := lines[n][:len(lines[n])-len(strings.TrimLeft(lines[n], " \t"))]
+		for errors.Is(err, shell.ErrIncompleteInput) && n+1 < len(lines) {
+			n++
+			trimmed += "\n" + strings.TrimPrefix(lines[n], indent)
+			retCode, exited, err = sh.Execute(trimmed)
+		}
+		if err != nil {
+			return false, fmt.Sprintf("`%s` could not be parsed: %v", trimmed, err)
+		}
+		if retCode != 0 {
+			return false, fmt.Sprintf("`%s` failed with status %d", trimmed, retCode)
+		}
+		if exited {
+			break
+		}
+	}
+	return true, ""
+}
+
+// WriteTAP reports results in the Test Anything Protocol format.
+func WriteTAP(w io.Writer, results []Result) {
+	_, _ = fmt.Fprintf(w, "1..%d\n", len(results))
+	for i, result := range results {
+		status := "ok"
+		if !result.Passed {
+			status = "not ok"
+		}
+		_, _ = fmt.Fprintf(w, "%s %d %s: %s\n", status, i+1, result.File, result.Case.Name)
+		if result.Passed {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(w, "# (in test file %s, line %d)\n", result.File, result.Case.Line)
+		_, _ = fmt.Fprintf(w, "#   %s\n", result.Failure)
+		for _, line := range strings.Split(strings.TrimRight(result.Output+result.Errors, "\n"), "\n") {
+			if line != "" {
+				_, _ = fmt.Fprintf(w, "#   %s\n", line)
+			}
+		}
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit reports results as JUnit XML, one testsuite per test file.
+func WriteJUnit(w io.Writer, results []Result) error {
+	var report junitTestSuites
+	suiteIdx := make(map[string]int)
+
+	for _, result := range results {
+		idx, ok := suiteIdx[result.File]
+		if !ok {
+			idx = len(report.Suites)
+			suiteIdx[result.File] = idx
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.File})
+		}
+		suite := &report.Suites[idx]
+
+		testCase := junitTestCase{
+			Name:      result.Case.Name,
+			ClassName: result.File,
+			Time:      formatSeconds(result.Duration),
+			SystemOut: result.Output,
+			SystemErr: result.Errors,
+		}
+		if !result.Passed {
+			testCase.Failure = &junitFailure{Message: result.Failure, Text: result.Output + result.Errors}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	for i := range report.Suites {
+		var total time.Duration
+		for _, result := range results {
+			if result.File == report.Suites[i].Name {
+				total += result.Duration
+			}
+		}
+		report.Suites[i].Time = formatSeconds(total)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// Main is the entry point of "gocli test [-format tap|junit] [PATH...]".
+// Returns 0 when every discovered case passes, 1 on failures and 2 on usage errors.
+func Main(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "tap", "report format: tap or junit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "tap" && *format != "junit" {
+		_, _ = fmt.Fprintf(stderr, "test: unknown format %q\n", *format)
+		return 2
+	}
+
+	roots := fs.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	var results []Result
+	for _, root := range roots {
+		files, err := Discover(root)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "test: %v\n", err)
+			return 2
+		}
+		for _, path := range files {
+			suite, err := ParseFile(path)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "test: %v\n", err)
+				return 2
+			}
+			for _, c := range suite.Cases {
+				results = append(results, RunCase(suite, c))
+			}
+		}
+	}
+
+	if *format == "junit" {
+		if err := WriteJUnit(stdout, results); err != nil {
+			_, _ = fmt.Fprintf(stderr, "test: %v\n", err)
+			return 2
+		}
+	} else {
+		WriteTAP(stdout, results)
+	}
+
+	for _, result := range results {
+		if !result.Passed {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package testrunner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSuite = `GREETING=hello
+
+@test "echo prints greeting" {
+  assert --stdout hello echo $GREETING
+}
+
+@test "missing file fails" {
+  echo before
+  cat /nonexistent/file
+  echo after
+}
+`
+
+func TestParse(t *testing.T) {
+	suite, err := Parse("sample.t.sh", strings.NewReader(sampleSuite))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"GREETING=hello", ""}, suite.Preamble[:2])
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, "echo prints greeting", suite.Cases[0].Name)
+	assert.Equal(t, 3, suite.Cases[0].Line)
+	assert.Equal(t, []string{"  echo before", "  cat /nonexistent/file", "  echo after"}, suite.Cases[1].Body)
+}
+
+func TestParse_Unterminated(t *testing.T) {
+	_, err := Parse("broken.t.sh", strings.NewReader("@test \"never closed\" {\n  echo\n"))
+	assert.Error(t, err)
+}
+
+func TestRunCase(t *testing.T) {
+	suite, err := Parse("sample.t.sh", strings.NewReader(sampleSuite))
+	require.NoError(t, err)
+
+	passed := RunCase(suite, suite.Cases[0])
+	assert.True(t, passed.Passed)
+	assert.Empty(t, passed.Failure)
+
+	failed := RunCase(suite, suite.Cases[1])
+	assert.False(t, failed.Passed)
+	assert.Equal(t, "`cat /nonexistent/file` failed with status 1", failed.Failure)
+	assert.Equal(t, "before\n", failed.Output)
+	assert.Equal(t, "cat: open /nonexistent/file: no such file or directory\n", failed.Errors)
+}
+
+func TestWriteTAP(t *testing.T) {
+	results := []Result{
+		{File: "a.t.sh", Case: Case{Name: "works", Line: 1}, Passed: true},
+		{File: "a.t.sh", Case: Case{Name: "breaks", Line: 5}, Failure: "`false` failed with status 1", Output: "oops\n", Errors: "assert: expected 1\n"},
+	}
+
+	var buf bytes.Buffer
+	WriteTAP(&buf, results)
+
+	expected := "1..2\n" +
+		"ok 1 a.t.sh: works\n" +
+		"not ok 2 a.t.sh: breaks\n" +
+		"# (in test file a.t.sh, line 5)\n" +
+		"#   `false` failed with status 1\n" +
+		"#   oops\n" +
+		"#   assert: expected 1\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteJUnit(t *testing.T) {
+	results := []Result{
+		{File: "a.t.sh", Case: Case{Name: "works"}, Passed: true},
+		{File: "a.t.sh", Case: Case{Name: "breaks"}, Failure: "boom", Output: "out\n", Errors: "err\n"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, results))
+
+	output := buf.String()
+	assert.Contains(t, output, `<testsuite name="a.t.sh" tests="2" failures="1"`)
+	assert.Contains(t, output, `<failure message="boom">out&#xA;err&#xA;</failure>`)
+	assert.Contains(t, output, `<system-err>err`)
+}
+
+func TestMain_DiscoversFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "ok.t.sh"), []byte("@test \"ok\" {\n  echo ok\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.sh"), []byte("@test \"bad\" {\n  cat /nonexistent\n}\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{dir}, &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "1..1\nok 1 "+filepath.Join(dir, "nested", "ok.t.sh")+": ok\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	code = Main([]string{"-format", "xml", dir}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+}
+
+func TestRunCase_RunsDeferred(t *testing.T) {
+	suite, err := Parse("defer.t.sh", strings.NewReader("@test \"cleanup\" {\n  defer echo cleanup\n  false\n  echo unreachable\n}\n"))
+	require.NoError(t, err)
+
+	result := RunCase(suite, suite.Cases[0])
+	assert.False(t, result.Passed)
+	assert.Equal(t, "cleanup\n", result.Output)
+}
+
+func TestRunCase_HereDocument(t *testing.T) {
+	suite, err := Parse("heredoc.t.sh", strings.NewReader("@test \"heredoc\" {\n  NAME=world\n  cat << EOF\n  hello $NAME\n    indented\n  EOF\n  echo done\n}\n"))
+	require.NoError(t, err)
+
+	result := RunCase(suite, suite.Cases[0])
+	assert.True(t, result.Passed, result.Failure)
+	assert.Equal(t, "hello world\n  indented\ndone\n", result.Output)
+}
+
+func TestMain_RestoresDirectoryAfterCase(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile("a.t.sh", []byte("@test \"leaves\" {\n  cd /\n}\n"), 0644))
+	require.NoError(t, os.WriteFile("b.t.sh", []byte("@test \"stays\" {\n  cat b.t.sh > /dev/null\n}\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := Main([]string{"."}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "1..2\nok 1 a.t.sh: leaves\nok 2 b.t.sh: stays\n", stdout.String())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, dir, cwd)
+}